@@ -7,5 +7,4 @@ import (
 	_ "github.com/golang/protobuf/protoc-gen-go"
 	_ "github.com/alvaroloes/enumer"
 	_ "golang.org/x/tools/cmd/goimports"
-	_ "github.com/golangci/golangci-lint/cmd/golangci-lint"
 )
@@ -20,6 +20,7 @@ import (
 var configcheckArgs struct {
 	format string
 	what   string
+	deep   bool
 }
 
 var ConfigcheckCmd = &cli.Subcommand{
@@ -28,6 +29,7 @@ var ConfigcheckCmd = &cli.Subcommand{
 	SetupFlags: func(f *pflag.FlagSet) {
 		f.StringVar(&configcheckArgs.format, "format", "", "dump parsed config object [pretty|yaml|json]")
 		f.StringVar(&configcheckArgs.what, "what", "all", "what to print [all|config|jobs|logging]")
+		f.BoolVar(&configcheckArgs.deep, "deep", false, "check config against datasets, certificates and hooks on this system")
 	},
 	Run: func(subcommand *cli.Subcommand, args []string) error {
 		formatMap := map[string]func(interface{}){
@@ -69,7 +71,7 @@ var ConfigcheckCmd = &cli.Subcommand{
 		}
 
 		// further: try to build logging outlets
-		outlets, err := logging.OutletsFromConfig(*subcommand.Config().Global.Logging)
+		outlets, _, err := logging.OutletsFromConfig(*subcommand.Config().Global.Logging)
 		if err != nil {
 			err := errors.Wrap(err, "cannot build logging from config")
 			if configcheckArgs.what == "logging" {
@@ -113,8 +115,24 @@ var ConfigcheckCmd = &cli.Subcommand{
 
 		if hadErr {
 			return fmt.Errorf("config parsing failed")
-		} else {
-			return nil
 		}
+
+		if configcheckArgs.deep {
+			datasets, err := listAllDatasets()
+			if err != nil {
+				return errors.Wrap(err, "cannot list datasets")
+			}
+			var errCount int
+			for _, f := range runDeepChecks(subcommand.Config(), confJobs, datasets) {
+				fmt.Println(f)
+				if f.Severity == deepCheckError {
+					errCount++
+				}
+			}
+			if errCount > 0 {
+				return fmt.Errorf("deep config check found %d error(s)", errCount)
+			}
+		}
+		return nil
 	},
 }
@@ -0,0 +1,319 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/tlsconf"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+type deepCheckSeverity string
+
+const (
+	deepCheckWarning deepCheckSeverity = "warning"
+	deepCheckError   deepCheckSeverity = "error"
+)
+
+type deepCheckFinding struct {
+	Severity deepCheckSeverity
+	Job      string // empty if not specific to a job
+	Msg      string
+}
+
+func (f deepCheckFinding) String() string {
+	if f.Job == "" {
+		return fmt.Sprintf("%s: %s", f.Severity, f.Msg)
+	}
+	return fmt.Sprintf("%s: job %q: %s", f.Severity, f.Job, f.Msg)
+}
+
+// certificates that expire within this duration produce a warning
+const deepCheckCertExpiryWarning = 30 * 24 * time.Hour
+
+// deepCheckJob is the part of a job's config that deep checks are concerned with.
+type deepCheckJob struct {
+	name string
+	// the job's filesystems filter including dataset_selection and dataset_properties,
+	// nil if the job neither sends nor snapshots
+	filter zfs.DatasetFilter
+	// empty if the job does not create snapshots
+	snapPrefix string
+	hooks      config.HookList
+	tls        []tlsconf.Files
+	// set if the job owns the dataset subtree below it
+	ownedRoot *zfs.DatasetPath
+
+	// datasets passing filter
+	matched []*zfs.DatasetPath
+}
+
+type deepChecker struct {
+	// all filesystems and volumes on this system
+	datasets []*zfs.DatasetPath
+	now      time.Time
+	findings []deepCheckFinding
+}
+
+func (c *deepChecker) errorf(job string, format string, args ...interface{}) {
+	c.findings = append(c.findings, deepCheckFinding{deepCheckError, job, fmt.Sprintf(format, args...)})
+}
+
+func (c *deepChecker) warnf(job string, format string, args ...interface{}) {
+	c.findings = append(c.findings, deepCheckFinding{deepCheckWarning, job, fmt.Sprintf(format, args...)})
+}
+
+func listAllDatasets() ([]*zfs.DatasetPath, error) {
+	return zfs.ZFSListMapping(context.Background(), zfs.NoFilter())
+}
+
+// runDeepChecks checks the config against the datasets and files present on this system.
+// jobs must have been built from conf.
+func runDeepChecks(conf *config.Config, jobs []job.Job, datasets []*zfs.DatasetPath) []deepCheckFinding {
+	c := &deepChecker{datasets: datasets, now: time.Now()}
+
+	owned := make(map[string]*zfs.DatasetPath, len(jobs))
+	for _, j := range jobs {
+		if rfs, ok := j.OwnedDatasetSubtreeRoot(); ok {
+			owned[j.Name()] = rfs
+		}
+	}
+
+	var djs []*deepCheckJob
+	for _, je := range conf.Jobs {
+		dj, err := deepCheckJobFromConfig(je)
+		if err != nil {
+			c.errorf(je.Name(), "%s", err)
+			continue
+		}
+		dj.ownedRoot = owned[dj.name]
+		djs = append(djs, dj)
+	}
+
+	for _, dj := range djs {
+		c.checkFilter(dj)
+		c.checkOwnedRoot(dj)
+		c.checkTLS(dj)
+		c.checkHooks(dj)
+	}
+	c.checkOverlap(conf, djs)
+	c.checkFilterOverlaps(conf, jobs, datasets, owned)
+	c.checkSnapshotPrefixes(djs)
+	return c.findings
+}
+
+func deepCheckJobFromConfig(in config.JobEnum) (*deepCheckJob, error) {
+	dj := &deepCheckJob{name: in.Name()}
+	var fsf config.FilesystemsFilter
+	var sel *config.DatasetSelection
+	var dp *config.DatasetProperties
+	var snapshotting *config.SnapshottingEnum
+	switch v := in.Ret.(type) {
+	case *config.PushJob:
+		fsf, sel, dp, snapshotting = v.Filesystems, v.DatasetSelection, v.DatasetProperties, &v.Snapshotting
+		dj.tls = tlsFilesFromConnect(v.Connect)
+	case *config.SourceJob:
+		fsf, sel, dp, snapshotting = v.Filesystems, v.DatasetSelection, v.DatasetProperties, &v.Snapshotting
+		dj.tls = tlsFilesFromServe(v.Serve)
+	case *config.SnapJob:
+		fsf, sel, dp, snapshotting = v.Filesystems, v.DatasetSelection, v.DatasetProperties, &v.Snapshotting
+	case *config.PullJob:
+		dj.tls = tlsFilesFromConnect(v.Connect)
+	case *config.SinkJob:
+		dj.tls = tlsFilesFromServe(v.Serve)
+	default:
+		return nil, fmt.Errorf("unknown job type %T", v)
+	}
+	if fsf != nil {
+		var err error
+		// built like the job builds it, so that the check sees the same datasets
+		if dj.filter, _, err = filters.DatasetFilterFromConfig(fsf, sel, dp); err != nil {
+			return nil, fmt.Errorf("cannot build filesystems filter: %s", err)
+		}
+	}
+	if snapshotting != nil {
+		switch v := snapshotting.Ret.(type) {
+		case *config.SnapshottingPeriodic:
+			dj.snapPrefix, dj.hooks = v.Prefix, v.Hooks
+		case *config.SnapshottingManual:
+			dj.snapPrefix, dj.hooks = v.Prefix, v.Hooks
+		}
+	}
+	return dj, nil
+}
+
+func tlsFilesFromConnect(in config.ConnectEnum) []tlsconf.Files {
+	if v, ok := in.Ret.(*config.TLSConnect); ok {
+		return []tlsconf.Files{{CA: v.Ca, Cert: v.Cert, Key: v.Key, CRL: v.CRL}}
+	}
+	return nil
+}
+
+func tlsFilesFromServe(in config.ServeEnum) []tlsconf.Files {
+	if v, ok := in.Ret.(*config.TLSServe); ok {
+		return []tlsconf.Files{{CA: v.Ca, Cert: v.Cert, Key: v.Key, CRL: v.CRL}}
+	}
+	return nil
+}
+
+func (c *deepChecker) checkFilter(dj *deepCheckJob) {
+	if dj.filter == nil {
+		return
+	}
+	for _, ds := range c.datasets {
+		pass, err := zfs.FilterDataset(context.Background(), dj.filter, ds)
+		if err != nil {
+			c.errorf(dj.name, "cannot apply filesystems filter to %s: %s", ds.ToString(), err)
+			return
+		}
+		if pass {
+			dj.matched = append(dj.matched, ds)
+		}
+	}
+	if len(dj.matched) == 0 {
+		c.errorf(dj.name, "filesystems filter does not match any dataset")
+	}
+}
+
+func (c *deepChecker) exists(p *zfs.DatasetPath) bool {
+	for _, ds := range c.datasets {
+		if ds.Equal(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *deepChecker) checkOwnedRoot(dj *deepCheckJob) {
+	if dj.ownedRoot == nil || c.exists(dj.ownedRoot) {
+		return
+	}
+	pool, err := zfs.NewDatasetPath(strings.SplitN(dj.ownedRoot.ToString(), "/", 2)[0])
+	if err == nil && !c.exists(pool) {
+		c.errorf(dj.name, "root_fs %s: pool %s does not exist", dj.ownedRoot.ToString(), pool.ToString())
+		return
+	}
+	c.errorf(dj.name, "root_fs %s does not exist", dj.ownedRoot.ToString())
+}
+
+// checkOverlap reports datasets that one job sends or snapshots and that another job receives into.
+func (c *deepChecker) checkOverlap(conf *config.Config, djs []*deepCheckJob) {
+	for _, owner := range djs {
+		if owner.ownedRoot == nil {
+			continue
+		}
+		for _, dj := range djs {
+			if job.OverlapAllowed(conf, owner.name, dj.name) {
+				continue
+			}
+			var inside []string
+			for _, ds := range dj.matched {
+				if ds.HasPrefix(owner.ownedRoot) {
+					inside = append(inside, ds.ToString())
+				}
+			}
+			if len(inside) > 0 {
+				c.errorf(dj.name, "filesystems filter matches %d dataset(s) below root_fs %s of job %q, e.g. %s",
+					len(inside), owner.ownedRoot.ToString(), owner.name, inside[0])
+			}
+		}
+	}
+}
+
+// checkFilterOverlaps reports datasets matched by the filesystems filters of two jobs,
+// which the daemon refuses to start with.
+// Overlaps with root_fs are reported by checkOverlap.
+func (c *deepChecker) checkFilterOverlaps(conf *config.Config, jobs []job.Job, datasets []*zfs.DatasetPath, owned map[string]*zfs.DatasetPath) {
+	overlaps, err := job.DisallowedOverlaps(conf, jobs, datasets)
+	if err != nil {
+		c.errorf("", "%s", err)
+		return
+	}
+	for _, o := range overlaps {
+		if owned[o.Jobs[0]] != nil || owned[o.Jobs[1]] != nil {
+			continue
+		}
+		c.errorf(o.Jobs[0], "filesystems filter overlaps with job %q on %d dataset(s), e.g. %s, use allow_overlap_with if this is intended",
+			o.Jobs[1], len(o.Datasets), o.Datasets[0])
+	}
+}
+
+// checkSnapshotPrefixes reports jobs that snapshot the same datasets with colliding prefixes.
+// Pruning of either job would then consider the other job's snapshots its own.
+func (c *deepChecker) checkSnapshotPrefixes(djs []*deepCheckJob) {
+	for i, a := range djs {
+		for _, b := range djs[i+1:] {
+			if a.snapPrefix == "" || b.snapPrefix == "" {
+				continue
+			}
+			if !strings.HasPrefix(a.snapPrefix, b.snapPrefix) && !strings.HasPrefix(b.snapPrefix, a.snapPrefix) {
+				continue
+			}
+			shared := sharedDatasets(a.matched, b.matched)
+			if len(shared) == 0 {
+				continue
+			}
+			c.errorf(a.name, "snapshot prefix %q collides with prefix %q of job %q on %d dataset(s), e.g. %s",
+				a.snapPrefix, b.snapPrefix, b.name, len(shared), shared[0])
+		}
+	}
+}
+
+func sharedDatasets(a, b []*zfs.DatasetPath) []string {
+	inA := make(map[string]bool, len(a))
+	for _, ds := range a {
+		inA[ds.ToString()] = true
+	}
+	var shared []string
+	for _, ds := range b {
+		if inA[ds.ToString()] {
+			shared = append(shared, ds.ToString())
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+func (c *deepChecker) checkTLS(dj *deepCheckJob) {
+	for _, files := range dj.tls {
+		cert, err := tlsconf.Check(files)
+		if err != nil {
+			c.errorf(dj.name, "tls: %s", err)
+			continue
+		}
+		if c.now.After(cert.NotAfter) {
+			c.errorf(dj.name, "tls: certificate %s expired at %s", files.Cert, cert.NotAfter)
+		} else if cert.NotAfter.Sub(c.now) < deepCheckCertExpiryWarning {
+			c.warnf(dj.name, "tls: certificate %s expires at %s", files.Cert, cert.NotAfter)
+		}
+		if c.now.Before(cert.NotBefore) {
+			c.errorf(dj.name, "tls: certificate %s is not valid before %s", files.Cert, cert.NotBefore)
+		}
+	}
+}
+
+func (c *deepChecker) checkHooks(dj *deepCheckJob) {
+	for i, h := range dj.hooks {
+		cmd, ok := h.Ret.(*config.HookCommand)
+		if !ok {
+			continue
+		}
+		st, err := os.Stat(cmd.Path)
+		if err != nil {
+			c.errorf(dj.name, "hook #%d: %s", i+1, err)
+			continue
+		}
+		if !st.Mode().IsRegular() {
+			c.errorf(dj.name, "hook #%d: %s is not a regular file", i+1, cmd.Path)
+		} else if st.Mode().Perm()&0111 == 0 {
+			c.errorf(dj.name, "hook #%d: %s is not executable", i+1, cmd.Path)
+		}
+	}
+}
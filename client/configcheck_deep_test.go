@@ -0,0 +1,196 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/zfs"
+	"github.com/zrepl/zrepl/zfs/zfsfake"
+)
+
+func TestDeepCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-configcheck-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	notExecutable := filepath.Join(dir, "hook.sh")
+	require.NoError(t, ioutil.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0644))
+
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: snap1
+  type: snap
+  filesystems: {"pool/data<": true}
+  snapshotting:
+    type: periodic
+    prefix: zrepl_
+    interval: 10m
+    hooks:
+    - type: command
+      path: ` + notExecutable + `
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: snap2
+  type: snap
+  filesystems: {"pool/data/b": true, "pool/backup<": true}
+  snapshotting:
+    type: periodic
+    prefix: zrepl_hourly_
+    interval: 1h
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: nomatch
+  type: snap
+  filesystems: {"pool/doesnotexist": true}
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: sink
+  type: sink
+  root_fs: pool/backup
+  serve:
+    type: local
+    listener_name: sink
+- name: pull
+  type: pull
+  root_fs: otherpool/backup
+  interval: manual
+  connect:
+    type: local
+    listener_name: sink
+    client_identity: pull
+  pruning:
+    keep_sender:
+    - type: last_n
+      count: 10
+    keep_receiver:
+    - type: last_n
+      count: 10
+`))
+	require.NoError(t, err)
+	jobs, err := job.JobsFromConfig(conf)
+	require.NoError(t, err)
+
+	var datasets []*zfs.DatasetPath
+	for _, n := range []string{"pool", "pool/data", "pool/data/a", "pool/data/b", "pool/backup", "pool/backup/client"} {
+		p, err := zfs.NewDatasetPath(n)
+		require.NoError(t, err)
+		datasets = append(datasets, p)
+	}
+
+	var msgs []string
+	for _, f := range runDeepChecks(conf, jobs, datasets) {
+		assert.Equal(t, deepCheckError, f.Severity, f.String())
+		msgs = append(msgs, f.String())
+	}
+	expect := []string{
+		`job "snap1": hook #1: ` + notExecutable + ` is not executable`,
+		`job "nomatch": filesystems filter does not match any dataset`,
+		`job "pull": root_fs otherpool/backup: pool otherpool does not exist`,
+		`job "snap2": filesystems filter matches 2 dataset(s) below root_fs pool/backup of job "sink", e.g. pool/backup`,
+		`job "snap1": filesystems filter overlaps with job "snap2" on 1 dataset(s), e.g. pool/data/b, use allow_overlap_with if this is intended`,
+		`job "snap1": snapshot prefix "zrepl_" collides with prefix "zrepl_hourly_" of job "snap2" on 1 dataset(s), e.g. pool/data/b`,
+	}
+	require.Len(t, msgs, len(expect), strings.Join(msgs, "\n"))
+	for i := range expect {
+		assert.Equal(t, "error: "+expect[i], msgs[i])
+	}
+}
+
+func TestDeepCheckAllowOverlapWith(t *testing.T) {
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: snap1
+  type: snap
+  filesystems: {"pool/data<": true}
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: snap2
+  type: snap
+  filesystems: {"pool/data/b": true}
+  allow_overlap_with: [snap1]
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+`))
+	require.NoError(t, err)
+	jobs, err := job.JobsFromConfig(conf)
+	require.NoError(t, err)
+
+	var datasets []*zfs.DatasetPath
+	for _, n := range []string{"pool", "pool/data", "pool/data/b"} {
+		p, err := zfs.NewDatasetPath(n)
+		require.NoError(t, err)
+		datasets = append(datasets, p)
+	}
+	assert.Empty(t, runDeepChecks(conf, jobs, datasets))
+}
+
+func TestDeepCheckDatasetSelection(t *testing.T) {
+	b := zfsfake.New()
+	defer zfs.SetBackend(zfs.SetBackend(b))
+	require.NoError(t, b.AddPool("pool"))
+	for fs, tier := range map[string]string{"pool/data": "silver", "pool/data/a": "silver", "pool/other": "gold"} {
+		require.NoError(t, b.Create(fs, map[string]string{"com.example:tier": tier}))
+	}
+
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: nomatch
+  type: snap
+  filesystems: {"pool/data<": true}
+  dataset_selection:
+    properties:
+      com.example:tier: gold
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: match
+  type: snap
+  filesystems: {"pool<": true}
+  dataset_selection:
+    properties:
+      com.example:tier: gold
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+`))
+	require.NoError(t, err)
+	jobs, err := job.JobsFromConfig(conf)
+	require.NoError(t, err)
+	datasets, err := listAllDatasets()
+	require.NoError(t, err)
+
+	var msgs []string
+	for _, f := range runDeepChecks(conf, jobs, datasets) {
+		msgs = append(msgs, f.String())
+	}
+	assert.Equal(t, []string{`error: job "nomatch": filesystems filter does not match any dataset`}, msgs)
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/zrepl/zrepl/cli"
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon"
+	"github.com/zrepl/zrepl/daemon/logging"
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/version"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+var DebugCmd = &cli.Subcommand{
+	Use:   "debug",
+	Short: "debugging and support tools",
+	SetupSubcommands: func() []*cli.Subcommand {
+		return []*cli.Subcommand{debugBundle}
+	},
+}
+
+var debugBundleArgs struct {
+	output string
+}
+
+var debugBundle = &cli.Subcommand{
+	Use:   "bundle -o FILE.tar.gz",
+	Short: "collect status, version, profiles, logs, the redacted config and `zfs list` output for bug reports",
+	SetupFlags: func(f *pflag.FlagSet) {
+		f.StringVarP(&debugBundleArgs.output, "output", "o", "", "path of the tar.gz file to write")
+	},
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		return runDebugBundle(subcommand.Config(), args)
+	},
+}
+
+type debugBundleItem struct {
+	name    string
+	collect func() ([]byte, error)
+}
+
+func runDebugBundle(conf *config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.Errorf("debug bundle command takes no arguments")
+	}
+	if debugBundleArgs.output == "" {
+		return errors.Errorf("must specify the output file with -o")
+	}
+
+	httpc, err := controlHttpClient(conf.Global.Control.SockPath)
+	if err != nil {
+		return err
+	}
+
+	items := []debugBundleItem{
+		{"version.json", func() ([]byte, error) { return debugBundleVersion(httpc) }},
+		{"status.json", func() ([]byte, error) {
+			var status json.RawMessage
+			if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointStatus, struct{}{}, &status); err != nil {
+				return nil, err
+			}
+			var buf bytes.Buffer
+			err := json.Indent(&buf, status, "", "  ")
+			return buf.Bytes(), err
+		}},
+		{"goroutines.txt", func() ([]byte, error) { return debugBundleProfile(httpc, "goroutine", 2) }},
+		{"heap.pprof", func() ([]byte, error) { return debugBundleProfile(httpc, "heap", 0) }},
+		{"logs.txt", func() ([]byte, error) { return debugBundleLogs(httpc) }},
+		{"config.json", func() ([]byte, error) {
+			return json.MarshalIndent(config.Redacted(conf), "", "  ")
+		}},
+		{"zfs-list.txt", func() ([]byte, error) {
+			cmd := exec.Command(zfs.ZFS_BINARY, "list", "-t", "all", "-o", "name,type,used,referenced,creation,mountpoint")
+			out, err := cmd.CombinedOutput()
+			if err != nil && len(out) > 0 {
+				return nil, errors.Wrapf(err, "zfs list failed: %s", bytes.TrimSpace(out))
+			} else if err != nil {
+				return nil, errors.Wrap(err, "zfs list failed")
+			}
+			return out, nil
+		}},
+	}
+
+	// the bundle contains hostnames, dataset names and log entries
+	f, err := os.OpenFile(debugBundleArgs.output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	dir := "zrepl-debug-" + now.Format("20060102-150405")
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    path.Join(dir, name),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	var failed []string
+	for _, item := range items {
+		data, err := item.collect()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot collect %s: %s\n", item.name, err)
+			failed = append(failed, fmt.Sprintf("%s: %s", item.name, err))
+			continue
+		}
+		if err := write(item.name, data); err != nil {
+			return errors.Wrapf(err, "cannot write %s to bundle", item.name)
+		}
+	}
+	if len(failed) > 0 {
+		if err := write("errors.txt", []byte(strings.Join(failed, "\n")+"\n")); err != nil {
+			return errors.Wrap(err, "cannot write errors.txt to bundle")
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote debug bundle to %s\n", debugBundleArgs.output)
+	return nil
+}
+
+func debugBundleVersion(httpc http.Client) ([]byte, error) {
+	v := struct {
+		Client      *version.ZreplVersionInformation
+		Daemon      *version.ZreplVersionInformation `json:",omitempty"`
+		DaemonError string                           `json:",omitempty"`
+	}{
+		Client: version.NewZreplVersionInformation(),
+	}
+	var daemonVersion version.ZreplVersionInformation
+	if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointVersion, "", &daemonVersion); err != nil {
+		v.DaemonError = err.Error()
+	} else {
+		v.Daemon = &daemonVersion
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func debugBundleProfile(httpc http.Client, name string, debug int) ([]byte, error) {
+	var profile []byte
+	req := daemon.ProfileRequest{Name: name, Debug: debug}
+	if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointProfile, req, &profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func debugBundleLogs(httpc http.Client) ([]byte, error) {
+	var res daemon.LogsResponse
+	req := daemon.LogsRequest{Since: -1, Level: logger.Debug}
+	if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointLogs, req, &res); err != nil {
+		return nil, err
+	}
+	f := &logging.HumanFormatter{}
+	f.SetMetadataFlags(logging.MetadataAll &^ logging.MetadataColor)
+	var buf bytes.Buffer
+	if res.Truncated {
+		buf.WriteString("(older log entries were discarded by the memory outlet)\n")
+	}
+	for _, me := range res.Entries {
+		e := me.Entry()
+		line, err := f.Format(&e)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/zrepl/zrepl/cli"
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon"
+	"github.com/zrepl/zrepl/daemon/history"
+)
+
+var historyArgs struct {
+	job     string
+	limit   int
+	json    bool
+	verbose bool
+}
+
+var HistoryCmd = &cli.Subcommand{
+	Use:   "history",
+	Short: "show past job invocations",
+	SetupFlags: func(f *pflag.FlagSet) {
+		f.StringVar(&historyArgs.job, "job", "", "only show invocations of this job")
+		f.IntVar(&historyArgs.limit, "limit", 20, "maximum number of invocations to show, 0 for all retained invocations")
+		f.BoolVar(&historyArgs.json, "json", false, "print records as JSON")
+		f.BoolVarP(&historyArgs.verbose, "verbose", "v", false, "show per-filesystem results")
+	},
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		return runHistoryCmd(subcommand.Config(), args)
+	},
+}
+
+func runHistoryCmd(config *config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.Errorf("history command takes no arguments")
+	}
+
+	httpc, err := controlHttpClient(config.Global.Control.SockPath)
+	if err != nil {
+		return err
+	}
+
+	var records []*history.Record
+	err = jsonRequestResponse(httpc, daemon.ControlJobEndpointHistory,
+		daemon.HistoryRequest{Job: historyArgs.job, Limit: historyArgs.limit},
+		&records,
+	)
+	if err != nil {
+		return err
+	}
+
+	if historyArgs.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "JOB\tRUN\tSTART\tDURATION\tRESULT\tREPLICATED\tPRUNED\n")
+	for _, r := range records {
+		result := "ok"
+		if !r.Successful() {
+			result = "failed"
+		}
+		var pruned []string
+		for _, p := range r.Pruning {
+			pruned = append(pruned, fmt.Sprintf("%s:%d", p.Side, p.Destroyed))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			r.Job, r.Seq, r.StartAt.Format(time.RFC3339), r.FinishAt.Sub(r.StartAt).Round(time.Second),
+			result, ByteCountBinary(r.BytesReplicated()), strings.Join(pruned, " "))
+		if historyArgs.verbose {
+			printHistoryDetails(w, r)
+		}
+	}
+	return w.Flush()
+}
+
+func printHistoryDetails(w *tabwriter.Writer, r *history.Record) {
+	if rep := r.Replication; rep != nil {
+		fmt.Fprintf(w, "\treplication: %s (%d attempts)\n", rep.State, rep.Attempts)
+		if rep.Error != "" {
+			fmt.Fprintf(w, "\t\terror: %s\n", rep.Error)
+		}
+		for _, fs := range rep.Filesystems {
+			fmt.Fprintf(w, "\t\t%s\t%s\t%d/%d steps\t%s/%s",
+				fs.Name, fs.State, fs.StepsCompleted, fs.StepsTotal,
+				ByteCountBinary(fs.BytesReplicated), ByteCountBinary(fs.BytesExpected))
+			if fs.Error != "" {
+				fmt.Fprintf(w, "\terror: %s", fs.Error)
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	}
+	for _, p := range r.Pruning {
+		fmt.Fprintf(w, "\tpruning %s: %s, %d snapshots destroyed\n", p.Side, p.State, p.Destroyed)
+		if p.Error != "" {
+			fmt.Fprintf(w, "\t\terror: %s\n", p.Error)
+		}
+		for _, fs := range p.FailedFilesystems {
+			fmt.Fprintf(w, "\t\tfailed: %s\n", fs)
+		}
+	}
+}
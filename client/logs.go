@@ -0,0 +1,185 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/zrepl/zrepl/cli"
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon"
+	"github.com/zrepl/zrepl/daemon/logging"
+	"github.com/zrepl/zrepl/logger"
+)
+
+var logsArgs struct {
+	job    string
+	follow bool
+	level  logger.Level
+}
+
+var LogsCmd = &cli.Subcommand{
+	Use:   "logs [--job JOB] [--follow] [--level LEVEL]",
+	Short: "show the log entries retained by the daemon's memory outlet",
+	Example: `
+	logs --job prod_to_backups --level info
+	logs --follow`,
+	SetupFlags: func(f *pflag.FlagSet) {
+		logsArgs.level = logger.Debug
+		f.StringVar(&logsArgs.job, "job", "", "only show entries of this job")
+		f.BoolVarP(&logsArgs.follow, "follow", "f", false, "keep printing new entries")
+		f.Var(&logsArgs.level, "level", "minimum level of the entries to show")
+	},
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		return runLogsCmd(subcommand.Config(), args)
+	},
+}
+
+func runLogsCmd(config *config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.Errorf("logs command takes no arguments")
+	}
+
+	httpc, err := controlHttpClient(config.Global.Control.SockPath)
+	if err != nil {
+		return err
+	}
+
+	f := &logging.HumanFormatter{}
+	flags := logging.MetadataAll
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		flags &= ^logging.MetadataColor
+	}
+	f.SetMetadataFlags(flags)
+
+	req := daemon.LogsRequest{Since: -1, Job: logsArgs.job, Level: logsArgs.level}
+	for {
+		var res daemon.LogsResponse
+		if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointLogs, req, &res); err != nil {
+			return err
+		}
+		if res.Truncated {
+			fmt.Fprintf(os.Stderr, "(some log entries were discarded before they could be shown, consider increasing the size of the memory outlet)\n")
+		}
+		for _, me := range res.Entries {
+			e := me.Entry()
+			line, err := f.Format(&e)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", line)
+		}
+		if !logsArgs.follow {
+			return nil
+		}
+		req.Since = res.Next
+		req.Wait = true
+	}
+}
+
+var logLevelArgs struct {
+	rule logging.LevelRule
+	all  bool
+}
+
+var LogLevelCmd = &cli.Subcommand{
+	Use:   "loglevel",
+	Short: "show or change the log levels of the running daemon",
+	SetupSubcommands: func() []*cli.Subcommand {
+		return []*cli.Subcommand{logLevelShow, logLevelSet, logLevelReset}
+	},
+}
+
+func logLevelRuleFlags(f *pflag.FlagSet) {
+	f.StringVar(&logLevelArgs.rule.Outlet, "outlet", "", "only apply to this outlet, e.g. stdout, syslog, tcp, tcp#2 or memory")
+	f.StringVar(&logLevelArgs.rule.Job, "job", "", "only apply to entries of this job")
+	f.StringVar(&logLevelArgs.rule.Subsystem, "subsystem", "", "only apply to entries of this subsystem, e.g. repl, pruning or snapshot")
+}
+
+var logLevelShow = &cli.Subcommand{
+	Use:   "show",
+	Short: "show the configured levels of the outlets and the rules that override them",
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		if len(args) != 0 {
+			return errors.Errorf("show takes no arguments")
+		}
+		return runLogLevelCmd(subcommand.Config(), daemon.LogLevelsRequest{Op: "get"})
+	},
+}
+
+var logLevelSet = &cli.Subcommand{
+	Use:   "set LEVEL [--outlet OUTLET] [--job JOB] [--subsystem SUBSYSTEM]",
+	Short: "override the level of the outlets until the daemon is restarted",
+	Example: `
+	set debug --job prod_to_backups --outlet stdout
+	set debug --subsystem pruning`,
+	SetupFlags: logLevelRuleFlags,
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		if len(args) != 1 {
+			return errors.Errorf("expected exactly one argument: LEVEL")
+		}
+		if err := logLevelArgs.rule.Level.Set(args[0]); err != nil {
+			return err
+		}
+		return runLogLevelCmd(subcommand.Config(), daemon.LogLevelsRequest{Op: "set", Rule: logLevelArgs.rule})
+	},
+}
+
+var logLevelReset = &cli.Subcommand{
+	Use:   "reset [--outlet OUTLET] [--job JOB] [--subsystem SUBSYSTEM] | --all",
+	Short: "remove an override set with 'set', or all overrides",
+	SetupFlags: func(f *pflag.FlagSet) {
+		logLevelRuleFlags(f)
+		f.BoolVar(&logLevelArgs.all, "all", false, "remove all overrides")
+	},
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		if len(args) != 0 {
+			return errors.Errorf("reset takes no arguments")
+		}
+		empty := logging.LevelRule{}
+		if logLevelArgs.all == (logLevelArgs.rule != empty) {
+			return errors.Errorf("must specify either --all or at least one of --outlet, --job, --subsystem")
+		}
+		return runLogLevelCmd(subcommand.Config(), daemon.LogLevelsRequest{Op: "reset", Rule: logLevelArgs.rule, All: logLevelArgs.all})
+	},
+}
+
+func runLogLevelCmd(config *config.Config, req daemon.LogLevelsRequest) error {
+	httpc, err := controlHttpClient(config.Global.Control.SockPath)
+	if err != nil {
+		return err
+	}
+	var state logging.LevelState
+	if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointLogLevels, req, &state); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "OUTLET\tCONFIGURED LEVEL\n")
+	for _, o := range state.Configured {
+		fmt.Fprintf(w, "%s\t%s\n", o.Outlet, o.Level)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(state.Rules) == 0 {
+		return nil
+	}
+	any := func(s string) string {
+		if s == "" {
+			return "*"
+		}
+		return s
+	}
+	fmt.Printf("\noverrides, most specific first:\n")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "OUTLET\tJOB\tSUBSYSTEM\tLEVEL\n")
+	for _, r := range state.Rules {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", any(r.Outlet), any(r.Job), any(r.Subsystem), r.Level)
+	}
+	return w.Flush()
+}
@@ -1,16 +1,30 @@
 package client
 
 import (
+	"fmt"
+	"os"
+	"os/user"
+
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 
 	"github.com/zrepl/zrepl/cli"
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon"
 )
 
+var signalArgs struct {
+	filesystems []string
+	wakeup      bool
+}
+
 var SignalCmd = &cli.Subcommand{
-	Use:   "signal [wakeup|reset] JOB",
-	Short: "wake up a job from wait state or abort its current invocation",
+	Use:   "signal [wakeup|reset|verify|snapshot|pause|resume] JOB",
+	Short: "wake up a job from wait state, abort its current invocation, take snapshots now or pause and resume it",
+	SetupFlags: func(f *pflag.FlagSet) {
+		f.StringSliceVar(&signalArgs.filesystems, "filesystem", nil, "snapshot: only snapshot this filesystem (can be repeated)")
+		f.BoolVar(&signalArgs.wakeup, "wakeup", false, "snapshot: wake up the job after the snapshots have been taken")
+	},
 	Run: func(subcommand *cli.Subcommand, args []string) error {
 		return runSignalCmd(subcommand.Config(), args)
 	},
@@ -18,7 +32,10 @@ var SignalCmd = &cli.Subcommand{
 
 func runSignalCmd(config *config.Config, args []string) error {
 	if len(args) != 2 {
-		return errors.Errorf("Expected 2 arguments: [wakeup|reset] JOB")
+		return errors.Errorf("Expected 2 arguments: [wakeup|reset|verify|snapshot|pause|resume] JOB")
+	}
+	if args[0] != "snapshot" && (len(signalArgs.filesystems) > 0 || signalArgs.wakeup) {
+		return errors.Errorf("--filesystem and --wakeup are only valid for signal snapshot")
 	}
 
 	httpc, err := controlHttpClient(config.Global.Control.SockPath)
@@ -26,15 +43,25 @@ func runSignalCmd(config *config.Config, args []string) error {
 		return err
 	}
 
-	err = jsonRequestResponse(httpc, daemon.ControlJobEndpointSignal,
-		struct {
-			Name string
-			Op   string
-		}{
-			Name: args[1],
-			Op:   args[0],
-		},
-		struct{}{},
-	)
+	req := daemon.SignalRequest{
+		Name:        args[1],
+		Op:          args[0],
+		Filesystems: signalArgs.filesystems,
+		Wakeup:      signalArgs.wakeup,
+	}
+	if req.Op == "pause" || req.Op == "resume" {
+		req.By = signalSender()
+	}
+	err = jsonRequestResponse(httpc, daemon.ControlJobEndpointSignal, req, struct{}{})
 	return err
 }
+
+// signalSender describes the user who runs zrepl signal, shown as the originator of a pause
+func signalSender() string {
+	name := fmt.Sprintf("uid %d", os.Getuid())
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("zrepl signal (%s@%s)", name, host)
+}
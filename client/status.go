@@ -28,6 +28,7 @@ import (
 	"github.com/zrepl/zrepl/daemon/job"
 	"github.com/zrepl/zrepl/daemon/pruner"
 	"github.com/zrepl/zrepl/daemon/snapper"
+	"github.com/zrepl/zrepl/daemon/verifier"
 	"github.com/zrepl/zrepl/replication/report"
 )
 
@@ -79,6 +80,7 @@ func (p *bytesProgressHistory) Update(currentVal int64) (bytesPerSecondAvg int64
 type tui struct {
 	x, y   int
 	indent int
+	attr   termbox.Attribute
 
 	lock   sync.Mutex //For report and error
 	report map[string]job.Status
@@ -87,11 +89,31 @@ type tui struct {
 	jobFilter string
 
 	replicationProgress map[string]*bytesProgressHistory // by job name
+
+	// navigation state, protected by lock
+
+	selectedJob string
+	fsFilter    fsFilter
+	cursor      itemKey
+	expanded    map[itemKey]bool
+	// lines y in [bodyTop, bodyBottom) of the terminal display lines [scroll, scroll+bodyBottom-bodyTop) of the body
+	scroll, bodyTop, bodyBottom int
+	scrollToCursor              bool
+	message                     string // shown in the last line
+	confirmReset                string // job name, non-empty while waiting for confirmation
+
+	// valid after draw
+	renderingJob string
+	bodyLines    int
+	items        []itemKey
+	itemLines    map[itemKey]int
 }
 
 func newTui() tui {
 	return tui{
 		replicationProgress: make(map[string]*bytesProgressHistory),
+		expanded:            make(map[itemKey]bool),
+		itemLines:           make(map[itemKey]int),
 	}
 }
 
@@ -108,7 +130,10 @@ func (t *tui) write(text string) {
 			t.newline()
 			continue
 		}
-		termbox.SetCell(t.x, t.y, c, termbox.ColorDefault, termbox.ColorDefault)
+		// t.y is the line within the body, only the part within the scroll window is visible
+		if y := t.y - t.scroll + t.bodyTop; y >= t.bodyTop && y < t.bodyBottom {
+			termbox.SetCell(t.x, y, c, termbox.ColorDefault|t.attr, termbox.ColorDefault)
+		}
 		t.x += 1
 	}
 }
@@ -172,10 +197,10 @@ var statusFlags struct {
 
 var StatusCmd = &cli.Subcommand{
 	Use:   "status",
-	Short: "show job activity or dump as JSON for monitoring",
+	Short: "show job activity interactively or dump as JSON for monitoring",
 	SetupFlags: func(f *pflag.FlagSet) {
 		f.BoolVar(&statusFlags.Raw, "raw", false, "dump raw status description from zrepl daemon")
-		f.StringVar(&statusFlags.Job, "job", "", "only dump specified job")
+		f.StringVar(&statusFlags.Job, "job", "", "only show or dump specified job")
 	},
 	Run: runStatus,
 }
@@ -242,6 +267,21 @@ func runStatus(s *cli.Subcommand, args []string) error {
 		}
 	}()
 
+	signal := func(op, jobName string) {
+		err := jsonRequestResponse(httpc, daemon.ControlJobEndpointSignal,
+			daemon.SignalRequest{Name: jobName, Op: op},
+			struct{}{},
+		)
+		t.lock.Lock()
+		if err != nil {
+			t.message = fmt.Sprintf("%s %s: %s", op, jobName, err)
+		} else {
+			t.message = fmt.Sprintf("sent %s to job %s", op, jobName)
+		}
+		t.lock.Unlock()
+		t.draw()
+	}
+
 	termbox.HideCursor()
 	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
 
@@ -249,12 +289,17 @@ loop:
 	for {
 		switch ev := termbox.PollEvent(); ev.Type {
 		case termbox.EventKey:
-			switch ev.Key {
-			case termbox.KeyEsc:
-				break loop
-			case termbox.KeyCtrlC:
+			if ev.Key == termbox.KeyEsc || ev.Key == termbox.KeyCtrlC || ev.Ch == 'q' {
 				break loop
 			}
+			t.lock.Lock()
+			op := t.handleKey(ev)
+			jobName := t.selectedJob
+			t.lock.Unlock()
+			if op != "" {
+				go signal(op, jobName)
+			}
+			t.draw()
 		case termbox.EventResize:
 			t.draw()
 		}
@@ -273,129 +318,273 @@ func (t *tui) getReplicationProgresHistory(jobName string) *bytesProgressHistory
 	return p
 }
 
+// handleKey updates the navigation state and returns the signal to send to the selected job, if any.
+// Must be called with t.lock held.
+func (t *tui) handleKey(ev termbox.Event) (signalOp string) {
+	if t.confirmReset != "" {
+		if ev.Ch == 'y' && t.confirmReset == t.selectedJob {
+			signalOp = "reset"
+		}
+		t.confirmReset = ""
+		t.message = ""
+		return signalOp
+	}
+	switch {
+	case ev.Key == termbox.KeyArrowRight || ev.Key == termbox.KeyTab || ev.Ch == 'l':
+		t.selectJob(1)
+	case ev.Key == termbox.KeyArrowLeft || ev.Ch == 'h':
+		t.selectJob(-1)
+	case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+		t.moveCursor(1)
+	case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+		t.moveCursor(-1)
+	case ev.Key == termbox.KeyPgdn || ev.Key == termbox.KeySpace:
+		t.scrollBy(t.bodyHeight())
+	case ev.Key == termbox.KeyPgup:
+		t.scrollBy(-t.bodyHeight())
+	case ev.Key == termbox.KeyHome:
+		t.scroll = 0
+	case ev.Key == termbox.KeyEnd:
+		t.scroll = t.bodyLines
+		t.clampScroll()
+	case ev.Key == termbox.KeyEnter:
+		if t.cursor != (itemKey{}) {
+			t.expanded[t.cursor] = !t.expanded[t.cursor]
+			t.scrollToCursor = true
+		}
+	case ev.Ch == 'f':
+		t.fsFilter = t.fsFilter.next()
+		t.scroll = 0
+	case ev.Ch == 'w':
+		if t.selectedJob != "" {
+			signalOp = "wakeup"
+		}
+	case ev.Ch == 'r':
+		if t.selectedJob != "" {
+			t.confirmReset = t.selectedJob
+			t.message = fmt.Sprintf("abort current invocation of job %s? press y to confirm", t.selectedJob)
+		}
+	}
+	return signalOp
+}
+
+// visibleJobs returns the names of the jobs that can be selected, in alphabetical order.
+// Must be called with t.lock held.
+func (t *tui) visibleJobs() []string {
+	keys := make([]string, 0, len(t.report))
+	for k := range t.report {
+		if len(k) == 0 || daemon.IsInternalJobName(k) { //Internal job
+			continue
+		}
+		if t.jobFilter != "" && k != t.jobFilter {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const statusHelp = "←/→ job  ↑/↓ filesystem  enter expand  pgup/pgdn scroll  f filter  w wakeup  r reset  q quit"
+
 func (t *tui) draw() {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	t.drawLocked()
+	if t.scrollToCursor {
+		t.scrollToCursor = false
+		if t.adjustScrollToCursor() {
+			t.drawLocked()
+		}
+	}
+	termbox.Flush()
+}
+
+// writeLine writes text in line y of the terminal, outside of the scrollable body
+func writeLine(y int, text string, attr termbox.Attribute) {
+	x := 0
+	for _, c := range text {
+		termbox.SetCell(x, y, c, termbox.ColorDefault|attr, termbox.ColorDefault)
+		x++
+	}
+}
+
+func (t *tui) drawLocked() {
 	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	_, height := termbox.Size()
+	t.bodyTop, t.bodyBottom = 2, height-1
+	t.clampScroll()
 	t.x = 0
 	t.y = 0
 	t.indent = 0
+	t.items = t.items[:0]
+	t.itemLines = make(map[itemKey]int, len(t.itemLines))
+
+	jobs := t.visibleJobs()
+	found := false
+	for _, name := range jobs {
+		found = found || name == t.selectedJob
+	}
+	if !found && len(jobs) > 0 {
+		t.selectedJob = jobs[0]
+	}
+
+	// header: job tabs and help
+	x := 0
+	for _, name := range jobs {
+		attr := termbox.Attribute(0)
+		if name == t.selectedJob {
+			attr = termbox.AttrReverse
+		}
+		for _, c := range " " + name + " " {
+			termbox.SetCell(x, 0, c, termbox.ColorDefault|attr, termbox.ColorDefault)
+			x++
+		}
+		x++
+	}
+	writeLine(1, fmt.Sprintf("filter: %-8s %s", t.fsFilter, statusHelp), 0)
 
 	if t.err != nil {
 		t.write(t.err.Error())
+	} else if len(jobs) == 0 {
+		t.setIndent(0)
+		t.printf("no jobs to display")
+		t.newline()
 	} else {
-		//Iterate over map in alphabetical order
-		keys := make([]string, 0, len(t.report))
-		for k := range t.report {
-			if len(k) == 0 || daemon.IsInternalJobName(k) { //Internal job
-				continue
-			}
-			if t.jobFilter != "" && k != t.jobFilter {
-				continue
-			}
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
+		t.renderJob(t.selectedJob, t.report[t.selectedJob])
+	}
+	t.bodyLines = t.y + 1
 
-		if len(keys) == 0 {
-			t.setIndent(0)
-			t.printf("no jobs to display")
-			t.newline()
-			termbox.Flush()
-			return
+	found = false
+	for _, k := range t.items {
+		found = found || k == t.cursor
+	}
+	if !found {
+		t.cursor = itemKey{}
+		if len(t.items) > 0 {
+			t.cursor = t.items[0]
 		}
+	}
 
-		for _, k := range keys {
-			v := t.report[k]
+	footer := t.message
+	if footer == "" && t.bodyLines > t.bodyHeight() {
+		footer = fmt.Sprintf("lines %d-%d of %d", t.scroll+1, t.scroll+t.bodyHeight(), t.bodyLines)
+	}
+	writeLine(height-1, footer, 0)
+}
 
-			t.setIndent(0)
+func (t *tui) renderJob(k string, v job.Status) {
+	t.renderingJob = k
+
+	t.setIndent(0)
+
+	t.printf("Job: %s", k)
+	t.setIndent(1)
+	t.newline()
+	t.printf("Type: %s", v.Type)
+	t.setIndent(1)
+	t.newline()
+	if p := v.Paused; p != nil {
+		t.printf("PAUSED since %s by %s", p.Since.Format(time.RFC3339), p.By)
+		t.newline()
+	}
 
-			t.printf("Job: %s", k)
-			t.setIndent(1)
+	if v.Type == job.TypePush || v.Type == job.TypePull {
+		activeStatus, ok := v.JobSpecific.(*job.ActiveSideStatus)
+		if !ok || activeStatus == nil {
+			t.printf("ActiveSideStatus is null")
 			t.newline()
-			t.printf("Type: %s", v.Type)
-			t.setIndent(1)
+			return
+		}
+
+		t.printf("Replication:")
+		t.newline()
+		t.addIndent(1)
+		if !activeStatus.WaitWindowUntil.IsZero() {
+			t.printf("Waiting for replication window until %s", activeStatus.WaitWindowUntil.Format(time.RFC3339))
 			t.newline()
+		}
+		t.renderReplicationReport(activeStatus.Replication, t.getReplicationProgresHistory(k), activeStatus.PruningSender)
+		t.addIndent(-1)
 
-			if v.Type == job.TypePush || v.Type == job.TypePull {
-				activeStatus, ok := v.JobSpecific.(*job.ActiveSideStatus)
-				if !ok || activeStatus == nil {
-					t.printf("ActiveSideStatus is null")
-					t.newline()
-					continue
-				}
+		t.printf("Pruning Sender:")
+		t.newline()
+		t.addIndent(1)
+		t.renderPrunerReport(itemSectionPruningSender, activeStatus.PruningSender)
+		t.addIndent(-1)
 
-				t.printf("Replication:")
-				t.newline()
-				t.addIndent(1)
-				t.renderReplicationReport(activeStatus.Replication, t.getReplicationProgresHistory(k))
-				t.addIndent(-1)
+		t.printf("Pruning Receiver:")
+		t.newline()
+		t.addIndent(1)
+		t.renderPrunerReport(itemSectionPruningReceiver, activeStatus.PruningReceiver)
+		t.addIndent(-1)
 
-				t.printf("Pruning Sender:")
-				t.newline()
-				t.addIndent(1)
-				t.renderPrunerReport(activeStatus.PruningSender)
-				t.addIndent(-1)
+		if v.Type == job.TypePush {
+			t.printf("Snapshotting:")
+			t.newline()
+			t.addIndent(1)
+			t.renderSnapperReport(activeStatus.Snapshotting)
+			t.addIndent(-1)
+		}
 
-				t.printf("Pruning Receiver:")
-				t.newline()
-				t.addIndent(1)
-				t.renderPrunerReport(activeStatus.PruningReceiver)
-				t.addIndent(-1)
+		if activeStatus.VerificationRunning || activeStatus.Verification != nil {
+			t.printf("Verification:")
+			t.newline()
+			t.addIndent(1)
+			t.renderVerifyReport(activeStatus.Verification, activeStatus.VerificationRunning)
+			t.addIndent(-1)
+		}
 
-				if v.Type == job.TypePush {
-					t.printf("Snapshotting:")
-					t.newline()
-					t.addIndent(1)
-					t.renderSnapperReport(activeStatus.Snapshotting)
-					t.addIndent(-1)
-				}
-
-			} else if v.Type == job.TypeSnap {
-				snapStatus, ok := v.JobSpecific.(*job.SnapJobStatus)
-				if !ok || snapStatus == nil {
-					t.printf("SnapJobStatus is null")
-					t.newline()
-					continue
-				}
-				t.printf("Pruning snapshots:")
-				t.newline()
-				t.addIndent(1)
-				t.renderPrunerReport(snapStatus.Pruning)
-				t.addIndent(-1)
-				t.printf("Snapshotting:")
-				t.newline()
-				t.addIndent(1)
-				t.renderSnapperReport(snapStatus.Snapshotting)
-				t.addIndent(-1)
-			} else if v.Type == job.TypeSource {
+	} else if v.Type == job.TypeSnap {
+		snapStatus, ok := v.JobSpecific.(*job.SnapJobStatus)
+		if !ok || snapStatus == nil {
+			t.printf("SnapJobStatus is null")
+			t.newline()
+			return
+		}
+		t.printf("Pruning snapshots:")
+		t.newline()
+		t.addIndent(1)
+		t.renderPrunerReport(itemSectionPruning, snapStatus.Pruning)
+		t.addIndent(-1)
+		t.printf("Snapshotting:")
+		t.newline()
+		t.addIndent(1)
+		t.renderSnapperReport(snapStatus.Snapshotting)
+		t.addIndent(-1)
+	} else if v.Type == job.TypeSource || v.Type == job.TypeSink {
 
-				st := v.JobSpecific.(*job.PassiveStatus)
-				t.printf("Snapshotting:\n")
-				t.addIndent(1)
-				t.renderSnapperReport(st.Snapper)
-				t.addIndent(-1)
+		st := v.JobSpecific.(*job.PassiveStatus)
+		if st.Pruning != nil {
+			t.printf("Pruning snapshots:")
+			t.newline()
+			t.addIndent(1)
+			t.renderPrunerReport(itemSectionPruning, st.Pruning)
+			t.addIndent(-1)
+		}
+		if v.Type == job.TypeSource {
+			t.printf("Snapshotting:\n")
+			t.addIndent(1)
+			t.renderSnapperReport(st.Snapper)
+			t.addIndent(-1)
+		}
 
-			} else {
-				t.printf("No status representation for job type '%s', dumping as YAML", v.Type)
-				t.newline()
-				asYaml, err := yaml.Marshal(v.JobSpecific)
-				if err != nil {
-					t.printf("Error marshaling status to YAML: %s", err)
-					t.newline()
-					continue
-				}
-				t.write(string(asYaml))
-				t.newline()
-				continue
-			}
+	} else {
+		t.printf("No status representation for job type '%s', dumping as YAML", v.Type)
+		t.newline()
+		asYaml, err := yaml.Marshal(v.JobSpecific)
+		if err != nil {
+			t.printf("Error marshaling status to YAML: %s", err)
+			t.newline()
+			return
 		}
+		t.write(string(asYaml))
+		t.newline()
 	}
-	termbox.Flush()
 }
 
-func (t *tui) renderReplicationReport(rep *report.Report, history *bytesProgressHistory) {
+func (t *tui) renderReplicationReport(rep *report.Report, history *bytesProgressHistory, senderPruning *pruner.Report) {
 	if rep == nil {
 		t.printf("...\n")
 		return
@@ -463,9 +652,14 @@ func (t *tui) renderReplicationReport(rep *report.Report, history *bytesProgress
 		// Progress: [---------------]
 		expected, replicated := latest.BytesSum()
 		rate, changeCount := history.Update(replicated)
+		if latest.Progress != nil {
+			// prefer the daemon's rate, the client-side one is only a fallback for older daemons
+			rate = latest.Progress.BytesPerSecond
+		}
 		t.write("Progress: ")
 		t.drawBar(50, replicated, expected, changeCount)
 		t.write(fmt.Sprintf(" %s / %s @ %s/s", ByteCountBinary(replicated), ByteCountBinary(expected), ByteCountBinary(rate)))
+		t.write(formatETA(latest.Progress))
 		t.newline()
 
 		var maxFSLen int
@@ -474,15 +668,140 @@ func (t *tui) renderReplicationReport(rep *report.Report, history *bytesProgress
 				maxFSLen = len(fs.Info.Name)
 			}
 		}
+		hidden := 0
 		for _, fs := range latest.Filesystems {
-			t.printFilesystemStatus(fs, false, maxFSLen) // FIXME bring 'active' flag back
+			if !t.fsFilter.matches(replicationFSClass(fs)) {
+				hidden++
+				continue
+			}
+			key, selected := t.item(itemSectionReplication, fs.Info.Name)
+			t.writeCursor(selected)
+			t.printFilesystemStatus(fs, fs.State == report.FilesystemStepping, maxFSLen)
+			if t.expanded[key] {
+				t.addIndent(1)
+				t.renderFilesystemDetail(fs, senderPruning)
+				t.addIndent(-1)
+			}
+		}
+		t.printHidden(hidden)
+
+	}
+
+}
+
+func (t *tui) printHidden(hidden int) {
+	if hidden > 0 {
+		t.printf("(%d filesystems hidden by filter %q)", hidden, t.fsFilter)
+		t.newline()
+	}
+}
+
+// renderFilesystemDetail renders the steps and error of an expanded filesystem
+// and, if it is pruned on the sender, the snapshots the pruner destroys.
+func (t *tui) renderFilesystemDetail(fs *report.FilesystemReport, senderPruning *pruner.Report) {
+	if err := fs.Error(); err != nil {
+		t.printf("Error (%s):", err.Time.Format(time.RFC3339))
+		t.printfDrawIndentedAndWrappedIfMultiline(" %s", err.Err)
+		t.newline()
+	}
+	if len(fs.Steps) == 0 {
+		t.printf("no steps planned")
+		t.newline()
+	}
+	for i, step := range fs.Steps {
+		var state string
+		switch {
+		case i < fs.CurrentStep || fs.State == report.FilesystemDone:
+			state = "done"
+		case i == fs.CurrentStep && fs.State == report.FilesystemStepping:
+			state = "active"
+		case i == fs.CurrentStep && fs.State == report.FilesystemSteppingErrored:
+			state = "failed"
+		default:
+			state = "pending"
+		}
+		what := fmt.Sprintf("%s (full)", step.Info.To)
+		if step.IsIncremental() {
+			what = fmt.Sprintf("%s => %s", step.Info.From, step.Info.To)
+		}
+		t.printf("step %d/%d %-7s %s / %s  %s", i+1, len(fs.Steps), state,
+			ByteCountBinary(step.Info.BytesReplicated), ByteCountBinary(step.Info.BytesExpected), what)
+		if state == "active" && step.Progress != nil {
+			t.printf(" @ %s/s%s", ByteCountBinary(step.Progress.BytesPerSecond), formatETA(step.Progress))
 		}
+		t.newline()
+	}
+	if senderPruning == nil {
+		return
+	}
+	for _, pfs := range append(append([]pruner.FSReport{}, senderPruning.Pending...), senderPruning.Completed...) {
+		if pfs.Filesystem == fs.Info.Name {
+			t.printf("Pruning Sender:")
+			t.newline()
+			t.addIndent(1)
+			t.renderPrunerFSDetail(&pfs)
+			t.addIndent(-1)
+			break
+		}
+	}
+}
 
+func (t *tui) renderPrunerFSDetail(fs *pruner.FSReport) {
+	if fs.LastError != "" {
+		t.printf("Error:")
+		t.printfDrawIndentedAndWrappedIfMultiline(" %s", fs.LastError)
+		t.newline()
+	}
+	if len(fs.DestroyList) == 0 {
+		t.printf("destroy list is empty")
+		t.newline()
+		return
 	}
+	t.printf("destroy list (%d of %d snapshots):", len(fs.DestroyList), len(fs.SnapshotList))
+	t.newline()
+	t.addIndent(1)
+	for _, snap := range fs.DestroyList {
+		t.printf("%s  %s", snap.Date.Format(time.RFC3339), snap.Name)
+		t.newline()
+	}
+	t.addIndent(-1)
+}
 
+func (t *tui) renderVerifyReport(r *verifier.Report, running bool) {
+	if running {
+		t.printf("Status: running")
+		t.newline()
+	}
+	if r == nil {
+		return
+	}
+	t.printf("Last: %s (took %s)", r.FinishAt.Format(time.RFC3339), r.FinishAt.Sub(r.StartAt).Round(time.Second))
+	t.newline()
+	if r.Error != "" {
+		t.printfDrawIndentedAndWrappedIfMultiline("Error: %s", r.Error)
+		t.newline()
+		return
+	}
+	if r.Consistent() {
+		t.printf("Result: consistent")
+		t.newline()
+		return
+	}
+	t.printf("Result: inconsistent")
+	t.newline()
+	for _, fs := range r.Filesystems {
+		if fs.Error != "" {
+			t.printfDrawIndentedAndWrappedIfMultiline("%s: error: %s", fs.Filesystem, fs.Error)
+			t.newline()
+		}
+		for _, d := range fs.Drift {
+			t.printf("%s: %s", fs.Filesystem, d)
+			t.newline()
+		}
+	}
 }
 
-func (t *tui) renderPrunerReport(r *pruner.Report) {
+func (t *tui) renderPrunerReport(section string, r *pruner.Report) {
 	if r == nil {
 		t.printf("...\n")
 		return
@@ -553,41 +872,58 @@ func (t *tui) renderPrunerReport(r *pruner.Report) {
 	})
 
 	// Draw a table-like representation of 'all'
+	hidden := 0
 	for _, fs := range all {
-		t.write(rightPad(fs.Filesystem, maxFSname, " "))
-		t.write(" ")
-		if !fs.SkipReason.NotSkipped() {
-			t.printf("skipped: %s\n", fs.SkipReason)
+		if !t.fsFilter.matches(prunerFSClass(fs.FSReport, fs.completed)) {
+			hidden++
 			continue
 		}
-		if fs.LastError != "" {
-			if strings.ContainsAny(fs.LastError, "\r\n") {
-				t.printf("ERROR:")
-				t.printfDrawIndentedAndWrappedIfMultiline("%s\n", fs.LastError)
-			} else {
-				t.printfDrawIndentedAndWrappedIfMultiline("ERROR: %s\n", fs.LastError)
-			}
-			t.newline()
-			continue
+		key, selected := t.item(section, fs.Filesystem)
+		t.writeCursor(selected)
+		t.printPrunerFSRow(fs.FSReport, fs.completed, maxFSname)
+		if t.expanded[key] {
+			t.addIndent(1)
+			t.renderPrunerFSDetail(fs.FSReport)
+			t.addIndent(-1)
 		}
+	}
+	t.printHidden(hidden)
 
-		pruneRuleActionStr := fmt.Sprintf("(destroy %d of %d snapshots)",
-			len(fs.DestroyList), len(fs.SnapshotList))
-
-		if fs.completed {
-			t.printf("Completed  %s\n", pruneRuleActionStr)
-			continue
-		}
+}
 
-		t.write("Pending    ") // whitespace is padding 10
-		if len(fs.DestroyList) == 1 {
-			t.write(fs.DestroyList[0].Name)
+func (t *tui) printPrunerFSRow(fs *pruner.FSReport, completed bool, maxFSname int) {
+	t.write(rightPad(fs.Filesystem, maxFSname, " "))
+	t.write(" ")
+	if !fs.SkipReason.NotSkipped() {
+		t.printf("skipped: %s\n", fs.SkipReason)
+		return
+	}
+	if fs.LastError != "" {
+		if strings.ContainsAny(fs.LastError, "\r\n") {
+			t.printf("ERROR:")
+			t.printfDrawIndentedAndWrappedIfMultiline("%s\n", fs.LastError)
 		} else {
-			t.write(pruneRuleActionStr)
+			t.printfDrawIndentedAndWrappedIfMultiline("ERROR: %s\n", fs.LastError)
 		}
 		t.newline()
+		return
+	}
+
+	pruneRuleActionStr := fmt.Sprintf("(destroy %d of %d snapshots)",
+		len(fs.DestroyList), len(fs.SnapshotList))
+
+	if completed {
+		t.printf("Completed  %s\n", pruneRuleActionStr)
+		return
 	}
 
+	t.write("Pending    ") // whitespace is padding 10
+	if len(fs.DestroyList) == 1 {
+		t.write(fs.DestroyList[0].Name)
+	} else {
+		t.write(pruneRuleActionStr)
+	}
+	t.newline()
 }
 
 func (t *tui) renderSnapperReport(r *snapper.Report) {
@@ -622,8 +958,13 @@ func (t *tui) renderSnapperReport(r *snapper.Report) {
 	var widths struct {
 		path, state, duration int
 	}
-	rows := make([]*row, len(r.Progress))
-	for i, fs := range r.Progress {
+	rows := make([]*row, 0, len(r.Progress))
+	hidden := 0
+	for _, fs := range r.Progress {
+		if !t.fsFilter.matches(snapperFSClass(fs)) {
+			hidden++
+			continue
+		}
 		r := &row{
 			path:  fs.Path,
 			state: fs.State.String(),
@@ -644,7 +985,7 @@ func (t *tui) renderSnapperReport(r *snapper.Report) {
 			r.duration = dur(fs.DoneAt.Sub(fs.StartAt))
 			r.remainder = fmt.Sprintf("snap name: %q", fs.SnapName)
 		}
-		rows[i] = r
+		rows = append(rows, r)
 		if len(r.path) > widths.path {
 			widths.path = len(r.path)
 		}
@@ -667,7 +1008,15 @@ func (t *tui) renderSnapperReport(r *snapper.Report) {
 		}
 		t.newline()
 	}
+	t.printHidden(hidden)
 
+	if r.OutOfBand != nil {
+		t.printf("Last out-of-band round:")
+		t.newline()
+		t.addIndent(1)
+		t.renderSnapperReport(r.OutOfBand)
+		t.addIndent(-1)
+	}
 }
 
 func times(str string, n int) (out string) {
@@ -713,6 +1062,9 @@ func (t *tui) printFilesystemStatus(rep *report.FilesystemReport, active bool, m
 		rep.CurrentStep, len(rep.Steps),
 		ByteCountBinary(replicated), ByteCountBinary(expected),
 	)
+	if rep.State == report.FilesystemStepping && rep.Progress != nil {
+		status += fmt.Sprintf(" @ %s/s%s", ByteCountBinary(rep.Progress.BytesPerSecond), formatETA(rep.Progress))
+	}
 
 	activeIndicator := " "
 	if active {
@@ -743,6 +1095,14 @@ func (t *tui) printFilesystemStatus(rep *report.FilesystemReport, active bool, m
 	t.newline()
 }
 
+// formatETA returns the empty string if p has no ETA
+func formatETA(p *report.Progress) string {
+	if p == nil || p.ETA.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(", ETA %s (%s)", p.ETA.Format("15:04:05"), time.Until(p.ETA).Round(time.Second))
+}
+
 func ByteCountBinary(b int64) string {
 	const unit = 1024
 	if b < unit {
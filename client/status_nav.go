@@ -0,0 +1,203 @@
+package client
+
+import (
+	"github.com/gdamore/tcell/termbox"
+
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/daemon/snapper"
+	"github.com/zrepl/zrepl/replication/report"
+)
+
+// fsClass is the state of a filesystem in any of the reports, as far as filtering is concerned.
+type fsClass int
+
+const (
+	fsClassOther fsClass = iota
+	fsClassError
+	fsClassActive
+	fsClassPending
+)
+
+func replicationFSClass(fs *report.FilesystemReport) fsClass {
+	if fs.Error() != nil {
+		return fsClassError
+	}
+	switch fs.State {
+	case report.FilesystemStepping:
+		return fsClassActive
+	case report.FilesystemPlanning, report.FilesystemPaused:
+		return fsClassPending
+	}
+	return fsClassOther
+}
+
+func prunerFSClass(fs *pruner.FSReport, completed bool) fsClass {
+	if fs.LastError != "" {
+		return fsClassError
+	}
+	if !completed && fs.SkipReason.NotSkipped() {
+		return fsClassPending
+	}
+	return fsClassOther
+}
+
+func snapperFSClass(fs *snapper.ReportFilesystem) fsClass {
+	switch {
+	case fs.State == snapper.SnapError || fs.HooksHadError:
+		return fsClassError
+	case fs.State == snapper.SnapStarted:
+		return fsClassActive
+	case fs.State == snapper.SnapPending:
+		return fsClassPending
+	}
+	return fsClassOther
+}
+
+// fsFilter restricts the filesystems shown in the status TUI
+type fsFilter int
+
+const (
+	fsFilterAll fsFilter = iota
+	fsFilterError
+	fsFilterActive
+	fsFilterPending
+	fsFilterCount // must be last
+)
+
+func (f fsFilter) String() string {
+	switch f {
+	case fsFilterError:
+		return "error"
+	case fsFilterActive:
+		return "active"
+	case fsFilterPending:
+		return "pending"
+	}
+	return "all"
+}
+
+func (f fsFilter) next() fsFilter {
+	return (f + 1) % fsFilterCount
+}
+
+func (f fsFilter) matches(c fsClass) bool {
+	switch f {
+	case fsFilterError:
+		return c == fsClassError
+	case fsFilterActive:
+		return c == fsClassActive
+	case fsFilterPending:
+		return c == fsClassPending
+	}
+	return true
+}
+
+// itemKey identifies a selectable and expandable filesystem row in the status TUI
+type itemKey struct {
+	job, section, fs string
+}
+
+const (
+	itemSectionReplication     = "replication"
+	itemSectionPruningSender   = "pruning sender"
+	itemSectionPruningReceiver = "pruning receiver"
+	itemSectionPruning         = "pruning" // snap, sink and source jobs
+)
+
+// item records a selectable row at the current line and returns whether it is the selected one
+func (t *tui) item(section, fs string) (key itemKey, selected bool) {
+	key = itemKey{t.renderingJob, section, fs}
+	t.items = append(t.items, key)
+	t.itemLines[key] = t.y
+	return key, key == t.cursor
+}
+
+func (t *tui) writeCursor(selected bool) {
+	if selected {
+		t.attr = termbox.AttrReverse
+		t.write(">")
+		t.attr = 0
+		t.write(" ")
+	} else {
+		t.write("  ")
+	}
+}
+
+// moveCursor moves the cursor by delta rows, based on the rows of the last draw
+func (t *tui) moveCursor(delta int) {
+	if len(t.items) == 0 {
+		return
+	}
+	i := 0
+	for j, k := range t.items {
+		if k == t.cursor {
+			i = j + delta
+			break
+		}
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(t.items) {
+		i = len(t.items) - 1
+	}
+	t.cursor = t.items[i]
+	t.scrollToCursor = true
+}
+
+func (t *tui) bodyHeight() int {
+	h := t.bodyBottom - t.bodyTop
+	if h < 1 {
+		return 1
+	}
+	return h
+}
+
+func (t *tui) scrollBy(delta int) {
+	t.scroll += delta
+	t.clampScroll()
+}
+
+func (t *tui) clampScroll() {
+	if max := t.bodyLines - t.bodyHeight(); t.scroll > max {
+		t.scroll = max
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+}
+
+// adjustScrollToCursor returns true if scroll changed
+func (t *tui) adjustScrollToCursor() bool {
+	line, ok := t.itemLines[t.cursor]
+	if !ok {
+		return false
+	}
+	prev := t.scroll
+	if line < t.scroll {
+		t.scroll = line
+	} else if line >= t.scroll+t.bodyHeight() {
+		t.scroll = line - t.bodyHeight() + 1
+	}
+	return prev != t.scroll
+}
+
+func (t *tui) selectJob(delta int) {
+	jobs := t.visibleJobs()
+	if len(jobs) == 0 {
+		return
+	}
+	i := 0
+	for j, name := range jobs {
+		if name == t.selectedJob {
+			i = j + delta
+			break
+		}
+	}
+	i = (i%len(jobs) + len(jobs)) % len(jobs)
+	if jobs[i] != t.selectedJob {
+		t.selectedJob = jobs[i]
+		t.scroll = 0
+		t.cursor = itemKey{}
+	}
+}
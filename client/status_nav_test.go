@@ -0,0 +1,81 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/replication/report"
+)
+
+func TestStatusFSFilter(t *testing.T) {
+	failed := &report.FilesystemReport{
+		State:     report.FilesystemSteppingErrored,
+		StepError: report.NewTimedError("broken pipe", time.Now()),
+	}
+	stepping := &report.FilesystemReport{State: report.FilesystemStepping}
+	planning := &report.FilesystemReport{State: report.FilesystemPlanning}
+	done := &report.FilesystemReport{State: report.FilesystemDone}
+
+	assert.Equal(t, fsClassError, replicationFSClass(failed))
+	assert.Equal(t, fsClassActive, replicationFSClass(stepping))
+	assert.Equal(t, fsClassPending, replicationFSClass(planning))
+	assert.Equal(t, fsClassOther, replicationFSClass(done))
+
+	assert.Equal(t, fsClassError, prunerFSClass(&pruner.FSReport{LastError: "busy"}, true))
+	assert.Equal(t, fsClassPending, prunerFSClass(&pruner.FSReport{}, false))
+	assert.Equal(t, fsClassOther, prunerFSClass(&pruner.FSReport{}, true))
+
+	for _, c := range []fsClass{fsClassError, fsClassActive, fsClassPending, fsClassOther} {
+		assert.True(t, fsFilterAll.matches(c))
+	}
+	assert.True(t, fsFilterError.matches(fsClassError))
+	assert.False(t, fsFilterError.matches(fsClassActive))
+	assert.False(t, fsFilterPending.matches(fsClassOther))
+
+	f := fsFilterAll
+	for i := 0; i < int(fsFilterCount); i++ {
+		f = f.next()
+	}
+	assert.Equal(t, fsFilterAll, f, "cycles through all filters")
+}
+
+func TestStatusNavigation(t *testing.T) {
+	tui := newTui()
+	tui.report = map[string]job.Status{"b": {}, "a": {}, "_control": {}}
+	tui.selectedJob = "a"
+
+	tui.selectJob(1)
+	assert.Equal(t, "b", tui.selectedJob)
+	tui.selectJob(1)
+	assert.Equal(t, "a", tui.selectedJob, "wraps around, skips internal jobs")
+	tui.selectJob(-1)
+	assert.Equal(t, "b", tui.selectedJob)
+
+	tui.bodyTop, tui.bodyBottom = 2, 12 // 10 lines visible
+	tui.bodyLines = 100
+	for i := 0; i < 50; i++ {
+		k := itemKey{"b", itemSectionReplication, string(rune('a' + i))}
+		tui.items = append(tui.items, k)
+		tui.itemLines[k] = 2 * i
+	}
+	tui.cursor = tui.items[0]
+
+	tui.moveCursor(-1)
+	assert.Equal(t, tui.items[0], tui.cursor)
+	tui.moveCursor(10)
+	assert.Equal(t, tui.items[10], tui.cursor)
+	assert.True(t, tui.adjustScrollToCursor())
+	assert.Equal(t, 20-10+1, tui.scroll, "cursor line is the last visible line")
+	assert.False(t, tui.adjustScrollToCursor())
+	tui.moveCursor(100)
+	assert.Equal(t, tui.items[49], tui.cursor)
+
+	tui.scrollBy(1000)
+	assert.Equal(t, 90, tui.scroll)
+	tui.scrollBy(-1000)
+	assert.Equal(t, 0, tui.scroll)
+}
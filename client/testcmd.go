@@ -1,21 +1,30 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
 	"github.com/zrepl/zrepl/cli"
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc"
+	"github.com/zrepl/zrepl/rpc/versionhandshake"
+	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/transport/fromconfig"
 	"github.com/zrepl/zrepl/zfs"
 )
 
 var TestCmd = &cli.Subcommand{
 	Use: "test",
 	SetupSubcommands: func() []*cli.Subcommand {
-		return []*cli.Subcommand{testFilter, testPlaceholder}
+		return []*cli.Subcommand{testFilter, testPlaceholder, testConnect}
 	},
 }
 
@@ -27,9 +36,9 @@ var testFilterArgs struct {
 
 var testFilter = &cli.Subcommand{
 	Use:   "filesystems --job JOB [--all | --input INPUT]",
-	Short: "test filesystems filter specified in push or source job",
+	Short: "test filesystems filter specified in push, source or snap job",
 	SetupFlags: func(f *pflag.FlagSet) {
-		f.StringVar(&testFilterArgs.job, "job", "", "the name of the push or source job")
+		f.StringVar(&testFilterArgs.job, "job", "", "the name of the push, source or snap job")
 		f.StringVar(&testFilterArgs.input, "input", "", "a filesystem name to test against the job's filters")
 		f.BoolVar(&testFilterArgs.all, "all", false, "test all local filesystems")
 	},
@@ -48,20 +57,25 @@ func runTestFilterCmd(subcommand *cli.Subcommand, args []string) error {
 	conf := subcommand.Config()
 
 	var confFilter config.FilesystemsFilter
+	var confSel *config.DatasetSelection
+	var confProps *config.DatasetProperties
+	var confSnap config.SnapshottingEnum
 	job, err := conf.Job(testFilterArgs.job)
 	if err != nil {
 		return err
 	}
 	switch j := job.Ret.(type) {
 	case *config.SourceJob:
-		confFilter = j.Filesystems
+		confFilter, confSel, confProps, confSnap = j.Filesystems, j.DatasetSelection, j.DatasetProperties, j.Snapshotting
 	case *config.PushJob:
-		confFilter = j.Filesystems
+		confFilter, confSel, confProps, confSnap = j.Filesystems, j.DatasetSelection, j.DatasetProperties, j.Snapshotting
+	case *config.SnapJob:
+		confFilter, confSel, confProps, confSnap = j.Filesystems, j.DatasetSelection, j.DatasetProperties, j.Snapshotting
 	default:
 		return fmt.Errorf("job type %T does not have filesystems filter", j)
 	}
 
-	f, err := filters.DatasetMapFilterFromConfig(confFilter)
+	f, props, err := filters.DatasetFilterFromConfig(confFilter, confSel, confProps)
 	if err != nil {
 		return fmt.Errorf("filter invalid: %s", err)
 	}
@@ -89,21 +103,33 @@ func runTestFilterCmd(subcommand *cli.Subcommand, args []string) error {
 		fspaths[i] = path
 	}
 
+	ctx := context.Background()
 	hadFilterErr := false
 	for _, in := range fspaths {
 		var res string
-		var errStr string
-		pass, err := f.Filter(in)
+		var detail string
+		var pass bool
+		var err error
+		if df, ok := f.(filters.DecidingFilter); ok {
+			var d filters.FilterDecision
+			d, err = df.Decide(ctx, in)
+			pass, detail = d.Pass, d.Reason
+			if err == nil && pass && props != nil {
+				detail += testFilterOverrides(ctx, props, in.ToString(), confSnap)
+			}
+		} else {
+			pass, err = f.Filter(in)
+		}
 		if err != nil {
 			res = "ERROR"
-			errStr = err.Error()
+			detail = err.Error()
 			hadFilterErr = true
 		} else if pass {
 			res = "ACCEPT"
 		} else {
 			res = "REJECT"
 		}
-		fmt.Printf("%s\t%s\t%s\n", res, in.ToString(), errStr)
+		fmt.Printf("%s\t%s\t%s\n", res, in.ToString(), detail)
 	}
 
 	if hadFilterErr {
@@ -112,6 +138,25 @@ func runTestFilterCmd(subcommand *cli.Subcommand, args []string) error {
 	return nil
 }
 
+// testFilterOverrides describes the per-dataset overrides of fs
+func testFilterOverrides(ctx context.Context, props *filters.DatasetProperties, fs string, snap config.SnapshottingEnum) string {
+	var s string
+	if d, ok, reason, err := props.Interval(ctx, fs); err != nil {
+		s += "; " + err.Error()
+	} else if ok {
+		s += "; interval: " + reason
+		if p, isPeriodic := snap.Ret.(*config.SnapshottingPeriodic); isPeriodic && d < p.Interval {
+			s += fmt.Sprintf(" (ignored, shorter than the job's interval %s)", p.Interval)
+		}
+	}
+	if _, ok, reason, err := props.KeepRules(ctx, fs); err != nil {
+		s += "; " + err.Error()
+	} else if ok {
+		s += "; keep: " + reason
+	}
+	return s
+}
+
 var testPlaceholderArgs struct {
 	ds  string
 	all bool
@@ -176,3 +221,160 @@ func runTestPlaceholder(subcommand *cli.Subcommand, args []string) error {
 	}
 	return nil
 }
+
+var testConnectArgs struct {
+	timeout       time.Duration
+	throughputMiB int64
+}
+
+var testConnect = &cli.Subcommand{
+	Use:   "connect JOB [--throughput MiB]",
+	Short: "test the connection of a push or pull job to its server, stage by stage",
+	Example: `
+	connect prod_to_backups
+	connect prod_to_backups --throughput 64`,
+	SetupFlags: func(f *pflag.FlagSet) {
+		f.DurationVar(&testConnectArgs.timeout, "timeout", 10*time.Second, "timeout for each stage")
+		f.Int64Var(&testConnectArgs.throughputMiB, "throughput", 0, "if > 0, measure throughput of the data connection by transferring this many MiB")
+	},
+	Run: runTestConnect,
+}
+
+func runTestConnect(subcommand *cli.Subcommand, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("must specify exactly one job name as positional argument")
+	}
+	if testConnectArgs.throughputMiB < 0 {
+		return fmt.Errorf("--throughput must not be negative")
+	}
+	conf := subcommand.Config()
+	job, err := conf.Job(args[0])
+	if err != nil {
+		return err
+	}
+	var connect config.ConnectEnum
+	var clientSends bool
+	switch j := job.Ret.(type) {
+	case *config.PushJob:
+		connect, clientSends = j.Connect, true
+	case *config.PullJob:
+		connect, clientSends = j.Connect, false
+	default:
+		return fmt.Errorf("job type %T does not connect to a server", j)
+	}
+	if _, ok := connect.Ret.(*config.LocalConnect); ok {
+		return fmt.Errorf("local transport only works within the daemon process")
+	}
+	cn, err := fromconfig.ConnecterFromConfig(conf.Global, connect)
+	if err != nil {
+		return errors.Wrap(err, "cannot build connecter")
+	}
+
+	stage := func(name string, timeout time.Duration, f func(ctx context.Context) (detail string, err error)) error {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		begin := time.Now()
+		detail, err := f(ctx)
+		took := time.Since(begin)
+		if err != nil {
+			fmt.Printf("%-20s FAILED after %s: %s\n", name, took, err)
+			return fmt.Errorf("stage %q failed", name)
+		}
+		fmt.Printf("%-20s %-12s %s\n", name, took.Round(time.Microsecond), detail)
+		return nil
+	}
+
+	// The first two stages use a connection of their own
+	// because the rpc.Client below performs them implicitly.
+	var wire transport.Wire
+	defer func() {
+		if wire != nil {
+			wire.Close()
+		}
+	}()
+	err = stage("transport connect", testConnectArgs.timeout, func(ctx context.Context) (string, error) {
+		w, err := cn.Connect(ctx)
+		if err != nil {
+			return "", err
+		}
+		wire = w
+		return fmt.Sprintf("remote address %s", w.RemoteAddr()), nil
+	})
+	if err != nil {
+		return err
+	}
+	err = stage("version handshake", testConnectArgs.timeout, func(ctx context.Context) (string, error) {
+		dl, _ := ctx.Deadline()
+		if err := versionhandshake.DoHandshakeCurrentVersion(wire, dl); err != nil {
+			return "", err
+		}
+		return "", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	client := rpc.NewClient(cn, rpc.Loggers{
+		General: logger.NewNullLogger(),
+		Control: logger.NewNullLogger(),
+		Data:    logger.NewNullLogger(),
+	})
+	defer client.Close()
+
+	checkPing := func(res *pdu.PingRes, req *pdu.PingReq) (string, error) {
+		if res.GetEcho() != req.GetMessage() {
+			return "", fmt.Errorf("pilot message not echoed correctly")
+		}
+		if res.GetClientIdentity() == "" {
+			return "client identity: (not reported by server)", nil
+		}
+		return fmt.Sprintf("client identity: %q", res.GetClientIdentity()), nil
+	}
+	err = stage("control ping", testConnectArgs.timeout, func(ctx context.Context) (string, error) {
+		req := &pdu.PingReq{Message: uuid.New().String()}
+		res, err := client.Ping(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return checkPing(res, req)
+	})
+	if err != nil {
+		return err
+	}
+	err = stage("data ping", testConnectArgs.timeout, func(ctx context.Context) (string, error) {
+		req := &pdu.PingReq{Message: uuid.New().String()}
+		res, err := client.PingDataconn(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return checkPing(res, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	if testConnectArgs.throughputMiB == 0 {
+		return nil
+	}
+	name := "throughput (recv)"
+	if clientSends {
+		name = "throughput (send)"
+	}
+	// no timeout, the duration of the transfer depends on the link
+	return stage(name, 0, func(ctx context.Context) (string, error) {
+		begin := time.Now()
+		res, err := client.ThroughputTest(ctx, &pdu.ThroughputTestReq{
+			Bytes:       testConnectArgs.throughputMiB << 20,
+			ClientSends: clientSends,
+		})
+		if err != nil {
+			return "", err
+		}
+		rate := float64(res.GetBytes()) / time.Since(begin).Seconds()
+		return fmt.Sprintf("%s @ %s/s", ByteCountBinary(res.GetBytes()), ByteCountBinary(int64(rate))), nil
+	})
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/zrepl/zrepl/cli"
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon"
+	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/verifier"
+)
+
+var verifyArgs struct {
+	json    bool
+	timeout time.Duration
+}
+
+var VerifyCmd = &cli.Subcommand{
+	Use:   "verify JOB",
+	Short: "compare sender and receiver of an active job and report inconsistencies",
+	SetupFlags: func(f *pflag.FlagSet) {
+		f.BoolVar(&verifyArgs.json, "json", false, "print the report as JSON")
+		f.DurationVar(&verifyArgs.timeout, "timeout", 1*time.Hour, "give up waiting for the verification to complete after this duration")
+	},
+	Run: func(subcommand *cli.Subcommand, args []string) error {
+		return runVerifyCmd(subcommand.Config(), args)
+	},
+}
+
+func runVerifyCmd(config *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.Errorf("Expected 1 argument: JOB")
+	}
+	jobName := args[0]
+
+	httpc, err := controlHttpClient(config.Global.Control.SockPath)
+	if err != nil {
+		return err
+	}
+
+	getStatus := func() (*job.ActiveSideStatus, error) {
+		m := make(map[string]job.Status)
+		if err := jsonRequestResponse(httpc, daemon.ControlJobEndpointStatus, struct{}{}, &m); err != nil {
+			return nil, err
+		}
+		s, ok := m[jobName]
+		if !ok {
+			return nil, errors.Errorf("job %q does not exist", jobName)
+		}
+		as, ok := s.JobSpecific.(*job.ActiveSideStatus)
+		if !ok || as == nil {
+			return nil, errors.Errorf("job %q is of type %s, only push and pull jobs can be verified", jobName, s.Type)
+		}
+		return as, nil
+	}
+	if _, err := getStatus(); err != nil {
+		return err
+	}
+
+	requestedAt := time.Now()
+	err = jsonRequestResponse(httpc, daemon.ControlJobEndpointSignal,
+		daemon.SignalRequest{
+			Name: jobName,
+			Op:   "verify",
+		},
+		struct{}{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// the job performs the verification when it is idle, poll its status until the report shows up
+	var report *verifier.Report
+	deadline := time.Now().Add(verifyArgs.timeout)
+	for report == nil {
+		if time.Now().After(deadline) {
+			return errors.Errorf("timeout waiting for verification to complete")
+		}
+		time.Sleep(1 * time.Second)
+		s, err := getStatus()
+		if err != nil {
+			return err
+		}
+		if !s.VerificationRunning && s.Verification != nil && !s.Verification.StartAt.Before(requestedAt) {
+			report = s.Verification
+		}
+	}
+
+	if verifyArgs.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printVerifyReport(report)
+	}
+
+	if !report.Consistent() {
+		return errors.Errorf("verification found inconsistencies")
+	}
+	return nil
+}
+
+func printVerifyReport(r *verifier.Report) {
+	fmt.Printf("Verification: %s - %s (took %s)\n", r.StartAt.Format(time.RFC3339), r.FinishAt.Format(time.RFC3339), r.FinishAt.Sub(r.StartAt).Round(time.Millisecond))
+	if r.Error != "" {
+		fmt.Printf("Error: %s\n", r.Error)
+		return
+	}
+	for _, fs := range r.Filesystems {
+		switch {
+		case fs.Error != "":
+			fmt.Printf("%s\tERROR\t%s\n", fs.Filesystem, fs.Error)
+		case len(fs.Drift) == 0:
+			fmt.Printf("%s\tOK\t%d snapshots verified\n", fs.Filesystem, fs.Verified)
+		default:
+			fmt.Printf("%s\tDRIFT\t%d snapshots verified\n", fs.Filesystem, fs.Verified)
+			for _, d := range fs.Drift {
+				fmt.Printf("\t%s\n", d)
+			}
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log/syslog"
+	"math"
 	"os"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -51,40 +53,164 @@ func (j JobEnum) Name() string {
 	return name
 }
 
+// Paused returns whether the job is paused in the config.
+func (j JobEnum) Paused() bool {
+	switch v := j.Ret.(type) {
+	case *SnapJob:
+		return v.Paused
+	case *PushJob:
+		return v.Paused
+	case *SinkJob:
+		return v.Paused
+	case *PullJob:
+		return v.Paused
+	case *SourceJob:
+		return v.Paused
+	default:
+		panic(fmt.Sprintf("unknown job type %T", v))
+	}
+}
+
+// AllowOverlapWith returns the names of the jobs whose datasets may overlap with this job's datasets.
+func (j JobEnum) AllowOverlapWith() []string {
+	switch v := j.Ret.(type) {
+	case *SnapJob:
+		return v.AllowOverlapWith
+	case *PushJob:
+		return v.AllowOverlapWith
+	case *SinkJob:
+		return v.AllowOverlapWith
+	case *PullJob:
+		return v.AllowOverlapWith
+	case *SourceJob:
+		return v.AllowOverlapWith
+	default:
+		panic(fmt.Sprintf("unknown job type %T", v))
+	}
+}
+
 type ActiveJob struct {
-	Type    string                `yaml:"type"`
-	Name    string                `yaml:"name"`
-	Connect ConnectEnum           `yaml:"connect"`
-	Pruning PruningSenderReceiver `yaml:"pruning"`
-	Debug   JobDebugSettings      `yaml:"debug,optional"`
+	Type             string                `yaml:"type"`
+	Name             string                `yaml:"name"`
+	Connect          ConnectEnum           `yaml:"connect"`
+	Pruning          PruningSenderReceiver `yaml:"pruning"`
+	Replication      *Replication          `yaml:"replication,optional,fromdefaults"`
+	Verify           *Verify               `yaml:"verify,optional"`
+	Send             *SendOptions          `yaml:"send,optional,fromdefaults"`
+	Debug            JobDebugSettings      `yaml:"debug,optional"`
+	AllowOverlapWith []string              `yaml:"allow_overlap_with,optional"`
+	Paused           bool                  `yaml:"paused,optional,default=false"`
+}
+
+// SendOptions are the zfs send flags that alter the stream format.
+type SendOptions struct {
+	LargeBlocks  bool `yaml:"large_blocks,optional,default=false"`
+	EmbeddedData bool `yaml:"embedded_data,optional,default=false"`
+	Compressed   bool `yaml:"compressed,optional,default=false"`
+	Holds        bool `yaml:"holds,optional,default=false"`
+}
+
+// RecvOptions configure the receiving side of a replication.
+type RecvOptions struct {
+	// the zfs send flags that the receiving side can handle
+	AcceptSend *SendOptions `yaml:"accept_send,optional,fromdefaults"`
+	// use `zfs recv -s` so that interrupted receives can be resumed
+	Resumable bool `yaml:"resumable,optional,default=false"`
+}
+
+// Verify configures the periodic consistency audit between sender and receiver.
+// Verification can always be triggered manually using `zrepl verify`.
+type Verify struct {
+	Interval PositiveDurationOrManual `yaml:"interval"`
+}
+
+type Replication struct {
+	Windows       []ReplicationWindow `yaml:"windows,optional"`
+	OnWindowClose string              `yaml:"on_window_close,optional,default=pause"`
+}
+
+// ReplicationWindow is a daily time-of-day range in local time, e.g. "22:00-06:00".
+// A range whose end lies before its start wraps around midnight.
+type ReplicationWindow struct {
+	// offsets since midnight
+	Start, End time.Duration
+}
+
+var _ yaml.Unmarshaler = (*ReplicationWindow)(nil)
+
+var replicationWindowRegex = regexp.MustCompile(`^\s*(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\s*$`)
+
+func (w *ReplicationWindow) UnmarshalYAML(u func(interface{}, bool) error) (err error) {
+	var s string
+	if err := u(&s, true); err != nil {
+		return err
+	}
+	comps := replicationWindowRegex.FindStringSubmatch(s)
+	if comps == nil {
+		return fmt.Errorf("replication window must have format HH:MM-HH:MM, got %q", s)
+	}
+	timeOfDay := func(h, m string) (time.Duration, error) {
+		hours, _ := strconv.Atoi(h) // regex guarantees digits
+		minutes, _ := strconv.Atoi(m)
+		if hours > 23 || minutes > 59 {
+			return 0, fmt.Errorf("invalid time of day %s:%s", h, m)
+		}
+		return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+	}
+	if w.Start, err = timeOfDay(comps[1], comps[2]); err != nil {
+		return err
+	}
+	if w.End, err = timeOfDay(comps[3], comps[4]); err != nil {
+		return err
+	}
+	if w.Start == w.End {
+		return fmt.Errorf("replication window %q must not be empty", s)
+	}
+	return nil
+}
+
+func (w ReplicationWindow) String() string {
+	f := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int((d % time.Hour).Minutes()))
+	}
+	return fmt.Sprintf("%s-%s", f(w.Start), f(w.End))
 }
 
 type PassiveJob struct {
-	Type  string           `yaml:"type"`
-	Name  string           `yaml:"name"`
-	Serve ServeEnum        `yaml:"serve"`
-	Debug JobDebugSettings `yaml:"debug,optional"`
+	Type             string           `yaml:"type"`
+	Name             string           `yaml:"name"`
+	Serve            ServeEnum        `yaml:"serve"`
+	Debug            JobDebugSettings `yaml:"debug,optional"`
+	AllowOverlapWith []string         `yaml:"allow_overlap_with,optional"`
+	Paused           bool             `yaml:"paused,optional,default=false"`
 }
 
 type SnapJob struct {
-	Type         string            `yaml:"type"`
-	Name         string            `yaml:"name"`
-	Pruning      PruningLocal      `yaml:"pruning"`
-	Debug        JobDebugSettings  `yaml:"debug,optional"`
-	Snapshotting SnapshottingEnum  `yaml:"snapshotting"`
-	Filesystems  FilesystemsFilter `yaml:"filesystems"`
+	Type              string             `yaml:"type"`
+	Name              string             `yaml:"name"`
+	Pruning           PruningLocal       `yaml:"pruning"`
+	Debug             JobDebugSettings   `yaml:"debug,optional"`
+	Snapshotting      SnapshottingEnum   `yaml:"snapshotting"`
+	Filesystems       FilesystemsFilter  `yaml:"filesystems"`
+	DatasetSelection  *DatasetSelection  `yaml:"dataset_selection,optional"`
+	DatasetProperties *DatasetProperties `yaml:"dataset_properties,optional"`
+	AllowOverlapWith  []string           `yaml:"allow_overlap_with,optional"`
+	Paused            bool               `yaml:"paused,optional,default=false"`
 }
 
 type PushJob struct {
-	ActiveJob    `yaml:",inline"`
-	Snapshotting SnapshottingEnum  `yaml:"snapshotting"`
-	Filesystems  FilesystemsFilter `yaml:"filesystems"`
+	ActiveJob         `yaml:",inline"`
+	Snapshotting      SnapshottingEnum   `yaml:"snapshotting"`
+	Filesystems       FilesystemsFilter  `yaml:"filesystems"`
+	DatasetSelection  *DatasetSelection  `yaml:"dataset_selection,optional"`
+	DatasetProperties *DatasetProperties `yaml:"dataset_properties,optional"`
 }
 
 type PullJob struct {
 	ActiveJob `yaml:",inline"`
 	RootFS    string                   `yaml:"root_fs"`
 	Interval  PositiveDurationOrManual `yaml:"interval"`
+	Recv      *RecvOptions             `yaml:"recv,optional,fromdefaults"`
 }
 
 type PositiveDurationOrManual struct {
@@ -120,17 +246,80 @@ func (i *PositiveDurationOrManual) UnmarshalYAML(u func(interface{}, bool) error
 
 type SinkJob struct {
 	PassiveJob `yaml:",inline"`
-	RootFS     string `yaml:"root_fs"`
+	RootFS     string          `yaml:"root_fs"`
+	Recv       *RecvOptions    `yaml:"recv,optional,fromdefaults"`
+	Pruning    *PruningPassive `yaml:"pruning,optional"`
 }
 
 type SourceJob struct {
-	PassiveJob   `yaml:",inline"`
-	Snapshotting SnapshottingEnum  `yaml:"snapshotting"`
-	Filesystems  FilesystemsFilter `yaml:"filesystems"`
+	PassiveJob        `yaml:",inline"`
+	Snapshotting      SnapshottingEnum   `yaml:"snapshotting"`
+	Filesystems       FilesystemsFilter  `yaml:"filesystems"`
+	DatasetSelection  *DatasetSelection  `yaml:"dataset_selection,optional"`
+	DatasetProperties *DatasetProperties `yaml:"dataset_properties,optional"`
+	Pruning           *PruningPassive    `yaml:"pruning,optional"`
+	// the zfs send flags that pull jobs may request
+	Send *SendOptions `yaml:"send,optional,fromdefaults"`
 }
 
 type FilesystemsFilter map[string]bool
 
+// DatasetSelection restricts the datasets selected by a job's filesystems filter
+// to those that satisfy all conditions.
+type DatasetSelection struct {
+	// filesystem or volume, all types if empty
+	Types []string `yaml:"types,optional"`
+	// property name => value, a value prefixed with '!' excludes the value
+	Properties    map[string]string `yaml:"properties,optional"`
+	MaxUsed       *ByteSize         `yaml:"max_used,optional"`
+	MaxReferenced *ByteSize         `yaml:"max_referenced,optional"`
+}
+
+// ByteSize is a size in bytes that is specified with an optional unit suffix like in zfs(8),
+// e.g. "512", "100M", "1.5T" or "2 TiB". All units are powers of 1024.
+type ByteSize uint64
+
+func (b *ByteSize) UnmarshalYAML(u func(interface{}, bool) error) (err error) {
+	var s string
+	if err := u(&s, true); err != nil {
+		return err
+	}
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(v)
+	return nil
+}
+
+var byteSizeRegex = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*(?:([KMGTPE])(?:I?B)?|B)?\s*$`)
+
+// ParseByteSize parses sizes in the format of ByteSize.
+func ParseByteSize(s string) (uint64, error) {
+	comps := byteSizeRegex.FindStringSubmatch(strings.ToUpper(s))
+	if comps == nil {
+		return 0, fmt.Errorf("invalid size %q, must be a number with an optional unit like K, M, G, T or TiB", s)
+	}
+	v, err := strconv.ParseFloat(comps[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	if comps[2] != "" {
+		v *= math.Pow(1024, float64(strings.Index("KMGTPE", comps[2])+1))
+	}
+	if v >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return uint64(v), nil
+}
+
+// DatasetProperties enables per-dataset configuration through the ZFS user properties
+// <prefix>replicate, <prefix>interval and <prefix>keep.
+type DatasetProperties struct {
+	// defaults to "zrepl:", must contain a colon (requirement for ZFS user properties)
+	Prefix string `yaml:"prefix,optional"`
+}
+
 type SnapshottingEnum struct {
 	Ret interface{}
 }
@@ -144,6 +333,9 @@ type SnapshottingPeriodic struct {
 
 type SnapshottingManual struct {
 	Type string `yaml:"type"`
+	// only used for out-of-band snapshots (zrepl signal snapshot)
+	Prefix string   `yaml:"prefix,optional"`
+	Hooks  HookList `yaml:"hooks,optional"`
 }
 
 type PruningSenderReceiver struct {
@@ -155,6 +347,15 @@ type PruningLocal struct {
 	Keep []PruningEnum `yaml:"keep"`
 }
 
+// PruningPassive is the retention policy that a sink or source job enforces on its datasets,
+// independently of the keep rules of the connecting active side.
+type PruningPassive struct {
+	Keep     []PruningEnum `yaml:"keep"`
+	Interval time.Duration `yaml:"interval,optional,positive,default=10m"`
+	// allow, cap or override
+	ClientPruning string `yaml:"client_pruning,optional,default=allow"`
+}
+
 type LoggingOutletEnumList []LoggingOutletEnum
 
 func (l *LoggingOutletEnumList) SetDefault() {
@@ -179,6 +380,9 @@ type Global struct {
 	Monitoring []MonitoringEnum       `yaml:"monitoring,optional"`
 	Control    *GlobalControl         `yaml:"control,optional,fromdefaults"`
 	Serve      *GlobalServe           `yaml:"serve,optional,fromdefaults"`
+	StateDir   string                 `yaml:"state_dir,optional"`
+	History    *GlobalHistory         `yaml:"history,optional,fromdefaults"`
+	Tracing    *GlobalTracing         `yaml:"tracing,optional"`
 }
 
 func Default(i interface{}) {
@@ -213,6 +417,7 @@ type TLSConnect struct {
 	Ca            string        `yaml:"ca"`
 	Cert          string        `yaml:"cert"`
 	Key           string        `yaml:"key"`
+	CRL           string        `yaml:"crl,optional"`
 	ServerCN      string        `yaml:"server_cn"`
 	DialTimeout   time.Duration `yaml:"dial_timeout,zeropositive,default=10s"`
 }
@@ -252,12 +457,14 @@ type TCPServe struct {
 
 type TLSServe struct {
 	ServeCommon      `yaml:",inline"`
-	Listen           string        `yaml:"listen,hostport"`
-	Ca               string        `yaml:"ca"`
-	Cert             string        `yaml:"cert"`
-	Key              string        `yaml:"key"`
-	ClientCNs        []string      `yaml:"client_cns"`
-	HandshakeTimeout time.Duration `yaml:"handshake_timeout,zeropositive,default=10s"`
+	Listen           string            `yaml:"listen,hostport"`
+	Ca               string            `yaml:"ca"`
+	Cert             string            `yaml:"cert"`
+	Key              string            `yaml:"key"`
+	CRL              string            `yaml:"crl,optional"`
+	ClientCNs        []string          `yaml:"client_cns,optional"`
+	ClientSANs       map[string]string `yaml:"client_sans,optional"` // "dns:NAME" or "uri:URI" => client identity
+	HandshakeTimeout time.Duration     `yaml:"handshake_timeout,zeropositive,default=10s"`
 }
 
 type StdinserverServer struct {
@@ -320,6 +527,56 @@ type TCPLoggingOutlet struct {
 	TLS                 *TCPLoggingOutletTLS `yaml:"tls,optional"`
 }
 
+// MemoryLoggingOutlet keeps the most recent log entries in memory for `zrepl logs`.
+type MemoryLoggingOutlet struct {
+	Type  string    `yaml:"type"`
+	Level string    `yaml:"level"`
+	Size  *ByteSize `yaml:"size,optional"` // defaults to 4MiB
+}
+
+// JournaldLoggingOutlet writes log entries to the systemd journal, with their fields as journal fields.
+type JournaldLoggingOutlet struct {
+	Type             string `yaml:"type"`
+	Level            string `yaml:"level"`
+	SyslogIdentifier string `yaml:"syslog_identifier,default=zrepl"`
+}
+
+// SyslogRFC5424LoggingOutlet sends RFC 5424 messages with the fields of log entries as structured data
+// to a remote syslog server.
+type SyslogRFC5424LoggingOutlet struct {
+	Type             string               `yaml:"type"`
+	Level            string               `yaml:"level"`
+	Net              string               `yaml:"net,default=udp"`
+	Address          string               `yaml:"address,hostport"`
+	RetryInterval    time.Duration        `yaml:"retry_interval,positive,default=10s"`
+	TLS              *TCPLoggingOutletTLS `yaml:"tls,optional"`
+	Facility         *SyslogFacility      `yaml:"facility,optional,fromdefaults"`
+	Hostname         string               `yaml:"hostname,optional"` // defaults to os.Hostname()
+	AppName          string               `yaml:"app_name,default=zrepl"`
+	StructuredDataID string               `yaml:"structured_data_id"`
+}
+
+// GELFLoggingOutlet sends log entries in Graylog Extended Log Format.
+type GELFLoggingOutlet struct {
+	Type          string               `yaml:"type"`
+	Level         string               `yaml:"level"`
+	Net           string               `yaml:"net,default=udp"`
+	Address       string               `yaml:"address,hostport"`
+	RetryInterval time.Duration        `yaml:"retry_interval,positive,default=10s"`
+	TLS           *TCPLoggingOutletTLS `yaml:"tls,optional"`
+	Host          string               `yaml:"host,optional"` // defaults to os.Hostname()
+	ChunkSize     int                  `yaml:"chunk_size,optional,default=1420"`
+}
+
+type FileLoggingOutlet struct {
+	LoggingOutletCommon `yaml:",inline"`
+	Path                string        `yaml:"path"`
+	RotateSize          *ByteSize     `yaml:"rotate_size,optional"`
+	RotateInterval      time.Duration `yaml:"rotate_interval,optional,zeropositive"`
+	Keep                int           `yaml:"keep,optional,default=7"` // 0 keeps all rotated files
+	Compress            bool          `yaml:"compress,optional,default=false"`
+}
+
 type TCPLoggingOutletTLS struct {
 	CA   string `yaml:"ca"`
 	Cert string `yaml:"cert"`
@@ -335,6 +592,36 @@ type PrometheusMonitoring struct {
 	Listen string `yaml:"listen,hostport"`
 }
 
+// PushMonitoringCommon is shared by monitoring types that periodically push the metrics.
+type PushMonitoringCommon struct {
+	Type     string        `yaml:"type"`
+	Interval time.Duration `yaml:"interval,optional,positive,default=10s"`
+	// Metrics are grouped by the value of this label, empty disables grouping.
+	JobLabel string `yaml:"job_label,optional,default=zrepl_job"`
+}
+
+type InfluxDBMonitoring struct {
+	PushMonitoringCommon `yaml:",inline"`
+	// http(s)://host:port/write?db=DB or udp://host:port
+	URL string `yaml:"url"`
+}
+
+type StatsDMonitoring struct {
+	PushMonitoringCommon `yaml:",inline"`
+	Address              string `yaml:"address,hostport"`
+	Prefix               string `yaml:"prefix,optional,default=zrepl"`
+	// Use DogStatsD tags for labels instead of encoding them in the metric name
+	DogStatsD bool `yaml:"dogstatsd,optional,default=false"`
+}
+
+type PushgatewayMonitoring struct {
+	PushMonitoringCommon `yaml:",inline"`
+	URL                  string `yaml:"url"`
+	Job                  string `yaml:"job,optional,default=zrepl"`
+	// Value of the instance grouping label, defaults to the hostname
+	Instance string `yaml:"instance,optional"`
+}
+
 type SyslogFacility syslog.Priority
 
 func (f *SyslogFacility) SetDefault() {
@@ -347,6 +634,19 @@ type GlobalControl struct {
 	SockPath string `yaml:"sockpath,default=/var/run/zrepl/control"`
 }
 
+type GlobalHistory struct {
+	// number of records kept per job
+	Retention int `yaml:"retention,default=100"`
+}
+
+type GlobalTracing struct {
+	// URL of the OTLP/HTTP endpoint of an OpenTelemetry collector, e.g. http://localhost:4318
+	OTLPEndpoint   string            `yaml:"otlp_endpoint"`
+	ServiceName    string            `yaml:"service_name,default=zrepl"`
+	Headers        map[string]string `yaml:"headers,optional"`
+	ExportInterval time.Duration     `yaml:"export_interval,optional,positive,default=5s"`
+}
+
 type GlobalServe struct {
 	StdinServer *GlobalStdinServer `yaml:"stdinserver,optional,fromdefaults"`
 }
@@ -467,16 +767,24 @@ func (t *SnapshottingEnum) UnmarshalYAML(u func(interface{}, bool) error) (err e
 
 func (t *LoggingOutletEnum) UnmarshalYAML(u func(interface{}, bool) error) (err error) {
 	t.Ret, err = enumUnmarshal(u, map[string]interface{}{
-		"stdout": &StdoutLoggingOutlet{},
-		"syslog": &SyslogLoggingOutlet{},
-		"tcp":    &TCPLoggingOutlet{},
+		"stdout":         &StdoutLoggingOutlet{},
+		"syslog":         &SyslogLoggingOutlet{},
+		"tcp":            &TCPLoggingOutlet{},
+		"memory":         &MemoryLoggingOutlet{},
+		"journald":       &JournaldLoggingOutlet{},
+		"file":           &FileLoggingOutlet{},
+		"syslog_rfc5424": &SyslogRFC5424LoggingOutlet{},
+		"gelf":           &GELFLoggingOutlet{},
 	})
 	return
 }
 
 func (t *MonitoringEnum) UnmarshalYAML(u func(interface{}, bool) error) (err error) {
 	t.Ret, err = enumUnmarshal(u, map[string]interface{}{
-		"prometheus": &PrometheusMonitoring{},
+		"prometheus":  &PrometheusMonitoring{},
+		"influxdb":    &InfluxDBMonitoring{},
+		"statsd":      &StatsDMonitoring{},
+		"pushgateway": &PushgatewayMonitoring{},
 	})
 	return
 }
@@ -587,6 +895,24 @@ func ParseConfigBytes(bytes []byte) (*Config, error) {
 	return c, nil
 }
 
+// ParseKeepRules parses a YAML list of keep rules, e.g. a flow sequence as used in ZFS user properties:
+// [{type: last_n, count: 10}, {type: regex, regex: "^manual_"}]
+func ParseKeepRules(in string) ([]PruningEnum, error) {
+	var rules []PruningEnum
+	if err := yaml.UnmarshalStrict([]byte(in), &rules); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("keep rules must not be empty")
+	}
+	return rules, nil
+}
+
+// ParsePositiveDuration parses durations in the format of the grid keep rule, e.g. "10m", "1h" or "1d".
+func ParsePositiveDuration(e string) (time.Duration, error) {
+	return parsePostitiveDuration(e)
+}
+
 var durationStringRegex *regexp.Regexp = regexp.MustCompile(`^\s*(\d+)\s*(s|m|h|d|w)\s*$`)
 
 func parsePostitiveDuration(e string) (d time.Duration, err error) {
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeepRules(t *testing.T) {
+	rules, err := ParseKeepRules(`[{type: last_n, count: 10}, {type: regex, regex: "^manual_"}]`)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, 10, rules[0].Ret.(*PruneKeepLastN).Count)
+	assert.Equal(t, "^manual_", rules[1].Ret.(*PruneKeepRegex).Regex)
+
+	_, err = ParseKeepRules(`[]`)
+	assert.Error(t, err)
+	_, err = ParseKeepRules(`[{type: last_n, count: 10, foo: bar}]`)
+	assert.Error(t, err)
+	_, err = ParseKeepRules(`{type: last_n, count: 10}`)
+	assert.Error(t, err)
+}
+
+func TestDatasetPropertiesConfig(t *testing.T) {
+	c := testValidConfig(t, `
+jobs:
+- name: snap
+  type: snap
+  filesystems: {"pool<": true}
+  dataset_properties:
+    prefix: "backup:"
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+- name: push
+  type: push
+  filesystems: {"pool<": true}
+  dataset_properties: {}
+  connect:
+    type: local
+    listener_name: sink
+    client_identity: push
+  snapshotting:
+    type: manual
+  pruning:
+    keep_sender:
+    - type: last_n
+      count: 10
+    keep_receiver:
+    - type: last_n
+      count: 10
+`)
+	assert.Equal(t, "backup:", c.Jobs[0].Ret.(*SnapJob).DatasetProperties.Prefix)
+	assert.Equal(t, "", c.Jobs[1].Ret.(*PushJob).DatasetProperties.Prefix)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tcs := map[string]uint64{
+		"0":       0,
+		"512":     512,
+		"512B":    512,
+		"1K":      1 << 10,
+		"100M":    100 << 20,
+		"1.5T":    3 << 39,
+		"2 TiB":   2 << 40,
+		"2tb":     2 << 40,
+		" 1 G ":   1 << 30,
+		"1 PiB":   1 << 50,
+		"0.5 KiB": 512,
+	}
+	for in, exp := range tcs {
+		v, err := ParseByteSize(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, exp, v, in)
+		}
+	}
+	for _, in := range []string{"", "-1", "1X", "T", "1 TiBB", "1e3", "99999999E"} {
+		_, err := ParseByteSize(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestDatasetSelectionConfig(t *testing.T) {
+	c := testValidConfig(t, `
+jobs:
+- name: offsite
+  type: push
+  connect:
+    type: local
+    listener_name: sink
+    client_identity: offsite
+  filesystems: {"pool<": true}
+  dataset_selection:
+    types: [filesystem]
+    properties:
+      canmount: "!off"
+    max_used: 2 TiB
+  snapshotting:
+    type: manual
+  pruning:
+    keep_sender:
+    - type: last_n
+      count: 10
+    keep_receiver:
+    - type: last_n
+      count: 10
+`)
+	sel := c.Jobs[0].Ret.(*PushJob).DatasetSelection
+	require.NotNil(t, sel)
+	assert.Equal(t, []string{"filesystem"}, sel.Types)
+	assert.Equal(t, map[string]string{"canmount": "!off"}, sel.Properties)
+	require.NotNil(t, sel.MaxUsed)
+	assert.Equal(t, ByteSize(2<<40), *sel.MaxUsed)
+	assert.Nil(t, sel.MaxReferenced)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/syslog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -52,9 +53,41 @@ global:
       ca: /etc/zrepl/log/ca.crt
       cert: /etc/zrepl/log/key.pem
       key: /etc/zrepl/log/cert.pem
+  - type: memory
+    level: debug
+    size: 16MiB
+  - type: journald
+    level: info
+  - type: file
+    level: info
+    format: logfmt
+    path: /var/log/zrepl.log
+    rotate_size: 100M
+    rotate_interval: 24h
+    compress: true
+  - type: syslog_rfc5424
+    level: info
+    address: logs.example.com:514
+    structured_data_id: zrepl@32473
+  - type: gelf
+    level: debug
+    net: tcp
+    address: graylog.example.com:12201
 `)
-	assert.Equal(t, 4, len(*conf.Global.Logging))
+	assert.Equal(t, 9, len(*conf.Global.Logging))
 	assert.NotNil(t, (*conf.Global.Logging)[3].Ret.(*TCPLoggingOutlet).TLS)
+	assert.Equal(t, ByteSize(16<<20), *(*conf.Global.Logging)[4].Ret.(*MemoryLoggingOutlet).Size)
+	assert.Equal(t, "zrepl", (*conf.Global.Logging)[5].Ret.(*JournaldLoggingOutlet).SyslogIdentifier)
+	file := (*conf.Global.Logging)[6].Ret.(*FileLoggingOutlet)
+	assert.Equal(t, ByteSize(100<<20), *file.RotateSize)
+	assert.Equal(t, 24*time.Hour, file.RotateInterval)
+	assert.Equal(t, 7, file.Keep)
+	assert.True(t, file.Compress)
+	rfc5424 := (*conf.Global.Logging)[7].Ret.(*SyslogRFC5424LoggingOutlet)
+	assert.Equal(t, "udp", rfc5424.Net)
+	assert.Equal(t, SyslogFacility(syslog.LOG_LOCAL0), *rfc5424.Facility)
+	assert.Equal(t, "zrepl@32473", rfc5424.StructuredDataID)
+	assert.Equal(t, 1420, (*conf.Global.Logging)[8].Ret.(*GELFLoggingOutlet).ChunkSize)
 }
 
 func TestDefaultLoggingOutlet(t *testing.T) {
@@ -75,6 +108,55 @@ global:
 	assert.Equal(t, ":9091", conf.Global.Monitoring[0].Ret.(*PrometheusMonitoring).Listen)
 }
 
+func TestPushMonitoring(t *testing.T) {
+	conf := testValidGlobalSection(t, `
+global:
+  monitoring:
+    - type: influxdb
+      url: http://influx.example.com:8086/write?db=zrepl
+    - type: statsd
+      address: localhost:8125
+      dogstatsd: true
+      interval: 30s
+    - type: pushgateway
+      url: http://pushgateway.example.com:9091
+      job_label: ""
+`)
+	require.Len(t, conf.Global.Monitoring, 3)
+
+	influx := conf.Global.Monitoring[0].Ret.(*InfluxDBMonitoring)
+	assert.Equal(t, 10*time.Second, influx.Interval)
+	assert.Equal(t, "zrepl_job", influx.JobLabel)
+
+	statsd := conf.Global.Monitoring[1].Ret.(*StatsDMonitoring)
+	assert.Equal(t, 30*time.Second, statsd.Interval)
+	assert.Equal(t, "zrepl", statsd.Prefix)
+	assert.True(t, statsd.DogStatsD)
+
+	pgw := conf.Global.Monitoring[2].Ret.(*PushgatewayMonitoring)
+	assert.Equal(t, "zrepl", pgw.Job)
+	assert.Equal(t, "", pgw.JobLabel)
+}
+
+func TestTracing(t *testing.T) {
+	conf := testValidGlobalSection(t, `
+global:
+  tracing:
+    otlp_endpoint: http://localhost:4318
+    headers:
+      Authorization: Bearer secret
+`)
+	tc := conf.Global.Tracing
+	require.NotNil(t, tc)
+	assert.Equal(t, "http://localhost:4318", tc.OTLPEndpoint)
+	assert.Equal(t, "zrepl", tc.ServiceName)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer secret"}, tc.Headers)
+	assert.Equal(t, 5*time.Second, tc.ExportInterval)
+
+	conf = testValidGlobalSection(t, "")
+	assert.Nil(t, conf.Global.Tracing)
+}
+
 func TestSyslogLoggingOutletFacility(t *testing.T) {
 	type SyslogFacilityPriority struct {
 		Facility string
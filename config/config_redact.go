@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces secrets in the output of Redacted.
+const RedactedValue = "REDACTED"
+
+// values of these keys are replaced by RedactedValue
+var redactedKeys = map[string]bool{
+	"dsn":           true,
+	"key":           true,
+	"identity_file": true,
+	"headers":       true,
+}
+
+// values of these keys are URLs whose passwords and secret query parameters are replaced by RedactedValue
+var urlKeys = map[string]bool{
+	"url":           true,
+	"otlp_endpoint": true,
+}
+
+// Redacted returns c as a tree of maps, slices and plain values that uses the keys of the config file
+// and is suitable for JSON encoding, e.g. for inclusion in bug reports.
+// Secrets are replaced by RedactedValue: DSNs, paths of private keys, HTTP headers and passwords in URLs,
+// including query parameters such as p, token or api_key.
+func Redacted(c *Config) interface{} {
+	return redact(reflect.ValueOf(c), "")
+}
+
+func redact(v reflect.Value, key string) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem(), key)
+	}
+	if redactedKeys[key] {
+		return RedactedValue
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		// enums (JobEnum, ServeEnum, ...) are represented by their value
+		if v.NumField() == 1 && v.Type().Field(0).Name == "Ret" {
+			return redact(v.Field(0), key)
+		}
+		m := make(map[string]interface{}, v.NumField())
+		redactStruct(v, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i] = redact(v.Index(i), "")
+		}
+		return l
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[fmt.Sprint(k.Interface())] = redact(v.MapIndex(k), "")
+		}
+		return m
+	case reflect.String:
+		if urlKeys[key] {
+			return redactURL(v.String())
+		}
+		return v.String()
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+func redactStruct(v reflect.Value, m map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		tag := f.Tag.Get("yaml")
+		name := strings.Split(tag, ",")[0]
+		if strings.Contains(tag, ",inline") {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			redactStruct(fv, m)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		m[name] = redact(v.Field(i), name)
+	}
+}
+
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		// might contain anything
+		return RedactedValue
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), RedactedValue)
+		}
+	}
+	if u.RawQuery != "" {
+		q, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return RedactedValue
+		}
+		for k := range q {
+			if isSecretQueryParam(k) {
+				q[k] = []string{RedactedValue}
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// query parameters that carry credentials, e.g. InfluxDB's p (password)
+var (
+	secretQueryParams         = map[string]bool{"p": true, "pass": true, "pwd": true}
+	secretQueryParamFragments = []string{"password", "token", "secret", "key", "auth", "signature"}
+)
+
+func isSecretQueryParam(name string) bool {
+	name = strings.ToLower(name)
+	if secretQueryParams[name] {
+		return true
+	}
+	for _, f := range secretQueryParamFragments {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
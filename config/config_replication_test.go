@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zrepl/yaml-config"
+)
+
+func TestReplicationWindow(t *testing.T) {
+	cases := []struct {
+		Comment, Input string
+		Result         *ReplicationWindow
+	}{
+		{"empty is error", `""`, nil},
+		{"no range is error", "22:00", nil},
+		{"hour out of range is error", "24:00-06:00", nil},
+		{"minute out of range is error", "22:60-06:00", nil},
+		{"empty range is error", "06:00-06:00", nil},
+		{"same day", "01:30-05:00", &ReplicationWindow{Start: 90 * time.Minute, End: 5 * time.Hour}},
+		{"across midnight", "22:00-06:00", &ReplicationWindow{Start: 22 * time.Hour, End: 6 * time.Hour}},
+		{"whitespace", " 7:05 - 8:00 ", &ReplicationWindow{Start: 7*time.Hour + 5*time.Minute, End: 8 * time.Hour}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Comment, func(t *testing.T) {
+			var out struct {
+				FieldName ReplicationWindow `yaml:"fieldname"`
+			}
+			input := fmt.Sprintf("\nfieldname: %s\n", tc.Input)
+			err := yaml.UnmarshalStrict([]byte(input), &out)
+			if tc.Result == nil {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, *tc.Result, out.FieldName)
+			}
+		})
+	}
+}
+
+func TestReplicationOptions(t *testing.T) {
+	tmpl := `
+jobs:
+- name: foo
+  type: push
+  connect:
+    type: local
+    listener_name: foo
+    client_identity: bar
+  filesystems: {"<": true}
+  snapshotting:
+    type: manual
+  pruning:
+    keep_sender:
+    - type: last_n
+      count: 10
+    keep_receiver:
+    - type: last_n
+      count: 10
+%s
+`
+	t.Run("default", func(t *testing.T) {
+		c := testValidConfig(t, fmt.Sprintf(tmpl, ""))
+		r := c.Jobs[0].Ret.(*PushJob).Replication
+		require.NotNil(t, r)
+		assert.Empty(t, r.Windows)
+		assert.Equal(t, "pause", r.OnWindowClose)
+	})
+
+	t.Run("windows", func(t *testing.T) {
+		c := testValidConfig(t, fmt.Sprintf(tmpl, `
+  replication:
+    windows: ["22:00-06:00", "12:00-13:00"]
+    on_window_close: cancel
+`))
+		r := c.Jobs[0].Ret.(*PushJob).Replication
+		assert.Equal(t, []ReplicationWindow{
+			{Start: 22 * time.Hour, End: 6 * time.Hour},
+			{Start: 12 * time.Hour, End: 13 * time.Hour},
+		}, r.Windows)
+		assert.Equal(t, "cancel", r.OnWindowClose)
+		assert.Equal(t, "22:00-06:00", r.Windows[0].String())
+	})
+	t.Run("verify", func(t *testing.T) {
+		c := testValidConfig(t, fmt.Sprintf(tmpl, `
+  verify:
+    interval: 24h
+`))
+		v := c.Jobs[0].Ret.(*PushJob).Verify
+		require.NotNil(t, v)
+		assert.Equal(t, PositiveDurationOrManual{Interval: 24 * time.Hour}, v.Interval)
+		assert.Nil(t, testValidConfig(t, fmt.Sprintf(tmpl, "")).Jobs[0].Ret.(*PushJob).Verify)
+	})
+}
+
+func TestSendRecvOptions(t *testing.T) {
+	push := `
+jobs:
+- name: foo
+  type: push
+  connect:
+    type: local
+    listener_name: foo
+    client_identity: bar
+  filesystems: {"<": true}
+  snapshotting:
+    type: manual
+  pruning:
+    keep_sender:
+    - type: last_n
+      count: 10
+    keep_receiver:
+    - type: last_n
+      count: 10
+%s
+`
+	sink := `
+jobs:
+- name: foo
+  type: sink
+  root_fs: "pool/backup"
+  serve:
+    type: local
+    listener_name: foo
+%s
+`
+	t.Run("default", func(t *testing.T) {
+		s := testValidConfig(t, fmt.Sprintf(push, "")).Jobs[0].Ret.(*PushJob).Send
+		require.NotNil(t, s)
+		assert.Equal(t, SendOptions{}, *s)
+		r := testValidConfig(t, fmt.Sprintf(sink, "")).Jobs[0].Ret.(*SinkJob).Recv
+		require.NotNil(t, r)
+		require.NotNil(t, r.AcceptSend)
+		assert.Equal(t, SendOptions{}, *r.AcceptSend)
+		assert.False(t, r.Resumable)
+	})
+	t.Run("set", func(t *testing.T) {
+		s := testValidConfig(t, fmt.Sprintf(push, `
+  send:
+    large_blocks: true
+    compressed: true
+`)).Jobs[0].Ret.(*PushJob).Send
+		assert.Equal(t, SendOptions{LargeBlocks: true, Compressed: true}, *s)
+		r := testValidConfig(t, fmt.Sprintf(sink, `
+  recv:
+    accept_send:
+      large_blocks: true
+      embedded_data: true
+      compressed: true
+      holds: true
+    resumable: true
+`)).Jobs[0].Ret.(*SinkJob).Recv
+		assert.Equal(t, SendOptions{LargeBlocks: true, EmbeddedData: true, Compressed: true, Holds: true}, *r.AcceptSend)
+		assert.True(t, r.Resumable)
+	})
+	t.Run("source", func(t *testing.T) {
+		source := `
+jobs:
+- name: foo
+  type: source
+  serve:
+    type: local
+    listener_name: foo
+  filesystems: {"<": true}
+  snapshotting:
+    type: manual
+%s
+`
+		s := testValidConfig(t, fmt.Sprintf(source, "")).Jobs[0].Ret.(*SourceJob).Send
+		require.NotNil(t, s)
+		assert.Equal(t, SendOptions{}, *s)
+		s = testValidConfig(t, fmt.Sprintf(source, `
+  send:
+    compressed: true
+`)).Jobs[0].Ret.(*SourceJob).Send
+		assert.Equal(t, SendOptions{Compressed: true}, *s)
+	})
+}
@@ -8,26 +8,36 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"runtime/pprof"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/zrepl/zrepl/daemon/history"
 	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/job/snapshot"
+	"github.com/zrepl/zrepl/daemon/logging"
 	"github.com/zrepl/zrepl/daemon/nethelpers"
 	"github.com/zrepl/zrepl/logger"
 	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/util/systemd"
 	"github.com/zrepl/zrepl/version"
 	"github.com/zrepl/zrepl/zfs"
 )
 
 type controlJob struct {
-	sockaddr *net.UnixAddr
-	jobs     *jobs
+	sockaddr  *net.UnixAddr
+	jobs      *jobs
+	history   *history.Store
+	logLevels *logging.LevelControl
+
+	// closed once the control socket is listening or listening failed
+	listening chan struct{}
 }
 
-func newControlJob(sockpath string, jobs *jobs) (j *controlJob, err error) {
-	j = &controlJob{jobs: jobs}
+func newControlJob(sockpath string, jobs *jobs, history *history.Store, logLevels *logging.LevelControl) (j *controlJob, err error) {
+	j = &controlJob{jobs: jobs, history: history, logLevels: logLevels, listening: make(chan struct{})}
 
 	j.sockaddr, err = net.ResolveUnixAddr("unix", sockpath)
 	if err != nil {
@@ -69,22 +79,84 @@ func (j *controlJob) RegisterMetrics(registerer prometheus.Registerer) {
 }
 
 const (
-	ControlJobEndpointPProf   string = "/debug/pprof"
-	ControlJobEndpointVersion string = "/version"
-	ControlJobEndpointStatus  string = "/status"
-	ControlJobEndpointSignal  string = "/signal"
+	ControlJobEndpointPProf     string = "/debug/pprof"
+	ControlJobEndpointProfile   string = "/debug/profile"
+	ControlJobEndpointVersion   string = "/version"
+	ControlJobEndpointStatus    string = "/status"
+	ControlJobEndpointSignal    string = "/signal"
+	ControlJobEndpointHistory   string = "/history"
+	ControlJobEndpointLogLevels string = "/loglevels"
+	ControlJobEndpointLogs      string = "/logs"
 )
 
+// Request for ControlJobEndpointSignal, the response is empty.
+type SignalRequest struct {
+	Name string
+	Op   string
+	// only for Op "snapshot"
+	Filesystems []string `json:",omitempty"`
+	Wakeup      bool     `json:",omitempty"`
+	// only for Op "pause" and "resume": who or what sent the request, shown in status
+	By string `json:",omitempty"`
+}
+
+// Request for ControlJobEndpointHistory, the response is a []*history.Record, newest first.
+type HistoryRequest struct {
+	Job   string // empty for all jobs
+	Limit int    // 0 means all retained records
+}
+
+// Request for ControlJobEndpointProfile, the response is the profile written by runtime/pprof.
+type ProfileRequest struct {
+	Name  string // e.g. goroutine or heap, see pprof.Profiles
+	Debug int    // see pprof.Profile.WriteTo
+}
+
+// Request for ControlJobEndpointLogLevels, the response is a logging.LevelState.
+type LogLevelsRequest struct {
+	Op   string // get, set or reset
+	Rule logging.LevelRule
+	// only for Op "reset": remove all rules
+	All bool `json:",omitempty"`
+}
+
+// Request for ControlJobEndpointLogs, the response is a LogsResponse.
+type LogsRequest struct {
+	// offset returned as LogsResponse.Next by the previous request, -1 for all retained entries
+	Since int64
+	Job   string `json:",omitempty"`
+	Level logger.Level
+	// wait for new entries if there are none after Since
+	Wait bool `json:",omitempty"`
+}
+
+type LogsResponse struct {
+	Entries   []logging.MemoryEntry
+	Next      int64
+	Truncated bool // entries after Since were discarded before they could be returned
+}
+
+// must be shorter than the control server's WriteTimeout
+var logsWaitTimeout = envconst.Duration("ZREPL_CONTROL_LOGS_WAIT_TIMEOUT", 500*time.Millisecond)
+
 func (j *controlJob) Run(ctx context.Context) {
 
 	log := job.GetLogger(ctx)
 	defer log.Info("control job finished")
 
-	l, err := nethelpers.ListenUnixPrivate(j.sockaddr)
-	if err != nil {
-		log.WithError(err).Error("error listening")
-		return
+	var err error
+	l := systemd.TakeUnixListener(j.sockaddr)
+	if l != nil {
+		log.WithField("sockpath", j.sockaddr.Name).Info("using control socket passed by systemd")
+	} else {
+		l, err = nethelpers.ListenUnixPrivate(j.sockaddr)
+		if err != nil {
+			close(j.listening)
+			log.WithError(err).Error("error listening")
+			return
+		}
 	}
+	close(j.listening)
 
 	pprofServer := NewPProfServer(ctx)
 	if listen := envconst.String("ZREPL_DAEMON_AUTOSTART_PPROF_SERVER", ""); listen != "" {
@@ -106,6 +178,23 @@ func (j *controlJob) Run(ctx context.Context) {
 			return struct{}{}, nil
 		}}})
 
+	mux.Handle(ControlJobEndpointProfile,
+		requestLogger{log: log, handler: jsonRequestResponder{log, func(decoder jsonDecoder) (interface{}, error) {
+			var req ProfileRequest
+			if decoder(&req) != nil {
+				return nil, errors.Errorf("decode failed")
+			}
+			p := pprof.Lookup(req.Name)
+			if p == nil {
+				return nil, errors.Errorf("profile %q does not exist", req.Name)
+			}
+			var buf bytes.Buffer
+			if err := p.WriteTo(&buf, req.Debug); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}}})
+
 	mux.Handle(ControlJobEndpointVersion,
 		requestLogger{log: log, handler: jsonResponder{log, func() (interface{}, error) {
 			return version.NewZreplVersionInformation(), nil
@@ -120,11 +209,7 @@ func (j *controlJob) Run(ctx context.Context) {
 
 	mux.Handle(ControlJobEndpointSignal,
 		requestLogger{log: log, handler: jsonRequestResponder{log, func(decoder jsonDecoder) (interface{}, error) {
-			type reqT struct {
-				Name string
-				Op   string
-			}
-			var req reqT
+			var req SignalRequest
 			if decoder(&req) != nil {
 				return nil, errors.Errorf("decode failed")
 			}
@@ -135,12 +220,87 @@ func (j *controlJob) Run(ctx context.Context) {
 				err = j.jobs.wakeup(req.Name)
 			case "reset":
 				err = j.jobs.reset(req.Name)
+			case "verify":
+				err = j.jobs.verify(req.Name)
+			case "snapshot":
+				err = j.jobs.snapshot(req.Name, snapshot.Request{
+					Filesystems: req.Filesystems,
+					Wakeup:      req.Wakeup,
+				})
+			case "pause":
+				if req.By == "" {
+					req.By = "zrepl signal"
+				}
+				if err = j.jobs.pause(req.Name, req.By); err == nil {
+					log.WithField("job", req.Name).WithField("by", req.By).Info("job paused")
+				}
+			case "resume":
+				if err = j.jobs.resume(req.Name); err == nil {
+					log.WithField("job", req.Name).WithField("by", req.By).Info("job resumed")
+				}
 			default:
 				err = fmt.Errorf("operation %q is invalid", req.Op)
 			}
 
 			return struct{}{}, err
 		}}})
+	mux.Handle(ControlJobEndpointHistory,
+		requestLogger{log: log, handler: jsonRequestResponder{log, func(decoder jsonDecoder) (interface{}, error) {
+			var req HistoryRequest
+			if decoder(&req) != nil {
+				return nil, errors.Errorf("decode failed")
+			}
+			if req.Limit < 0 {
+				return nil, errors.Errorf("limit must not be negative")
+			}
+			return j.history.Query(req.Job, req.Limit)
+		}}})
+
+	mux.Handle(ControlJobEndpointLogLevels,
+		requestLogger{log: log, handler: jsonRequestResponder{log, func(decoder jsonDecoder) (interface{}, error) {
+			var req LogLevelsRequest
+			if decoder(&req) != nil {
+				return nil, errors.Errorf("decode failed")
+			}
+			if req.Rule.Job != "" && !j.jobs.exists(req.Rule.Job) {
+				return nil, errors.Errorf("job %q does not exist", req.Rule.Job)
+			}
+			var err error
+			switch req.Op {
+			case "get":
+			case "set":
+				err = j.logLevels.Set(req.Rule)
+			case "reset":
+				err = j.logLevels.Reset(req.Rule, req.All)
+			default:
+				err = fmt.Errorf("operation %q is invalid", req.Op)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if req.Op != "get" {
+				log.WithField("rule", req.Rule).WithField("op", req.Op).Info("log levels changed")
+			}
+			return j.logLevels.State(), nil
+		}}})
+	mux.Handle(ControlJobEndpointLogs,
+		// don't log requests to the logs endpoint, a follower would see its own requests
+		jsonRequestResponder{log, func(decoder jsonDecoder) (interface{}, error) {
+			var req LogsRequest
+			if decoder(&req) != nil {
+				return nil, errors.Errorf("decode failed")
+			}
+			mem := j.logLevels.Memory()
+			if req.Wait {
+				ctx, cancel := context.WithTimeout(ctx, logsWaitTimeout)
+				mem.Wait(ctx, req.Since)
+				cancel()
+			}
+			var res LogsResponse
+			res.Entries, res.Next, res.Truncated = mem.Read(req.Since, req.Job, req.Level)
+			return res, nil
+		}})
+
 	server := http.Server{
 		Handler: mux,
 		// control socket is local, 1s timeout should be more than sufficient, even on a loaded system
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
@@ -14,12 +15,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/history"
 	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/daemon/job/reset"
+	"github.com/zrepl/zrepl/daemon/job/snapshot"
+	"github.com/zrepl/zrepl/daemon/job/verify"
 	"github.com/zrepl/zrepl/daemon/job/wakeup"
 	"github.com/zrepl/zrepl/daemon/logging"
 	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/systemd"
+	"github.com/zrepl/zrepl/util/tracing"
 	"github.com/zrepl/zrepl/version"
+	"github.com/zrepl/zrepl/zfs"
 )
 
 func Run(conf *config.Config) error {
@@ -34,7 +42,7 @@ func Run(conf *config.Config) error {
 		cancel()
 	}()
 
-	outlets, err := logging.OutletsFromConfig(*conf.Global.Logging)
+	outlets, logLevels, err := logging.OutletsFromConfig(*conf.Global.Logging)
 	if err != nil {
 		return errors.Wrap(err, "cannot build logging from config")
 	}
@@ -47,18 +55,85 @@ func Run(conf *config.Config) error {
 	log := logger.NewLogger(outlets, 1*time.Second)
 	log.Info(version.NewZreplVersionInformation().String())
 
+	// reopen log files after external rotation, e.g. by logrotate(8)
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+	go func() {
+		for range sighup {
+			if err := logLevels.Reopen(); err != nil {
+				log.WithError(err).Error("cannot reopen log files")
+			} else {
+				log.Debug("reopened log files on SIGHUP")
+			}
+		}
+	}()
+
+	if tc := conf.Global.Tracing; tc != nil {
+		exporter, err := tracing.NewOTLPExporter(ctx, tc.OTLPEndpoint, tc.Headers, tc.ExportInterval)
+		if err != nil {
+			return errors.Wrap(err, "cannot build tracing exporter from config")
+		}
+		tracingLog := logging.LogSubsystem(log, logging.SubsysTracing)
+		shutdown := tracing.Init(exporter, tc.ServiceName, tc.ExportInterval, func(err error) {
+			tracingLog.WithError(err).Warn("cannot export spans")
+		})
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), tc.ExportInterval)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				tracingLog.WithError(err).Warn("cannot export remaining spans")
+			}
+		}()
+		tracingLog.WithField("endpoint", tc.OTLPEndpoint).Info("exporting traces via OTLP")
+	}
+
+	// datasets that are not present at startup are not checked
+	datasets, err := zfs.ZFSListMapping(ctx, zfs.NoFilter())
+	if err != nil {
+		log.WithError(err).Error("cannot list datasets, not checking jobs for overlapping datasets")
+	} else if err := job.ValidateOverlaps(conf, confJobs, datasets); err != nil {
+		return err
+	}
+
 	for _, job := range confJobs {
 		if IsInternalJobName(job.Name()) {
 			panic(fmt.Sprintf("internal job name used for config job '%s'", job.Name())) //FIXME
 		}
 	}
 
+	var historyDir string
+	if conf.Global.StateDir != "" {
+		historyDir = filepath.Join(conf.Global.StateDir, "history")
+	}
+	historyStore, err := history.NewStore(historyDir, conf.Global.History.Retention)
+	if err != nil && historyDir != "" {
+		log.WithError(err).Warn("cannot open job history in state_dir, keeping it in memory until the daemon exits")
+		historyStore, err = history.NewStore("", conf.Global.History.Retention)
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot open job history")
+	}
+
+	var pauseDir string
+	if conf.Global.StateDir != "" {
+		pauseDir = filepath.Join(conf.Global.StateDir, "pause")
+	}
+	pauseStore := pause.NewStore(pauseDir)
+	pausedInConfig := make(map[string]bool, len(conf.Jobs))
+	for _, jc := range conf.Jobs {
+		pausedInConfig[jc.Name()] = jc.Paused()
+	}
+
 	ctx = job.WithLogger(ctx, log)
+	ctx = history.WithStore(ctx, historyStore)
 
 	jobs := newJobs()
 
+	logInheritedSockets(log)
+
 	// start control socket
-	controlJob, err := newControlJob(conf.Global.Control.SockPath, jobs)
+	controlJob, err := newControlJob(conf.Global.Control.SockPath, jobs, historyStore, logLevels)
 	if err != nil {
 		panic(err) // FIXME
 	}
@@ -72,6 +147,8 @@ func Run(conf *config.Config) error {
 		switch v := jc.Ret.(type) {
 		case *config.PrometheusMonitoring:
 			job, err = newPrometheusJobFromConfig(v)
+		case *config.InfluxDBMonitoring, *config.StatsDMonitoring, *config.PushgatewayMonitoring:
+			job, err = newPushMonitoringJobFromConfig(i, v)
 		default:
 			return errors.Errorf("unknown monitoring job #%d (type %T)", i, v)
 		}
@@ -85,15 +162,29 @@ func Run(conf *config.Config) error {
 
 	// start regular jobs
 	for _, j := range confJobs {
-		jobs.start(ctx, j, false)
+		gate, err := pauseStore.Gate(j.Name(), pausedInConfig[j.Name()], time.Now())
+		if err != nil {
+			log.WithField(logJobField, j.Name()).WithError(err).Warn("cannot load pause state, starting job unpaused")
+		}
+		if st := gate.State(); st.Paused {
+			log.WithField(logJobField, j.Name()).WithField("paused_by", st.By).WithField("paused_since", st.Since).
+				Info("job is paused")
+		}
+		jobs.start(pause.Context(ctx, gate), j, false)
 	}
 
+	notifyReady(ctx, log, controlJob, len(confJobs))
+	go runWatchdog(ctx, log, jobs)
+
 	select {
 	case <-jobs.wait():
 		log.Info("all jobs finished")
 	case <-ctx.Done():
 		log.WithError(ctx.Err()).Info("context finished")
 	}
+	if err := systemd.Notify(systemd.StateStopping); err != nil {
+		log.WithError(err).Error("cannot notify systemd")
+	}
 	log.Info("daemon exiting")
 	return nil
 }
@@ -102,17 +193,25 @@ type jobs struct {
 	wg sync.WaitGroup
 
 	// m protects all fields below it
-	m       sync.RWMutex
-	wakeups map[string]wakeup.Func // by Job.Name
-	resets  map[string]reset.Func  // by Job.Name
-	jobs    map[string]job.Job
+	m         sync.RWMutex
+	wakeups   map[string]wakeup.Func   // by Job.Name
+	resets    map[string]reset.Func    // by Job.Name
+	verifies  map[string]verify.Func   // by Job.Name
+	snapshots map[string]snapshot.Func // by Job.Name
+	pauses    map[string]*pause.Gate   // by Job.Name, only jobs that can be paused
+	jobs      map[string]job.Job
+	exited    map[string]bool // by Job.Name, jobs whose Run returned
 }
 
 func newJobs() *jobs {
 	return &jobs{
-		wakeups: make(map[string]wakeup.Func),
-		resets:  make(map[string]reset.Func),
-		jobs:    make(map[string]job.Job),
+		wakeups:   make(map[string]wakeup.Func),
+		resets:    make(map[string]reset.Func),
+		verifies:  make(map[string]verify.Func),
+		snapshots: make(map[string]snapshot.Func),
+		pauses:    make(map[string]*pause.Gate),
+		jobs:      make(map[string]job.Job),
+		exited:    make(map[string]bool),
 	}
 }
 
@@ -149,11 +248,23 @@ func (s *jobs) status() map[string]*job.Status {
 	close(c)
 	ret := make(map[string]*job.Status, len(s.jobs))
 	for res := range c {
+		if g, ok := s.pauses[res.name]; ok && res.status != nil {
+			if st := g.State(); st.Paused {
+				res.status.Paused = &st
+			}
+		}
 		ret[res.name] = res.status
 	}
 	return ret
 }
 
+func (s *jobs) exists(job string) bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	_, ok := s.jobs[job]
+	return ok
+}
+
 func (s *jobs) wakeup(job string) error {
 	s.m.RLock()
 	defer s.m.RUnlock()
@@ -176,6 +287,79 @@ func (s *jobs) reset(job string) error {
 	return wu()
 }
 
+func (s *jobs) verify(job string) error {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	vf, ok := s.verifies[job]
+	if !ok {
+		return errors.Errorf("Job %s does not exist", job)
+	}
+	return vf()
+}
+
+func (s *jobs) snapshot(job string, req snapshot.Request) error {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	if _, ok := s.jobs[job]; !ok {
+		return errors.Errorf("Job %s does not exist", job)
+	}
+	sf, ok := s.snapshots[job]
+	if !ok {
+		return errors.Errorf("Job %s does not create snapshots", job)
+	}
+	return sf(req)
+}
+
+func (s *jobs) pause(job, by string) error {
+	g, err := s.pauseGate(job)
+	if err != nil {
+		return err
+	}
+	if err := g.Pause(by); err != nil {
+		return err
+	}
+	updatePauseMetrics(job, pause.State{}, g.State())
+	return nil
+}
+
+func (s *jobs) resume(job string) error {
+	g, err := s.pauseGate(job)
+	if err != nil {
+		return err
+	}
+	was, err := g.Resume()
+	if err != nil {
+		return err
+	}
+	updatePauseMetrics(job, was, g.State())
+	return nil
+}
+
+func (s *jobs) pauseGate(job string) (*pause.Gate, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	if _, ok := s.jobs[job]; !ok {
+		return nil, errors.Errorf("Job %s does not exist", job)
+	}
+	g, ok := s.pauses[job]
+	if !ok {
+		return nil, errors.Errorf("Job %s cannot be paused", job)
+	}
+	return g, nil
+}
+
+func jobCreatesSnapshots(j job.Job) bool {
+	switch j.Status().Type {
+	case job.TypePush, job.TypeSource, job.TypeSnap:
+		return true
+	default:
+		return false
+	}
+}
+
 const (
 	jobNamePrometheus = "_prometheus"
 	jobNameControl    = "_control"
@@ -211,12 +395,26 @@ func (s *jobs) start(ctx context.Context, j job.Job, internal bool) {
 	ctx, resetFunc := reset.Context(ctx)
 	s.wakeups[jobName] = wakeup
 	s.resets[jobName] = resetFunc
+	ctx, verifyFunc := verify.Context(ctx)
+	s.verifies[jobName] = verifyFunc
+	if jobCreatesSnapshots(j) {
+		ctx, s.snapshots[jobName] = snapshot.Context(ctx)
+	}
+	if g := pause.GetGate(ctx); g != nil {
+		s.pauses[jobName] = g
+		updatePauseMetrics(jobName, pause.State{}, g.State())
+	}
 
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 		jobLog.Info("starting job")
 		defer jobLog.Info("job exited")
+		defer func() {
+			s.m.Lock()
+			defer s.m.Unlock()
+			s.exited[jobName] = true
+		}()
 		j.Run(ctx)
 	}()
 }
@@ -1,7 +1,11 @@
 package filters
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -19,13 +23,29 @@ type DatasetMapFilter struct {
 }
 
 type datasetMapFilterEntry struct {
-	path *zfs.DatasetPath
+	path *zfs.DatasetPath // nil for glob and regex entries
 	// the mapping. since this datastructure acts as both mapping and filter
 	// we have to convert it to the desired rep dynamically
 	mapping      string
 	subtreeMatch bool
+
+	// the pattern passed to Add
+	pattern string
+	// glob entries: the path components of the pattern, see globMatch
+	glob []string
+	// regex entries
+	regex *regexp.Regexp
 }
 
+const (
+	// prefix of regex patterns, '~' is not allowed in dataset names
+	regexPatternPrefix = "~"
+	// characters that make a pattern a glob pattern, none of them is allowed in dataset names
+	globPatternChars = "*?["
+	// glob path component that matches zero or more path components
+	globAnyComponents = "**"
+)
+
 func NewDatasetMapFilter(capacity int, filterMode bool) *DatasetMapFilter {
 	return &DatasetMapFilter{
 		entries:    make([]datasetMapFilterEntry, 0, capacity),
@@ -41,6 +61,24 @@ func (m *DatasetMapFilter) Add(pathPattern, mapping string) (err error) {
 		}
 	}
 
+	isRegex := strings.HasPrefix(pathPattern, regexPatternPrefix)
+	isGlob := !isRegex && strings.ContainsAny(pathPattern, globPatternChars)
+	if (isRegex || isGlob) && !m.filterMode {
+		return fmt.Errorf("glob and regex patterns are only supported in filters")
+	}
+	if isRegex {
+		re, err := regexp.Compile("^(?:" + strings.TrimPrefix(pathPattern, regexPatternPrefix) + ")$")
+		if err != nil {
+			return fmt.Errorf("pattern is not a valid regular expression: %s", err)
+		}
+		m.entries = append(m.entries, datasetMapFilterEntry{
+			mapping: mapping,
+			pattern: pathPattern,
+			regex:   re,
+		})
+		return nil
+	}
+
 	// assert path glob adheres to spec
 	const SUBTREE_PATTERN string = "<"
 	patternCount := strings.Count(pathPattern, SUBTREE_PATTERN)
@@ -51,6 +89,25 @@ func (m *DatasetMapFilter) Add(pathPattern, mapping string) (err error) {
 		return
 	}
 
+	if isGlob {
+		glob := strings.Split(strings.TrimSuffix(pathPattern, SUBTREE_PATTERN), "/")
+		for _, c := range glob {
+			if c == "" {
+				return fmt.Errorf("glob pattern must not contain empty path components")
+			}
+			if _, err := path.Match(c, ""); err != nil {
+				return fmt.Errorf("glob pattern component %q is invalid: %s", c, err)
+			}
+		}
+		m.entries = append(m.entries, datasetMapFilterEntry{
+			mapping:      mapping,
+			subtreeMatch: patternCount > 0,
+			pattern:      pathPattern,
+			glob:         glob,
+		})
+		return nil
+	}
+
 	pathStr := strings.TrimSuffix(pathPattern, SUBTREE_PATTERN)
 	path, err := zfs.NewDatasetPath(pathStr)
 	if err != nil {
@@ -61,6 +118,7 @@ func (m *DatasetMapFilter) Add(pathPattern, mapping string) (err error) {
 		path:         path,
 		mapping:      mapping,
 		subtreeMatch: patternCount > 0,
+		pattern:      pathPattern,
 	}
 	m.entries = append(m.entries, entry)
 	return
@@ -69,37 +127,124 @@ func (m *DatasetMapFilter) Add(pathPattern, mapping string) (err error) {
 
 // find the most specific prefix mapping we have
 //
-// longer prefix wins over shorter prefix, direct wins over glob
+// Entries of all kinds are ranked by specificity, in order of precedence:
+//   - the entry naming the deepest dataset on the path to path, i.e., entries without '<'
+//     and regexes (which name path itself) or the subtree entry whose root is closest to path
+//   - among those, entries without '<' win over subtree entries
+//   - full path entries win over globs, which win over regexes
+//   - globs with more literal path components win
+//
+// If several entries are equally specific, rejecting entries win.
 func (m DatasetMapFilter) mostSpecificPrefixMapping(path *zfs.DatasetPath) (idx int, found bool) {
-	lcp, lcp_entry_idx := -1, -1
-	direct_idx := -1
+	var best []int
+	var bestSpec specificity
 	for e := range m.entries {
-		entry := m.entries[e]
-		ep := m.entries[e].path
-		lep := ep.Length()
-
-		switch {
-		case !entry.subtreeMatch && ep.Equal(path):
-			direct_idx = e
-			continue
-		case entry.subtreeMatch && path.HasPrefix(ep) && lep > lcp:
-			lcp = lep
-			lcp_entry_idx = e
-		default:
+		spec, ok := m.entries[e].match(path)
+		if !ok {
 			continue
 		}
+		switch {
+		case len(best) == 0 || bestSpec.less(spec):
+			best, bestSpec = []int{e}, spec
+		case !spec.less(bestSpec):
+			best = append(best, e)
+		}
+	}
+	if len(best) == 0 {
+		return -1, false
 	}
+	return m.rejectingEntryFirst(best), true
+}
 
-	if lcp_entry_idx >= 0 || direct_idx >= 0 {
-		found = true
-		switch {
-		case direct_idx >= 0:
-			idx = direct_idx
-		case lcp_entry_idx >= 0:
-			idx = lcp_entry_idx
+// specificity of a matching entry, see mostSpecificPrefixMapping
+type specificity struct {
+	depth    int // path components of the dataset named by the entry
+	exact    bool
+	kind     int // 2 for full paths, 1 for globs, 0 for regexes
+	literals int // glob components without wildcards
+}
+
+func (s specificity) less(o specificity) bool {
+	switch {
+	case s.depth != o.depth:
+		return s.depth < o.depth
+	case s.exact != o.exact:
+		return o.exact
+	case s.kind != o.kind:
+		return s.kind < o.kind
+	default:
+		return s.literals < o.literals
+	}
+}
+
+func (e datasetMapFilterEntry) match(p *zfs.DatasetPath) (spec specificity, ok bool) {
+	switch {
+	case e.glob != nil:
+		var comps []string
+		if p.Length() > 0 {
+			comps = strings.Split(p.ToString(), "/")
+		}
+		spec = specificity{kind: 1, exact: !e.subtreeMatch}
+		for _, c := range e.glob {
+			if !strings.ContainsAny(c, globPatternChars) {
+				spec.literals++
+			}
 		}
+		if !e.subtreeMatch {
+			spec.depth = len(comps)
+			return spec, globMatch(e.glob, comps)
+		}
+		// the closest matching ancestor is the most specific root
+		for spec.depth = len(comps); spec.depth >= 0; spec.depth-- {
+			if globMatch(e.glob, comps[:spec.depth]) {
+				return spec, true
+			}
+		}
+		return spec, false
+	case e.regex != nil:
+		return specificity{depth: p.Length(), exact: true}, e.regex.MatchString(p.ToString())
+	case e.subtreeMatch:
+		return specificity{depth: e.path.Length(), kind: 2}, p.HasPrefix(e.path)
+	default:
+		return specificity{depth: p.Length(), exact: true, kind: 2}, e.path.Equal(p)
 	}
-	return
+}
+
+// rejectingEntryFirst returns the first rejecting entry of idxs ordered by pattern,
+// or the first accepting entry if there is no rejecting one
+func (m DatasetMapFilter) rejectingEntryFirst(idxs []int) int {
+	sort.Slice(idxs, func(i, j int) bool {
+		ri := m.entries[idxs[i]].mapping == MapFilterResultOmit
+		rj := m.entries[idxs[j]].mapping == MapFilterResultOmit
+		if ri != rj {
+			return ri
+		}
+		return m.entries[idxs[i]].pattern < m.entries[idxs[j]].pattern
+	})
+	return idxs[0]
+}
+
+// globMatch matches the path components comps against the components of glob.
+// Each component is matched with path.Match, except for '**' which matches zero or more components.
+func globMatch(glob []string, comps []string) bool {
+	if len(glob) == 0 {
+		return len(comps) == 0
+	}
+	if glob[0] == globAnyComponents {
+		for i := 0; i <= len(comps); i++ {
+			if globMatch(glob[1:], comps[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(comps) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], comps[0]); !ok {
+		return false
+	}
+	return globMatch(glob[1:], comps[1:])
 }
 
 // Returns target == nil if there is no mapping
@@ -144,20 +289,28 @@ func (m DatasetMapFilter) Map(source *zfs.DatasetPath) (target *zfs.DatasetPath,
 }
 
 func (m DatasetMapFilter) Filter(p *zfs.DatasetPath) (pass bool, err error) {
+	d, err := m.Decide(context.Background(), p)
+	return d.Pass, err
+}
+
+// Decide is like Filter but also returns the pattern that decided.
+// The decision only depends on the dataset name, ctx is unused.
+func (m DatasetMapFilter) Decide(ctx context.Context, p *zfs.DatasetPath) (FilterDecision, error) {
 
 	if !m.filterMode {
-		err = fmt.Errorf("using a mapping as a filter does not work")
-		return
+		return FilterDecision{}, fmt.Errorf("using a mapping as a filter does not work")
 	}
 
 	mi, hasMapping := m.mostSpecificPrefixMapping(p)
 	if !hasMapping {
-		pass = false
-		return
+		return FilterDecision{false, "no matching rule"}, nil
 	}
 	me := m.entries[mi]
-	pass, err = m.parseDatasetFilterResult(me.mapping)
-	return
+	pass, err := m.parseDatasetFilterResult(me.mapping)
+	if err != nil {
+		return FilterDecision{}, err
+	}
+	return FilterDecision{pass, fmt.Sprintf("rule %q", me.pattern)}, nil
 }
 
 // Construct a new filter-only DatasetMapFilter from a mapping
@@ -182,6 +335,10 @@ func (m DatasetMapFilter) InvertedFilter() (inv *DatasetMapFilter, err error) {
 		}
 		inv.entries[i].mapping = MapFilterResultOk
 		inv.entries[i].subtreeMatch = e.subtreeMatch
+		inv.entries[i].pattern = e.mapping
+		if e.subtreeMatch {
+			inv.entries[i].pattern += "<"
+		}
 	}
 
 	return inv, nil
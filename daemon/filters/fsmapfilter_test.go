@@ -1,11 +1,12 @@
 package filters
 
 import (
+	"context"
+	"testing"
+
 	"github.com/zrepl/zrepl/zfs"
 )
 
-import "testing"
-
 func TestDatasetMapFilter(t *testing.T) {
 
 	type testCase struct {
@@ -54,6 +55,109 @@ func TestDatasetMapFilter(t *testing.T) {
 				"tank/home/bob/downloads": false,
 			},
 		},
+		{
+			"glob_components",
+			map[string]string{
+				"*/home/*":          "ok",
+				"tank/h?m[aeiou]/x": "ok",
+			},
+			map[string]bool{
+				"tank/home/alice":   true,
+				"zroot/home/bob":    true,
+				"tank/home":         false,
+				"tank/home/alice/a": false,
+				"tank/hame/x":       true,
+				"tank/hxmx/x":       false,
+			},
+		},
+		{
+			"glob_subtree_and_any_components",
+			map[string]string{
+				"*/home/*<": "ok",
+				"**/tmp<":   "!",
+			},
+			map[string]bool{
+				"tank/home":               false,
+				"tank/home/alice":         true,
+				"tank/home/alice/docs":    true,
+				"tank/home/alice/tmp":     false,
+				"tank/home/alice/tmp/foo": false,
+				"tmp":                     false,
+			},
+		},
+		{
+			"precedence_of_glob_over_subtree_and_full_path_over_glob",
+			map[string]string{
+				"tank<":                 "ok",
+				"tank/*/cache":          "!",
+				"tank/home/cache":       "ok",
+				"tank/home/alice/cache": "!",
+			},
+			map[string]bool{
+				"tank/var/cache":        false,
+				"tank/home/cache":       true,
+				"tank/var/cache/foo":    true,
+				"tank/home/alice/cache": false,
+			},
+		},
+		{
+			"precedence_of_non_subtree_glob_and_reject_among_globs",
+			map[string]string{
+				"*/*<":     "ok",
+				"*/*/*<":   "!",
+				"tank/*/x": "ok",
+				"*/home/*": "!",
+				"tank/*/*": "ok",
+			},
+			map[string]bool{
+				"tank/home":       true,
+				"tank/home/alice": false,
+				"tank/var/x":      true,
+				"tank/var/y":      true,
+				"tank/var/y/z":    false,
+			},
+		},
+		{
+			"specific_subtree_over_broad_glob",
+			map[string]string{
+				"tank<":             "ok",
+				"**/tmp<":           "!",
+				"tank/a/tmp/keep<":  "ok",
+				"*/home/*":          "!",
+				"tank/home/*":       "ok",
+				"tank/b/tmp/keep/x": "ok",
+			},
+			map[string]bool{
+				"tank/a/tmp":             false,
+				"tank/a/tmp/other":       false,
+				"tank/a/tmp/keep":        true,
+				"tank/a/tmp/keep/x":      true,
+				"tank/a/tmp/keep/tmp":    false, // closer to the dataset than tank/a/tmp/keep
+				"tank/a/tmp/keep/tmp/y":  false,
+				"tank/home/alice":        true, // more literal components than */home/*
+				"zroot/home/alice":       false,
+				"tank/b/tmp/keep":        false,
+				"tank/b/tmp/keep/x":      true,
+				"tank/b/tmp/keep/x/more": false,
+			},
+		},
+		{
+			"regex",
+			map[string]string{
+				"tank<":               "ok",
+				`~tank/(home|srv)/.*`: "!",
+				`~tank/home/[a-z]+`:   "ok",
+				"tank/home/*":         "!",
+			},
+			map[string]bool{
+				"tank/srv/www":          false,
+				"tank/home":             true,
+				"tank/home/alice":       false, // glob wins over regex
+				"tank/home/alice/docs":  false,
+				"tank/srv":              true,
+				"xtank/home/alice/docs": false, // regex is anchored
+			},
+		},
 	}
 
 	for tc := range tcs {
@@ -87,3 +191,45 @@ func TestDatasetMapFilter(t *testing.T) {
 	}
 
 }
+
+func TestDatasetMapFilterDecide(t *testing.T) {
+	f, err := DatasetMapFilterFromConfig(map[string]bool{
+		"tank<":     true,
+		"**/tmp<":   false,
+		`~tank/\d+`: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, exp := range map[string]FilterDecision{
+		"tank/a":     {true, `rule "tank<"`},
+		"tank/a/tmp": {false, `rule "**/tmp<"`},
+		"tank/123":   {false, `rule "~tank/\\d+"`},
+		"zroot":      {false, "no matching rule"},
+	} {
+		dp, err := zfs.NewDatasetPath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := f.Decide(context.Background(), dp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != exp {
+			t.Errorf("%s: expected %#v, got %#v", p, exp, d)
+		}
+	}
+}
+
+func TestDatasetMapFilterInvalidPatterns(t *testing.T) {
+	for _, p := range []string{"tank/[a", "tank//*", "*/a<b", "~tank/(", "tank/*/"} {
+		f := NewDatasetMapFilter(1, true)
+		if err := f.Add(p, MapFilterResultOk); err == nil {
+			t.Errorf("expected error for pattern %q", p)
+		}
+	}
+	m := NewDatasetMapFilter(1, false)
+	if err := m.Add("tank/*", "backup"); err == nil {
+		t.Errorf("expected error for glob pattern in mapping")
+	}
+}
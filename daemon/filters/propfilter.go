@@ -0,0 +1,247 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+const DefaultDatasetPropertyPrefix = "zrepl:"
+
+// Suffixes of the user properties read by DatasetProperties.
+const (
+	DatasetPropertyReplicate = "replicate"
+	DatasetPropertyInterval  = "interval"
+	DatasetPropertyKeep      = "keep"
+)
+
+var (
+	datasetPropertiesCacheTTL = envconst.Duration("ZREPL_DATASET_PROPERTIES_CACHE_TTL", 10*time.Second)
+	// minimum age of the cache before it is refreshed because a dataset is not in the cache
+	datasetPropertiesMinRefreshInterval = envconst.Duration("ZREPL_DATASET_PROPERTIES_MIN_REFRESH_INTERVAL", 1*time.Second)
+)
+
+// DatasetProperties reads per-dataset configuration from ZFS user properties.
+//
+// The properties of all datasets are read with a single zfs get invocation and cached for a short time,
+// so that filtering all datasets does not require a zfs get invocation per dataset.
+// User properties are inherited, i.e., a property set on a dataset applies to all its children
+// unless they override it.
+type DatasetProperties struct {
+	replicate, interval, keep string // property names
+
+	getAll func(ctx context.Context, props []string) (map[string]map[string]zfs.PropertyValue, error)
+
+	mtx      sync.Mutex
+	cache    map[string]map[string]zfs.PropertyValue
+	cachedAt time.Time
+}
+
+func NewDatasetProperties(prefix string) (*DatasetProperties, error) {
+	if prefix == "" {
+		prefix = DefaultDatasetPropertyPrefix
+	}
+	if !strings.Contains(prefix, ":") {
+		return nil, fmt.Errorf("dataset property prefix %q must contain a colon", prefix)
+	}
+	if strings.ToLower(prefix) != prefix {
+		return nil, fmt.Errorf("dataset property prefix %q must be lower case", prefix)
+	}
+	return &DatasetProperties{
+		replicate: prefix + DatasetPropertyReplicate,
+		interval:  prefix + DatasetPropertyInterval,
+		keep:      prefix + DatasetPropertyKeep,
+		getAll:    zfs.ZFSGetAllDatasets,
+	}, nil
+}
+
+// get returns the properties of fs, which are all unset if fs does not exist
+func (p *DatasetProperties) get(ctx context.Context, fs string) (map[string]zfs.PropertyValue, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	age := time.Since(p.cachedAt)
+	props, ok := p.cache[fs]
+	if p.cache != nil && age < datasetPropertiesCacheTTL && (ok || age < datasetPropertiesMinRefreshInterval) {
+		return props, nil
+	}
+	all, err := p.getAll(ctx, []string{p.replicate, p.interval, p.keep})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get dataset properties: %s", err)
+	}
+	p.cache, p.cachedAt = all, time.Now()
+	return p.cache[fs], nil
+}
+
+func describeProperty(name string, v zfs.PropertyValue) string {
+	return fmt.Sprintf("property %s=%s (%s)", name, v.Value, v.SourceString())
+}
+
+// InvalidPropertyError is returned by the methods of DatasetProperties if a property is set to a malformed value.
+// It only affects the dataset it is returned for.
+type InvalidPropertyError struct {
+	FS, Reason, Msg string
+}
+
+func (e *InvalidPropertyError) Error() string {
+	return fmt.Sprintf("%s on %s: %s", e.Reason, e.FS, e.Msg)
+}
+
+// Replicate returns whether fs is selected by the replicate property, ok is false if the property is not set.
+func (p *DatasetProperties) Replicate(ctx context.Context, fs string) (replicate, ok bool, reason string, err error) {
+	props, err := p.get(ctx, fs)
+	if err != nil {
+		return false, false, "", err
+	}
+	v, ok := props[p.replicate]
+	if !ok || !v.IsSet() {
+		return false, false, "", nil
+	}
+	reason = describeProperty(p.replicate, v)
+	switch v.Value {
+	case "on":
+		return true, true, reason, nil
+	case "off":
+		return false, true, reason, nil
+	default:
+		return false, false, reason, &InvalidPropertyError{fs, reason, "value must be 'on' or 'off'"}
+	}
+}
+
+// Interval returns the snapshot interval for fs, ok is false if the property is not set.
+func (p *DatasetProperties) Interval(ctx context.Context, fs string) (d time.Duration, ok bool, reason string, err error) {
+	props, err := p.get(ctx, fs)
+	if err != nil {
+		return 0, false, "", err
+	}
+	v, ok := props[p.interval]
+	if !ok || !v.IsSet() {
+		return 0, false, "", nil
+	}
+	reason = describeProperty(p.interval, v)
+	d, err = config.ParsePositiveDuration(v.Value)
+	if err != nil {
+		return 0, false, reason, &InvalidPropertyError{fs, reason, fmt.Sprintf("invalid duration: %s", err)}
+	}
+	return d, true, reason, nil
+}
+
+// KeepRules returns the keep rules for fs, ok is false if the property is not set.
+func (p *DatasetProperties) KeepRules(ctx context.Context, fs string) (rules []config.PruningEnum, ok bool, reason string, err error) {
+	props, err := p.get(ctx, fs)
+	if err != nil {
+		return nil, false, "", err
+	}
+	v, ok := props[p.keep]
+	if !ok || !v.IsSet() {
+		return nil, false, "", nil
+	}
+	reason = describeProperty(p.keep, v)
+	rules, err = config.ParseKeepRules(v.Value)
+	if err != nil {
+		return nil, false, reason, &InvalidPropertyError{fs, reason, fmt.Sprintf("invalid keep rules: %s", err)}
+	}
+	return rules, true, reason, nil
+}
+
+// FilterDecision is the result of a filter and the reason for it.
+type FilterDecision struct {
+	Pass   bool
+	Reason string
+}
+
+// DecidingFilter is a filter that can explain its decisions.
+type DecidingFilter interface {
+	zfs.DatasetFilter
+	Decide(ctx context.Context, p *zfs.DatasetPath) (FilterDecision, error)
+}
+
+func decide(ctx context.Context, f zfs.DatasetFilter, p *zfs.DatasetPath) (FilterDecision, error) {
+	if df, ok := f.(DecidingFilter); ok {
+		return df.Decide(ctx, p)
+	}
+	pass, err := zfs.FilterDataset(ctx, f, p)
+	return FilterDecision{Pass: pass}, err
+}
+
+var _ DecidingFilter = (*DatasetMapFilter)(nil)
+var _ DecidingFilter = (*PropertyFilter)(nil)
+var _ zfs.DatasetContextFilter = (*PropertyFilter)(nil)
+
+// PropertyFilter selects datasets by the replicate property of DatasetProperties.
+// Datasets for which the property is not set are filtered by the fallback filter.
+type PropertyFilter struct {
+	props    *DatasetProperties
+	fallback zfs.DatasetFilter
+}
+
+func NewPropertyFilter(props *DatasetProperties, fallback zfs.DatasetFilter) *PropertyFilter {
+	return &PropertyFilter{props, fallback}
+}
+
+func (f *PropertyFilter) Properties() *DatasetProperties { return f.props }
+
+// Filter is FilterContext for callers without a context, zfs.FilterDataset prefers the latter.
+func (f *PropertyFilter) Filter(p *zfs.DatasetPath) (pass bool, err error) {
+	return f.FilterContext(context.Background(), p)
+}
+
+func (f *PropertyFilter) FilterContext(ctx context.Context, p *zfs.DatasetPath) (pass bool, err error) {
+	d, err := f.Decide(ctx, p)
+	return d.Pass, err
+}
+
+// Decide is like Filter but also returns the reason for the decision.
+//
+// A malformed replicate property rejects the dataset it applies to (and is logged)
+// instead of failing the filter, which would abort the job for all datasets.
+func (f *PropertyFilter) Decide(ctx context.Context, p *zfs.DatasetPath) (FilterDecision, error) {
+	replicate, ok, reason, err := f.props.Replicate(ctx, p.ToString())
+	if ipe, isInvalid := err.(*InvalidPropertyError); isInvalid {
+		getLogger(ctx).WithError(err).WithField("fs", p.ToString()).Error("rejecting dataset with invalid property")
+		return FilterDecision{false, fmt.Sprintf("%s: %s, dataset is rejected", ipe.Reason, ipe.Msg)}, nil
+	}
+	if err != nil {
+		return FilterDecision{}, err
+	}
+	if ok {
+		return FilterDecision{replicate, reason}, nil
+	}
+	d, err := decide(ctx, f.fallback, p)
+	if err != nil {
+		return FilterDecision{}, err
+	}
+	if d.Reason == "" {
+		return FilterDecision{d.Pass, fmt.Sprintf("filesystems filter (property %s not set)", f.props.replicate)}, nil
+	}
+	return FilterDecision{d.Pass, fmt.Sprintf("filesystems filter %s (property %s not set)", d.Reason, f.props.replicate)}, nil
+}
+
+// DatasetFilterFromConfig builds the filter of a job's filesystems.
+// If dp is not nil, the filesystems filter is wrapped in a *PropertyFilter and props is the DatasetProperties it uses.
+// If sel is not nil, the result is a *DatasetSelectionFilter that restricts the datasets passed by the former.
+func DatasetFilterFromConfig(in config.FilesystemsFilter, sel *config.DatasetSelection, dp *config.DatasetProperties) (f zfs.DatasetFilter, props *DatasetProperties, err error) {
+	f, err = DatasetMapFilterFromConfig(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dp != nil {
+		props, err = NewDatasetProperties(dp.Prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		f = NewPropertyFilter(props, f)
+	}
+	if sel != nil {
+		f, err = NewDatasetSelectionFilter(f, *sel)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid dataset_selection: %s", err)
+		}
+	}
+	return f, props, nil
+}
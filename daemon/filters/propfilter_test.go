@@ -0,0 +1,112 @@
+package filters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+func TestPropertyFilter(t *testing.T) {
+
+	local := func(v string) zfs.PropertyValue { return zfs.PropertyValue{Value: v, Source: zfs.PropertySourceLocal} }
+	inherited := func(v, from string) zfs.PropertyValue {
+		return zfs.PropertyValue{Value: v, Source: zfs.PropertySourceInherited, InheritedFrom: from}
+	}
+	unset := zfs.PropertyValue{Value: "-", Source: zfs.PropertySourceNone}
+
+	fetches := 0
+	all := map[string]map[string]zfs.PropertyValue{
+		"pool":               {"zrepl:replicate": unset, "zrepl:interval": unset, "zrepl:keep": unset},
+		"pool/data":          {"zrepl:replicate": local("on"), "zrepl:interval": local("1h"), "zrepl:keep": unset},
+		"pool/data/scratch":  {"zrepl:replicate": local("off"), "zrepl:interval": inherited("1h", "pool/data"), "zrepl:keep": unset},
+		"pool/data/a":        {"zrepl:replicate": inherited("on", "pool/data"), "zrepl:interval": local("1m"), "zrepl:keep": local(`[{type: last_n, count: 3}]`)},
+		"pool/data/invalid":  {"zrepl:replicate": local("yes"), "zrepl:interval": local("soon"), "zrepl:keep": local("nope")},
+		"pool/other":         {"zrepl:replicate": unset, "zrepl:interval": unset, "zrepl:keep": unset},
+		"pool/other/ignored": {"zrepl:replicate": unset, "zrepl:interval": unset, "zrepl:keep": unset},
+	}
+
+	ctx := context.Background()
+	props, err := NewDatasetProperties("")
+	require.NoError(t, err)
+	props.getAll = func(ctx context.Context, names []string) (map[string]map[string]zfs.PropertyValue, error) {
+		assert.Equal(t, []string{"zrepl:replicate", "zrepl:interval", "zrepl:keep"}, names)
+		fetches++
+		return all, nil
+	}
+	fallback, err := DatasetMapFilterFromConfig(config.FilesystemsFilter{"pool/other": true})
+	require.NoError(t, err)
+	f := NewPropertyFilter(props, fallback)
+
+	decide := func(fs string) (FilterDecision, error) {
+		p, err := zfs.NewDatasetPath(fs)
+		require.NoError(t, err)
+		return f.Decide(ctx, p)
+	}
+
+	d, err := decide("pool/data/a")
+	require.NoError(t, err)
+	assert.Equal(t, FilterDecision{true, "property zrepl:replicate=on (inherited from pool/data)"}, d)
+
+	d, err = decide("pool/data/scratch")
+	require.NoError(t, err)
+	assert.Equal(t, FilterDecision{false, "property zrepl:replicate=off (local)"}, d)
+
+	d, err = decide("pool/other")
+	require.NoError(t, err)
+	assert.Equal(t, FilterDecision{true, `filesystems filter rule "pool/other" (property zrepl:replicate not set)`}, d)
+
+	d, err = decide("pool/other/ignored")
+	require.NoError(t, err)
+	assert.False(t, d.Pass)
+
+	// a malformed property only rejects the dataset it applies to
+	d, err = decide("pool/data/invalid")
+	require.NoError(t, err)
+	assert.Equal(t, FilterDecision{false, "property zrepl:replicate=yes (local): value must be 'on' or 'off', dataset is rejected"}, d)
+
+	assert.Equal(t, 1, fetches, "properties of all datasets must be fetched with a single invocation")
+
+	// unknown datasets are treated as if no property was set, the cache is refreshed at most every datasetPropertiesMinRefreshInterval
+	d, err = decide("pool/new")
+	require.NoError(t, err)
+	assert.False(t, d.Pass)
+	assert.Equal(t, 1, fetches)
+	props.cachedAt = props.cachedAt.Add(-datasetPropertiesMinRefreshInterval)
+	_, err = decide("pool/new")
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+
+	interval, ok, _, err := props.Interval(ctx, "pool/data/scratch")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, interval)
+	_, ok, _, err = props.Interval(ctx, "pool/other")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, _, _, err = props.Interval(ctx, "pool/data/invalid")
+	assert.IsType(t, &InvalidPropertyError{}, err)
+
+	rules, ok, reason, err := props.KeepRules(ctx, "pool/data/a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "property zrepl:keep=[{type: last_n, count: 3}] (local)", reason)
+	_, _, _, err = props.KeepRules(ctx, "pool/data/invalid")
+	assert.Error(t, err)
+}
+
+func TestNewDatasetProperties(t *testing.T) {
+	p, err := NewDatasetProperties("org.example:")
+	require.NoError(t, err)
+	assert.Equal(t, "org.example:replicate", p.replicate)
+	_, err = NewDatasetProperties("nocolon")
+	assert.Error(t, err)
+	_, err = NewDatasetProperties("Upper:")
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+var datasetSelectionCacheTTL = envconst.Duration("ZREPL_DATASET_SELECTION_CACHE_TTL", 10*time.Second)
+
+// DatasetSelectionFilter restricts the datasets passed by another filter
+// to those with the configured type, property values and sizes.
+//
+// zfs.ZFSListMapping lists the required properties with the datasets (see zfs.DatasetPropertiesFilter).
+// Filter, which is called for individual datasets, uses a listing of all datasets that is cached for a short time.
+type DatasetSelectionFilter struct {
+	inner zfs.DatasetFilter
+
+	types         map[string]bool // nil means all types
+	props         map[string]propertyCondition
+	maxUsed       *uint64
+	maxReferenced *uint64
+
+	listProps []string
+
+	list     func(props []string) (map[string]map[string]string, error)
+	mtx      sync.Mutex
+	cache    map[string]map[string]string
+	cachedAt time.Time
+}
+
+type propertyCondition struct {
+	value  string
+	negate bool
+}
+
+func (c propertyCondition) String() string {
+	if c.negate {
+		return "!" + c.value
+	}
+	return c.value
+}
+
+var _ zfs.DatasetPropertiesFilter = (*DatasetSelectionFilter)(nil)
+var _ DecidingFilter = (*DatasetSelectionFilter)(nil)
+var _ zfs.DatasetContextFilter = (*DatasetSelectionFilter)(nil)
+
+func NewDatasetSelectionFilter(inner zfs.DatasetFilter, in config.DatasetSelection) (*DatasetSelectionFilter, error) {
+	f := &DatasetSelectionFilter{
+		inner: inner,
+		props: make(map[string]propertyCondition, len(in.Properties)),
+		list:  listAllDatasetProperties,
+	}
+	needProps := make(map[string]bool)
+	if len(in.Types) > 0 {
+		f.types = make(map[string]bool, len(in.Types))
+		for _, t := range in.Types {
+			if t != "filesystem" && t != "volume" {
+				return nil, fmt.Errorf("invalid dataset type %q, must be 'filesystem' or 'volume'", t)
+			}
+			f.types[t] = true
+		}
+		needProps["type"] = true
+	}
+	for p, v := range in.Properties {
+		if p == "" || p == "name" {
+			return nil, fmt.Errorf("invalid property name %q", p)
+		}
+		c := propertyCondition{value: v}
+		if strings.HasPrefix(v, "!") {
+			c = propertyCondition{value: strings.TrimPrefix(v, "!"), negate: true}
+		}
+		f.props[p] = c
+		needProps[p] = true
+	}
+	if in.MaxUsed != nil {
+		v := uint64(*in.MaxUsed)
+		f.maxUsed = &v
+		needProps["used"] = true
+	}
+	if in.MaxReferenced != nil {
+		v := uint64(*in.MaxReferenced)
+		f.maxReferenced = &v
+		needProps["referenced"] = true
+	}
+	for p := range needProps {
+		f.listProps = append(f.listProps, p)
+	}
+	sort.Strings(f.listProps)
+	return f, nil
+}
+
+func listAllDatasetProperties(props []string) (map[string]map[string]string, error) {
+	rows, err := zfs.ZFSList(append([]string{"name"}, props...), "-r", "-t", "filesystem,volume")
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]map[string]string, len(rows))
+	for _, row := range rows {
+		m := make(map[string]string, len(props))
+		for i, p := range props {
+			m[p] = row[1+i]
+		}
+		res[row[0]] = m
+	}
+	return res, nil
+}
+
+func (f *DatasetSelectionFilter) FilterProperties() []string { return f.listProps }
+
+func (f *DatasetSelectionFilter) FilterWithProperties(ctx context.Context, p *zfs.DatasetPath, props map[string]string) (pass bool, err error) {
+	d, err := f.decideWithProperties(ctx, p, props)
+	return d.Pass, err
+}
+
+func (f *DatasetSelectionFilter) Filter(p *zfs.DatasetPath) (pass bool, err error) {
+	return f.FilterContext(context.Background(), p)
+}
+
+func (f *DatasetSelectionFilter) FilterContext(ctx context.Context, p *zfs.DatasetPath) (pass bool, err error) {
+	d, err := f.Decide(ctx, p)
+	return d.Pass, err
+}
+
+// Decide is like Filter but also returns the reason for the decision.
+func (f *DatasetSelectionFilter) Decide(ctx context.Context, p *zfs.DatasetPath) (FilterDecision, error) {
+	props, ok, err := f.cachedProperties(p.ToString())
+	if err != nil {
+		return FilterDecision{}, err
+	}
+	if !ok {
+		return FilterDecision{false, "dataset does not exist"}, nil
+	}
+	return f.decideWithProperties(ctx, p, props)
+}
+
+func (f *DatasetSelectionFilter) cachedProperties(fs string) (props map[string]string, ok bool, err error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	age := time.Since(f.cachedAt)
+	props, ok = f.cache[fs]
+	if f.cache != nil && age < datasetSelectionCacheTTL && (ok || age < datasetPropertiesMinRefreshInterval) {
+		return props, ok, nil
+	}
+	all, err := f.list(f.listProps)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot list dataset properties: %s", err)
+	}
+	f.cache, f.cachedAt = all, time.Now()
+	props, ok = f.cache[fs]
+	return props, ok, nil
+}
+
+func (f *DatasetSelectionFilter) decideWithProperties(ctx context.Context, p *zfs.DatasetPath, props map[string]string) (FilterDecision, error) {
+	inner, err := decide(ctx, f.inner, p)
+	if err != nil || !inner.Pass {
+		return inner, err
+	}
+
+	reject := func(format string, args ...interface{}) (FilterDecision, error) {
+		return FilterDecision{false, "dataset_selection: " + fmt.Sprintf(format, args...)}, nil
+	}
+	if f.types != nil && !f.types[props["type"]] {
+		return reject("type %s is not selected", props["type"])
+	}
+	names := make([]string, 0, len(f.props))
+	for n := range f.props {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		c := f.props[n]
+		if (props[n] == c.value) == c.negate {
+			return reject("property %s=%s does not match %s", n, props[n], c)
+		}
+	}
+	checkSize := func(prop string, max *uint64) (exceeds bool, v uint64, err error) {
+		if max == nil {
+			return false, 0, nil
+		}
+		v, err = strconv.ParseUint(props[prop], 10, 64)
+		if err != nil {
+			return false, 0, fmt.Errorf("cannot parse property %s=%q of %s: %s", prop, props[prop], p.ToString(), err)
+		}
+		return v > *max, v, nil
+	}
+	if exceeds, v, err := checkSize("used", f.maxUsed); err != nil {
+		return FilterDecision{}, err
+	} else if exceeds {
+		return reject("used %d exceeds max_used %d", v, *f.maxUsed)
+	}
+	if exceeds, v, err := checkSize("referenced", f.maxReferenced); err != nil {
+		return FilterDecision{}, err
+	} else if exceeds {
+		return reject("referenced %d exceeds max_referenced %d", v, *f.maxReferenced)
+	}
+	return inner, nil
+}
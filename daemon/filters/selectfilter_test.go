@@ -0,0 +1,73 @@
+package filters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+func TestDatasetSelectionFilter(t *testing.T) {
+	inner, err := DatasetMapFilterFromConfig(config.FilesystemsFilter{"pool<": true, "pool/excluded": false})
+	require.NoError(t, err)
+	maxUsed := config.ByteSize(1 << 40)
+	f, err := NewDatasetSelectionFilter(inner, config.DatasetSelection{
+		Types:      []string{"filesystem"},
+		Properties: map[string]string{"canmount": "!off"},
+		MaxUsed:    &maxUsed,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"canmount", "type", "used"}, f.FilterProperties())
+
+	all := map[string]map[string]string{
+		"pool":          {"type": "filesystem", "canmount": "on", "used": "2199023255552"},
+		"pool/a":        {"type": "filesystem", "canmount": "on", "used": "1024"},
+		"pool/vol":      {"type": "volume", "canmount": "-", "used": "1024"},
+		"pool/nomount":  {"type": "filesystem", "canmount": "off", "used": "1024"},
+		"pool/excluded": {"type": "filesystem", "canmount": "on", "used": "1024"},
+	}
+	lists := 0
+	f.list = func(props []string) (map[string]map[string]string, error) {
+		assert.Equal(t, f.FilterProperties(), props)
+		lists++
+		return all, nil
+	}
+
+	tcs := map[string]FilterDecision{
+		"pool":          {false, "dataset_selection: used 2199023255552 exceeds max_used 1099511627776"},
+		"pool/a":        {true, `rule "pool<"`},
+		"pool/vol":      {false, "dataset_selection: type volume is not selected"},
+		"pool/nomount":  {false, "dataset_selection: property canmount=off does not match !off"},
+		"pool/excluded": {false, `rule "pool/excluded"`},
+	}
+	for fs, exp := range tcs {
+		p, err := zfs.NewDatasetPath(fs)
+		require.NoError(t, err)
+
+		d, err := f.Decide(context.Background(), p)
+		require.NoError(t, err)
+		assert.Equal(t, exp, d, fs)
+
+		// as called by zfs.ZFSListMapping
+		pass, err := f.FilterWithProperties(context.Background(), p, all[fs])
+		require.NoError(t, err)
+		assert.Equal(t, exp.Pass, pass, fs)
+	}
+	assert.Equal(t, 1, lists, "listing must be cached")
+
+	p, err := zfs.NewDatasetPath("pool/doesnotexist")
+	require.NoError(t, err)
+	pass, err := f.Filter(p)
+	require.NoError(t, err)
+	assert.False(t, pass)
+
+	_, err = f.FilterWithProperties(context.Background(), p, map[string]string{"type": "filesystem", "canmount": "on", "used": "-"})
+	assert.Error(t, err)
+
+	_, err = NewDatasetSelectionFilter(inner, config.DatasetSelection{Types: []string{"snapshot"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,325 @@
+// Package history persists a compact record of each job invocation.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/replication/report"
+)
+
+type Record struct {
+	Job string
+	// per-job sequence number assigned by Store.Append, continues across daemon restarts
+	Seq int
+	// number of the invocation since the daemon started, as shown by zrepl status
+	Invocation int
+	StartAt    time.Time
+	FinishAt   time.Time
+	// nil for jobs that do not replicate
+	Replication *Replication `json:",omitempty"`
+	Pruning     []*Pruning   `json:",omitempty"`
+}
+
+type Replication struct {
+	// state of the last attempt
+	State    report.AttemptState
+	Attempts int
+	// non-empty if planning of the last attempt failed
+	Error       string `json:",omitempty"`
+	Filesystems []*Filesystem
+}
+
+type Filesystem struct {
+	Name            string
+	State           report.FilesystemState
+	Error           string `json:",omitempty"`
+	StepsCompleted  int
+	StepsTotal      int
+	BytesExpected   int64
+	BytesReplicated int64
+}
+
+type Pruning struct {
+	Side  string
+	State string
+	// non-empty if pruning failed entirely
+	Error string `json:",omitempty"`
+	// number of snapshots destroyed (or attempted to destroy, see FailedFilesystems)
+	Destroyed         int
+	FailedFilesystems []string `json:",omitempty"`
+}
+
+func (r *Record) BytesReplicated() (n int64) {
+	if r.Replication == nil {
+		return 0
+	}
+	for _, fs := range r.Replication.Filesystems {
+		n += fs.BytesReplicated
+	}
+	return n
+}
+
+// Successful returns true if replication and pruning completed without errors.
+func (r *Record) Successful() bool {
+	if r.Replication != nil {
+		if r.Replication.State != report.AttemptDone {
+			return false
+		}
+		for _, fs := range r.Replication.Filesystems {
+			if fs.Error != "" {
+				return false
+			}
+		}
+	}
+	for _, p := range r.Pruning {
+		if p.Error != "" || len(p.FailedFilesystems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ReplicationFromReport returns nil if rep is nil.
+func ReplicationFromReport(rep *report.Report) *Replication {
+	if rep == nil || len(rep.Attempts) == 0 {
+		return nil
+	}
+	a := rep.Attempts[len(rep.Attempts)-1]
+	r := &Replication{
+		State:       a.State,
+		Attempts:    len(rep.Attempts),
+		Filesystems: make([]*Filesystem, 0, len(a.Filesystems)),
+	}
+	if a.PlanError != nil {
+		r.Error = a.PlanError.Err
+	}
+	for _, fsr := range a.Filesystems {
+		fs := &Filesystem{
+			Name:       fsr.Info.Name,
+			State:      fsr.State,
+			StepsTotal: len(fsr.Steps),
+		}
+		if err := fsr.Error(); err != nil {
+			fs.Error = err.Err
+		}
+		switch fsr.State {
+		case report.FilesystemDone:
+			fs.StepsCompleted = len(fsr.Steps)
+		case report.FilesystemStepping, report.FilesystemPaused, report.FilesystemSteppingErrored:
+			fs.StepsCompleted = fsr.CurrentStep
+		}
+		fs.BytesExpected, fs.BytesReplicated = fsr.BytesSum()
+		r.Filesystems = append(r.Filesystems, fs)
+	}
+	sort.Slice(r.Filesystems, func(i, j int) bool {
+		return r.Filesystems[i].Name < r.Filesystems[j].Name
+	})
+	return r
+}
+
+// PruningFromReport returns nil if rep is nil.
+func PruningFromReport(side string, rep *pruner.Report) *Pruning {
+	if rep == nil {
+		return nil
+	}
+	p := &Pruning{Side: side, State: rep.State, Error: rep.Error}
+	for _, fs := range rep.Completed {
+		if fs.LastError != "" {
+			p.FailedFilesystems = append(p.FailedFilesystems, fs.Filesystem)
+			continue
+		}
+		p.Destroyed += len(fs.DestroyList)
+	}
+	for _, fs := range rep.Pending {
+		if fs.LastError != "" {
+			p.FailedFilesystems = append(p.FailedFilesystems, fs.Filesystem)
+		}
+	}
+	return p
+}
+
+// Store persists records in a directory, one file per job.
+// Each file contains at most retention records in JSON lines format, oldest first.
+type Store struct {
+	dir       string // empty if records are only kept in memory
+	retention int
+
+	mtx sync.Mutex
+	mem map[string][]*Record // by job, used if dir is empty
+}
+
+const fileSuffix = ".jsonl"
+
+// NewStore creates dir if it does not exist.
+// If dir is empty, the records are kept in memory and lost when the daemon exits.
+func NewStore(dir string, retention int) (*Store, error) {
+	if retention <= 0 {
+		return nil, errors.New("retention must be positive")
+	}
+	if dir == "" {
+		return &Store{retention: retention, mem: make(map[string][]*Record)}, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "cannot create history directory")
+	}
+	return &Store{dir: dir, retention: retention}, nil
+}
+
+// Persistent returns true if the records survive a daemon restart.
+func (s *Store) Persistent() bool { return s.dir != "" }
+
+// job names are not restricted, hence escaped for use as file names
+func (s *Store) path(job string) string {
+	return filepath.Join(s.dir, url.PathEscape(job)+fileSuffix)
+}
+
+func (s *Store) read(job string) ([]*Record, error) {
+	if !s.Persistent() {
+		return append([]*Record(nil), s.mem[job]...), nil
+	}
+	f, err := os.Open(s.path(job))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []*Record
+	scan := bufio.NewScanner(f)
+	scan.Buffer(nil, 1<<24) // records of jobs with many filesystems can be large
+	for scan.Scan() {
+		var r Record
+		if err := json.Unmarshal(scan.Bytes(), &r); err != nil {
+			// skip corrupted lines, e.g. from a crash during write
+			continue
+		}
+		records = append(records, &r)
+	}
+	return records, scan.Err()
+}
+
+// Append assigns r.Seq and adds r to the history of r.Job,
+// discarding the oldest records beyond the retention limit.
+func (s *Store) Append(r *Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	records, err := s.read(r.Job)
+	if err != nil {
+		return errors.Wrap(err, "cannot read history")
+	}
+	r.Seq = 1
+	if len(records) > 0 {
+		r.Seq = records[len(records)-1].Seq + 1
+	}
+	records = append(records, r)
+	if len(records) > s.retention {
+		records = records[len(records)-s.retention:]
+	}
+	if !s.Persistent() {
+		s.mem[r.Job] = records
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf) // Encode appends a newline
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	// write & rename for atomic replacement
+	tmp, err := ioutil.TempFile(s.dir, "."+url.PathEscape(r.Job)+fileSuffix) // random suffix, hence not matched by Query
+	if err != nil {
+		return errors.Wrap(err, "cannot write history")
+	}
+	defer os.Remove(tmp.Name()) // no-op after successful rename
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "cannot write history")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "cannot write history")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), s.path(r.Job)), "cannot write history")
+}
+
+// Query returns the most recent records of job, or of all jobs if job is empty, newest first.
+// If limit is positive, at most limit records are returned.
+func (s *Store) Query(job string, limit int) ([]*Record, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var jobs []string
+	if job != "" {
+		jobs = []string{job}
+	} else if !s.Persistent() {
+		for j := range s.mem {
+			jobs = append(jobs, j)
+		}
+	} else {
+		entries, err := ioutil.ReadDir(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			n := e.Name()
+			if !e.Mode().IsRegular() || !strings.HasSuffix(n, fileSuffix) {
+				continue
+			}
+			j, err := url.PathUnescape(strings.TrimSuffix(n, fileSuffix))
+			if err != nil {
+				continue // not created by us
+			}
+			jobs = append(jobs, j)
+		}
+	}
+
+	var records []*Record
+	for _, j := range jobs {
+		rs, err := s.read(j)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read history of job %q: %s", j, err)
+		}
+		records = append(records, rs...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].StartAt.Equal(records[j].StartAt) {
+			return records[i].StartAt.After(records[j].StartAt)
+		}
+		return records[i].Job < records[j].Job
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+type contextKey int
+
+const contextKeyStore contextKey = 0
+
+func WithStore(ctx context.Context, s *Store) context.Context {
+	return context.WithValue(ctx, contextKeyStore, s)
+}
+
+// GetStore returns nil if ctx has no store.
+func GetStore(ctx context.Context) *Store {
+	s, _ := ctx.Value(contextKeyStore).(*Store)
+	return s
+}
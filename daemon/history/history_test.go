@@ -0,0 +1,149 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/replication/report"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-history-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewStore(dir, 3)
+	require.NoError(t, err)
+
+	base := time.Unix(1000, 0).UTC()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, s.Append(&Record{Job: "a/b", Invocation: i, StartAt: base.Add(time.Duration(2*i) * time.Minute)}))
+	}
+	require.NoError(t, s.Append(&Record{Job: "other", Invocation: 1, StartAt: base.Add(7 * time.Minute)}))
+
+	rs, err := s.Query("a/b", 0)
+	require.NoError(t, err)
+	require.Len(t, rs, 3, "retention")
+	assert.Equal(t, []int{5, 4, 3}, invocations(rs))
+
+	rs, err = s.Query("", 0)
+	require.NoError(t, err)
+	require.Len(t, rs, 4)
+	assert.Equal(t, "a/b", rs[0].Job)
+	assert.Equal(t, "a/b", rs[1].Job)
+	assert.Equal(t, "other", rs[2].Job)
+
+	rs, err = s.Query("", 2)
+	require.NoError(t, err)
+	assert.Len(t, rs, 2)
+
+	rs, err = s.Query("doesnotexist", 0)
+	require.NoError(t, err)
+	assert.Empty(t, rs)
+
+	// survives re-opening, sequence numbers continue although invocations restart at 1
+	s, err = NewStore(dir, 3)
+	require.NoError(t, err)
+	rs, err = s.Query("a/b", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5}, invocations(rs))
+	assert.Equal(t, 5, rs[0].Seq)
+	require.NoError(t, s.Append(&Record{Job: "a/b", Invocation: 1, StartAt: base.Add(time.Hour)}))
+	rs, err = s.Query("a/b", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{6, 5, 4}, seqs(rs))
+}
+
+func TestMemoryStore(t *testing.T) {
+	s, err := NewStore("", 2)
+	require.NoError(t, err)
+	assert.False(t, s.Persistent())
+
+	base := time.Unix(1000, 0).UTC()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.Append(&Record{Job: "a", Invocation: i, StartAt: base.Add(time.Duration(i) * time.Minute)}))
+	}
+	require.NoError(t, s.Append(&Record{Job: "b", Invocation: 1, StartAt: base}))
+
+	rs, err := s.Query("a", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, seqs(rs))
+	rs, err = s.Query("", 0)
+	require.NoError(t, err)
+	assert.Len(t, rs, 3)
+	assert.Equal(t, "b", rs[2].Job)
+}
+
+func seqs(rs []*Record) []int {
+	is := make([]int, len(rs))
+	for i, r := range rs {
+		is[i] = r.Seq
+	}
+	return is
+}
+
+func invocations(rs []*Record) []int {
+	is := make([]int, len(rs))
+	for i, r := range rs {
+		is[i] = r.Invocation
+	}
+	return is
+}
+
+func TestFromReports(t *testing.T) {
+	rep := &report.Report{
+		Attempts: []*report.AttemptReport{
+			{State: report.AttemptPlanningError},
+			{
+				State: report.AttemptFanOutError,
+				Filesystems: []*report.FilesystemReport{
+					{
+						Info:        &report.FilesystemInfo{Name: "pool/b"},
+						State:       report.FilesystemSteppingErrored,
+						StepError:   report.NewTimedError("broken pipe", time.Now()),
+						CurrentStep: 1,
+						Steps: []*report.StepReport{
+							{Info: &report.StepInfo{BytesExpected: 10, BytesReplicated: 10}},
+							{Info: &report.StepInfo{BytesExpected: 20, BytesReplicated: 5}},
+						},
+					},
+					{
+						Info:  &report.FilesystemInfo{Name: "pool/a"},
+						State: report.FilesystemDone,
+						Steps: []*report.StepReport{
+							{Info: &report.StepInfo{BytesExpected: 100, BytesReplicated: 100}},
+						},
+					},
+				},
+			},
+		},
+	}
+	r := &Record{Replication: ReplicationFromReport(rep)}
+	require.NotNil(t, r.Replication)
+	assert.Equal(t, 2, r.Replication.Attempts)
+	assert.Equal(t, []*Filesystem{
+		{Name: "pool/a", State: report.FilesystemDone, StepsCompleted: 1, StepsTotal: 1, BytesExpected: 100, BytesReplicated: 100},
+		{Name: "pool/b", State: report.FilesystemSteppingErrored, Error: "broken pipe", StepsCompleted: 1, StepsTotal: 2, BytesExpected: 30, BytesReplicated: 15},
+	}, r.Replication.Filesystems)
+	assert.Equal(t, int64(115), r.BytesReplicated())
+	assert.False(t, r.Successful())
+
+	p := PruningFromReport("sender", &pruner.Report{
+		State: "Done",
+		Completed: []pruner.FSReport{
+			{Filesystem: "pool/a", DestroyList: make([]pruner.SnapshotReport, 3)},
+			{Filesystem: "pool/b", DestroyList: make([]pruner.SnapshotReport, 2), LastError: "dataset is busy"},
+		},
+	})
+	assert.Equal(t, 3, p.Destroyed)
+	assert.Equal(t, []string{"pool/b"}, p.FailedFilesystems)
+
+	assert.Nil(t, ReplicationFromReport(nil))
+	assert.Nil(t, PruningFromReport("receiver", nil))
+}
@@ -10,19 +10,25 @@ import (
 
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/daemon/job/reset"
+	"github.com/zrepl/zrepl/daemon/job/verify"
 	"github.com/zrepl/zrepl/daemon/job/wakeup"
 	"github.com/zrepl/zrepl/daemon/logging"
 	"github.com/zrepl/zrepl/daemon/pruner"
 	"github.com/zrepl/zrepl/daemon/snapper"
+	"github.com/zrepl/zrepl/daemon/verifier"
 	"github.com/zrepl/zrepl/endpoint"
 	"github.com/zrepl/zrepl/replication"
 	"github.com/zrepl/zrepl/replication/driver"
 	"github.com/zrepl/zrepl/replication/logic"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
 	"github.com/zrepl/zrepl/replication/report"
 	"github.com/zrepl/zrepl/rpc"
 	"github.com/zrepl/zrepl/transport"
 	"github.com/zrepl/zrepl/transport/fromconfig"
+	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/util/tracing"
 	"github.com/zrepl/zrepl/zfs"
 )
 
@@ -33,12 +39,30 @@ type ActiveSide struct {
 
 	prunerFactory *pruner.PrunerFactory
 
+	windows *replicationWindows // nil if replication is not restricted to windows
+
+	verifyInterval config.PositiveDurationOrManual
+
+	sendOptions *pdu.SendOptions
+
 	promRepStateSecs    *prometheus.HistogramVec // labels: state
 	promPruneSecs       *prometheus.HistogramVec // labels: prune_side
 	promBytesReplicated *prometheus.CounterVec   // labels: filesystem
+	promRepRate         prometheus.Gauge
+	promRepETA          prometheus.Gauge
+	promRepFSRate       *prometheus.GaugeVec // labels: filesystem
+	promRepFSETA        *prometheus.GaugeVec // labels: filesystem
+	promVerifyDrift     *prometheus.GaugeVec // labels: kind
+	promVerifyErrors    prometheus.Gauge
+	promVerifyFinished  prometheus.Gauge
 
 	tasksMtx sync.Mutex
 	tasks    activeSideTasks
+
+	// verification runs independently of invocations, hence not part of tasks
+	verifyMtx     sync.Mutex
+	verifyRunning bool
+	verifyReport  *verifier.Report
 }
 
 //go:generate enumer -type=ActiveSideState
@@ -54,6 +78,9 @@ const (
 type activeSideTasks struct {
 	state ActiveSideState
 
+	// non-zero while an invocation is deferred until the next replication window opens
+	waitWindowUntil time.Time
+
 	// valid for state ActiveSideReplicating, ActiveSidePruneSender, ActiveSidePruneReceiver, ActiveSideDone
 	replicationReport driver.ReportFunc
 	replicationCancel context.CancelFunc
@@ -92,7 +119,10 @@ type modePush struct {
 	sender   *endpoint.Sender
 	receiver *rpc.Client
 	fsfilter endpoint.FSFilter
+	props    *filters.DatasetProperties // nil if dataset_properties is not configured
 	snapper  *snapper.PeriodicOrManual
+
+	sendOptions *pdu.SendOptions // the local sender only allows the job's own flags
 }
 
 func (m *modePush) ConnectEndpoints(loggers rpc.Loggers, connecter transport.Connecter) {
@@ -101,7 +131,7 @@ func (m *modePush) ConnectEndpoints(loggers rpc.Loggers, connecter transport.Con
 	if m.receiver != nil || m.sender != nil {
 		panic("inconsistent use of ConnectEndpoints and DisconnectEndpoints")
 	}
-	m.sender = endpoint.NewSender(m.fsfilter)
+	m.sender = endpoint.NewSender(m.fsfilter, m.sendOptions)
 	m.receiver = rpc.NewClient(connecter, loggers)
 }
 
@@ -139,13 +169,15 @@ func (m *modePush) ResetConnectBackoff() {
 
 func modePushFromConfig(g *config.Global, in *config.PushJob) (*modePush, error) {
 	m := &modePush{}
-	fsf, err := filters.DatasetMapFilterFromConfig(in.Filesystems)
+	fsf, props, err := filters.DatasetFilterFromConfig(in.Filesystems, in.DatasetSelection, in.DatasetProperties)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannnot build filesystem filter")
 	}
 	m.fsfilter = fsf
+	m.props = props
+	m.sendOptions = sendOptionsFromConfig(in.Send)
 
-	if m.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting); err != nil {
+	if m.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting, intervalOverride(props)); err != nil {
 		return nil, errors.Wrap(err, "cannot build snapper")
 	}
 
@@ -158,6 +190,9 @@ type modePull struct {
 	sender   *rpc.Client
 	rootFS   *zfs.DatasetPath
 	interval config.PositiveDurationOrManual
+
+	acceptedSendOptions *pdu.SendOptions
+	recvResumable       bool
 }
 
 func (m *modePull) ConnectEndpoints(loggers rpc.Loggers, connecter transport.Connecter) {
@@ -166,7 +201,7 @@ func (m *modePull) ConnectEndpoints(loggers rpc.Loggers, connecter transport.Con
 	if m.receiver != nil || m.sender != nil {
 		panic("inconsistent use of ConnectEndpoints and DisconnectEndpoints")
 	}
-	m.receiver = endpoint.NewReceiver(m.rootFS, false)
+	m.receiver = endpoint.NewReceiver(m.rootFS, false, m.acceptedSendOptions, m.recvResumable)
 	m.sender = rpc.NewClient(connecter, loggers)
 }
 
@@ -235,6 +270,9 @@ func modePullFromConfig(g *config.Global, in *config.PullJob) (m *modePull, err
 		return nil, errors.New("RootFS must not be empty") // duplicates error check of receiver
 	}
 
+	m.acceptedSendOptions = acceptedSendOptionsFromConfig(in.Recv)
+	m.recvResumable = in.Recv != nil && in.Recv.Resumable
+
 	return m, nil
 }
 
@@ -256,6 +294,34 @@ func activeSide(g *config.Global, in *config.ActiveJob, mode activeMode) (j *Act
 		Help:        "number of bytes replicated from sender to receiver per filesystem",
 		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
 	}, []string{"filesystem"})
+	j.promRepRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "replication",
+		Name:        "rate_bytes_per_second",
+		Help:        "moving average of the replication rate of the current attempt, 0 if not replicating",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	})
+	j.promRepETA = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "replication",
+		Name:        "eta_timestamp_seconds",
+		Help:        "unix time at which the current attempt is expected to complete, 0 if unknown or not replicating",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	})
+	j.promRepFSRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "replication",
+		Name:        "filesystem_rate_bytes_per_second",
+		Help:        "moving average of the replication rate per filesystem that is being replicated",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	}, []string{"filesystem"})
+	j.promRepFSETA = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "replication",
+		Name:        "filesystem_eta_timestamp_seconds",
+		Help:        "unix time at which replication of a filesystem is expected to complete, 0 if unknown",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	}, []string{"filesystem"})
 
 	j.connecter, err = fromconfig.ConnecterFromConfig(g, in.Connect)
 	if err != nil {
@@ -273,6 +339,42 @@ func activeSide(g *config.Global, in *config.ActiveJob, mode activeMode) (j *Act
 	if err != nil {
 		return nil, err
 	}
+	if push, ok := mode.(*modePush); ok && push.props != nil {
+		j.prunerFactory.SetSenderRulesOverride(keepRulesOverride{props: push.props})
+	}
+
+	j.windows, err = replicationWindowsFromConfig(in.Replication)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid replication windows")
+	}
+
+	j.sendOptions = sendOptionsFromConfig(in.Send)
+
+	j.verifyInterval = config.PositiveDurationOrManual{Manual: true}
+	if in.Verify != nil {
+		j.verifyInterval = in.Verify.Interval
+	}
+	j.promVerifyDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "verify",
+		Name:        "drift",
+		Help:        "number of inconsistencies between sender and receiver found by the last verification",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	}, []string{"kind"})
+	j.promVerifyErrors = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "verify",
+		Name:        "errors",
+		Help:        "number of filesystems that could not be verified during the last verification, -1 if verification failed entirely",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	})
+	j.promVerifyFinished = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "verify",
+		Name:        "last_finished_timestamp_seconds",
+		Help:        "unix time at which the last verification finished",
+		ConstLabels: prometheus.Labels{"zrepl_job": j.name},
+	})
 
 	return j, nil
 }
@@ -281,6 +383,13 @@ func (j *ActiveSide) RegisterMetrics(registerer prometheus.Registerer) {
 	registerer.MustRegister(j.promRepStateSecs)
 	registerer.MustRegister(j.promPruneSecs)
 	registerer.MustRegister(j.promBytesReplicated)
+	registerer.MustRegister(j.promRepRate)
+	registerer.MustRegister(j.promRepETA)
+	registerer.MustRegister(j.promRepFSRate)
+	registerer.MustRegister(j.promRepFSETA)
+	registerer.MustRegister(j.promVerifyDrift)
+	registerer.MustRegister(j.promVerifyErrors)
+	registerer.MustRegister(j.promVerifyFinished)
 }
 
 func (j *ActiveSide) Name() string { return j.name }
@@ -289,12 +398,17 @@ type ActiveSideStatus struct {
 	Replication                    *report.Report
 	PruningSender, PruningReceiver *pruner.Report
 	Snapshotting                   *snapper.Report
+	// non-zero if the next invocation is deferred until a replication window opens
+	WaitWindowUntil time.Time
+	// result of the last verification, nil if none completed yet
+	Verification        *verifier.Report
+	VerificationRunning bool
 }
 
 func (j *ActiveSide) Status() *Status {
 	tasks := j.updateTasks(nil)
 
-	s := &ActiveSideStatus{}
+	s := &ActiveSideStatus{WaitWindowUntil: tasks.waitWindowUntil}
 	t := j.mode.Type()
 	if tasks.replicationReport != nil {
 		s.Replication = tasks.replicationReport()
@@ -306,6 +420,9 @@ func (j *ActiveSide) Status() *Status {
 		s.PruningReceiver = tasks.prunerReceiver.Report()
 	}
 	s.Snapshotting = j.mode.SnapperReport()
+	j.verifyMtx.Lock()
+	s.Verification, s.VerificationRunning = j.verifyReport, j.verifyRunning
+	j.verifyMtx.Unlock()
 	return &Status{Type: t, JobSpecific: s}
 }
 
@@ -327,11 +444,46 @@ func (j *ActiveSide) Run(ctx context.Context) {
 	periodicDone := make(chan struct{})
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	if w, ok := j.connecter.(transport.ReloadWatcher); ok {
+		go w.WatchReload(ctx)
+	}
 	go j.mode.RunPeriodic(ctx, periodicDone)
 
+	var verifyTicker <-chan time.Time
+	if !j.verifyInterval.Manual {
+		t := time.NewTicker(j.verifyInterval.Interval)
+		defer t.Stop()
+		verifyTicker = t.C
+	}
+
 	invocationCount := 0
+	deferred := false           // an invocation became due while the job was paused
+	var windowTimer *time.Timer // non-nil while an invocation waits for the next replication window
+	stopWindowWait := func() {
+		if windowTimer == nil {
+			return
+		}
+		windowTimer.Stop()
+		windowTimer = nil
+		j.updateTasks(func(tasks *activeSideTasks) {
+			tasks.waitWindowUntil = time.Time{}
+		})
+	}
+	defer stopWindowWait()
 outer:
 	for {
+		var resumed <-chan struct{}
+		if deferred {
+			resumed = pause.Resumed(ctx)
+		}
+		var windowOpened <-chan time.Time
+		if windowTimer != nil {
+			windowOpened = windowTimer.C
+		}
+		var resetDeferred <-chan struct{}
+		if deferred || windowTimer != nil {
+			resetDeferred = reset.Wait(ctx)
+		}
 		log.Info("wait for wakeups")
 		select {
 		case <-ctx.Done():
@@ -341,21 +493,160 @@ outer:
 		case <-wakeup.Wait(ctx):
 			j.mode.ResetConnectBackoff()
 		case <-periodicDone:
+		case <-resumed:
+			log.Info("job resumed, starting deferred invocation")
+		case <-windowOpened:
+			log.Info("replication window opened, starting deferred invocation")
+		case <-resetDeferred:
+			log.Info("reset received, cancelling deferred invocation")
+			deferred = false
+			stopWindowWait()
+			continue
+
+		case <-verify.Wait(ctx):
+			j.mode.ResetConnectBackoff()
+			j.doVerify(ctx)
+			continue
+		case <-verifyTicker:
+			j.doVerify(ctx)
+			continue
+		}
+		// the checks below start a new wait for the window if necessary
+		stopWindowWait()
+		if pause.IsPaused(ctx) {
+			log.Info("job is paused, deferring invocation until it is resumed")
+			deferred = true
+			continue
+		}
+		deferred = false
+		if windowTimer = j.replicationWindowTimer(ctx); windowTimer != nil {
+			continue
 		}
 		invocationCount++
 		invLog := log.WithField("invocation", invocationCount)
+		startAt := time.Now()
 		j.do(WithLogger(ctx, invLog))
+		j.appendHistory(WithLogger(ctx, invLog), invocationCount, startAt)
 	}
 }
 
-func (j *ActiveSide) do(ctx context.Context) {
+// replicationWindowTimer returns nil if no replication windows are configured or one is open.
+// Otherwise, it returns a timer that fires when the next window opens,
+// and reports that time in the status until Run stops waiting.
+func (j *ActiveSide) replicationWindowTimer(ctx context.Context) *time.Timer {
+	if j.windows == nil {
+		return nil
+	}
+	open, opensAt := j.windows.At(time.Now())
+	if open {
+		return nil
+	}
+	GetLogger(ctx).WithField("until", opensAt).Info("outside of replication windows, deferring invocation")
+	j.updateTasks(func(tasks *activeSideTasks) {
+		tasks.waitWindowUntil = opensAt
+	})
+	return time.NewTimer(time.Until(opensAt))
+}
 
+// doVerify compares sender and receiver and publishes the result in status and metrics.
+// It is called from Run and thus never runs concurrently with an invocation.
+func (j *ActiveSide) doVerify(ctx context.Context) {
 	log := GetLogger(ctx)
 	ctx = logging.WithSubsystemLoggers(ctx, log)
 	loggers := rpc.GetLoggersOrPanic(ctx) // filled by WithSubsystemLoggers
 	j.mode.ConnectEndpoints(loggers, j.connecter)
 	defer j.mode.DisconnectEndpoints()
 
+	j.verifyMtx.Lock()
+	j.verifyRunning = true
+	j.verifyMtx.Unlock()
+
+	log.Info("start verification")
+	sender, receiver := j.mode.SenderReceiver()
+	r := verifier.Verify(ctx, sender, receiver)
+
+	j.verifyMtx.Lock()
+	j.verifyRunning = false
+	j.verifyReport = r
+	j.verifyMtx.Unlock()
+
+	j.promVerifyFinished.Set(float64(r.FinishAt.Unix()))
+	if r.Error != "" {
+		log.WithField("err", r.Error).Error("verification failed")
+		j.promVerifyErrors.Set(-1)
+		return
+	}
+	j.promVerifyErrors.Set(float64(r.ErrorCount()))
+	for kind, n := range r.DriftCount() {
+		j.promVerifyDrift.WithLabelValues(string(kind)).Set(float64(n))
+	}
+	if r.Consistent() {
+		log.Info("verification finished, sender and receiver are consistent")
+	} else {
+		log.WithField("drift", r.DriftCount()).WithField("errors", r.ErrorCount()).
+			Warn("verification finished, found inconsistencies")
+	}
+}
+
+var progressMetricsInterval = envconst.Duration("ZREPL_JOB_REPLICATION_PROGRESS_METRICS_INTERVAL", 5*time.Second)
+
+// updateProgressMetrics sets the rate and ETA gauges from the replication report until stop is closed,
+// then resets them.
+func (j *ActiveSide) updateProgressMetrics(rep driver.ReportFunc, stop <-chan struct{}) {
+	defer func() {
+		j.promRepRate.Set(0)
+		j.promRepETA.Set(0)
+		j.promRepFSRate.Reset()
+		j.promRepFSETA.Reset()
+	}()
+	etaSeconds := func(p *report.Progress) float64 {
+		if p.ETA.IsZero() {
+			return 0
+		}
+		return float64(p.ETA.Unix())
+	}
+	t := time.NewTicker(progressMetricsInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		r := rep()
+		if len(r.Attempts) == 0 {
+			continue
+		}
+		latest := r.Attempts[len(r.Attempts)-1]
+		if p := latest.Progress; p != nil {
+			j.promRepRate.Set(float64(p.BytesPerSecond))
+			j.promRepETA.Set(etaSeconds(p))
+		}
+		for _, fs := range latest.Filesystems {
+			if fs.Progress == nil || fs.State != report.FilesystemStepping {
+				j.promRepFSRate.DeleteLabelValues(fs.Info.Name)
+				j.promRepFSETA.DeleteLabelValues(fs.Info.Name)
+				continue
+			}
+			j.promRepFSRate.WithLabelValues(fs.Info.Name).Set(float64(fs.Progress.BytesPerSecond))
+			j.promRepFSETA.WithLabelValues(fs.Info.Name).Set(etaSeconds(fs.Progress))
+		}
+	}
+}
+
+func (j *ActiveSide) do(ctx context.Context) {
+
+	// root span of the trace that covers replication and pruning of this invocation
+	ctx, span := tracing.Start(ctx, "job.invocation", tracing.SpanKindInternal)
+	span.SetAttribute("job", j.name)
+	defer span.End()
+
+	log := tracing.WithLogFields(ctx, GetLogger(ctx))
+	ctx = logging.WithSubsystemLoggers(ctx, log)
+	loggers := rpc.GetLoggersOrPanic(ctx) // filled by WithSubsystemLoggers
+	j.mode.ConnectEndpoints(loggers, j.connecter)
+	defer j.mode.DisconnectEndpoints()
+
 	// allow cancellation of an invocation (this function)
 	ctx, cancelThisRun := context.WithCancel(ctx)
 	defer cancelThisRun()
@@ -377,19 +668,59 @@ func (j *ActiveSide) do(ctx context.Context) {
 		default:
 		}
 		ctx, repCancel := context.WithCancel(ctx)
+		pauseRep := make(chan struct{})
+		var pauseRepOnce sync.Once
+		pauseReplication := func() { pauseRepOnce.Do(func() { close(pauseRep) }) }
+		ctx = driver.WithPause(ctx, pauseRep)
+		go func() {
+			select {
+			case <-pause.Paused(ctx):
+				log.Info("job paused, pausing replication at next step boundary")
+				pauseReplication()
+			case <-ctx.Done():
+			}
+		}()
+		if j.windows != nil {
+			open, closesAt := j.windows.At(time.Now())
+			if !open {
+				closesAt = time.Now() // window closed in the meantime
+			}
+			closeTimer := time.AfterFunc(time.Until(closesAt), func() {
+				l := log.WithField("action", j.windows.onClose)
+				switch j.windows.onClose {
+				case windowClosePause:
+					l.Info("replication window closed, pausing replication at next step boundary")
+					pauseReplication()
+				case windowCloseCancel:
+					l.Info("replication window closed, cancelling replication")
+					repCancel()
+				}
+			})
+			defer closeTimer.Stop()
+		}
 		var repWait driver.WaitFunc
+		var repReport driver.ReportFunc
 		j.updateTasks(func(tasks *activeSideTasks) {
 			// reset it
 			*tasks = activeSideTasks{}
 			tasks.replicationCancel = repCancel
 			tasks.replicationReport, repWait = replication.Do(
-				ctx, logic.NewPlanner(j.promRepStateSecs, j.promBytesReplicated, sender, receiver),
+				ctx, logic.NewPlanner(j.promRepStateSecs, j.promBytesReplicated, sender, receiver, j.sendOptions),
 			)
+			repReport = tasks.replicationReport
 			tasks.state = ActiveSideReplicating
 		})
 		log.Info("start replication")
+		stopProgressMetrics := make(chan struct{})
+		progressMetricsDone := make(chan struct{})
+		go func() {
+			defer close(progressMetricsDone)
+			j.updateProgressMetrics(repReport, stopProgressMetrics)
+		}()
 		repWait(true) // wait blocking
 		repCancel()   // always cancel to free up context resources
+		close(stopProgressMetrics)
+		<-progressMetricsDone
 	}
 
 	{
@@ -398,6 +729,10 @@ func (j *ActiveSide) do(ctx context.Context) {
 			return
 		default:
 		}
+		if pause.IsPaused(ctx) {
+			log.Info("job is paused, skipping pruning")
+			return
+		}
 		ctx, senderCancel := context.WithCancel(ctx)
 		tasks := j.updateTasks(func(tasks *activeSideTasks) {
 			tasks.prunerSender = j.prunerFactory.BuildSenderPruner(ctx, sender, sender)
@@ -415,6 +750,10 @@ func (j *ActiveSide) do(ctx context.Context) {
 			return
 		default:
 		}
+		if pause.IsPaused(ctx) {
+			log.Info("job is paused, skipping receiver pruning")
+			return
+		}
 		ctx, receiverCancel := context.WithCancel(ctx)
 		tasks := j.updateTasks(func(tasks *activeSideTasks) {
 			tasks.prunerReceiver = j.prunerFactory.BuildReceiverPruner(ctx, receiver, sender)
@@ -0,0 +1,92 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zrepl/zrepl/config"
+)
+
+type windowCloseAction string
+
+const (
+	// let executing steps complete, but do not start new ones
+	windowClosePause windowCloseAction = "pause"
+	// cancel the replication immediately, relying on resumable send & recv
+	windowCloseCancel windowCloseAction = "cancel"
+)
+
+// replicationWindows restricts replication to a set of daily time-of-day ranges.
+type replicationWindows struct {
+	windows []config.ReplicationWindow
+	onClose windowCloseAction
+}
+
+// returns nil, nil if no windows are configured
+func replicationWindowsFromConfig(in *config.Replication) (*replicationWindows, error) {
+	if in == nil || len(in.Windows) == 0 {
+		return nil, nil
+	}
+	w := &replicationWindows{windows: in.Windows}
+	switch a := windowCloseAction(in.OnWindowClose); a {
+	case windowClosePause, windowCloseCancel:
+		w.onClose = a
+	default:
+		return nil, fmt.Errorf("invalid on_window_close action %q, must be one of %q or %q", in.OnWindowClose, windowClosePause, windowCloseCancel)
+	}
+	return w, nil
+}
+
+// windowInstance returns the absolute begin and end of w on the day of t shifted by dayOffset days.
+func windowInstance(w config.ReplicationWindow, t time.Time, dayOffset int) (begin, end time.Time) {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d+dayOffset, 0, 0, 0, 0, t.Location())
+	begin = midnight.Add(w.Start)
+	end = midnight.Add(w.End)
+	if !end.After(begin) {
+		end = time.Date(y, m, d+dayOffset+1, 0, 0, 0, 0, t.Location()).Add(w.End)
+	}
+	return begin, end
+}
+
+// containing returns the latest end of all window instances that contain t.
+func (ws *replicationWindows) containing(t time.Time) (end time.Time, ok bool) {
+	for _, w := range ws.windows {
+		// a window that wraps around midnight may have begun on the previous day
+		for _, off := range []int{-1, 0} {
+			b, e := windowInstance(w, t, off)
+			if !t.Before(b) && t.Before(e) && e.After(end) {
+				end, ok = e, true
+			}
+		}
+	}
+	return end, ok
+}
+
+// At returns whether t lies within one of the windows.
+// If it does, change is the time at which the windows close, taking
+// adjacent or overlapping windows into account.
+// Otherwise, change is the time at which the next window opens.
+func (ws *replicationWindows) At(t time.Time) (open bool, change time.Time) {
+	end, open := ws.containing(t)
+	if open {
+		// chain adjacent or overlapping windows, bounded in case windows cover the whole day
+		for i := 0; i < 2*len(ws.windows); i++ {
+			next, ok := ws.containing(end)
+			if !ok || !next.After(end) {
+				break
+			}
+			end = next
+		}
+		return true, end
+	}
+	for _, w := range ws.windows {
+		for _, off := range []int{0, 1} {
+			b, _ := windowInstance(w, t, off)
+			if b.After(t) && (change.IsZero() || b.Before(change)) {
+				change = b
+			}
+		}
+	}
+	return false, change
+}
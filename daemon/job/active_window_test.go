@@ -0,0 +1,67 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zrepl/zrepl/config"
+)
+
+func TestReplicationWindowsAt(t *testing.T) {
+	hm := func(h, m int) time.Duration { return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute }
+	day := func(d, h, m int) time.Time { return time.Date(2019, 11, d, h, m, 0, 0, time.UTC) }
+
+	type testCase struct {
+		name    string
+		windows []config.ReplicationWindow
+		at      time.Time
+		open    bool
+		change  time.Time
+	}
+	night := config.ReplicationWindow{Start: hm(22, 0), End: hm(6, 0)}
+	lunch := config.ReplicationWindow{Start: hm(12, 0), End: hm(13, 0)}
+	tcs := []testCase{
+		{"before night window", []config.ReplicationWindow{night}, day(10, 21, 59), false, day(10, 22, 0)},
+		{"at window start", []config.ReplicationWindow{night}, day(10, 22, 0), true, day(11, 6, 0)},
+		{"after midnight", []config.ReplicationWindow{night}, day(11, 3, 0), true, day(11, 6, 0)},
+		{"at window end", []config.ReplicationWindow{night}, day(11, 6, 0), false, day(11, 22, 0)},
+		{"next window is lunch", []config.ReplicationWindow{night, lunch}, day(11, 7, 0), false, day(11, 12, 0)},
+		{"in lunch", []config.ReplicationWindow{night, lunch}, day(11, 12, 30), true, day(11, 13, 0)},
+		{"next window is tomorrow", []config.ReplicationWindow{lunch}, day(11, 14, 0), false, day(12, 12, 0)},
+		{
+			"adjacent windows are chained",
+			[]config.ReplicationWindow{night, {Start: hm(6, 0), End: hm(8, 0)}},
+			day(11, 5, 0), true, day(11, 8, 0),
+		},
+		{
+			"overlapping windows are chained",
+			[]config.ReplicationWindow{lunch, {Start: hm(12, 30), End: hm(14, 0)}},
+			day(11, 12, 10), true, day(11, 14, 0),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ws := &replicationWindows{windows: tc.windows}
+			open, change := ws.At(tc.at)
+			assert.Equal(t, tc.open, open)
+			assert.Equal(t, tc.change, change)
+		})
+	}
+}
+
+func TestReplicationWindowsFromConfig(t *testing.T) {
+	ws, err := replicationWindowsFromConfig(&config.Replication{OnWindowClose: "pause"})
+	assert.NoError(t, err)
+	assert.Nil(t, ws)
+
+	windows := []config.ReplicationWindow{{Start: time.Hour, End: 2 * time.Hour}}
+	_, err = replicationWindowsFromConfig(&config.Replication{Windows: windows, OnWindowClose: "foo"})
+	assert.Error(t, err)
+
+	ws, err = replicationWindowsFromConfig(&config.Replication{Windows: windows, OnWindowClose: "cancel"})
+	assert.NoError(t, err)
+	assert.Equal(t, windowCloseCancel, ws.onClose)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
 )
 
 func JobsFromConfig(c *config.Config) ([]Job, error) {
@@ -23,6 +24,10 @@ func JobsFromConfig(c *config.Config) ([]Job, error) {
 		js[i] = j
 	}
 
+	if err := validateAllowOverlapWith(c); err != nil {
+		return nil, err
+	}
+
 	// receiving-side root filesystems must not overlap
 	{
 		rfss := make([]string, 0, len(js))
@@ -118,3 +123,24 @@ func validateReceivingSidesDoNotOverlap(receivingRootFSs []string) error {
 	}
 	return nil
 }
+
+// in may be nil, the result is nil then
+func sendOptionsFromConfig(in *config.SendOptions) *pdu.SendOptions {
+	if in == nil {
+		return nil
+	}
+	return &pdu.SendOptions{
+		LargeBlocks:  in.LargeBlocks,
+		EmbeddedData: in.EmbeddedData,
+		Compressed:   in.Compressed,
+		Holds:        in.Holds,
+	}
+}
+
+// in may be nil, the result is nil (accept no flags) then
+func acceptedSendOptionsFromConfig(in *config.RecvOptions) *pdu.SendOptions {
+	if in == nil {
+		return nil
+	}
+	return sendOptionsFromConfig(in.AcceptSend)
+}
@@ -0,0 +1,47 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/daemon/snapper"
+	"github.com/zrepl/zrepl/pruning"
+)
+
+// intervalOverride avoids passing a typed nil to the snapper
+func intervalOverride(props *filters.DatasetProperties) snapper.IntervalOverride {
+	if props == nil {
+		return nil
+	}
+	return props
+}
+
+// keepRulesOverride adapts the keep property of filters.DatasetProperties to pruner.KeepRulesOverride
+type keepRulesOverride struct {
+	props *filters.DatasetProperties
+	local bool // rule not_replicated is not supported by local pruners
+}
+
+var _ pruner.KeepRulesOverride = keepRulesOverride{}
+
+func (o keepRulesOverride) KeepRules(ctx context.Context, fs string) ([]pruning.KeepRule, bool, error) {
+	in, ok, reason, err := o.props.KeepRules(ctx, fs)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if o.local {
+		for _, r := range in {
+			if _, ok := r.Ret.(*config.PruneKeepNotReplicated); ok {
+				return nil, false, fmt.Errorf("%s on %s: single-site pruner cannot support `not_replicated` keep rule", reason, fs)
+			}
+		}
+	}
+	rules, err := pruning.RulesFromConfig(in)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s on %s: %s", reason, fs, err)
+	}
+	return rules, true, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/logger"
 	"github.com/zrepl/zrepl/zfs"
 )
@@ -54,6 +55,8 @@ const (
 type Status struct {
 	Type        Type
 	JobSpecific interface{}
+	// nil unless the job is paused, filled in by the daemon
+	Paused *pause.State
 }
 
 func (s *Status) MarshalJSON() ([]byte, error) {
@@ -69,6 +72,11 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 		"type":         typeJson,
 		string(s.Type): jobJSON,
 	}
+	if s.Paused != nil {
+		if m["paused"], err = json.Marshal(s.Paused); err != nil {
+			return nil, err
+		}
+	}
 	return json.Marshal(m)
 }
 
@@ -84,6 +92,12 @@ func (s *Status) UnmarshalJSON(in []byte) (err error) {
 	if err := json.Unmarshal(tJSON, &s.Type); err != nil {
 		return err
 	}
+	if pJSON, ok := m["paused"]; ok {
+		s.Paused = &pause.State{}
+		if err := json.Unmarshal(pJSON, s.Paused); err != nil {
+			return err
+		}
+	}
 	key := string(s.Type)
 	jobJSON, ok := m[key]
 	if !ok {
@@ -0,0 +1,58 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/zrepl/zrepl/daemon/history"
+)
+
+// appendHistory persists rec if the daemon provides a history store.
+func appendHistory(ctx context.Context, rec *history.Record) {
+	s := history.GetStore(ctx)
+	if s == nil {
+		return
+	}
+	if err := s.Append(rec); err != nil {
+		GetLogger(ctx).WithError(err).Error("cannot persist job history record")
+	}
+}
+
+func (j *ActiveSide) appendHistory(ctx context.Context, invocation int, startAt time.Time) {
+	tasks := j.updateTasks(nil)
+	if tasks.replicationReport == nil {
+		return
+	}
+	rep := tasks.replicationReport()
+	if rep.StartAt.Before(startAt) {
+		// invocation was cancelled before replication started, tasks are from the previous invocation
+		return
+	}
+	rec := &history.Record{
+		Job:         j.name,
+		Invocation:  invocation,
+		StartAt:     startAt,
+		FinishAt:    time.Now(),
+		Replication: history.ReplicationFromReport(rep),
+	}
+	if tasks.prunerSender != nil {
+		rec.Pruning = append(rec.Pruning, history.PruningFromReport("sender", tasks.prunerSender.Report()))
+	}
+	if tasks.prunerReceiver != nil {
+		rec.Pruning = append(rec.Pruning, history.PruningFromReport("receiver", tasks.prunerReceiver.Report()))
+	}
+	appendHistory(ctx, rec)
+}
+
+func (j *SnapJob) appendHistory(ctx context.Context, invocation int, startAt time.Time) {
+	rec := &history.Record{
+		Job:        j.name,
+		Invocation: invocation,
+		StartAt:    startAt,
+		FinishAt:   time.Now(),
+	}
+	if j.pruner != nil {
+		rec.Pruning = append(rec.Pruning, history.PruningFromReport("local", j.pruner.Report()))
+	}
+	appendHistory(ctx, rec)
+}
@@ -0,0 +1,176 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// Jobs that snapshot, send or prune the datasets matched by a filesystems filter on this machine
+// implement localDatasetFilterJob.
+type localDatasetFilterJob interface {
+	localDatasetFilter() (f zfs.DatasetFilter, ok bool)
+}
+
+func (j *ActiveSide) localDatasetFilter() (zfs.DatasetFilter, bool) {
+	push, ok := j.mode.(*modePush)
+	if !ok {
+		return nil, false
+	}
+	return push.fsfilter, true
+}
+
+func (j *PassiveSide) localDatasetFilter() (zfs.DatasetFilter, bool) {
+	source, ok := j.mode.(*modeSource)
+	if !ok {
+		return nil, false
+	}
+	return source.fsfilter, true
+}
+
+func (j *SnapJob) localDatasetFilter() (zfs.DatasetFilter, bool) {
+	return j.fsfilter, true
+}
+
+// Overlap is a set of datasets that two jobs operate on.
+type Overlap struct {
+	// sorted
+	Jobs [2]string
+	// sorted
+	Datasets []string
+}
+
+func (o Overlap) String() string {
+	return fmt.Sprintf("jobs %q and %q both operate on %d dataset(s), e.g. %s", o.Jobs[0], o.Jobs[1], len(o.Datasets), o.Datasets[0])
+}
+
+// localDatasets returns the datasets j operates on: those matched by its filesystems filter
+// and those in the subtree it owns.
+func localDatasets(j Job, datasets []*zfs.DatasetPath) (map[string]bool, error) {
+	m := make(map[string]bool)
+	if fj, ok := j.(localDatasetFilterJob); ok {
+		if f, ok := fj.localDatasetFilter(); ok {
+			for _, ds := range datasets {
+				pass, err := f.Filter(ds)
+				if err != nil {
+					return nil, fmt.Errorf("cannot apply filesystems filter of job %q to %s: %s", j.Name(), ds.ToString(), err)
+				}
+				if pass {
+					m[ds.ToString()] = true
+				}
+			}
+		}
+	}
+	if rfs, ok := j.OwnedDatasetSubtreeRoot(); ok {
+		for _, ds := range datasets {
+			if ds.HasPrefix(rfs) {
+				m[ds.ToString()] = true
+			}
+		}
+	}
+	return m, nil
+}
+
+// FindOverlaps returns the pairs of jobs that operate on the same datasets, given all datasets on this machine.
+// The result is sorted by job names.
+func FindOverlaps(jobs []Job, datasets []*zfs.DatasetPath) ([]Overlap, error) {
+	sets := make([]map[string]bool, len(jobs))
+	for i, j := range jobs {
+		var err error
+		if sets[i], err = localDatasets(j, datasets); err != nil {
+			return nil, err
+		}
+	}
+	var overlaps []Overlap
+	for i := range jobs {
+		for k := i + 1; k < len(jobs); k++ {
+			var shared []string
+			for ds := range sets[i] {
+				if sets[k][ds] {
+					shared = append(shared, ds)
+				}
+			}
+			if len(shared) == 0 {
+				continue
+			}
+			sort.Strings(shared)
+			names := [2]string{jobs[i].Name(), jobs[k].Name()}
+			if names[1] < names[0] {
+				names[0], names[1] = names[1], names[0]
+			}
+			overlaps = append(overlaps, Overlap{Jobs: names, Datasets: shared})
+		}
+	}
+	sort.Slice(overlaps, func(i, k int) bool {
+		if overlaps[i].Jobs[0] != overlaps[k].Jobs[0] {
+			return overlaps[i].Jobs[0] < overlaps[k].Jobs[0]
+		}
+		return overlaps[i].Jobs[1] < overlaps[k].Jobs[1]
+	})
+	return overlaps, nil
+}
+
+// DisallowedOverlaps returns the overlaps between jobs that are not allowed by allow_overlap_with of either job.
+// jobs must have been built from c.
+func DisallowedOverlaps(c *config.Config, jobs []Job, datasets []*zfs.DatasetPath) ([]Overlap, error) {
+	overlaps, err := FindOverlaps(jobs, datasets)
+	if err != nil {
+		return nil, err
+	}
+	var disallowed []Overlap
+	for _, o := range overlaps {
+		if !OverlapAllowed(c, o.Jobs[0], o.Jobs[1]) {
+			disallowed = append(disallowed, o)
+		}
+	}
+	return disallowed, nil
+}
+
+// OverlapAllowed returns true if job a or b allows overlaps with the other job.
+func OverlapAllowed(c *config.Config, a, b string) bool {
+	for _, je := range c.Jobs {
+		for _, other := range je.AllowOverlapWith() {
+			if (je.Name() == a && other == b) || (je.Name() == b && other == a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateOverlaps returns an error if DisallowedOverlaps finds any overlaps.
+func ValidateOverlaps(c *config.Config, jobs []Job, datasets []*zfs.DatasetPath) error {
+	overlaps, err := DisallowedOverlaps(c, jobs, datasets)
+	if err != nil {
+		return err
+	}
+	if len(overlaps) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(overlaps))
+	for i, o := range overlaps {
+		msgs[i] = o.String()
+	}
+	return fmt.Errorf("jobs with overlapping datasets are forbidden unless allowed with allow_overlap_with: %s", strings.Join(msgs, "; "))
+}
+
+func validateAllowOverlapWith(c *config.Config) error {
+	names := make(map[string]bool, len(c.Jobs))
+	for _, je := range c.Jobs {
+		names[je.Name()] = true
+	}
+	for _, je := range c.Jobs {
+		for _, other := range je.AllowOverlapWith() {
+			if other == je.Name() {
+				return fmt.Errorf("job %q: allow_overlap_with must not contain the job itself", je.Name())
+			}
+			if !names[other] {
+				return fmt.Errorf("job %q: allow_overlap_with refers to unknown job %q", je.Name(), other)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+func TestOverlaps(t *testing.T) {
+	snapJob := func(name, filter, extra string) string {
+		return `
+- name: ` + name + `
+  type: snap
+  filesystems: {` + filter + `}` + extra + `
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+`
+	}
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:` +
+		snapJob("a", `"pool/data<": true`, "") +
+		snapJob("b", `"pool/data/x": true`, "") +
+		snapJob("c", `"pool/other": true, "pool/data/y": true`, "\n  allow_overlap_with: [a]") +
+		snapJob("d", `"pool/sink/client": true`, "") + `
+- name: sink
+  type: sink
+  root_fs: pool/sink
+  serve:
+    type: local
+    listener_name: sink
+`))
+	require.NoError(t, err)
+	jobs, err := JobsFromConfig(conf)
+	require.NoError(t, err)
+
+	var datasets []*zfs.DatasetPath
+	for _, n := range []string{"pool", "pool/data", "pool/data/x", "pool/data/y", "pool/other", "pool/sink", "pool/sink/client"} {
+		p, err := zfs.NewDatasetPath(n)
+		require.NoError(t, err)
+		datasets = append(datasets, p)
+	}
+
+	overlaps, err := FindOverlaps(jobs, datasets)
+	require.NoError(t, err)
+	assert.Equal(t, []Overlap{
+		{Jobs: [2]string{"a", "b"}, Datasets: []string{"pool/data/x"}},
+		{Jobs: [2]string{"a", "c"}, Datasets: []string{"pool/data/y"}},
+		{Jobs: [2]string{"d", "sink"}, Datasets: []string{"pool/sink/client"}},
+	}, overlaps)
+
+	disallowed, err := DisallowedOverlaps(conf, jobs, datasets)
+	require.NoError(t, err)
+	assert.Equal(t, []Overlap{overlaps[0], overlaps[2]}, disallowed)
+	assert.Error(t, ValidateOverlaps(conf, jobs, datasets))
+}
+
+func TestValidateAllowOverlapWith(t *testing.T) {
+	parse := func(allow string) *config.Config {
+		conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: a
+  type: snap
+  filesystems: {"pool<": true}
+  allow_overlap_with: [` + allow + `]
+  snapshotting:
+    type: manual
+  pruning:
+    keep:
+    - type: last_n
+      count: 10
+`))
+		require.NoError(t, err)
+		return conf
+	}
+	_, err := JobsFromConfig(parse("a"))
+	assert.Error(t, err)
+	_, err = JobsFromConfig(parse("doesnotexist"))
+	assert.Error(t, err)
+	_, err = JobsFromConfig(parse(""))
+	assert.NoError(t, err)
+}
@@ -3,15 +3,19 @@ package job
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/daemon/logging"
+	"github.com/zrepl/zrepl/daemon/pruner"
 	"github.com/zrepl/zrepl/daemon/snapper"
 	"github.com/zrepl/zrepl/endpoint"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
 	"github.com/zrepl/zrepl/rpc"
 	"github.com/zrepl/zrepl/transport"
 	"github.com/zrepl/zrepl/transport/fromconfig"
@@ -28,21 +32,30 @@ type passiveMode interface {
 	Handler() rpc.Handler
 	RunPeriodic(ctx context.Context)
 	SnapperReport() *snapper.Report // may be nil
+	Pruning() *passivePruning       // may be nil
 	Type() Type
 }
 
 type modeSink struct {
-	rootDataset *zfs.DatasetPath
+	rootDataset         *zfs.DatasetPath
+	acceptedSendOptions *pdu.SendOptions
+	recvResumable       bool
+	pruning             *passivePruning
 }
 
 func (m *modeSink) Type() Type { return TypeSink }
 
 func (m *modeSink) Handler() rpc.Handler {
-	return endpoint.NewReceiver(m.rootDataset, true)
+	h := endpoint.NewReceiver(m.rootDataset, true, m.acceptedSendOptions, m.recvResumable)
+	if m.pruning == nil {
+		return h
+	}
+	return m.pruning.wrapHandler(h, sinkClientTargetFS)
 }
 
 func (m *modeSink) RunPeriodic(_ context.Context)  {}
 func (m *modeSink) SnapperReport() *snapper.Report { return nil }
+func (m *modeSink) Pruning() *passivePruning       { return m.pruning }
 
 func modeSinkFromConfig(g *config.Global, in *config.SinkJob) (m *modeSink, err error) {
 	m = &modeSink{}
@@ -53,34 +66,78 @@ func modeSinkFromConfig(g *config.Global, in *config.SinkJob) (m *modeSink, err
 	if m.rootDataset.Length() <= 0 {
 		return nil, errors.New("root dataset must not be empty") // duplicates error check of receiver
 	}
+	m.acceptedSendOptions = acceptedSendOptionsFromConfig(in.Recv)
+	m.recvResumable = in.Recv != nil && in.Recv.Resumable
+
+	if m.pruning, err = passivePruningFromConfig(in.Name, in.Pruning); err != nil {
+		return nil, errors.Wrap(err, "cannot build sink pruning")
+	}
+	if m.pruning != nil {
+		// The most recent snapshot of a filesystem is the base of the next incremental replication.
+		keep := append([]config.PruningEnum{{Ret: &config.PruneKeepLastN{Type: "last_n", Count: 1}}}, in.Pruning.Keep...)
+		f, err := pruner.NewLocalPrunerFactory(config.PruningLocal{Keep: keep}, m.pruning.promPruneSecs)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot build sink pruning rules")
+		}
+		// the pruner operates on the filesystems of all clients
+		target := endpoint.NewReceiver(m.rootDataset, false, nil, false)
+		m.pruning.build = func(ctx context.Context) *pruner.Pruner {
+			return f.BuildLocalPruner(ctx, target, alwaysUpToDateReplicationCursorHistory{target})
+		}
+	}
 	return m, nil
 }
 
 type modeSource struct {
-	fsfilter zfs.DatasetFilter
-	snapper  *snapper.PeriodicOrManual
+	fsfilter           zfs.DatasetFilter
+	snapper            *snapper.PeriodicOrManual
+	pruning            *passivePruning
+	allowedSendOptions *pdu.SendOptions
 }
 
 func modeSourceFromConfig(g *config.Global, in *config.SourceJob) (m *modeSource, err error) {
 	// FIXME exact dedup of modePush
 	m = &modeSource{}
-	fsf, err := filters.DatasetMapFilterFromConfig(in.Filesystems)
+	fsf, props, err := filters.DatasetFilterFromConfig(in.Filesystems, in.DatasetSelection, in.DatasetProperties)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannnot build filesystem filter")
 	}
 	m.fsfilter = fsf
+	m.allowedSendOptions = sendOptionsFromConfig(in.Send)
 
-	if m.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting); err != nil {
+	if m.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting, intervalOverride(props)); err != nil {
 		return nil, errors.Wrap(err, "cannot build snapper")
 	}
 
+	if m.pruning, err = passivePruningFromConfig(in.Name, in.Pruning); err != nil {
+		return nil, errors.Wrap(err, "cannot build source pruning")
+	}
+	if m.pruning != nil {
+		// the source's replication cursors determine which snapshots are replicated
+		f, err := pruner.NewPrunerFactory(config.PruningSenderReceiver{KeepSender: in.Pruning.Keep}, m.pruning.promPruneSecs)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot build source pruning rules")
+		}
+		if props != nil {
+			f.SetSenderRulesOverride(keepRulesOverride{props: props})
+		}
+		sender := endpoint.NewSender(m.fsfilter, nil)
+		m.pruning.build = func(ctx context.Context) *pruner.Pruner {
+			return f.BuildSenderPruner(ctx, sender, sender)
+		}
+	}
+
 	return m, nil
 }
 
 func (m *modeSource) Type() Type { return TypeSource }
 
 func (m *modeSource) Handler() rpc.Handler {
-	return endpoint.NewSender(m.fsfilter)
+	h := endpoint.NewSender(m.fsfilter, m.allowedSendOptions)
+	if m.pruning == nil {
+		return h
+	}
+	return m.pruning.wrapHandler(h, func(_ context.Context, fs string) string { return fs })
 }
 
 func (m *modeSource) RunPeriodic(ctx context.Context) {
@@ -91,6 +148,8 @@ func (m *modeSource) SnapperReport() *snapper.Report {
 	return m.snapper.Report()
 }
 
+func (m *modeSource) Pruning() *passivePruning { return m.pruning }
+
 func passiveSideFromConfig(g *config.Global, in *config.PassiveJob, mode passiveMode) (s *PassiveSide, err error) {
 
 	s = &PassiveSide{mode: mode, name: in.Name}
@@ -105,12 +164,16 @@ func (j *PassiveSide) Name() string { return j.name }
 
 type PassiveStatus struct {
 	Snapper *snapper.Report
+	Pruning *pruner.Report // nil if pruning is not configured or has not run yet
 }
 
 func (s *PassiveSide) Status() *Status {
 	st := &PassiveStatus{
 		Snapper: s.mode.SnapperReport(),
 	}
+	if p := s.mode.Pruning(); p != nil {
+		st.Pruning = p.report()
+	}
 	return &Status{Type: s.mode.Type(), JobSpecific: st}
 }
 
@@ -123,7 +186,11 @@ func (j *PassiveSide) OwnedDatasetSubtreeRoot() (rfs *zfs.DatasetPath, ok bool)
 	return sink.rootDataset.Copy(), true
 }
 
-func (*PassiveSide) RegisterMetrics(registerer prometheus.Registerer) {}
+func (j *PassiveSide) RegisterMetrics(registerer prometheus.Registerer) {
+	if p := j.mode.Pruning(); p != nil {
+		registerer.MustRegister(p.promPruneSecs)
+	}
+}
 
 func (j *PassiveSide) Run(ctx context.Context) {
 
@@ -134,12 +201,18 @@ func (j *PassiveSide) Run(ctx context.Context) {
 		ctx, cancel := context.WithCancel(ctx) // shadowing
 		defer cancel()
 		go j.mode.RunPeriodic(ctx)
+		if p := j.mode.Pruning(); p != nil {
+			go p.run(ctx)
+		}
 	}
 
 	handler := j.mode.Handler()
 	if handler == nil {
 		panic(fmt.Sprintf("implementation error: j.mode.Handler() returned nil: %#v", j))
 	}
+	if g := pause.GetGate(ctx); g != nil {
+		handler = &pausableHandler{handler, g, j.name}
+	}
 
 	ctxInterceptor := func(handlerCtx context.Context) context.Context {
 		return logging.WithSubsystemLoggers(handlerCtx, log)
@@ -156,3 +229,40 @@ func (j *PassiveSide) Run(ctx context.Context) {
 
 	server.Serve(ctx, listener)
 }
+
+// pausableHandler refuses requests that replicate or destroy snapshots while the job is paused.
+// Requests that only inspect the job's datasets are served as usual.
+type pausableHandler struct {
+	rpc.Handler
+	gate    *pause.Gate
+	jobName string
+}
+
+func (h *pausableHandler) checkPaused() error {
+	if st := h.gate.State(); st.Paused {
+		return fmt.Errorf("job %q is paused (since %s by %s)", h.jobName, st.Since.Format(time.RFC3339), st.By)
+	}
+	return nil
+}
+
+func (h *pausableHandler) Send(ctx context.Context, r *pdu.SendReq) (*pdu.SendRes, zfs.StreamCopier, error) {
+	if err := h.checkPaused(); err != nil {
+		return nil, nil, err
+	}
+	return h.Handler.Send(ctx, r)
+}
+
+func (h *pausableHandler) Receive(ctx context.Context, r *pdu.ReceiveReq, receive zfs.StreamCopier) (*pdu.ReceiveRes, error) {
+	if err := h.checkPaused(); err != nil {
+		receive.Close()
+		return nil, err
+	}
+	return h.Handler.Receive(ctx, r, receive)
+}
+
+func (h *pausableHandler) DestroySnapshots(ctx context.Context, r *pdu.DestroySnapshotsReq) (*pdu.DestroySnapshotsRes, error) {
+	if err := h.checkPaused(); err != nil {
+		return nil, err
+	}
+	return h.Handler.DestroySnapshots(ctx, r)
+}
@@ -0,0 +1,178 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/job/pause"
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/endpoint"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc"
+)
+
+type clientPruningMode string
+
+const (
+	// clients destroy snapshots as requested, in addition to the passive side's pruning
+	clientPruningAllow clientPruningMode = "allow"
+	// clients can only destroy snapshots that the passive side's keep rules destroy as well
+	clientPruningCap clientPruningMode = "cap"
+	// only the passive side's keep rules destroy snapshots, all client requests are refused
+	clientPruningOverride clientPruningMode = "override"
+)
+
+// passivePruning runs the retention policy of a sink or source job on its own datasets.
+type passivePruning struct {
+	jobName       string
+	interval      time.Duration
+	clientMode    clientPruningMode
+	promPruneSecs *prometheus.HistogramVec // labels: prune_side
+
+	// set by the passive mode, builds a pruner for the mode's datasets
+	build func(ctx context.Context) *pruner.Pruner
+
+	mtx    sync.Mutex
+	pruner *pruner.Pruner // most recent run, nil before the first run
+}
+
+// returns nil, nil if in is nil
+func passivePruningFromConfig(jobName string, in *config.PruningPassive) (*passivePruning, error) {
+	if in == nil {
+		return nil, nil
+	}
+	p := &passivePruning{
+		jobName:  jobName,
+		interval: in.Interval,
+	}
+	switch m := clientPruningMode(in.ClientPruning); m {
+	case clientPruningAllow, clientPruningCap, clientPruningOverride:
+		p.clientMode = m
+	default:
+		return nil, fmt.Errorf("invalid client_pruning mode %q, must be one of %q, %q or %q",
+			in.ClientPruning, clientPruningAllow, clientPruningCap, clientPruningOverride)
+	}
+	p.promPruneSecs = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   "zrepl",
+		Subsystem:   "pruning",
+		Name:        "time",
+		Help:        "seconds spent in pruner",
+		ConstLabels: prometheus.Labels{"zrepl_job": jobName},
+	}, []string{"prune_side"})
+	return p, nil
+}
+
+func (p *passivePruning) run(ctx context.Context) {
+	log := GetLogger(ctx)
+	for {
+		if pause.IsPaused(ctx) {
+			log.Info("job is paused, deferring pruning until it is resumed")
+			if !pause.Wait(ctx) {
+				return
+			}
+		}
+		log.Info("start pruning")
+		pr := p.build(ctx)
+		p.mtx.Lock()
+		p.pruner = pr
+		p.mtx.Unlock()
+		pr.Prune()
+		log.WithField("state", pr.State().String()).Info("finished pruning")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// may return nil
+func (p *passivePruning) report() *pruner.Report {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.pruner == nil {
+		return nil
+	}
+	return p.pruner.Report()
+}
+
+// wrapHandler restricts the DestroySnapshots requests of clients according to p.clientMode.
+// targetFS maps the filesystem of a client request to the filesystem as presented to the pruner.
+func (p *passivePruning) wrapHandler(h rpc.Handler, targetFS func(ctx context.Context, fs string) string) rpc.Handler {
+	if p.clientMode == clientPruningAllow {
+		return h
+	}
+	return &clientPruningHandler{h, p, targetFS}
+}
+
+// targetFS function for sink jobs, whose pruner sees the client identity as the first path component
+func sinkClientTargetFS(ctx context.Context, fs string) string {
+	clientIdentity, _ := ctx.Value(endpoint.ClientIdentityKey).(string)
+	return path.Join(clientIdentity, fs)
+}
+
+type clientPruningHandler struct {
+	rpc.Handler
+	pruning  *passivePruning
+	targetFS func(ctx context.Context, fs string) string
+}
+
+func (h *clientPruningHandler) DestroySnapshots(ctx context.Context, req *pdu.DestroySnapshotsReq) (*pdu.DestroySnapshotsRes, error) {
+	var allowed map[string]bool
+	if h.pruning.clientMode == clientPruningCap {
+		// The plan is computed for every request, but only for the requested filesystem:
+		// building the pruner is cheap, planning lists the filesystem's versions
+		// and, on a source, reads its replication cursor and dataset properties.
+		// This is about the cost of the client's own planning and happens once per filesystem
+		// and pruning run of the client. It is not cached because the plan of the passive side's
+		// last run may be outdated by snapshots or replications since.
+		destroyList, err := h.pruning.build(ctx).DestroyList(h.targetFS(ctx, req.GetFilesystem()))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot determine snapshots destroyed by the retention policy")
+		}
+		allowed = make(map[string]bool, len(destroyList))
+		for _, fsv := range destroyList {
+			allowed[fsv.GetName()] = true
+		}
+	}
+
+	refusal := fmt.Sprintf("refused by job %q: snapshot is kept by its retention policy (client_pruning: %s)",
+		h.pruning.jobName, h.pruning.clientMode)
+	var pass []*pdu.FilesystemVersion
+	var results []*pdu.DestroySnapshotRes
+	for _, fsv := range req.GetSnapshots() {
+		if allowed[fsv.GetName()] {
+			pass = append(pass, fsv)
+		} else {
+			results = append(results, &pdu.DestroySnapshotRes{Snapshot: fsv, Error: refusal})
+		}
+	}
+	if len(results) > 0 {
+		pruner.GetLogger(ctx).
+			WithField("fs", req.GetFilesystem()).
+			WithField("client_pruning", h.pruning.clientMode).
+			WithField("refused", len(results)).
+			WithField("allowed", len(pass)).
+			Info("refusing to destroy snapshots requested by client")
+	}
+
+	if len(pass) > 0 {
+		res, err := h.Handler.DestroySnapshots(ctx, &pdu.DestroySnapshotsReq{
+			Filesystem: req.GetFilesystem(),
+			Snapshots:  pass,
+		})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res.GetResults()...)
+	}
+	return &pdu.DestroySnapshotsRes{Results: results}, nil
+}
@@ -0,0 +1,248 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/pruner"
+	"github.com/zrepl/zrepl/endpoint"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/zfs"
+	"github.com/zrepl/zrepl/zfs/zfsfake"
+)
+
+func sinkModeWithPruning(t *testing.T, pruning string) (*modeSink, error) {
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: sink
+  type: sink
+  root_fs: pool/sink
+  serve:
+    type: local
+    listener_name: sink
+  pruning:` + pruning))
+	require.NoError(t, err)
+	return modeSinkFromConfig(conf.Global, conf.Jobs[0].Ret.(*config.SinkJob))
+}
+
+// versions lists the snapshots of pool/sink/client/pool/a, which a client "client" replicated from pool/a
+func setupSinkPruningTest(t *testing.T, snaps ...string) (b *zfsfake.Backend, versions func() []string, cleanup func()) {
+	b = zfsfake.New()
+	prev := zfs.SetBackend(b)
+	require.NoError(t, b.AddPool("pool"))
+	require.NoError(t, b.Create("pool/sink", nil))
+	for _, p := range []string{"pool/sink/client", "pool/sink/client/pool"} {
+		dp, err := zfs.NewDatasetPath(p)
+		require.NoError(t, err)
+		require.NoError(t, zfs.ZFSCreatePlaceholderFilesystem(dp))
+	}
+	require.NoError(t, b.Create("pool/sink/client/pool/a", nil))
+	fs, err := zfs.NewDatasetPath("pool/sink/client/pool/a")
+	require.NoError(t, err)
+	for _, s := range snaps {
+		require.NoError(t, zfs.ZFSSnapshot(fs, s, false))
+	}
+	versions = func() []string {
+		vs, err := zfs.ZFSListFilesystemVersions(fs, nil)
+		require.NoError(t, err)
+		names := make([]string, len(vs))
+		for i := range vs {
+			names[i] = vs[i].Name
+		}
+		return names
+	}
+	return b, versions, func() { zfs.SetBackend(prev) }
+}
+
+func TestSinkPruning(t *testing.T) {
+	_, versions, cleanup := setupSinkPruningTest(t, "manual_1", "zrepl_1", "zrepl_2", "zrepl_3")
+	defer cleanup()
+
+	m, err := sinkModeWithPruning(t, `
+    keep:
+    - type: regex
+      regex: "^manual_"
+`)
+	require.NoError(t, err)
+	require.Equal(t, clientPruningAllow, m.pruning.clientMode)
+	require.Equal(t, 10*time.Minute, m.pruning.interval)
+
+	p := m.pruning.build(context.Background())
+	p.Prune()
+	assert.Equal(t, pruner.Done, p.State())
+	assert.Equal(t, []string{"manual_1", "zrepl_3"}, versions(), "the most recent snapshot is always kept")
+}
+
+// clientDestroy sends the DestroySnapshots request of client "client" for snaps of fs to h
+// and returns the error of each snapshot, empty if it was destroyed
+func clientDestroy(t *testing.T, h interface {
+	DestroySnapshots(context.Context, *pdu.DestroySnapshotsReq) (*pdu.DestroySnapshotsRes, error)
+}, fs string, snaps ...string) map[string]string {
+	req := &pdu.DestroySnapshotsReq{Filesystem: fs}
+	for _, s := range snaps {
+		req.Snapshots = append(req.Snapshots, &pdu.FilesystemVersion{Type: pdu.FilesystemVersion_Snapshot, Name: s})
+	}
+	ctx := context.WithValue(context.Background(), endpoint.ClientIdentityKey, "client")
+	res, err := h.DestroySnapshots(ctx, req)
+	require.NoError(t, err)
+	errs := make(map[string]string)
+	for _, r := range res.GetResults() {
+		errs[r.GetSnapshot().GetName()] = r.GetError()
+	}
+	return errs
+}
+
+func TestSinkClientPruning(t *testing.T) {
+	keep := `
+    keep:
+    - type: regex
+      regex: "^manual_"
+    - type: last_n
+      count: 3
+`
+	t.Run("cap", func(t *testing.T) {
+		_, versions, cleanup := setupSinkPruningTest(t, "manual_1", "zrepl_1", "zrepl_2", "zrepl_3", "zrepl_4")
+		defer cleanup()
+		m, err := sinkModeWithPruning(t, keep+"    client_pruning: cap\n")
+		require.NoError(t, err)
+
+		errs := clientDestroy(t, m.Handler(), "pool/a", "manual_1", "zrepl_1", "zrepl_2")
+		require.Len(t, errs, 3)
+		assert.Empty(t, errs["zrepl_1"])
+		assert.Contains(t, errs["manual_1"], "client_pruning: cap")
+		assert.Contains(t, errs["zrepl_2"], "client_pruning: cap")
+		assert.Equal(t, []string{"manual_1", "zrepl_2", "zrepl_3", "zrepl_4"}, versions())
+	})
+
+	t.Run("override", func(t *testing.T) {
+		_, versions, cleanup := setupSinkPruningTest(t, "manual_1", "zrepl_1", "zrepl_2", "zrepl_3", "zrepl_4")
+		defer cleanup()
+		m, err := sinkModeWithPruning(t, keep+"    client_pruning: override\n")
+		require.NoError(t, err)
+
+		errs := clientDestroy(t, m.Handler(), "pool/a", "zrepl_1", "zrepl_2")
+		require.Len(t, errs, 2)
+		assert.Contains(t, errs["zrepl_1"], "client_pruning: override")
+		assert.Contains(t, errs["zrepl_2"], "client_pruning: override")
+		assert.Len(t, versions(), 5)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := sinkModeWithPruning(t, keep+"    client_pruning: never\n")
+		assert.Error(t, err)
+	})
+}
+
+func sourceModeWithPruning(t *testing.T, pruning string) (*modeSource, error) {
+	conf, err := config.ParseConfigBytes([]byte(`
+jobs:
+- name: source
+  type: source
+  serve:
+    type: local
+    listener_name: source
+  filesystems: {
+    "pool/src/a": true
+  }
+  dataset_properties: {}
+  snapshotting:
+    type: manual
+  pruning:` + pruning))
+	require.NoError(t, err)
+	return modeSourceFromConfig(conf.Global, conf.Jobs[0].Ret.(*config.SourceJob))
+}
+
+// versions lists the snapshots of pool/src/a, whose replication cursor is at cursor if not empty
+func setupSourcePruningTest(t *testing.T, cursor string, snaps ...string) (b *zfsfake.Backend, versions func() []string, cleanup func()) {
+	b = zfsfake.New()
+	prev := zfs.SetBackend(b)
+	require.NoError(t, b.AddPool("pool"))
+	require.NoError(t, b.Create("pool/src", nil))
+	require.NoError(t, b.Create("pool/src/a", nil))
+	fs, err := zfs.NewDatasetPath("pool/src/a")
+	require.NoError(t, err)
+	for _, s := range snaps {
+		require.NoError(t, zfs.ZFSSnapshot(fs, s, false))
+	}
+	if cursor != "" {
+		_, err := zfs.ZFSSetReplicationCursor(fs, cursor)
+		require.NoError(t, err)
+	}
+	versions = func() []string {
+		vs, err := zfs.ZFSListFilesystemVersions(fs, nil)
+		require.NoError(t, err)
+		var names []string
+		for i := range vs {
+			if vs[i].Type == zfs.Snapshot {
+				names = append(names, vs[i].Name)
+			}
+		}
+		return names
+	}
+	return b, versions, func() { zfs.SetBackend(prev) }
+}
+
+func TestSourcePruning(t *testing.T) {
+	keep := `
+    keep:
+    - type: not_replicated
+    - type: regex
+      regex: "^manual_"
+`
+
+	t.Run("replication_cursor", func(t *testing.T) {
+		_, versions, cleanup := setupSourcePruningTest(t, "zrepl_2", "manual_1", "zrepl_1", "zrepl_2", "zrepl_3", "zrepl_4")
+		defer cleanup()
+		m, err := sourceModeWithPruning(t, keep)
+		require.NoError(t, err)
+		require.Equal(t, clientPruningAllow, m.pruning.clientMode)
+
+		p := m.pruning.build(context.Background())
+		p.Prune()
+		assert.Equal(t, pruner.Done, p.State())
+		assert.Equal(t, []string{"manual_1", "zrepl_2", "zrepl_3", "zrepl_4"}, versions(), "the snapshot at the cursor and the snapshots after it are kept")
+	})
+
+	t.Run("never_replicated", func(t *testing.T) {
+		_, versions, cleanup := setupSourcePruningTest(t, "", "manual_1", "zrepl_1", "zrepl_2")
+		defer cleanup()
+		m, err := sourceModeWithPruning(t, keep)
+		require.NoError(t, err)
+
+		m.pruning.build(context.Background()).Prune()
+		assert.Equal(t, []string{"manual_1", "zrepl_1", "zrepl_2"}, versions(), "pruning starts after the first replication")
+	})
+
+	t.Run("keep_rules_override", func(t *testing.T) {
+		b, versions, cleanup := setupSourcePruningTest(t, "zrepl_2", "manual_1", "zrepl_1", "zrepl_2", "zrepl_3", "zrepl_4")
+		defer cleanup()
+		require.NoError(t, b.Set("pool/src/a", map[string]string{"zrepl:keep": "[{type: last_n, count: 1}]"}))
+		m, err := sourceModeWithPruning(t, keep)
+		require.NoError(t, err)
+
+		p := m.pruning.build(context.Background())
+		p.Prune()
+		assert.Equal(t, pruner.Done, p.State())
+		assert.Equal(t, []string{"zrepl_4"}, versions(), "zrepl:keep replaces the keep rules of the job")
+	})
+
+	t.Run("cap", func(t *testing.T) {
+		_, versions, cleanup := setupSourcePruningTest(t, "zrepl_2", "manual_1", "zrepl_1", "zrepl_2", "zrepl_3", "zrepl_4")
+		defer cleanup()
+		m, err := sourceModeWithPruning(t, keep+"    client_pruning: cap\n")
+		require.NoError(t, err)
+
+		errs := clientDestroy(t, m.Handler(), "pool/src/a", "manual_1", "zrepl_1", "zrepl_2", "zrepl_3")
+		require.Len(t, errs, 4)
+		assert.Empty(t, errs["zrepl_1"])
+		assert.Contains(t, errs["manual_1"], "client_pruning: cap")
+		assert.Contains(t, errs["zrepl_2"], "client_pruning: cap", "the snapshot at the replication cursor is kept")
+		assert.Contains(t, errs["zrepl_3"], "client_pruning: cap")
+		assert.Equal(t, []string{"manual_1", "zrepl_2", "zrepl_3", "zrepl_4"}, versions())
+	})
+}
@@ -0,0 +1,250 @@
+// Package pause implements pausing and resuming jobs.
+//
+// Jobs check the Gate of their context at safe points, e.g. before
+// starting an invocation, and wait until the job is resumed.
+// The pause state of a job is persisted in a Store so that it survives daemon restarts.
+package pause
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ByConfig is State.By for jobs paused by `paused: true` in the job config.
+const ByConfig = "config"
+
+type State struct {
+	Paused bool
+	// who or what paused the job, e.g. ByConfig
+	By    string    `json:",omitempty"`
+	Since time.Time `json:",omitempty"`
+}
+
+var (
+	AlreadyPaused = errors.New("already paused")
+	NotPaused     = errors.New("not paused")
+)
+
+// Gate is the pause state of a single job.
+type Gate struct {
+	mtx   sync.Mutex
+	state State
+	// closed while paused, replaced by an open channel on resume
+	paused chan struct{}
+	// closed while not paused, replaced by an open channel on pause
+	resumed chan struct{}
+	persist func(State) error // may be nil
+}
+
+func NewGate(initial State, persist func(State) error) *Gate {
+	g := &Gate{
+		state:   initial,
+		paused:  make(chan struct{}),
+		resumed: make(chan struct{}),
+		persist: persist,
+	}
+	if initial.Paused {
+		close(g.paused)
+	} else {
+		close(g.resumed)
+	}
+	return g
+}
+
+func (g *Gate) State() State {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.state
+}
+
+// Pause pauses the job and persists the new state.
+func (g *Gate) Pause(by string) error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if g.state.Paused {
+		return AlreadyPaused
+	}
+	next := State{Paused: true, By: by, Since: time.Now()}
+	if err := g.save(next); err != nil {
+		return err
+	}
+	g.state = next
+	close(g.paused)
+	g.resumed = make(chan struct{})
+	return nil
+}
+
+// Resume resumes the job and persists the new state.
+// It returns the state before the job was resumed.
+// Jobs paused ByConfig cannot be resumed.
+func (g *Gate) Resume() (was State, err error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if !g.state.Paused {
+		return g.state, NotPaused
+	}
+	if g.state.By == ByConfig {
+		return g.state, errors.New("job is paused in the config, remove `paused: true` from the job config to resume it")
+	}
+	next := State{}
+	if err := g.save(next); err != nil {
+		return g.state, err
+	}
+	was, g.state = g.state, next
+	close(g.resumed)
+	g.paused = make(chan struct{})
+	return was, nil
+}
+
+func (g *Gate) save(s State) error {
+	if g.persist == nil {
+		return nil
+	}
+	return errors.Wrap(g.persist(s), "cannot persist pause state")
+}
+
+type contextKey int
+
+const contextKeyGate contextKey = iota
+
+func Context(ctx context.Context, g *Gate) context.Context {
+	return context.WithValue(ctx, contextKeyGate, g)
+}
+
+// Returns nil if ctx has no gate, i.e. the job cannot be paused.
+func GetGate(ctx context.Context) *Gate {
+	g, _ := ctx.Value(contextKeyGate).(*Gate)
+	return g
+}
+
+// IsPaused returns whether the job of ctx is paused.
+func IsPaused(ctx context.Context) bool {
+	g := GetGate(ctx)
+	return g != nil && g.State().Paused
+}
+
+// Paused returns a channel that is closed while the job of ctx is paused.
+// The channel is never closed if ctx has no gate.
+func Paused(ctx context.Context) <-chan struct{} {
+	g := GetGate(ctx)
+	if g == nil {
+		return make(chan struct{})
+	}
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.paused
+}
+
+// Resumed returns a channel that is closed while the job of ctx is not paused.
+func Resumed(ctx context.Context) <-chan struct{} {
+	g := GetGate(ctx)
+	if g == nil {
+		c := make(chan struct{})
+		close(c)
+		return c
+	}
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.resumed
+}
+
+// Wait blocks while the job of ctx is paused.
+// Returns false if ctx is done before the job is resumed.
+func Wait(ctx context.Context) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-Resumed(ctx):
+			if !IsPaused(ctx) { // paused again in the meantime
+				return true
+			}
+		}
+	}
+}
+
+// Store persists the pause state of jobs in a directory, one file per paused job.
+type Store struct {
+	dir string // empty if the pause state is only kept by the gates
+}
+
+// NewStore returns a store that keeps its files in dir, which is created when the first job is paused.
+// If dir is empty, nothing is persisted and paused jobs are resumed when the daemon restarts.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Persistent returns true if the pause state survives a daemon restart.
+func (s *Store) Persistent() bool { return s.dir != "" }
+
+// the file of job, whose name may contain path separators
+func (s *Store) path(job string) string {
+	return filepath.Join(s.dir, url.PathEscape(job)+".json")
+}
+
+func (s *Store) Load(job string) (State, error) {
+	var st State
+	if !s.Persistent() {
+		return st, nil
+	}
+	buf, err := ioutil.ReadFile(s.path(job))
+	if os.IsNotExist(err) {
+		return st, nil
+	} else if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(buf, &st); err != nil {
+		return st, fmt.Errorf("cannot decode pause state of job %q: %s", job, err)
+	}
+	return st, nil
+}
+
+func (s *Store) Save(job string, st State) error {
+	if !s.Persistent() {
+		return nil
+	}
+	if !st.Paused {
+		err := os.Remove(s.path(job))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	buf, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, "cannot create pause state directory")
+	}
+	// write and rename to not leave a truncated file behind on a crash
+	tmp := s.path(job) + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(job))
+}
+
+// Gate returns the gate of job, initialized from the job config or the persisted state.
+// A job paused in the config is always paused, regardless of the persisted state.
+// If the persisted state cannot be loaded, the returned gate is not paused and err describes why.
+func (s *Store) Gate(job string, pausedInConfig bool, now time.Time) (*Gate, error) {
+	persist := func(st State) error { return s.Save(job, st) }
+	if pausedInConfig {
+		return NewGate(State{Paused: true, By: ByConfig, Since: now}, persist), nil
+	}
+	st, err := s.Load(job)
+	if err != nil {
+		st = State{}
+	}
+	return NewGate(st, persist), err
+}
@@ -0,0 +1,127 @@
+package pause
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateWait(t *testing.T) {
+	g := NewGate(State{}, nil)
+	ctx := Context(context.Background(), g)
+	assert.False(t, IsPaused(ctx))
+	assert.True(t, Wait(ctx))
+
+	require.NoError(t, g.Pause("test"))
+	assert.Equal(t, AlreadyPaused, g.Pause("test"))
+	assert.True(t, IsPaused(ctx))
+	select {
+	case <-Paused(ctx):
+	default:
+		t.Fatal("Paused channel must be closed while paused")
+	}
+
+	waitDone := make(chan bool)
+	go func() { waitDone <- Wait(ctx) }()
+	select {
+	case <-waitDone:
+		t.Fatal("Wait must block while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	was, err := g.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, "test", was.By)
+	assert.True(t, <-waitDone)
+	_, err = g.Resume()
+	assert.Equal(t, NotPaused, err)
+
+	require.NoError(t, g.Pause("test"))
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, Wait(cancelCtx))
+}
+
+func TestNoGate(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, IsPaused(ctx))
+	assert.True(t, Wait(ctx))
+	select {
+	case <-Paused(ctx):
+		t.Fatal("jobs without a gate are never paused")
+	default:
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-pause-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// the directory is only created when a job is paused
+	s := NewStore(filepath.Join(dir, "pause"))
+	assert.True(t, s.Persistent())
+	now := time.Now()
+
+	g, err := s.Gate("job/with/slashes", false, now)
+	require.NoError(t, err)
+	assert.False(t, g.State().Paused)
+	_, err = os.Stat(filepath.Join(dir, "pause"))
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, g.Pause("someone"))
+
+	// restart
+	g, err = s.Gate("job/with/slashes", false, now)
+	require.NoError(t, err)
+	st := g.State()
+	assert.True(t, st.Paused)
+	assert.Equal(t, "someone", st.By)
+	assert.False(t, st.Since.IsZero())
+
+	_, err = g.Resume()
+	require.NoError(t, err)
+	g, err = s.Gate("job/with/slashes", false, now)
+	require.NoError(t, err)
+	assert.False(t, g.State().Paused)
+
+	// paused in config, cannot be resumed
+	g, err = s.Gate("configured", true, now)
+	require.NoError(t, err)
+	assert.Equal(t, State{Paused: true, By: ByConfig, Since: now}, g.State())
+	_, err = g.Resume()
+	assert.Error(t, err)
+	assert.True(t, g.State().Paused)
+}
+
+func TestStoreNotPersistent(t *testing.T) {
+	s := NewStore("")
+	assert.False(t, s.Persistent())
+	g, err := s.Gate("job", false, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, g.Pause("someone"))
+	assert.True(t, g.State().Paused)
+
+	// restart
+	g, err = s.Gate("job", false, time.Now())
+	require.NoError(t, err)
+	assert.False(t, g.State().Paused)
+}
+
+func TestStoreDirCannotBeCreated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-pause-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+
+	g, err := NewStore(filepath.Join(file, "pause")).Gate("job", false, time.Now())
+	assert.Error(t, err)
+	require.NotNil(t, g, "jobs start unpaused if the state cannot be loaded")
+	assert.False(t, g.State().Paused)
+	assert.Error(t, g.Pause("someone"))
+	assert.False(t, g.State().Paused)
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/daemon/job/wakeup"
 	"github.com/zrepl/zrepl/daemon/logging"
 	"github.com/zrepl/zrepl/daemon/pruner"
@@ -37,13 +39,13 @@ func (j *SnapJob) Type() Type { return TypeSnap }
 
 func snapJobFromConfig(g *config.Global, in *config.SnapJob) (j *SnapJob, err error) {
 	j = &SnapJob{}
-	fsf, err := filters.DatasetMapFilterFromConfig(in.Filesystems)
+	fsf, props, err := filters.DatasetFilterFromConfig(in.Filesystems, in.DatasetSelection, in.DatasetProperties)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot build filesystem filter")
 	}
 	j.fsfilter = fsf
 
-	if j.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting); err != nil {
+	if j.snapper, err = snapper.FromConfig(g, fsf, in.Snapshotting, intervalOverride(props)); err != nil {
 		return nil, errors.Wrap(err, "cannot build snapper")
 	}
 	j.name = in.Name
@@ -58,6 +60,9 @@ func snapJobFromConfig(g *config.Global, in *config.SnapJob) (j *SnapJob, err er
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot build snapjob pruning rules")
 	}
+	if props != nil {
+		j.prunerFactory.SetRulesOverride(keepRulesOverride{props: props, local: true})
+	}
 	return j, nil
 }
 
@@ -96,8 +101,13 @@ func (j *SnapJob) Run(ctx context.Context) {
 	go j.snapper.Run(ctx, periodicDone)
 
 	invocationCount := 0
+	deferred := false // an invocation became due while the job was paused
 outer:
 	for {
+		var resumed <-chan struct{}
+		if deferred {
+			resumed = pause.Resumed(ctx)
+		}
 		log.Info("wait for wakeups")
 		select {
 		case <-ctx.Done():
@@ -106,10 +116,20 @@ outer:
 
 		case <-wakeup.Wait(ctx):
 		case <-periodicDone:
+		case <-resumed:
+			log.Info("job resumed, starting deferred invocation")
+		}
+		if pause.IsPaused(ctx) {
+			log.Info("job is paused, deferring invocation until it is resumed")
+			deferred = true
+			continue
 		}
+		deferred = false
 		invocationCount++
 		invLog := log.WithField("invocation", invocationCount)
+		startAt := time.Now()
 		j.doPrune(WithLogger(ctx, invLog))
+		j.appendHistory(WithLogger(ctx, invLog), invocationCount, startAt)
 	}
 }
 
@@ -162,7 +182,7 @@ func (h alwaysUpToDateReplicationCursorHistory) ListFilesystems(ctx context.Cont
 func (j *SnapJob) doPrune(ctx context.Context) {
 	log := GetLogger(ctx)
 	ctx = logging.WithSubsystemLoggers(ctx, log)
-	sender := endpoint.NewSender(j.fsfilter)
+	sender := endpoint.NewSender(j.fsfilter, nil)
 	j.pruner = j.prunerFactory.BuildLocalPruner(ctx, sender, alwaysUpToDateReplicationCursorHistory{sender})
 	log.Info("start pruning")
 	j.pruner.Prune()
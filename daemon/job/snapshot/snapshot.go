@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+)
+
+type contextKey int
+
+const contextKeySnapshot contextKey = iota
+
+// Request asks a job to take snapshots outside of its regular snapshotting schedule.
+type Request struct {
+	// empty means all filesystems that the job snapshots
+	Filesystems []string
+	// wake up the job after the snapshots have been taken
+	Wakeup bool
+
+	reply chan error
+}
+
+// Reply must be called exactly once by the receiver of the request.
+// A nil error means that the request was accepted.
+func (r Request) Reply(err error) {
+	r.reply <- err
+}
+
+func Wait(ctx context.Context) <-chan Request {
+	wc, ok := ctx.Value(contextKeySnapshot).(chan Request)
+	if !ok {
+		wc = make(chan Request)
+	}
+	return wc
+}
+
+type Func func(Request) error
+
+var NotAccepting = errors.New("job is not accepting snapshot requests (snapshot round in progress?)")
+
+// Context returns a context and a Func to request snapshots.
+// Like wakeups, requests are not queued: Func fails if the job is not waiting for requests.
+func Context(ctx context.Context) (context.Context, Func) {
+	wc := make(chan Request)
+	sf := func(r Request) error {
+		r.reply = make(chan error, 1)
+		select {
+		case wc <- r:
+			return <-r.reply
+		default:
+			return NotAccepting
+		}
+	}
+	return context.WithValue(ctx, contextKeySnapshot, wc), sf
+}
@@ -0,0 +1,37 @@
+package verify
+
+import (
+	"context"
+	"errors"
+)
+
+type contextKey int
+
+const contextKeyVerify contextKey = iota
+
+func Wait(ctx context.Context) <-chan struct{} {
+	wc, ok := ctx.Value(contextKeyVerify).(chan struct{})
+	if !ok {
+		wc = make(chan struct{})
+	}
+	return wc
+}
+
+type Func func() error
+
+var AlreadyRequested = errors.New("verification already requested")
+
+// Context returns a context and a Func to request verification.
+// Unlike wakeups, a request is queued if the job is busy.
+func Context(ctx context.Context) (context.Context, Func) {
+	wc := make(chan struct{}, 1)
+	vf := func() error {
+		select {
+		case wc <- struct{}{}:
+			return nil
+		default:
+			return AlreadyRequested
+		}
+	}
+	return context.WithValue(ctx, contextKeyVerify, wc), vf
+}
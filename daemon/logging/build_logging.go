@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"log/syslog"
 	"os"
 
@@ -11,9 +12,11 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/filters"
 	"github.com/zrepl/zrepl/daemon/hooks"
 	"github.com/zrepl/zrepl/daemon/pruner"
 	"github.com/zrepl/zrepl/daemon/snapper"
+	"github.com/zrepl/zrepl/daemon/verifier"
 	"github.com/zrepl/zrepl/endpoint"
 	"github.com/zrepl/zrepl/logger"
 	"github.com/zrepl/zrepl/replication/driver"
@@ -22,47 +25,90 @@ import (
 	"github.com/zrepl/zrepl/rpc/transportmux"
 	"github.com/zrepl/zrepl/tlsconf"
 	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/util/systemd"
+	"github.com/zrepl/zrepl/util/tracing"
 )
 
-func OutletsFromConfig(in config.LoggingOutletEnumList) (*logger.Outlets, error) {
+// OutletsFromConfig builds the outlets of the daemon's logger.
+// Their levels can be changed at runtime through the returned LevelControl.
+// If no memory outlet is configured, one with level info is added for `zrepl logs`.
+func OutletsFromConfig(in config.LoggingOutletEnumList) (*logger.Outlets, *LevelControl, error) {
 
 	outlets := logger.NewOutlets()
+	ctl := newLevelControl()
 
 	if len(in) == 0 {
 		// Default config
 		out := WriterOutlet{&HumanFormatter{}, os.Stdout}
-		outlets.Add(out, logger.Warn)
-		return outlets, nil
+		outlets.Add(ctl.wrap("stdout", out, logger.Warn), logger.Debug)
 	}
 
-	var syslogOutlets, stdoutOutlets int
+	var syslogOutlets, stdoutOutlets, memoryOutlets int
+	typeCount := make(map[string]int)
 	for lei, le := range in {
 
 		outlet, minLevel, err := ParseOutlet(le)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot parse outlet #%d", lei)
+			return nil, nil, errors.Wrapf(err, "cannot parse outlet #%d", lei)
 		}
 		var _ logger.Outlet = WriterOutlet{}
 		var _ logger.Outlet = &SyslogOutlet{}
-		switch outlet.(type) {
+		var typ string
+		switch o := outlet.(type) {
 		case *SyslogOutlet:
 			syslogOutlets++
+			typ = "syslog"
 		case WriterOutlet:
 			stdoutOutlets++
+			typ = "stdout"
+		case *TCPOutlet:
+			typ = "tcp"
+		case *JournaldOutlet:
+			typ = "journald"
+		case *FileOutlet:
+			typ = "file"
+		case *SyslogRFC5424Outlet:
+			typ = "syslog_rfc5424"
+		case *GELFOutlet:
+			typ = "gelf"
+		case *MemoryOutlet:
+			memoryOutlets++
+			typ = "memory"
+			ctl.memory = o
+		default:
+			typ = fmt.Sprintf("%T", o)
 		}
 
-		outlets.Add(outlet, minLevel)
+		// outlets of the same type are named tcp, tcp#2, tcp#3, ...
+		typeCount[typ]++
+		name := typ
+		if typeCount[typ] > 1 {
+			name = fmt.Sprintf("%s#%d", typ, typeCount[typ])
+		}
+		outlets.Add(ctl.wrap(name, outlet, minLevel), logger.Debug)
 
 	}
 
 	if syslogOutlets > 1 {
-		return nil, errors.Errorf("can only define one 'syslog' outlet")
+		return nil, nil, errors.Errorf("can only define one 'syslog' outlet")
 	}
 	if stdoutOutlets > 1 {
-		return nil, errors.Errorf("can only define one 'stdout' outlet")
+		return nil, nil, errors.Errorf("can only define one 'stdout' outlet")
+	}
+	if memoryOutlets > 1 {
+		return nil, nil, errors.Errorf("can only define one 'memory' outlet")
+	}
+
+	if ctl.memory == nil {
+		m, err := NewMemoryOutlet(MemoryOutletDefaultSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctl.memory = m
+		outlets.Add(ctl.wrap("memory", m, logger.Info), logger.Debug)
 	}
 
-	return outlets, nil
+	return outlets, ctl, nil
 
 }
 
@@ -72,6 +118,7 @@ const (
 	SubsysReplication  Subsystem = "repl"
 	SubsyEndpoint      Subsystem = "endpoint"
 	SubsysPruning      Subsystem = "pruning"
+	SubsysVerify       Subsystem = "verify"
 	SubsysSnapshot     Subsystem = "snapshot"
 	SubsysHooks        Subsystem = "hook"
 	SubsysTransport    Subsystem = "transport"
@@ -79,17 +126,28 @@ const (
 	SubsysRPC          Subsystem = "rpc"
 	SubsysRPCControl   Subsystem = "rpc.ctrl"
 	SubsysRPCData      Subsystem = "rpc.data"
+	SubsysTracing      Subsystem = "tracing"
+	SubsysFilters      Subsystem = "filters"
 )
 
+var Subsystems = []Subsystem{
+	SubsysReplication, SubsyEndpoint, SubsysPruning, SubsysVerify, SubsysSnapshot, SubsysHooks,
+	SubsysTransport, SubsysTransportMux, SubsysRPC, SubsysRPCControl, SubsysRPCData, SubsysTracing,
+	SubsysFilters,
+}
+
 func WithSubsystemLoggers(ctx context.Context, log logger.Logger) context.Context {
+	log = tracing.WithLogFields(ctx, log)
 	ctx = logic.WithLogger(ctx, log.WithField(SubsysField, SubsysReplication))
 	ctx = driver.WithLogger(ctx, log.WithField(SubsysField, SubsysReplication))
 	ctx = endpoint.WithLogger(ctx, log.WithField(SubsysField, SubsyEndpoint))
 	ctx = pruner.WithLogger(ctx, log.WithField(SubsysField, SubsysPruning))
+	ctx = verifier.WithLogger(ctx, log.WithField(SubsysField, SubsysVerify))
 	ctx = snapper.WithLogger(ctx, log.WithField(SubsysField, SubsysSnapshot))
 	ctx = hooks.WithLogger(ctx, log.WithField(SubsysField, SubsysHooks))
 	ctx = transport.WithLogger(ctx, log.WithField(SubsysField, SubsysTransport))
 	ctx = transportmux.WithLogger(ctx, log.WithField(SubsysField, SubsysTransportMux))
+	ctx = filters.WithLogger(ctx, log.WithField(SubsysField, SubsysFilters))
 	ctx = rpc.WithLoggers(ctx,
 		rpc.Loggers{
 			General: log.WithField(SubsysField, SubsysRPC),
@@ -165,6 +223,44 @@ func ParseOutlet(in config.LoggingOutletEnum) (o logger.Outlet, level logger.Lev
 			break
 		}
 		o, err = parseSyslogOutlet(v, f)
+	case *config.MemoryLoggingOutlet:
+		level, err = logger.ParseLevel(v.Level)
+		if err != nil {
+			err = errors.Wrap(err, "cannot parse 'level' field")
+			break
+		}
+		size := MemoryOutletDefaultSize
+		if v.Size != nil {
+			size = int(*v.Size)
+		}
+		o, err = NewMemoryOutlet(size)
+	case *config.JournaldLoggingOutlet:
+		level, err = logger.ParseLevel(v.Level)
+		if err != nil {
+			err = errors.Wrap(err, "cannot parse 'level' field")
+			break
+		}
+		o, err = NewJournaldOutlet(systemd.JournalSocket, v.SyslogIdentifier)
+	case *config.SyslogRFC5424LoggingOutlet:
+		level, err = logger.ParseLevel(v.Level)
+		if err != nil {
+			err = errors.Wrap(err, "cannot parse 'level' field")
+			break
+		}
+		o, err = parseSyslogRFC5424Outlet(v)
+	case *config.GELFLoggingOutlet:
+		level, err = logger.ParseLevel(v.Level)
+		if err != nil {
+			err = errors.Wrap(err, "cannot parse 'level' field")
+			break
+		}
+		o, err = parseGELFOutlet(v)
+	case *config.FileLoggingOutlet:
+		level, f, err = parseCommon(v.LoggingOutletCommon)
+		if err != nil {
+			break
+		}
+		o, err = parseFileOutlet(v, f)
 	default:
 		panic(v)
 	}
@@ -188,35 +284,55 @@ func parseStdoutOutlet(in *config.StdoutLoggingOutlet, formatter EntryFormatter)
 	}, nil
 }
 
-func parseTCPOutlet(in *config.TCPLoggingOutlet, formatter EntryFormatter) (out *TCPOutlet, err error) {
-	var tlsConfig *tls.Config
-	if in.TLS != nil {
-		tlsConfig, err = func(m *config.TCPLoggingOutletTLS, host string) (*tls.Config, error) {
-			clientCert, err := tls.LoadX509KeyPair(m.Cert, m.Key)
-			if err != nil {
-				return nil, errors.Wrap(err, "cannot load client cert")
-			}
+func parseFileOutlet(in *config.FileLoggingOutlet, formatter EntryFormatter) (*FileOutlet, error) {
+	var rotateSize int64
+	if in.RotateSize != nil {
+		rotateSize = int64(*in.RotateSize)
+	}
+	if in.Keep < 0 {
+		return nil, errors.Errorf("'keep' must not be negative")
+	}
+	formatter.SetMetadataFlags(MetadataAll & ^MetadataColor)
+	return NewFileOutlet(formatter, in.Path, rotateSize, in.RotateInterval, in.Keep, in.Compress)
+}
 
-			var rootCAs *x509.CertPool
-			if m.CA == "" {
-				if rootCAs, err = x509.SystemCertPool(); err != nil {
-					return nil, errors.Wrap(err, "cannot open system cert pool")
-				}
-			} else {
-				rootCAs, err = tlsconf.ParseCAFile(m.CA)
-				if err != nil {
-					return nil, errors.Wrap(err, "cannot parse CA cert")
-				}
+func parseOutletTLS(m *config.TCPLoggingOutletTLS, host string) (*tls.Config, error) {
+	if m == nil {
+		return nil, nil
+	}
+	tlsConfig, err := func(m *config.TCPLoggingOutletTLS, host string) (*tls.Config, error) {
+		clientCert, err := tls.LoadX509KeyPair(m.Cert, m.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot load client cert")
+		}
+
+		var rootCAs *x509.CertPool
+		if m.CA == "" {
+			if rootCAs, err = x509.SystemCertPool(); err != nil {
+				return nil, errors.Wrap(err, "cannot open system cert pool")
 			}
-			if rootCAs == nil {
-				panic("invariant violated")
+		} else {
+			rootCAs, err = tlsconf.ParseCAFile(m.CA)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot parse CA cert")
 			}
-
-			return tlsconf.ClientAuthClient(host, rootCAs, clientCert)
-		}(in.TLS, in.Address)
-		if err != nil {
-			return nil, errors.New("cannot not parse TLS config in field 'tls'")
 		}
+		if rootCAs == nil {
+			panic("invariant violated")
+		}
+
+		return tlsconf.ClientAuthClient(host, rootCAs, clientCert)
+	}(m, host)
+	if err != nil {
+		return nil, errors.New("cannot not parse TLS config in field 'tls'")
+	}
+	return tlsConfig, nil
+}
+
+func parseTCPOutlet(in *config.TCPLoggingOutlet, formatter EntryFormatter) (out *TCPOutlet, err error) {
+	tlsConfig, err := parseOutletTLS(in.TLS, in.Address)
+	if err != nil {
+		return nil, err
 	}
 
 	formatter.SetMetadataFlags(MetadataAll)
@@ -224,6 +340,62 @@ func parseTCPOutlet(in *config.TCPLoggingOutlet, formatter EntryFormatter) (out
 
 }
 
+func parseRemoteNet(network string, tls *config.TCPLoggingOutletTLS) error {
+	switch network {
+	case "udp", "udp4", "udp6":
+		if tls != nil {
+			return errors.Errorf("'tls' requires 'net' tcp")
+		}
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return errors.Errorf("'net' must be udp or tcp, got %q", network)
+	}
+	return nil
+}
+
+func parseSyslogRFC5424Outlet(in *config.SyslogRFC5424LoggingOutlet) (*SyslogRFC5424Outlet, error) {
+	if err := parseRemoteNet(in.Net, in.TLS); err != nil {
+		return nil, err
+	}
+	tlsConfig, err := parseOutletTLS(in.TLS, in.Address)
+	if err != nil {
+		return nil, err
+	}
+	header := RFC5424Header{
+		Facility:         syslog.Priority(*in.Facility),
+		Hostname:         in.Hostname,
+		AppName:          in.AppName,
+		StructuredDataID: in.StructuredDataID,
+	}
+	if header.Hostname == "" {
+		header.Hostname, _ = os.Hostname() // the header field is '-' if empty
+	}
+	if err := header.Validate(); err != nil {
+		return nil, err
+	}
+	return NewSyslogRFC5424Outlet(header, in.Net, in.Address, tlsConfig, in.RetryInterval), nil
+}
+
+func parseGELFOutlet(in *config.GELFLoggingOutlet) (*GELFOutlet, error) {
+	if err := parseRemoteNet(in.Net, in.TLS); err != nil {
+		return nil, err
+	}
+	tlsConfig, err := parseOutletTLS(in.TLS, in.Address)
+	if err != nil {
+		return nil, err
+	}
+	if in.ChunkSize <= gelfChunkHeaderLen {
+		return nil, errors.Errorf("'chunk_size' must be greater than %d", gelfChunkHeaderLen)
+	}
+	host := in.Host
+	if host == "" {
+		if host, err = os.Hostname(); err != nil {
+			return nil, errors.Wrap(err, "cannot get hostname for field 'host'")
+		}
+	}
+	return NewGELFOutlet(host, in.ChunkSize, in.Net, in.Address, tlsConfig, in.RetryInterval), nil
+}
+
 func parseSyslogOutlet(in *config.SyslogLoggingOutlet, formatter EntryFormatter) (out *SyslogOutlet, err error) {
 	out = &SyslogOutlet{}
 	out.Formatter = formatter
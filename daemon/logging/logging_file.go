@@ -0,0 +1,235 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+// FileOutlet writes log entries to a file and rotates it by size and / or time.
+//
+// Rotated files are named PATH.TIMESTAMP, or PATH.TIMESTAMP.gz if compression is enabled.
+// Compression and removal of rotated files happen in the background.
+//
+// Reopen closes the file so that the next entry is written to a newly opened file at path,
+// which allows for rotation by external tools like logrotate(8).
+type FileOutlet struct {
+	formatter EntryFormatter
+	path      string
+	// 0 disables rotation by size
+	rotateSize int64
+	// 0 disables rotation by time
+	rotateInterval time.Duration
+	// number of rotated files to keep, 0 keeps all
+	keep     int
+	compress bool
+
+	mtx  sync.Mutex
+	file *os.File // nil if closed
+	size int64
+	// time of the last write to file, used for rotation by time
+	lastWrite time.Time
+
+	cleanupMtx sync.Mutex
+	cleanupWg  sync.WaitGroup
+}
+
+const fileOutletRotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+func NewFileOutlet(formatter EntryFormatter, path string, rotateSize int64, rotateInterval time.Duration, keep int, compress bool) (*FileOutlet, error) {
+	if path == "" || !filepath.IsAbs(path) {
+		return nil, errors.Errorf("path must be absolute, got %q", path)
+	}
+	// the file itself is opened on the first write, so that checking the config does not create it
+	if fi, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, errors.Wrap(err, "cannot stat log file directory")
+	} else if !fi.IsDir() {
+		return nil, errors.Errorf("%q is not a directory", filepath.Dir(path))
+	}
+	return &FileOutlet{
+		formatter:      formatter,
+		path:           path,
+		rotateSize:     rotateSize,
+		rotateInterval: rotateInterval,
+		keep:           keep,
+		compress:       compress,
+	}, nil
+}
+
+// callers must hold o.mtx
+func (o *FileOutlet) open() error {
+	f, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return errors.Wrap(err, "cannot open log file")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "cannot stat log file")
+	}
+	o.file = f
+	o.size = fi.Size()
+	o.lastWrite = fi.ModTime()
+	return nil
+}
+
+func (o *FileOutlet) WriteEntry(entry logger.Entry) error {
+	formatted, err := o.formatter.Format(&entry)
+	if err != nil {
+		return err
+	}
+	formatted = append(formatted, '\n')
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.file == nil {
+		if err := o.open(); err != nil {
+			return err
+		}
+	}
+	if o.needsRotation(entry.Time, int64(len(formatted))) {
+		if err := o.rotate(entry.Time); err != nil {
+			return err
+		}
+	}
+	n, err := o.file.Write(formatted)
+	o.size += int64(n)
+	o.lastWrite = entry.Time
+	return err
+}
+
+// callers must hold o.mtx
+func (o *FileOutlet) needsRotation(now time.Time, n int64) bool {
+	if o.size == 0 {
+		return false
+	}
+	if o.rotateSize > 0 && o.size+n > o.rotateSize {
+		return true
+	}
+	// intervals are aligned to the zero time, i.e., a rotate_interval of 24h rotates at midnight UTC
+	if o.rotateInterval > 0 && !now.Truncate(o.rotateInterval).Equal(o.lastWrite.Truncate(o.rotateInterval)) {
+		return true
+	}
+	return false
+}
+
+// callers must hold o.mtx
+func (o *FileOutlet) rotate(now time.Time) error {
+	if err := o.file.Close(); err != nil {
+		return errors.Wrap(err, "cannot close log file for rotation")
+	}
+	o.file = nil
+	rotated := o.path + "." + now.Format(fileOutletRotatedTimeFormat)
+	if err := os.Rename(o.path, rotated); os.IsNotExist(err) {
+		// moved by an external tool that has not told us to reopen it yet
+		return o.open()
+	} else if err != nil {
+		return errors.Wrap(err, "cannot rename log file for rotation")
+	}
+	if err := o.open(); err != nil {
+		return err
+	}
+	o.cleanupWg.Add(1)
+	go func() {
+		defer o.cleanupWg.Done()
+		o.cleanup()
+	}()
+	return nil
+}
+
+// Reopen closes the log file, the next entry is written to a newly opened file.
+func (o *FileOutlet) Reopen() error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	o.file = nil
+	return errors.Wrap(err, "cannot close log file")
+}
+
+// rotatedFiles returns the rotated files, oldest first
+func (o *FileOutlet) rotatedFiles() ([]string, error) {
+	matches, err := filepath.Glob(o.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, m := range matches {
+		ts := strings.TrimSuffix(strings.TrimPrefix(m, o.path+"."), ".gz")
+		if _, err := time.Parse(fileOutletRotatedTimeFormat, ts); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// cleanup compresses rotated files if enabled and removes the oldest if there are more than o.keep.
+// Errors are ignored because there is no outlet to report them to, the next cleanup retries.
+func (o *FileOutlet) cleanup() {
+	o.cleanupMtx.Lock()
+	defer o.cleanupMtx.Unlock()
+
+	rotated, err := o.rotatedFiles()
+	if err != nil {
+		return
+	}
+	if o.keep > 0 && len(rotated) > o.keep {
+		for _, r := range rotated[:len(rotated)-o.keep] {
+			os.Remove(r)
+		}
+		rotated = rotated[len(rotated)-o.keep:]
+	}
+	if !o.compress {
+		return
+	}
+	for _, r := range rotated {
+		if !strings.HasSuffix(r, ".gz") {
+			_ = compressFile(r)
+		}
+	}
+}
+
+func compressFile(path string) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+func TestFileOutlet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-logging-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "zrepl.log")
+
+	f := &LogfmtFormatter{}
+	f.SetMetadataFlags(MetadataNone)
+	o, err := NewFileOutlet(f, path, 40, time.Hour, 2, true)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "file must be opened on first write")
+
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	write := func(msg string) {
+		require.NoError(t, o.WriteEntry(logger.Entry{Level: logger.Info, Message: msg, Time: now}))
+		now = now.Add(time.Second)
+	}
+	read := func(path string) string {
+		c, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(c)
+	}
+
+	write("first")  // msg=first\n is 10 bytes
+	write("second") // 11 bytes
+	assert.Equal(t, "msg=first\nmsg=second\n", read(path))
+	write("third, rotates by size")
+	assert.Equal(t, "msg=\"third, rotates by size\"\n", read(path))
+	now = now.Add(time.Hour)
+	write("fourth, rotates by time")
+	write("fifth")
+	write("sixth, rotates by size")
+	o.cleanupWg.Wait()
+
+	rotated, err := o.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 2)
+	for _, r := range rotated {
+		assert.True(t, strings.HasSuffix(r, ".gz"), r)
+	}
+	gzf, err := os.Open(rotated[1])
+	require.NoError(t, err)
+	defer gzf.Close()
+	zr, err := gzip.NewReader(gzf)
+	require.NoError(t, err)
+	c, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "msg=\"fourth, rotates by time\"\nmsg=fifth\n", string(c))
+
+	// external rotation
+	require.NoError(t, os.Rename(path, path+".1"))
+	write("seventh") // rotation finds no file to rename
+	assert.Equal(t, "msg=\"sixth, rotates by size\"\n", read(path+".1"))
+	require.NoError(t, os.Rename(path, path+".2"))
+	write("eighth")
+	require.NoError(t, o.Reopen())
+	write("ninth")
+	assert.Equal(t, "msg=seventh\nmsg=eighth\n", read(path+".2"))
+	assert.Equal(t, "msg=ninth\n", read(path))
+}
@@ -0,0 +1,262 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+// LevelRule overrides the minimum level of the entries written to outlets at runtime.
+// Empty fields match all outlets, jobs or subsystems.
+type LevelRule struct {
+	Outlet    string `json:",omitempty"`
+	Job       string `json:",omitempty"`
+	Subsystem string `json:",omitempty"`
+	Level     logger.Level
+}
+
+type levelRuleKey struct {
+	outlet, job, subsystem string
+}
+
+func (r LevelRule) key() levelRuleKey { return levelRuleKey{r.Outlet, r.Job, r.Subsystem} }
+
+// If several rules match an entry, the rule with the highest specificity wins,
+// i.e., rules for a job win over rules for a subsystem, which win over rules for an outlet.
+func (r LevelRule) specificity() (s int) {
+	if r.Job != "" {
+		s += 4
+	}
+	if r.Subsystem != "" {
+		s += 2
+	}
+	if r.Outlet != "" {
+		s++
+	}
+	return s
+}
+
+// LevelControl changes the levels of the outlets built by OutletsFromConfig at runtime.
+type LevelControl struct {
+	mtx     sync.Mutex
+	outlets []*controlledOutlet
+	rules   map[levelRuleKey]LevelRule
+	memory  *MemoryOutlet // nil if there is no memory outlet
+}
+
+// OutletLevel is the configured level of an outlet.
+type OutletLevel struct {
+	Outlet string
+	Level  logger.Level
+}
+
+// LevelState is the state of LevelControl, the rules are ordered by decreasing specificity.
+type LevelState struct {
+	Configured []OutletLevel
+	Rules      []LevelRule
+}
+
+func newLevelControl() *LevelControl {
+	return &LevelControl{rules: make(map[levelRuleKey]LevelRule)}
+}
+
+// wrap wraps outlet in an outlet that filters entries according to the rules of c.
+// The result must be added to logger.Outlets with level logger.Debug.
+func (c *LevelControl) wrap(name string, outlet logger.Outlet, configured logger.Level) *controlledOutlet {
+	o := &controlledOutlet{name: name, outlet: outlet, configured: configured}
+	o.state.Store(outletLevels{min: configured, base: configured})
+	c.outlets = append(c.outlets, o)
+	return o
+}
+
+func (c *LevelControl) Memory() *MemoryOutlet { return c.memory }
+
+// Reopen reopens the files of all file outlets, e.g., after they were rotated by logrotate(8).
+func (c *LevelControl) Reopen() error {
+	var errs []string
+	for _, o := range c.outlets {
+		if f, ok := o.outlet.(*FileOutlet); ok {
+			if err := f.Reopen(); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", o.name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot reopen outlets: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *LevelControl) State() LevelState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	var s LevelState
+	for _, o := range c.outlets {
+		s.Configured = append(s.Configured, OutletLevel{o.name, o.configured})
+	}
+	s.Rules = c.sortedRules()
+	return s
+}
+
+// callers must hold c.mtx
+func (c *LevelControl) sortedRules() []LevelRule {
+	rules := make([]LevelRule, 0, len(c.rules))
+	for _, r := range c.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		si, sj := rules[i].specificity(), rules[j].specificity()
+		if si != sj {
+			return si > sj
+		}
+		return fmt.Sprint(rules[i].key()) < fmt.Sprint(rules[j].key())
+	})
+	return rules
+}
+
+func (c *LevelControl) checkOutlet(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, o := range c.outlets {
+		if o.name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("outlet %q does not exist", name)
+}
+
+// Set adds r, replacing a rule with the same outlet, job and subsystem.
+func (c *LevelControl) Set(r LevelRule) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if err := c.checkOutlet(r.Outlet); err != nil {
+		return err
+	}
+	if r.Subsystem != "" {
+		known := false
+		for _, s := range Subsystems {
+			known = known || string(s) == r.Subsystem
+		}
+		if !known {
+			return fmt.Errorf("subsystem %q does not exist", r.Subsystem)
+		}
+	}
+	if r.Level < logger.Debug || r.Level > logger.Error {
+		return fmt.Errorf("invalid level %d", r.Level)
+	}
+	c.rules[r.key()] = r
+	c.update()
+	return nil
+}
+
+// Reset removes the rule with the outlet, job and subsystem of r, or all rules if all is true.
+func (c *LevelControl) Reset(r LevelRule, all bool) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if all {
+		c.rules = make(map[levelRuleKey]LevelRule)
+	} else {
+		if _, ok := c.rules[r.key()]; !ok {
+			return fmt.Errorf("no rule for outlet=%q job=%q subsystem=%q", r.Outlet, r.Job, r.Subsystem)
+		}
+		delete(c.rules, r.key())
+	}
+	c.update()
+	return nil
+}
+
+// callers must hold c.mtx
+func (c *LevelControl) update() {
+	rules := c.sortedRules()
+	for _, o := range c.outlets {
+		s := outletLevels{base: o.baseLevel(rules)}
+		for _, r := range rules {
+			if r.Outlet != "" && r.Outlet != o.name {
+				continue
+			}
+			if r.Job != "" || r.Subsystem != "" {
+				s.rules = append(s.rules, r)
+			}
+		}
+		s.min = s.base
+		for _, r := range s.rules {
+			if r.Level < s.min {
+				s.min = r.Level
+			}
+		}
+		o.state.Store(s)
+	}
+}
+
+type controlledOutlet struct {
+	name       string
+	outlet     logger.Outlet
+	configured logger.Level
+	state      atomic.Value // outletLevels
+}
+
+// immutable once stored in controlledOutlet.state
+type outletLevels struct {
+	min   logger.Level // no entry below min is accepted
+	base  logger.Level // level for entries that match no rule
+	rules []LevelRule  // rules with job or subsystem, ordered by decreasing specificity
+}
+
+var _ logger.EntryFilter = (*controlledOutlet)(nil)
+
+// baseLevel returns the level of the most specific rule without job and subsystem
+// that applies to o, or the configured level.
+func (o *controlledOutlet) baseLevel(sorted []LevelRule) logger.Level {
+	for _, r := range sorted {
+		if r.Job == "" && r.Subsystem == "" && (r.Outlet == "" || r.Outlet == o.name) {
+			return r.Level
+		}
+	}
+	return o.configured
+}
+
+func fieldString(fields logger.Fields, name string) string {
+	switch v := fields[name].(type) {
+	case string:
+		return v
+	case Subsystem:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+func (o *controlledOutlet) Accept(e logger.Entry) bool {
+	s := o.state.Load().(outletLevels)
+	if e.Level < s.min {
+		return false
+	}
+	if len(s.rules) == 0 {
+		return e.Level >= s.base
+	}
+	job, subsys := fieldString(e.Fields, JobField), fieldString(e.Fields, SubsysField)
+	for _, r := range s.rules {
+		if (r.Job == "" || r.Job == job) && (r.Subsystem == "" || r.Subsystem == subsys) {
+			return e.Level >= r.Level
+		}
+	}
+	return e.Level >= s.base
+}
+
+// The logger only calls WriteEntry for accepted entries, except for its internal errors,
+// which are always accepted because their level is logger.Error.
+func (o *controlledOutlet) WriteEntry(e logger.Entry) error {
+	if !o.Accept(e) {
+		return nil
+	}
+	return o.outlet.WriteEntry(e)
+}
+
+func (o *controlledOutlet) String() string { return o.name }
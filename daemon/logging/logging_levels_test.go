@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/circlog"
+)
+
+type nullOutlet struct{}
+
+func (nullOutlet) WriteEntry(logger.Entry) error { return nil }
+
+func TestLevelControl(t *testing.T) {
+	c := newLevelControl()
+	stdout := c.wrap("stdout", nullOutlet{}, logger.Info)
+	tcp := c.wrap("tcp", nullOutlet{}, logger.Warn)
+
+	entry := func(l logger.Level, job string, subsys Subsystem) logger.Entry {
+		f := logger.Fields{}
+		if job != "" {
+			f[JobField] = job
+		}
+		if subsys != "" {
+			f[SubsysField] = subsys
+		}
+		return logger.Entry{Level: l, Fields: f}
+	}
+
+	assert.False(t, stdout.Accept(entry(logger.Debug, "prod", SubsysPruning)))
+	assert.True(t, stdout.Accept(entry(logger.Info, "prod", SubsysPruning)))
+	assert.False(t, tcp.Accept(entry(logger.Info, "prod", SubsysPruning)))
+
+	require.NoError(t, c.Set(LevelRule{Job: "prod", Level: logger.Debug}))
+	require.NoError(t, c.Set(LevelRule{Subsystem: string(SubsysPruning), Level: logger.Error}))
+	require.NoError(t, c.Set(LevelRule{Outlet: "tcp", Level: logger.Info}))
+
+	// job wins over subsystem
+	assert.True(t, stdout.Accept(entry(logger.Debug, "prod", SubsysPruning)))
+	assert.True(t, tcp.Accept(entry(logger.Debug, "prod", SubsysPruning)))
+	assert.False(t, stdout.Accept(entry(logger.Warn, "other", SubsysPruning)))
+	// outlet rule is the new base level of tcp
+	assert.True(t, tcp.Accept(entry(logger.Info, "other", SubsysSnapshot)))
+	assert.False(t, stdout.Accept(entry(logger.Debug, "other", SubsysSnapshot)))
+
+	s := c.State()
+	assert.Equal(t, []OutletLevel{{"stdout", logger.Info}, {"tcp", logger.Warn}}, s.Configured)
+	require.Len(t, s.Rules, 3)
+	assert.Equal(t, "prod", s.Rules[0].Job)
+	assert.Equal(t, "tcp", s.Rules[2].Outlet)
+
+	assert.Error(t, c.Set(LevelRule{Outlet: "nonexistent", Level: logger.Debug}))
+	assert.Error(t, c.Set(LevelRule{Subsystem: "nonexistent", Level: logger.Debug}))
+	assert.Error(t, c.Reset(LevelRule{Job: "nonexistent"}, false))
+
+	require.NoError(t, c.Reset(LevelRule{Job: "prod"}, false))
+	assert.False(t, stdout.Accept(entry(logger.Debug, "prod", SubsysSnapshot)))
+	require.NoError(t, c.Reset(LevelRule{}, true))
+	assert.Empty(t, c.State().Rules)
+	assert.False(t, tcp.Accept(entry(logger.Info, "prod", SubsysSnapshot)))
+}
+
+func TestMemoryOutlet(t *testing.T) {
+	o, err := NewMemoryOutlet(circlog.CIRCULARLOG_INIT_SIZE)
+	require.NoError(t, err)
+
+	write := func(l logger.Level, job, msg string) {
+		require.NoError(t, o.WriteEntry(logger.Entry{
+			Level: l, Message: msg, Time: time.Now(),
+			Fields: logger.Fields{JobField: job, SubsysField: SubsysPruning},
+		}))
+	}
+	write(logger.Info, "a", "first")
+	write(logger.Debug, "b", "second")
+
+	entries, next, truncated := o.Read(-1, "", logger.Debug)
+	assert.False(t, truncated)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "first", entries[0].Message)
+	assert.Equal(t, "pruning", entries[1].Fields[SubsysField])
+
+	entries, _, _ = o.Read(-1, "b", logger.Debug)
+	require.Len(t, entries, 1)
+	entries, _, _ = o.Read(-1, "", logger.Info)
+	require.Len(t, entries, 1)
+
+	entries, next2, _ := o.Read(next, "", logger.Debug)
+	assert.Empty(t, entries)
+	assert.Equal(t, next, next2)
+
+	write(logger.Warn, "a", "third")
+	entries, _, truncated = o.Read(next, "", logger.Debug)
+	assert.False(t, truncated)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "third", entries[0].Message)
+
+	// overflow the circular log
+	for i := 0; i < circlog.CIRCULARLOG_INIT_SIZE/50; i++ {
+		write(logger.Info, "a", "filler")
+	}
+	entries, _, truncated = o.Read(next, "", logger.Debug)
+	assert.True(t, truncated)
+	assert.NotEmpty(t, entries)
+	assert.Equal(t, "filler", entries[len(entries)-1].Message)
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/circlog"
+)
+
+const MemoryOutletDefaultSize = 4 << 20
+
+// MemoryOutlet keeps the most recent log entries in a util/circlog.CircularLog.
+// Entries are stored as JSON lines and addressed by the number of bytes written before them,
+// which allows readers to follow the log.
+type MemoryOutlet struct {
+	mtx sync.Mutex
+	log *circlog.CircularLog
+	// closed and replaced on every write
+	written chan struct{}
+}
+
+// MemoryEntry is the representation of a logger.Entry in a MemoryOutlet.
+type MemoryEntry struct {
+	Time    time.Time
+	Level   logger.Level
+	Message string
+	Fields  map[string]interface{} `json:",omitempty"`
+}
+
+func (e MemoryEntry) Entry() logger.Entry {
+	return logger.Entry{Level: e.Level, Message: e.Message, Time: e.Time, Fields: e.Fields}
+}
+
+func NewMemoryOutlet(size int) (*MemoryOutlet, error) {
+	log, err := circlog.NewCircularLog(size)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryOutlet{log: log, written: make(chan struct{})}, nil
+}
+
+func (o *MemoryOutlet) WriteEntry(e logger.Entry) error {
+	me := MemoryEntry{Time: e.Time, Level: e.Level, Message: e.Message}
+	if len(e.Fields) > 0 {
+		me.Fields = make(map[string]interface{}, len(e.Fields))
+		for k, v := range e.Fields {
+			switch v := v.(type) {
+			case error:
+				me.Fields[k] = v.Error()
+			case string, bool, int, int64, uint64, float64:
+				me.Fields[k] = v
+			default:
+				if _, err := json.Marshal(v); err != nil {
+					me.Fields[k] = fmt.Sprint(v)
+				} else {
+					me.Fields[k] = v
+				}
+			}
+		}
+	}
+	line, err := json.Marshal(me)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if _, err := o.log.Write(line); err != nil {
+		return err
+	}
+	close(o.written)
+	o.written = make(chan struct{})
+	return nil
+}
+
+// Read returns the entries written after offset since that have at least level minLevel
+// and, if job is not empty, belong to job.
+// A negative since returns all retained entries.
+// next is the offset to pass to the next call to Read.
+// truncated is true if entries after since were already discarded.
+func (o *MemoryOutlet) Read(since int64, job string, minLevel logger.Level) (entries []MemoryEntry, next int64, truncated bool) {
+	o.mtx.Lock()
+	total := int64(o.log.TotalWritten())
+	buf := append([]byte(nil), o.log.Bytes()...)
+	o.mtx.Unlock()
+
+	start := total - int64(len(buf))
+	if since > total {
+		// the daemon was restarted since the reader's last call
+		since, truncated = -1, true
+	}
+	var data []byte
+	if since < start {
+		truncated = truncated || since >= 0
+		// if start > 0, the first line may be partial and is skipped below
+		data = buf
+	} else {
+		data = buf[since-start:]
+	}
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var e MemoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue // partial line, its beginning was discarded by circlog
+		}
+		if e.Level < minLevel {
+			continue
+		}
+		if job != "" && fieldString(e.Fields, JobField) != job {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, truncated
+}
+
+// Wait blocks until entries were written after offset since or ctx is done.
+func (o *MemoryOutlet) Wait(ctx context.Context, since int64) {
+	o.mtx.Lock()
+	if int64(o.log.TotalWritten()) != since {
+		o.mtx.Unlock()
+		return
+	}
+	written := o.written
+	o.mtx.Unlock()
+	select {
+	case <-written:
+	case <-ctx.Done():
+	}
+}
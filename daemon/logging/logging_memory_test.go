@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/circlog"
+)
+
+func TestMemoryOutletRead(t *testing.T) {
+	const (
+		lineLen = 128
+		// circlog does not shrink below its initial size
+		size     = circlog.CIRCULARLOG_INIT_SIZE
+		capacity = size / lineLen
+	)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	emptyLine, err := json.Marshal(MemoryEntry{Time: now, Level: logger.Info})
+	require.NoError(t, err)
+	entry := func(i int) logger.Entry {
+		// pad the message so that the line has lineLen bytes including the newline
+		msg := fmt.Sprintf("%04d", i)
+		msg += strings.Repeat("x", lineLen-len(emptyLine)-1-len(msg))
+		return logger.Entry{Time: now, Level: logger.Info, Message: msg}
+	}
+
+	o, err := NewMemoryOutlet(size)
+	require.NoError(t, err)
+	for i := 0; i <= capacity; i++ {
+		require.NoError(t, o.WriteEntry(entry(i)))
+	}
+
+	// the first entry was discarded exactly at its line boundary
+	entries, next, truncated := o.Read(0, "", logger.Debug)
+	assert.True(t, truncated)
+	assert.Equal(t, int64((capacity+1)*lineLen), next)
+	require.Len(t, entries, capacity)
+	assert.Equal(t, entry(1).Message, entries[0].Message)
+
+	// the oldest retained line is partial
+	short := entry(capacity + 1)
+	short.Message = short.Message[:len(short.Message)-10]
+	require.NoError(t, o.WriteEntry(short))
+	entries, _, _ = o.Read(-1, "", logger.Debug)
+	require.Len(t, entries, capacity)
+	assert.Equal(t, entry(2).Message, entries[0].Message)
+
+	entries, next, truncated = o.Read(next, "", logger.Debug)
+	assert.False(t, truncated)
+	assert.Equal(t, int64((capacity+2)*lineLen-10), next)
+	require.Len(t, entries, 1)
+	assert.Equal(t, short.Message, entries[0].Message)
+}
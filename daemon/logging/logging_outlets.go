@@ -1,17 +1,21 @@
 package logging
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log/syslog"
 	"net"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/systemd"
 )
 
 type EntryFormatter interface {
@@ -38,14 +42,29 @@ func (h WriterOutlet) WriteEntry(entry logger.Entry) error {
 }
 
 type TCPOutlet struct {
+	*netOutlet
+}
+
+func NewTCPOutlet(formatter EntryFormatter, network, address string, tlsConfig *tls.Config, retryInterval time.Duration) *TCPOutlet {
+	frame := func(formatted []byte) ([][]byte, error) {
+		return [][]byte{append(formatted, '\n')}, nil
+	}
+	return &TCPOutlet{newNetOutlet(formatter, frame, network, address, tlsConfig, retryInterval)}
+}
+
+// netOutlet sends formatted log entries to a network address from a background goroutine.
+// The framing of the formatted entry depends on the protocol, each frame is sent with a separate write,
+// i.e., in a separate datagram for connectionless networks.
+type netOutlet struct {
 	formatter EntryFormatter
+	frame     func(formatted []byte) ([][]byte, error)
 	// Specifies how much time must pass between a connection error and a reconnection attempt
 	// Log entries written to the outlet during this time interval are silently dropped.
 	connect   func(ctx context.Context) (net.Conn, error)
-	entryChan chan *bytes.Buffer
+	entryChan chan [][]byte
 }
 
-func NewTCPOutlet(formatter EntryFormatter, network, address string, tlsConfig *tls.Config, retryInterval time.Duration) *TCPOutlet {
+func newNetOutlet(formatter EntryFormatter, frame func(formatted []byte) ([][]byte, error), network, address string, tlsConfig *tls.Config, retryInterval time.Duration) *netOutlet {
 
 	connect := func(ctx context.Context) (conn net.Conn, err error) {
 		deadl, ok := ctx.Deadline()
@@ -63,10 +82,11 @@ func NewTCPOutlet(formatter EntryFormatter, network, address string, tlsConfig *
 		return
 	}
 
-	entryChan := make(chan *bytes.Buffer, 1) // allow one message in flight while previos is in io.Copy()
+	entryChan := make(chan [][]byte, 1) // allow one message in flight while previos is written
 
-	o := &TCPOutlet{
+	o := &netOutlet{
 		formatter: formatter,
+		frame:     frame,
 		connect:   connect,
 		entryChan: entryChan,
 	}
@@ -77,11 +97,11 @@ func NewTCPOutlet(formatter EntryFormatter, network, address string, tlsConfig *
 }
 
 // FIXME: use this method
-func (h *TCPOutlet) Close() {
+func (h *netOutlet) Close() {
 	close(h.entryChan)
 }
 
-func (h *TCPOutlet) outLoop(retryInterval time.Duration) {
+func (h *netOutlet) outLoop(retryInterval time.Duration) {
 
 	var retry time.Time
 	var conn net.Conn
@@ -98,8 +118,8 @@ func (h *TCPOutlet) outLoop(retryInterval time.Duration) {
 			}
 		}
 		err = conn.SetWriteDeadline(time.Now().Add(retryInterval))
-		if err == nil {
-			_, err = io.Copy(conn, msg)
+		for i := 0; err == nil && i < len(msg); i++ {
+			_, err = conn.Write(msg[i])
 		}
 		if err != nil {
 			retry = time.Now().Add(retryInterval)
@@ -109,19 +129,20 @@ func (h *TCPOutlet) outLoop(retryInterval time.Duration) {
 	}
 }
 
-func (h *TCPOutlet) WriteEntry(e logger.Entry) error {
+func (h *netOutlet) WriteEntry(e logger.Entry) error {
 
 	ebytes, err := h.formatter.Format(&e)
 	if err != nil {
 		return err
 	}
 
-	buf := new(bytes.Buffer)
-	buf.Write(ebytes)
-	buf.WriteString("\n")
+	frames, err := h.frame(ebytes)
+	if err != nil {
+		return err
+	}
 
 	select {
-	case h.entryChan <- buf:
+	case h.entryChan <- frames:
 		return nil
 	default:
 		return errors.New("connection broken or not fast enough")
@@ -172,3 +193,78 @@ func (o *SyslogOutlet) WriteEntry(entry logger.Entry) error {
 	}
 
 }
+
+// JournaldOutlet writes log entries to the systemd journal.
+// The fields of an entry become journal fields prefixed with ZREPL_, e.g. ZREPL_JOB and ZREPL_SUBSYSTEM.
+type JournaldOutlet struct {
+	journal          *systemd.Journal
+	syslogIdentifier string
+}
+
+func NewJournaldOutlet(sockpath, syslogIdentifier string) (*JournaldOutlet, error) {
+	j, err := systemd.NewJournal(sockpath)
+	if err != nil {
+		return nil, err
+	}
+	return &JournaldOutlet{journal: j, syslogIdentifier: syslogIdentifier}, nil
+}
+
+func (o *JournaldOutlet) WriteEntry(entry logger.Entry) error {
+	var prio int
+	switch entry.Level {
+	case logger.Debug:
+		prio = systemd.JournalPriorityDebug
+	case logger.Info:
+		prio = systemd.JournalPriorityInfo
+	case logger.Warn:
+		prio = systemd.JournalPriorityWarning
+	default:
+		prio = systemd.JournalPriorityErr
+	}
+
+	fields := make([]systemd.JournalField, 0, 3+len(entry.Fields))
+	fields = append(fields,
+		systemd.JournalField{Name: "MESSAGE", Value: entry.Message},
+		systemd.JournalField{Name: "PRIORITY", Value: fmt.Sprint(prio)},
+		systemd.JournalField{Name: "SYSLOG_IDENTIFIER", Value: o.syslogIdentifier},
+	)
+	for _, name := range sortedFieldNames(entry.Fields) {
+		jname := systemd.JournalFieldName(name)
+		if jname == "" {
+			continue
+		}
+		jname = "ZREPL_" + jname
+		if len(jname) > 64 {
+			jname = jname[:64]
+		}
+		fields = append(fields, systemd.JournalField{Name: jname, Value: formatFieldValue(entry.Fields[name])})
+	}
+	return o.journal.Send(fields)
+}
+
+func sortedFieldNames(fields logger.Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatFieldValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String() // e.g. Subsystem
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,250 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+func syslogSeverity(l logger.Level) syslog.Priority {
+	switch l {
+	case logger.Debug:
+		return syslog.LOG_DEBUG
+	case logger.Info:
+		return syslog.LOG_INFO
+	case logger.Warn:
+		return syslog.LOG_WARNING
+	default:
+		return syslog.LOG_ERR
+	}
+}
+
+// RFC5424Header contains the header fields of the messages sent by a SyslogRFC5424Outlet.
+type RFC5424Header struct {
+	Facility syslog.Priority
+	Hostname string
+	AppName  string
+	// SD-ID of the structured data element that contains the fields of an entry,
+	// name@<private enterprise number>, e.g. zrepl@32473
+	StructuredDataID string
+}
+
+func (h RFC5424Header) Validate() error {
+	if h.Facility < syslog.LOG_KERN || h.Facility > syslog.LOG_LOCAL7 || h.Facility&7 != 0 {
+		return errors.Errorf("invalid facility %d", h.Facility)
+	}
+	if len(h.StructuredDataID) > 32 || rfc5424SDName(h.StructuredDataID) != h.StructuredDataID {
+		return errors.Errorf("invalid structured data id %q", h.StructuredDataID)
+	}
+	// SD-IDs without '@' are reserved for IANA-registered names (RFC 5424 section 6.3.2)
+	if !rfc5424PrivateSDID.MatchString(h.StructuredDataID) {
+		return errors.Errorf("structured data id %q must have the form name@<private enterprise number>", h.StructuredDataID)
+	}
+	return nil
+}
+
+// SyslogRFC5424Outlet sends RFC 5424 syslog messages to a remote syslog server.
+// The fields of a log entry are sent as parameters of a single structured data element.
+// Over TCP, messages are framed by octet counting (RFC 6587 / RFC 5425).
+type SyslogRFC5424Outlet struct {
+	*netOutlet
+}
+
+func NewSyslogRFC5424Outlet(header RFC5424Header, network, address string, tlsConfig *tls.Config, retryInterval time.Duration) *SyslogRFC5424Outlet {
+	f := &rfc5424Formatter{
+		facility: header.Facility,
+		hostname: rfc5424HeaderField(header.Hostname, 255),
+		appName:  rfc5424HeaderField(header.AppName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+		sdID:     header.StructuredDataID,
+	}
+	frame := func(formatted []byte) ([][]byte, error) {
+		return [][]byte{formatted}, nil
+	}
+	if !strings.HasPrefix(network, "udp") {
+		frame = func(formatted []byte) ([][]byte, error) {
+			framed := make([]byte, 0, len(formatted)+8)
+			framed = strconv.AppendInt(framed, int64(len(formatted)), 10)
+			framed = append(framed, ' ')
+			return [][]byte{append(framed, formatted...)}, nil
+		}
+	}
+	return &SyslogRFC5424Outlet{newNetOutlet(f, frame, network, address, tlsConfig, retryInterval)}
+}
+
+type rfc5424Formatter struct {
+	facility                        syslog.Priority
+	hostname, appName, procID, sdID string
+}
+
+const rfc5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// the format is fixed
+func (f *rfc5424Formatter) SetMetadataFlags(flags MetadataFlags) {}
+
+func (f *rfc5424Formatter) Format(e *logger.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	// MSGID is nil
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s - ",
+		f.facility|syslogSeverity(e.Level), e.Time.Format(rfc5424TimeFormat), f.hostname, f.appName, f.procID)
+	if len(e.Fields) == 0 || f.sdID == "" {
+		buf.WriteString("-")
+	} else {
+		buf.WriteString("[")
+		buf.WriteString(f.sdID)
+		for _, name := range sortedFieldNames(e.Fields) {
+			sdName := rfc5424SDName(name)
+			if sdName == "" {
+				continue
+			}
+			fmt.Fprintf(&buf, " %s=\"", sdName)
+			rfc5424ParamValueEscaper.WriteString(&buf, formatFieldValue(e.Fields[name]))
+			buf.WriteString("\"")
+		}
+		buf.WriteString("]")
+	}
+	if e.Message != "" {
+		buf.WriteString(" ")
+		buf.WriteString(e.Message)
+	}
+	return buf.Bytes(), nil
+}
+
+var rfc5424PrivateSDID = regexp.MustCompile(`^[^@]+@[0-9]+(\.[0-9]+)*$`)
+
+var rfc5424ParamValueEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// header fields are printable US-ASCII, '-' is the nil value
+func rfc5424HeaderField(s string, maxLen int) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	return string(b)
+}
+
+// SD-NAMEs are printable US-ASCII except '=', ' ', ']' and '"', at most 32 characters
+func rfc5424SDName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return string(b)
+}
+
+// GELFOutlet sends log entries in Graylog Extended Log Format (GELF) 1.1.
+// The fields of a log entry are sent as additional fields, e.g. _job and _subsystem.
+// Over UDP, messages larger than the chunk size are split into GELF chunks,
+// over TCP, messages are delimited by null bytes.
+type GELFOutlet struct {
+	*netOutlet
+}
+
+const (
+	gelfChunkHeaderLen = 12
+	gelfMaxChunks      = 128
+)
+
+func NewGELFOutlet(host string, chunkSize int, network, address string, tlsConfig *tls.Config, retryInterval time.Duration) *GELFOutlet {
+	frame := func(formatted []byte) ([][]byte, error) {
+		return [][]byte{append(formatted, 0)}, nil
+	}
+	if strings.HasPrefix(network, "udp") {
+		frame = func(formatted []byte) ([][]byte, error) {
+			return gelfChunks(formatted, chunkSize)
+		}
+	}
+	return &GELFOutlet{newNetOutlet(&gelfFormatter{host: host}, frame, network, address, tlsConfig, retryInterval)}
+}
+
+func gelfChunks(msg []byte, chunkSize int) ([][]byte, error) {
+	if len(msg) <= chunkSize {
+		return [][]byte{msg}, nil
+	}
+	dataLen := chunkSize - gelfChunkHeaderLen
+	n := (len(msg) + dataLen - 1) / dataLen
+	if n > gelfMaxChunks {
+		return nil, errors.Errorf("GELF message too large: %d bytes would need %d chunks, at most %d are allowed", len(msg), n, gelfMaxChunks)
+	}
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	chunks := make([][]byte, n)
+	for i := range chunks {
+		data := msg[i*dataLen:]
+		if len(data) > dataLen {
+			data = data[:dataLen]
+		}
+		c := make([]byte, 0, gelfChunkHeaderLen+len(data))
+		c = append(c, 0x1e, 0x0f)
+		c = append(c, id[:]...)
+		c = append(c, byte(i), byte(n))
+		chunks[i] = append(c, data...)
+	}
+	return chunks, nil
+}
+
+type gelfFormatter struct {
+	host string
+}
+
+// the format is fixed
+func (f *gelfFormatter) SetMetadataFlags(flags MetadataFlags) {}
+
+func (f *gelfFormatter) Format(e *logger.Entry) ([]byte, error) {
+	m := make(map[string]interface{}, 5+len(e.Fields))
+	m["version"] = "1.1"
+	m["host"] = f.host
+	m["short_message"] = e.Message
+	m["timestamp"] = float64(e.Time.UnixNano()/int64(time.Millisecond)) / 1e3
+	m["level"] = int(syslogSeverity(e.Level))
+	for name, v := range e.Fields {
+		name = "_" + gelfFieldName(name)
+		if name == "_id" {
+			name = "_id_" // reserved
+		}
+		switch v := v.(type) {
+		case int, int32, int64, uint, uint32, uint64, float32, float64:
+			m[name] = v
+		default:
+			m[name] = formatFieldValue(v)
+		}
+	}
+	return json.Marshal(m)
+}
+
+// additional field names must match ^[\w\.\-]*$
+func gelfFieldName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
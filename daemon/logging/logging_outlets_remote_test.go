@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/syslog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+var testRemoteEntry = logger.Entry{
+	Level:   logger.Warn,
+	Message: "replication failed",
+	Time:    time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC),
+	Fields: logger.Fields{
+		JobField:    "prod",
+		SubsysField: SubsysReplication,
+		"fs":        `pool/with "quotes" and ]`,
+		"attempt":   3,
+	},
+}
+
+func TestRFC5424Format(t *testing.T) {
+	f := &rfc5424Formatter{facility: syslog.LOG_LOCAL0, hostname: "host", appName: "zrepl", procID: "42", sdID: "zrepl@32473"}
+	b, err := f.Format(&testRemoteEntry)
+	require.NoError(t, err)
+	assert.Equal(t, `<132>1 2020-01-02T03:04:05.000006Z host zrepl 42 - [zrepl@32473 attempt="3" fs="pool/with \"quotes\" and \]" job="prod" subsystem="repl"] replication failed`, string(b))
+
+	e := logger.Entry{Level: logger.Debug, Message: "no fields", Time: testRemoteEntry.Time}
+	b, err = f.Format(&e)
+	require.NoError(t, err)
+	assert.Equal(t, `<135>1 2020-01-02T03:04:05.000006Z host zrepl 42 - - no fields`, string(b))
+
+	assert.Equal(t, "-", rfc5424HeaderField("", 48))
+	assert.Equal(t, "my_host", rfc5424HeaderField("my host", 48))
+	assert.Error(t, RFC5424Header{Facility: syslog.LOG_LOCAL0, StructuredDataID: "zrepl id"}.Validate())
+	assert.Error(t, RFC5424Header{Facility: syslog.LOG_LOCAL0 | syslog.LOG_ERR, StructuredDataID: "zrepl@32473"}.Validate())
+	assert.Error(t, RFC5424Header{Facility: syslog.LOG_LOCAL0}.Validate(), "structured data id is required")
+	assert.Error(t, RFC5424Header{Facility: syslog.LOG_LOCAL0, StructuredDataID: "zrepl"}.Validate(), "IANA-registered SD-IDs are reserved")
+	assert.NoError(t, RFC5424Header{Facility: syslog.LOG_LOCAL0, StructuredDataID: "zrepl@32473.1"}.Validate())
+}
+
+func TestSyslogRFC5424OutletTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	o := NewSyslogRFC5424Outlet(RFC5424Header{Facility: syslog.LOG_DAEMON, Hostname: "host", AppName: "zrepl", StructuredDataID: "zrepl@32473"},
+		"tcp", l.Addr().String(), nil, time.Second)
+	defer o.Close()
+	require.NoError(t, o.WriteEntry(logger.Entry{Level: logger.Info, Message: "first", Time: testRemoteEntry.Time}))
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	r := bufio.NewReader(conn)
+	length, err := r.ReadString(' ')
+	require.NoError(t, err)
+	msg := "<30>1 2020-01-02T03:04:05.000006Z host zrepl " + o.formatter.(*rfc5424Formatter).procID + " - - first"
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	require.NoError(t, err)
+	assert.Equal(t, len(msg), n)
+	buf := make([]byte, len(msg))
+	_, err = r.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, string(buf))
+}
+
+func TestGELFFormat(t *testing.T) {
+	f := &gelfFormatter{host: "host"}
+	b, err := f.Format(&testRemoteEntry)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &m))
+	assert.Equal(t, map[string]interface{}{
+		"version":       "1.1",
+		"host":          "host",
+		"short_message": "replication failed",
+		"timestamp":     1577934245.0,
+		"level":         4.0,
+		"_job":          "prod",
+		"_subsystem":    "repl",
+		"_fs":           `pool/with "quotes" and ]`,
+		"_attempt":      3.0,
+	}, m)
+}
+
+func TestGELFChunks(t *testing.T) {
+	msg := []byte(strings.Repeat("x", 25))
+	chunks, err := gelfChunks(msg, 100)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{msg}, chunks)
+
+	chunks, err = gelfChunks(msg, gelfChunkHeaderLen+10)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	var data []byte
+	for i, c := range chunks {
+		assert.Equal(t, []byte{0x1e, 0x0f}, c[:2])
+		assert.Equal(t, chunks[0][2:10], c[2:10], "message id")
+		assert.Equal(t, []byte{byte(i), 3}, c[10:12])
+		data = append(data, c[12:]...)
+	}
+	assert.Equal(t, msg, data)
+	assert.Len(t, chunks[2], gelfChunkHeaderLen+5)
+
+	_, err = gelfChunks(make([]byte, 129*10), gelfChunkHeaderLen+10)
+	assert.Error(t, err)
+}
+
+func TestGELFOutletUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	o := NewGELFOutlet("host", 1420, "udp", conn.LocalAddr().String(), nil, time.Second)
+	defer o.Close()
+	require.NoError(t, o.WriteEntry(testRemoteEntry))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf[:n], &m))
+	assert.Equal(t, "prod", m["_job"])
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/pushmetrics"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// pushMonitoringJob periodically exports the contents of the default Prometheus registry
+// to a push-based monitoring system.
+type pushMonitoringJob struct {
+	name     string
+	exporter pushmetrics.Exporter
+	interval time.Duration
+	jobLabel string
+}
+
+func newPushMonitoringJobFromConfig(i int, in interface{}) (*pushMonitoringJob, error) {
+	var (
+		common   config.PushMonitoringCommon
+		exporter pushmetrics.Exporter
+		err      error
+	)
+	switch v := in.(type) {
+	case *config.InfluxDBMonitoring:
+		common = v.PushMonitoringCommon
+		exporter, err = pushmetrics.NewInfluxDB(v.URL, v.JobLabel)
+	case *config.StatsDMonitoring:
+		common = v.PushMonitoringCommon
+		exporter, err = pushmetrics.NewStatsD(v.Address, v.Prefix, v.DogStatsD, v.JobLabel)
+	case *config.PushgatewayMonitoring:
+		common = v.PushMonitoringCommon
+		instance := v.Instance
+		if instance == "" {
+			instance, err = os.Hostname()
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot determine hostname for pushgateway instance")
+			}
+		}
+		exporter = pushmetrics.NewPushgateway(v.URL, v.Job, instance, v.JobLabel)
+	default:
+		panic(fmt.Sprintf("unknown push monitoring type %T", in))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot build %s exporter", common.Type)
+	}
+	return &pushMonitoringJob{
+		name:     fmt.Sprintf("_monitoring_%s_%d", common.Type, i),
+		exporter: exporter,
+		interval: common.Interval,
+		jobLabel: common.JobLabel,
+	}, nil
+}
+
+func (j *pushMonitoringJob) Name() string { return j.name }
+
+func (j *pushMonitoringJob) Status() *job.Status { return &job.Status{Type: job.TypeInternal} }
+
+func (j *pushMonitoringJob) OwnedDatasetSubtreeRoot() (p *zfs.DatasetPath, ok bool) {
+	return nil, false
+}
+
+func (j *pushMonitoringJob) RegisterMetrics(registerer prometheus.Registerer) {}
+
+func (j *pushMonitoringJob) Run(ctx context.Context) {
+	registerGlobalMetrics()
+	pushmetrics.Run(ctx, job.GetLogger(ctx), prometheus.DefaultGatherer, j.exporter, j.interval, j.jobLabel)
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/job"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/logger"
 	"github.com/zrepl/zrepl/rpc/dataconn/frameconn"
 	"github.com/zrepl/zrepl/zfs"
@@ -28,6 +30,8 @@ func newPrometheusJobFromConfig(in *config.PrometheusMonitoring) (*prometheusJob
 
 var prom struct {
 	taskLogEntries *prometheus.CounterVec
+	jobPaused      *prometheus.GaugeVec
+	jobPausedSince *prometheus.GaugeVec
 }
 
 func init() {
@@ -38,6 +42,33 @@ func init() {
 		Help:      "number of log entries per job task and level",
 	}, []string{"zrepl_job", "level"})
 	prometheus.MustRegister(prom.taskLogEntries)
+	prom.jobPaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "zrepl",
+		Subsystem: "job",
+		Name:      "paused",
+		Help:      "1 if the job is paused, 0 otherwise",
+	}, []string{"zrepl_job"})
+	prometheus.MustRegister(prom.jobPaused)
+	prom.jobPausedSince = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "zrepl",
+		Subsystem: "job",
+		Name:      "paused_since",
+		Help:      "unix time at which a paused job was paused, labeled by who or what paused it",
+	}, []string{"zrepl_job", "paused_by"})
+	prometheus.MustRegister(prom.jobPausedSince)
+}
+
+// prev is the state before st, its series is removed
+func updatePauseMetrics(job string, prev, st pause.State) {
+	if prev.Paused {
+		prom.jobPausedSince.DeleteLabelValues(job, prev.By)
+	}
+	if !st.Paused {
+		prom.jobPaused.WithLabelValues(job).Set(0)
+		return
+	}
+	prom.jobPaused.WithLabelValues(job).Set(1)
+	prom.jobPausedSince.WithLabelValues(job, st.By).Set(float64(st.Since.Unix()))
 }
 
 func (j *prometheusJob) Name() string { return jobNamePrometheus }
@@ -48,15 +79,24 @@ func (j *prometheusJob) OwnedDatasetSubtreeRoot() (p *zfs.DatasetPath, ok bool)
 
 func (j *prometheusJob) RegisterMetrics(registerer prometheus.Registerer) {}
 
-func (j *prometheusJob) Run(ctx context.Context) {
+var registerGlobalMetricsOnce sync.Once
+
+// registerGlobalMetrics registers the metrics of packages that are shared by all jobs.
+// It is safe to call from multiple monitoring jobs.
+func registerGlobalMetrics() {
+	registerGlobalMetricsOnce.Do(func() {
+		if err := zfs.PrometheusRegister(prometheus.DefaultRegisterer); err != nil {
+			panic(err)
+		}
+		if err := frameconn.PrometheusRegister(prometheus.DefaultRegisterer); err != nil {
+			panic(err)
+		}
+	})
+}
 
-	if err := zfs.PrometheusRegister(prometheus.DefaultRegisterer); err != nil {
-		panic(err)
-	}
+func (j *prometheusJob) Run(ctx context.Context) {
 
-	if err := frameconn.PrometheusRegister(prometheus.DefaultRegisterer); err != nil {
-		panic(err)
-	}
+	registerGlobalMetrics()
 
 	log := job.GetLogger(ctx)
 
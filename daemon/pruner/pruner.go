@@ -56,6 +56,13 @@ type args struct {
 	retryWait                      time.Duration
 	considerSnapAtCursorReplicated bool
 	promPruneSecs                  prometheus.Observer
+	rulesOverride                  KeepRulesOverride // may be nil
+}
+
+// KeepRulesOverride provides per-filesystem keep rules that replace the configured rules.
+// ok is false if there is no override for fs.
+type KeepRulesOverride interface {
+	KeepRules(ctx context.Context, fs string) (rules []pruning.KeepRule, ok bool, err error)
 }
 
 type Pruner struct {
@@ -78,14 +85,22 @@ type PrunerFactory struct {
 	retryWait                      time.Duration
 	considerSnapAtCursorReplicated bool
 	promPruneSecs                  *prometheus.HistogramVec
+	senderRulesOverride            KeepRulesOverride
 }
 
 type LocalPrunerFactory struct {
 	keepRules     []pruning.KeepRule
 	retryWait     time.Duration
 	promPruneSecs *prometheus.HistogramVec
+	rulesOverride KeepRulesOverride
 }
 
+// SetRulesOverride makes the local pruner use the rules of o instead of the configured rules where o has an override.
+func (f *LocalPrunerFactory) SetRulesOverride(o KeepRulesOverride) { f.rulesOverride = o }
+
+// SetSenderRulesOverride makes the sender-side pruner use the rules of o instead of keep_sender where o has an override.
+func (f *PrunerFactory) SetSenderRulesOverride(o KeepRulesOverride) { f.senderRulesOverride = o }
+
 func NewLocalPrunerFactory(in config.PruningLocal, promPruneSecs *prometheus.HistogramVec) (*LocalPrunerFactory, error) {
 	rules, err := pruning.RulesFromConfig(in.Keep)
 	if err != nil {
@@ -145,6 +160,7 @@ func (f *PrunerFactory) BuildSenderPruner(ctx context.Context, target Target, re
 			f.retryWait,
 			f.considerSnapAtCursorReplicated,
 			f.promPruneSecs.WithLabelValues("sender"),
+			f.senderRulesOverride,
 		},
 		state: Plan,
 	}
@@ -161,6 +177,7 @@ func (f *PrunerFactory) BuildReceiverPruner(ctx context.Context, target Target,
 			f.retryWait,
 			false, // senseless here anyways
 			f.promPruneSecs.WithLabelValues("receiver"),
+			nil,
 		},
 		state: Plan,
 	}
@@ -177,6 +194,7 @@ func (f *LocalPrunerFactory) BuildLocalPruner(ctx context.Context, target Target
 			f.retryWait,
 			false, // considerSnapAtCursorReplicated is not relevant for local pruning
 			f.promPruneSecs.WithLabelValues("local"),
+			f.rulesOverride,
 		},
 		state: Plan,
 	}
@@ -256,6 +274,22 @@ func (p *Pruner) State() State {
 	return p.state
 }
 
+// DestroyList plans the filesystem at path (as presented by the target) and
+// returns the snapshots that the keep rules would destroy, without destroying them.
+// It does not change the state of p.
+func (p *Pruner) DestroyList(path string) ([]*pdu.FilesystemVersion, error) {
+	pfs := &fs{path: path}
+	planFilesystem(&p.args, pfs, GetLogger(p.args.ctx).WithField("fs", path))
+	if pfs.planErr != nil {
+		return nil, pfs.planErr
+	}
+	destroyList := make([]*pdu.FilesystemVersion, len(pfs.destroyList))
+	for i := range pfs.destroyList {
+		destroyList[i] = pfs.destroyList[i].(snapshot).fsv
+	}
+	return destroyList, nil
+}
+
 type fs struct {
 	path string
 
@@ -372,7 +406,6 @@ func doOneAttempt(a *args, u updater) {
 	tfss := tfssres.GetFilesystems()
 
 	pfss := make([]*fs, len(tfss))
-tfss_loop:
 	for i, tfs := range tfss {
 
 		l := GetLogger(ctx).WithField("fs", tfs.Path)
@@ -393,81 +426,7 @@ tfss_loop:
 			continue
 		}
 
-		pfsPlanErrAndLog := func(err error, message string) {
-			t := fmt.Sprintf("%T", err)
-			pfs.planErr = err
-			pfs.planErrContext = message
-			l.WithField("orig_err_type", t).WithError(err).Error(fmt.Sprintf("%s: plan error, skipping filesystem", message))
-		}
-
-		tfsvsres, err := target.ListFilesystemVersions(ctx, &pdu.ListFilesystemVersionsReq{Filesystem: tfs.Path})
-		if err != nil {
-			pfsPlanErrAndLog(err, "cannot list filesystem versions")
-			continue tfss_loop
-		}
-		tfsvs := tfsvsres.GetVersions()
-		// no progress here since we could run in a live-lock (must have used target AND receiver before progress)
-
-		pfs.snaps = make([]pruning.Snapshot, 0, len(tfsvs))
-
-		rcReq := &pdu.ReplicationCursorReq{
-			Filesystem: tfs.Path,
-			Op: &pdu.ReplicationCursorReq_Get{
-				Get: &pdu.ReplicationCursorReq_GetOp{},
-			},
-		}
-		rc, err := receiver.ReplicationCursor(ctx, rcReq)
-		if err != nil {
-			pfsPlanErrAndLog(err, "cannot get replication cursor bookmark")
-			continue tfss_loop
-		}
-		if rc.GetNotexist() {
-			err := errors.New("replication cursor bookmark does not exist (one successful replication is required before pruning works)")
-			pfsPlanErrAndLog(err, "")
-			continue tfss_loop
-		}
-
-		// scan from older to newer, all snapshots older than cursor are interpreted as replicated
-		sort.Slice(tfsvs, func(i, j int) bool {
-			return tfsvs[i].CreateTXG < tfsvs[j].CreateTXG
-		})
-
-		haveCursorSnapshot := false
-		for _, tfsv := range tfsvs {
-			if tfsv.Type != pdu.FilesystemVersion_Snapshot {
-				continue
-			}
-			if tfsv.Guid == rc.GetGuid() {
-				haveCursorSnapshot = true
-			}
-		}
-		preCursor := haveCursorSnapshot
-		for _, tfsv := range tfsvs {
-			if tfsv.Type != pdu.FilesystemVersion_Snapshot {
-				continue
-			}
-			creation, err := tfsv.CreationAsTime()
-			if err != nil {
-				err := fmt.Errorf("%s: %s", tfsv.RelName(), err)
-				pfsPlanErrAndLog(err, "fs version with invalid creation date")
-				continue tfss_loop
-			}
-			// note that we cannot use CreateTXG because target and receiver could be on different pools
-			atCursor := tfsv.Guid == rc.GetGuid()
-			preCursor = preCursor && !atCursor
-			pfs.snaps = append(pfs.snaps, snapshot{
-				replicated: preCursor || (a.considerSnapAtCursorReplicated && atCursor),
-				date:       creation,
-				fsv:        tfsv,
-			})
-		}
-		if preCursor {
-			pfsPlanErrAndLog(fmt.Errorf("replication cursor not found in prune target filesystem versions"), "")
-			continue tfss_loop
-		}
-
-		// Apply prune rules
-		pfs.destroyList = pruning.PruneSnapshots(pfs.snaps, a.rules)
+		planFilesystem(a, pfs, l)
 	}
 
 	u(func(pruner *Pruner) {
@@ -515,6 +474,99 @@ tfss_loop:
 
 }
 
+// planFilesystem computes the snapshots and the destroy list of pfs.
+// Errors are stored in pfs.planErr.
+func planFilesystem(a *args, pfs *fs, l Logger) {
+	pfsPlanErrAndLog := func(err error, message string) {
+		t := fmt.Sprintf("%T", err)
+		pfs.planErr = err
+		pfs.planErrContext = message
+		l.WithField("orig_err_type", t).WithError(err).Error(fmt.Sprintf("%s: plan error, skipping filesystem", message))
+	}
+
+	tfsvsres, err := a.target.ListFilesystemVersions(a.ctx, &pdu.ListFilesystemVersionsReq{Filesystem: pfs.path})
+	if err != nil {
+		pfsPlanErrAndLog(err, "cannot list filesystem versions")
+		return
+	}
+	tfsvs := tfsvsres.GetVersions()
+	// no progress here since we could run in a live-lock (must have used target AND receiver before progress)
+
+	pfs.snaps = make([]pruning.Snapshot, 0, len(tfsvs))
+
+	rcReq := &pdu.ReplicationCursorReq{
+		Filesystem: pfs.path,
+		Op: &pdu.ReplicationCursorReq_Get{
+			Get: &pdu.ReplicationCursorReq_GetOp{},
+		},
+	}
+	rc, err := a.receiver.ReplicationCursor(a.ctx, rcReq)
+	if err != nil {
+		pfsPlanErrAndLog(err, "cannot get replication cursor bookmark")
+		return
+	}
+	if rc.GetNotexist() {
+		err := errors.New("replication cursor bookmark does not exist (one successful replication is required before pruning works)")
+		pfsPlanErrAndLog(err, "")
+		return
+	}
+
+	// scan from older to newer, all snapshots older than cursor are interpreted as replicated
+	sort.Slice(tfsvs, func(i, j int) bool {
+		return tfsvs[i].CreateTXG < tfsvs[j].CreateTXG
+	})
+
+	haveCursorSnapshot := false
+	for _, tfsv := range tfsvs {
+		if tfsv.Type != pdu.FilesystemVersion_Snapshot {
+			continue
+		}
+		if tfsv.Guid == rc.GetGuid() {
+			haveCursorSnapshot = true
+		}
+	}
+	preCursor := haveCursorSnapshot
+	for _, tfsv := range tfsvs {
+		if tfsv.Type != pdu.FilesystemVersion_Snapshot {
+			continue
+		}
+		creation, err := tfsv.CreationAsTime()
+		if err != nil {
+			err := fmt.Errorf("%s: %s", tfsv.RelName(), err)
+			pfsPlanErrAndLog(err, "fs version with invalid creation date")
+			return
+		}
+		// note that we cannot use CreateTXG because target and receiver could be on different pools
+		atCursor := tfsv.Guid == rc.GetGuid()
+		preCursor = preCursor && !atCursor
+		pfs.snaps = append(pfs.snaps, snapshot{
+			replicated: preCursor || (a.considerSnapAtCursorReplicated && atCursor),
+			date:       creation,
+			fsv:        tfsv,
+		})
+	}
+	if preCursor {
+		pfsPlanErrAndLog(fmt.Errorf("replication cursor not found in prune target filesystem versions"), "")
+		return
+	}
+
+	rules := a.rules
+	if a.rulesOverride != nil {
+		override, ok, err := a.rulesOverride.KeepRules(a.ctx, pfs.path)
+		if err != nil {
+			pfsPlanErrAndLog(err, "cannot determine keep rules override")
+			return
+		}
+		if ok {
+			l.Debug("using keep rules override")
+			rules = override
+		}
+	}
+
+	// Apply prune rules
+	pfs.destroyList = pruning.PruneSnapshots(pfs.snaps, rules)
+}
+
 // attempts to exec pfs, puts it back into the queue with the result
 func doOneAttemptExec(a *args, u updater, pfs *fs) {
 
@@ -0,0 +1,159 @@
+package pushmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxDB exports metrics in the InfluxDB line protocol over HTTP or UDP.
+//
+// Each sample becomes a line with the metric name as measurement, the labels as tags
+// and the job label (if grouping is enabled) as an additional tag.
+// Counters, gauges and untyped metrics have a single field 'value',
+// histograms and summaries have the fields 'count', 'sum' and 'quantile_<q>'.
+type InfluxDB struct {
+	url      *url.URL
+	jobLabel string
+	client   *http.Client
+}
+
+// NewInfluxDB accepts http(s)://host:port/write?db=DB URLs, user info is used for basic authentication,
+// and udp://host:port URLs.
+func NewInfluxDB(rawurl, jobLabel string) (*InfluxDB, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "udp":
+		if _, _, err := net.SplitHostPort(u.Host); err != nil {
+			return nil, fmt.Errorf("udp URL must have host:port: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("URL scheme must be http, https or udp, got %q", u.Scheme)
+	}
+	return &InfluxDB{url: u, jobLabel: jobLabel, client: &http.Client{}}, nil
+}
+
+var influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+var influxKeyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxLines encodes groups as line protocol, one line per metric
+func influxLines(groups []Group, jobLabel string, now time.Time) []string {
+	var lines []string
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	for _, g := range groups {
+		for _, mf := range g.Families {
+			var line strings.Builder
+			var lastKey string
+			flush := func() {
+				if line.Len() > 0 {
+					line.WriteString(" ")
+					line.WriteString(ts)
+					lines = append(lines, line.String())
+					line.Reset()
+				}
+			}
+			for _, s := range samples(mf) {
+				var key strings.Builder
+				key.WriteString(influxMeasurementEscaper.Replace(s.name))
+				if g.Job != "" {
+					fmt.Fprintf(&key, ",%s=%s", influxKeyEscaper.Replace(jobLabel), influxKeyEscaper.Replace(g.Job))
+				}
+				for _, l := range s.labels {
+					if l.GetValue() == "" {
+						continue // InfluxDB rejects empty tag values
+					}
+					fmt.Fprintf(&key, ",%s=%s", influxKeyEscaper.Replace(l.GetName()), influxKeyEscaper.Replace(l.GetValue()))
+				}
+				// the fields of one metric are consecutive samples with the same key
+				if key.String() != lastKey {
+					flush()
+					line.WriteString(key.String())
+					line.WriteString(" ")
+					lastKey = key.String()
+				} else {
+					line.WriteString(",")
+				}
+				line.WriteString(influxKeyEscaper.Replace(s.field))
+				line.WriteString("=")
+				line.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			}
+			flush()
+		}
+	}
+	return lines
+}
+
+func (e *InfluxDB) Export(ctx context.Context, groups []Group) error {
+	lines := influxLines(groups, e.jobLabel, time.Now())
+	if e.url.Scheme == "udp" {
+		return writeUDPPackets(ctx, e.url.Host, lines)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url.String(), strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %q from InfluxDB: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// most networks do not fragment packets of this size
+const udpMaxPacketSize = 1432
+
+// writeUDPPackets sends lines as newline-separated packets of at most udpMaxPacketSize bytes
+// (except for lines that are larger).
+func writeUDPPackets(ctx context.Context, address string, lines []string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		if err := conn.SetWriteDeadline(dl); err != nil {
+			return err
+		}
+	}
+	var packet bytes.Buffer
+	send := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, l := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(l) > udpMaxPacketSize {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteString("\n")
+		}
+		packet.WriteString(l)
+	}
+	return send()
+}
@@ -0,0 +1,50 @@
+package pushmetrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Pushgateway pushes metrics to a Prometheus Pushgateway.
+//
+// Each group is pushed with the grouping key {job, instance} and,
+// if grouping is enabled, the job label.
+// Pushing replaces the previously pushed metrics of the same group.
+type Pushgateway struct {
+	url, job, instance, jobLabel string
+}
+
+func NewPushgateway(url, job, instance, jobLabel string) *Pushgateway {
+	return &Pushgateway{url, job, instance, jobLabel}
+}
+
+type ctxHTTPDoer struct {
+	ctx context.Context
+}
+
+func (d ctxHTTPDoer) Do(req *http.Request) (*http.Response, error) {
+	return http.DefaultClient.Do(req.WithContext(d.ctx))
+}
+
+func (e *Pushgateway) Export(ctx context.Context, groups []Group) error {
+	for _, g := range groups {
+		families := g.Families
+		p := push.New(e.url, e.job).
+			Client(ctxHTTPDoer{ctx}).
+			Grouping("instance", e.instance).
+			Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				return families, nil
+			}))
+		if g.Job != "" {
+			p = p.Grouping(e.jobLabel, g.Job)
+		}
+		if err := p.Push(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
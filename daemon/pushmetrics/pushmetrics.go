@@ -0,0 +1,149 @@
+// Package pushmetrics periodically exports the contents of a prometheus.Gatherer
+// to monitoring systems, for setups in which the daemon cannot be scraped.
+package pushmetrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+type Logger = logger.Logger
+
+// Exporter sends metrics to a monitoring system.
+type Exporter interface {
+	Export(ctx context.Context, groups []Group) error
+}
+
+// Group holds the metrics that have the same value for the job label.
+type Group struct {
+	// The value of the job label,
+	// empty for metrics without the label or if grouping is disabled.
+	Job string
+	// The job label is removed from the metrics.
+	Families []*dto.MetricFamily
+}
+
+// GroupByLabel groups families by the value of label.
+// If label is empty, a single group is returned.
+// The returned groups are sorted by Group.Job.
+func GroupByLabel(families []*dto.MetricFamily, label string) []Group {
+	if label == "" {
+		return []Group{{Families: families}}
+	}
+	byJob := make(map[string]map[string]*dto.MetricFamily)
+	var jobs []string
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			job := ""
+			var labels []*dto.LabelPair
+			for _, l := range m.GetLabel() {
+				if l.GetName() == label {
+					job = l.GetValue()
+				} else {
+					labels = append(labels, l)
+				}
+			}
+			fams, ok := byJob[job]
+			if !ok {
+				fams = make(map[string]*dto.MetricFamily)
+				byJob[job] = fams
+				jobs = append(jobs, job)
+			}
+			gmf, ok := fams[mf.GetName()]
+			if !ok {
+				gmf = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				fams[mf.GetName()] = gmf
+			}
+			gm := proto.Clone(m).(*dto.Metric)
+			gm.Label = labels
+			gmf.Metric = append(gmf.Metric, gm)
+		}
+	}
+	sort.Strings(jobs)
+	groups := make([]Group, len(jobs))
+	for i, job := range jobs {
+		groups[i].Job = job
+		for _, mf := range byJob[job] {
+			groups[i].Families = append(groups[i].Families, mf)
+		}
+		sort.Slice(groups[i].Families, func(a, b int) bool {
+			return groups[i].Families[a].GetName() < groups[i].Families[b].GetName()
+		})
+	}
+	return groups
+}
+
+// sample is a single value of a metric, histograms and summaries are flattened into several samples.
+type sample struct {
+	name   string
+	labels []*dto.LabelPair
+	// "value" for counters, gauges and untyped metrics,
+	// "count", "sum" and "quantile_<q>" for histograms and summaries
+	field string
+	value float64
+}
+
+func samples(mf *dto.MetricFamily) []sample {
+	var ss []sample
+	add := func(m *dto.Metric, field string, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return // neither InfluxDB nor StatsD can represent these
+		}
+		ss = append(ss, sample{mf.GetName(), m.GetLabel(), field, v})
+	}
+	for _, m := range mf.GetMetric() {
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			add(m, "value", m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(m, "value", m.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			add(m, "value", m.GetUntyped().GetValue())
+		case dto.MetricType_HISTOGRAM:
+			add(m, "count", float64(m.GetHistogram().GetSampleCount()))
+			add(m, "sum", m.GetHistogram().GetSampleSum())
+		case dto.MetricType_SUMMARY:
+			add(m, "count", float64(m.GetSummary().GetSampleCount()))
+			add(m, "sum", m.GetSummary().GetSampleSum())
+			for _, q := range m.GetSummary().GetQuantile() {
+				add(m, fmt.Sprintf("quantile_%g", q.GetQuantile()), q.GetValue())
+			}
+		}
+	}
+	return ss
+}
+
+// Run exports the metrics gathered from g every interval until ctx is done.
+func Run(ctx context.Context, log Logger, g prometheus.Gatherer, e Exporter, interval time.Duration, jobLabel string) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		families, err := g.Gather()
+		if err != nil {
+			// Gather returns as many metrics as possible on error
+			log.WithError(err).Warn("error gathering metrics")
+		}
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		err = e.Export(ctx, GroupByLabel(families, jobLabel))
+		cancel()
+		if err != nil {
+			log.WithError(err).Error("cannot export metrics")
+		} else {
+			log.Debug("exported metrics")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pushmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func label(name, value string) *dto.LabelPair {
+	return &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)}
+}
+
+func testFamilies() []*dto.MetricFamily {
+	return []*dto.MetricFamily{
+		{
+			Name: proto.String("zrepl_replication_rate"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{Label: []*dto.LabelPair{label("zrepl_job", "prod")}, Gauge: &dto.Gauge{Value: proto.Float64(1024)}},
+				{Label: []*dto.LabelPair{label("zrepl_job", "backup")}, Gauge: &dto.Gauge{Value: proto.Float64(2)}},
+			},
+		},
+		{
+			Name: proto.String("zrepl_zfs_duration"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label:     []*dto.LabelPair{label("cmd", "zfs list")},
+					Histogram: &dto.Histogram{SampleCount: proto.Uint64(3), SampleSum: proto.Float64(0.5)},
+				},
+			},
+		},
+	}
+}
+
+func TestGroupByLabel(t *testing.T) {
+	groups := GroupByLabel(testFamilies(), "zrepl_job")
+	require.Len(t, groups, 3)
+	assert.Equal(t, "", groups[0].Job)
+	assert.Equal(t, "backup", groups[1].Job)
+	assert.Equal(t, "prod", groups[2].Job)
+
+	require.Len(t, groups[2].Families, 1)
+	prod := groups[2].Families[0]
+	assert.Equal(t, "zrepl_replication_rate", prod.GetName())
+	require.Len(t, prod.Metric, 1)
+	assert.Empty(t, prod.Metric[0].Label)
+	assert.Equal(t, float64(1024), prod.Metric[0].GetGauge().GetValue())
+
+	require.Len(t, groups[0].Families, 1)
+	assert.Equal(t, "zrepl_zfs_duration", groups[0].Families[0].GetName())
+
+	// input is not modified
+	assert.Len(t, testFamilies()[0].Metric[0].Label, 1)
+
+	all := GroupByLabel(testFamilies(), "")
+	require.Len(t, all, 1)
+	assert.Len(t, all[0].Families, 2)
+}
+
+func TestInfluxLines(t *testing.T) {
+	now := time.Unix(1, 0)
+	lines := influxLines(GroupByLabel(testFamilies(), "zrepl_job"), "zrepl_job", now)
+	assert.Equal(t, []string{
+		`zrepl_zfs_duration,cmd=zfs\ list count=3,sum=0.5 1000000000`,
+		`zrepl_replication_rate,zrepl_job=backup value=2 1000000000`,
+		`zrepl_replication_rate,zrepl_job=prod value=1024 1000000000`,
+	}, lines)
+}
+
+func TestStatsDLines(t *testing.T) {
+	groups := GroupByLabel(testFamilies(), "zrepl_job")
+	assert.Equal(t, []string{
+		"zrepl.zrepl_zfs_duration.zfs_list.count:3|g",
+		"zrepl.zrepl_zfs_duration.zfs_list.sum:0.5|g",
+		"zrepl.backup.zrepl_replication_rate:2|g",
+		"zrepl.prod.zrepl_replication_rate:1024|g",
+	}, statsdLines(groups, "zrepl", false, "zrepl_job"))
+	assert.Equal(t, []string{
+		"zrepl.zrepl_zfs_duration.count:3|g|#cmd:zfs_list",
+		"zrepl.zrepl_zfs_duration.sum:0.5|g|#cmd:zfs_list",
+		"zrepl.zrepl_replication_rate:2|g|#zrepl_job:backup",
+		"zrepl.zrepl_replication_rate:1024|g|#zrepl_job:prod",
+	}, statsdLines(groups, "zrepl", true, "zrepl_job"))
+}
@@ -0,0 +1,76 @@
+package pushmetrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// StatsD exports metrics as StatsD gauges over UDP.
+//
+// All metrics are sent as gauges because StatsD counters are deltas
+// whereas Prometheus counters are cumulative.
+// The metric name is prefix.[job.]name[.field] where field is omitted for counters, gauges and untyped metrics.
+// With plain StatsD, label values are appended to the metric name in the order of the label names.
+// With DogStatsD, labels and the job label are sent as tags.
+type StatsD struct {
+	address   string
+	prefix    string
+	dogStatsD bool
+	jobLabel  string
+}
+
+func NewStatsD(address, prefix string, dogStatsD bool, jobLabel string) (*StatsD, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, err
+	}
+	return &StatsD{address, prefix, dogStatsD, jobLabel}, nil
+}
+
+// characters with special meaning in the StatsD protocol or Graphite metric names
+var statsdNameSanitizer = strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "/", "_", "\n", "_")
+var dogStatsDTagSanitizer = strings.NewReplacer(",", "_", "|", "_", "#", "_", " ", "_", "\n", "_")
+
+func statsdLines(groups []Group, prefix string, dogStatsD bool, jobLabel string) []string {
+	var lines []string
+	for _, g := range groups {
+		for _, mf := range g.Families {
+			for _, s := range samples(mf) {
+				var name []string
+				if prefix != "" {
+					name = append(name, prefix)
+				}
+				if g.Job != "" && !dogStatsD {
+					name = append(name, statsdNameSanitizer.Replace(g.Job))
+				}
+				name = append(name, s.name)
+				var tags []string
+				if g.Job != "" && dogStatsD {
+					tags = append(tags, dogStatsDTagSanitizer.Replace(jobLabel+":"+g.Job))
+				}
+				for _, l := range s.labels {
+					if dogStatsD {
+						tags = append(tags, dogStatsDTagSanitizer.Replace(l.GetName()+":"+l.GetValue()))
+					} else if l.GetValue() != "" {
+						name = append(name, statsdNameSanitizer.Replace(l.GetValue()))
+					}
+				}
+				if s.field != "value" {
+					name = append(name, statsdNameSanitizer.Replace(s.field))
+				}
+				line := fmt.Sprintf("%s:%s|g", strings.Join(name, "."), strconv.FormatFloat(s.value, 'g', -1, 64))
+				if len(tags) > 0 {
+					line += "|#" + strings.Join(tags, ",")
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+func (e *StatsD) Export(ctx context.Context, groups []Group) error {
+	return writeUDPPackets(ctx, e.address, statsdLines(groups, e.prefix, e.dogStatsD, e.jobLabel))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/daemon/filters"
 	"github.com/zrepl/zrepl/daemon/hooks"
+	"github.com/zrepl/zrepl/daemon/job/pause"
 	"github.com/zrepl/zrepl/logger"
 	"github.com/zrepl/zrepl/zfs"
 )
@@ -47,10 +48,24 @@ type args struct {
 	log            Logger
 	prefix         string
 	interval       time.Duration
-	fsf            *filters.DatasetMapFilter
+	fsf            zfs.DatasetFilter
 	snapshotsTaken chan<- struct{}
 	hooks          *hooks.List
 	dryRun         bool
+	// shared by all Snappers of a job, serializes state Snapshotting
+	roundMtx *sync.Mutex
+	// out-of-band snapshot round: Stopped after Snapshotting
+	oneshot bool
+	// valid if oneshot, nil means all filesystems matched by fsf
+	filesystems []string
+	// may be nil
+	intervals IntervalOverride
+}
+
+// IntervalOverride provides per-filesystem snapshot intervals that override the job's interval.
+// ok is false if there is no override for fs.
+type IntervalOverride interface {
+	Interval(ctx context.Context, fs string) (d time.Duration, ok bool, reason string, err error)
 }
 
 type Snapper struct {
@@ -120,7 +135,7 @@ func getLogger(ctx context.Context) Logger {
 	return logger.NewNullLogger()
 }
 
-func PeriodicFromConfig(g *config.Global, fsf *filters.DatasetMapFilter, in *config.SnapshottingPeriodic) (*Snapper, error) {
+func PeriodicFromConfig(g *config.Global, fsf zfs.DatasetFilter, in *config.SnapshottingPeriodic, intervals IntervalOverride) (*Snapper, error) {
 	if in.Prefix == "" {
 		return nil, errors.New("prefix must not be empty")
 	}
@@ -134,10 +149,12 @@ func PeriodicFromConfig(g *config.Global, fsf *filters.DatasetMapFilter, in *con
 	}
 
 	args := args{
-		prefix:   in.Prefix,
-		interval: in.Interval,
-		fsf:      fsf,
-		hooks:    hookList,
+		prefix:    in.Prefix,
+		interval:  in.Interval,
+		fsf:       fsf,
+		hooks:     hookList,
+		roundMtx:  &sync.Mutex{},
+		intervals: intervals,
 		// ctx and log is set in Run()
 	}
 
@@ -163,7 +180,7 @@ func (s *Snapper) Run(ctx context.Context, snapshotsTaken chan<- struct{}) {
 		return s.state
 	}
 
-	var st state = syncUp
+	st := u(nil).sf() // SyncUp, or Planning for out-of-band rounds
 
 	for st != nil {
 		pre := u(nil)
@@ -189,6 +206,9 @@ func onErr(err error, u updater) state {
 		case Snapshotting:
 			s.state = ErrorWait
 		}
+		if s.args.oneshot {
+			s.state = Stopped
+		}
 		s.args.log.WithError(err).WithField("pre_state", preState).WithField("post_state", s.state).Error("snapshotting error")
 	}).sf()
 }
@@ -228,6 +248,13 @@ func syncUp(a args, u updater) state {
 }
 
 func plan(a args, u updater) state {
+	// out-of-band rounds are explicitly requested by the user
+	if !a.oneshot && pause.IsPaused(a.ctx) {
+		a.log.Info("job is paused, deferring snapshots until it is resumed")
+		if !pause.Wait(a.ctx) {
+			return onMainCtxDone(a.ctx, u)
+		}
+	}
 	u(func(snapper *Snapper) {
 		snapper.lastInvocation = time.Now()
 	})
@@ -235,6 +262,14 @@ func plan(a args, u updater) state {
 	if err != nil {
 		return onErr(err, u)
 	}
+	if a.filesystems != nil {
+		fss, err = selectFSes(fss, a.filesystems)
+		if err != nil {
+			return onErr(err, u)
+		}
+	} else if a.intervals != nil && !a.oneshot {
+		fss = filterIntervalOverrides(a, fss)
+	}
 
 	plan := make(map[*zfs.DatasetPath]*snapProgress, len(fss))
 	for _, fs := range fss {
@@ -249,6 +284,9 @@ func plan(a args, u updater) state {
 
 func snapshot(a args, u updater) state {
 
+	a.roundMtx.Lock()
+	defer a.roundMtx.Unlock()
+
 	var plan map[*zfs.DatasetPath]*snapProgress
 	u(func(snapper *Snapper) {
 		plan = snapper.plan
@@ -274,7 +312,15 @@ func snapshot(a args, u updater) state {
 			hooks.EnvSnapshot: snapname,
 		}
 
-		jobCallback := hooks.NewCallbackHookForFilesystem("snapshot", fs, func(_ context.Context) (err error) {
+		jobCallback := hooks.NewCallbackHookForFilesystem("snapshot", fs, func(ctx context.Context) (err error) {
+			unlock, err := zfs.LockDataset(ctx, fs.ToString(), zfs.DatasetLockOpSnapshot, func(heldBy string, heldSince time.Time) {
+				l.WithField("held_by", heldBy).WithField("held_since", heldSince).Info("waiting for dataset lock")
+			})
+			if err != nil {
+				l.WithError(err).Error("cannot lock dataset")
+				return err
+			}
+			defer unlock()
 			l.Debug("create snapshot")
 			err = zfs.ZFSSnapshot(fs, snapname, false) // TODO propagagte context to ZFSSnapshot
 			if err != nil {
@@ -366,6 +412,9 @@ func snapshot(a args, u updater) state {
 			snapper.state = Waiting
 			snapper.err = nil
 		}
+		if a.oneshot {
+			snapper.state = Stopped
+		}
 	}).sf()
 }
 
@@ -396,10 +445,79 @@ func wait(a args, u updater) state {
 	}
 }
 
-func listFSes(ctx context.Context, mf *filters.DatasetMapFilter) (fss []*zfs.DatasetPath, err error) {
+func listFSes(ctx context.Context, mf zfs.DatasetFilter) (fss []*zfs.DatasetPath, err error) {
 	return zfs.ZFSListMapping(ctx, mf)
 }
 
+// filterIntervalOverrides removes the filesystems from fss whose overridden interval
+// has not yet elapsed since their latest snapshot with the job's prefix.
+// Overrides can only make snapshots less frequent than the job's interval because
+// the snapper does not wake up more often than that, shorter overrides are logged and ignored.
+// Filesystems whose override cannot be determined are snapshotted as usual.
+func filterIntervalOverrides(a args, fss []*zfs.DatasetPath) []*zfs.DatasetPath {
+	now := time.Now()
+	ret := make([]*zfs.DatasetPath, 0, len(fss))
+	for _, fs := range fss {
+		l := a.log.WithField("fs", fs.ToString())
+		d, ok, reason, err := a.intervals.Interval(a.ctx, fs.ToString())
+		if err != nil {
+			l.WithError(err).Error("cannot determine snapshot interval override, using job interval")
+			ret = append(ret, fs)
+			continue
+		}
+		if ok && d < a.interval {
+			l.WithField("override", reason).WithField("interval", a.interval).
+				Warn("snapshot interval override is shorter than the job's interval and is ignored, using job interval")
+		}
+		if !ok || d <= a.interval {
+			ret = append(ret, fs)
+			continue
+		}
+		fsvs, err := zfs.ZFSListFilesystemVersions(fs, filters.NewTypedPrefixFilter(a.prefix, zfs.Snapshot))
+		if err != nil {
+			l.WithError(err).Error("cannot list filesystem versions")
+			ret = append(ret, fs)
+			continue
+		}
+		var latest time.Time
+		for _, v := range fsvs {
+			if v.Creation.After(latest) {
+				latest = v.Creation
+			}
+		}
+		// tolerate jitter of the job's wakeups
+		if next := latest.Add(d - a.interval/2); now.Before(next) {
+			l.WithField("override", reason).WithField("latest", latest).
+				Debug("skip filesystem, overridden snapshot interval has not elapsed")
+			continue
+		}
+		ret = append(ret, fs)
+	}
+	return ret
+}
+
+// selectFSes returns the filesystems in fss that are named in names.
+// It is an error if a name is not in fss.
+func selectFSes(fss []*zfs.DatasetPath, names []string) ([]*zfs.DatasetPath, error) {
+	byName := make(map[string]*zfs.DatasetPath, len(fss))
+	for _, fs := range fss {
+		byName[fs.ToString()] = fs
+	}
+	sel := make([]*zfs.DatasetPath, 0, len(names))
+	selected := make(map[string]bool, len(names))
+	for _, n := range names {
+		fs, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("filesystem %q is not snapshotted by this job", n)
+		}
+		if !selected[n] {
+			selected[n] = true
+			sel = append(sel, fs)
+		}
+	}
+	return sel, nil
+}
+
 func findSyncPoint(log Logger, fss []*zfs.DatasetPath, prefix string, interval time.Duration) (syncPoint time.Time, err error) {
 	type snapTime struct {
 		ds   *zfs.DatasetPath
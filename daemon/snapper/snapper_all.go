@@ -2,10 +2,14 @@ package snapper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/zrepl/zrepl/config"
-	"github.com/zrepl/zrepl/daemon/filters"
+	"github.com/zrepl/zrepl/daemon/hooks"
+	snapshotsignal "github.com/zrepl/zrepl/daemon/job/snapshot"
+	"github.com/zrepl/zrepl/zfs"
 )
 
 // FIXME: properly abstract snapshotting:
@@ -13,35 +17,108 @@ import (
 //     - timer-based trigger (periodic)
 //     - call from control socket (manual)
 //     - mixed modes?
-//   - support a `zrepl snapshot JOBNAME` subcommand for config.SnapshottingManual
 type PeriodicOrManual struct {
-	s *Snapper
+	s *Snapper // nil if manual
+
+	// template for out-of-band rounds, prefix is empty if not configured for manual snapshotting
+	oobArgs args
+
+	mtx sync.Mutex
+	oob *Snapper // most recent out-of-band round, nil if none
 }
 
+// Run runs the periodic snapshotter (if any) and serves out-of-band snapshot
+// requests (see package snapshot) until ctx is done.
 func (s *PeriodicOrManual) Run(ctx context.Context, wakeUpCommon chan<- struct{}) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
 	if s.s != nil {
-		s.s.Run(ctx, wakeUpCommon)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.s.Run(ctx, wakeUpCommon)
+		}()
+	}
+	for {
+		select {
+		case req := <-snapshotsignal.Wait(ctx):
+			if s.oobArgs.prefix == "" {
+				req.Reply(errors.New("manual snapshotting requires a prefix for out-of-band snapshots"))
+				continue
+			}
+			req.Reply(nil)
+			s.runOutOfBand(ctx, req, wakeUpCommon)
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-// Returns nil if manual
+func (s *PeriodicOrManual) runOutOfBand(ctx context.Context, req snapshotsignal.Request, wakeUpCommon chan<- struct{}) {
+	a := s.oobArgs
+	a.oneshot = true
+	a.filesystems = nil // all filesystems
+	if len(req.Filesystems) > 0 {
+		a.filesystems = req.Filesystems
+	}
+	oob := &Snapper{state: Planning, args: a}
+	s.mtx.Lock()
+	s.oob = oob
+	s.mtx.Unlock()
+
+	var snapshotsTaken chan<- struct{}
+	if req.Wakeup {
+		snapshotsTaken = wakeUpCommon
+	}
+	log := getLogger(ctx).WithField("filesystems", req.Filesystems).WithField("wakeup", req.Wakeup)
+	log.Info("start out-of-band snapshot round")
+	oob.Run(WithLogger(ctx, log), snapshotsTaken)
+	if r := oob.Report(); r.Error != "" {
+		log.WithField("err", r.Error).Error("out-of-band snapshot round failed")
+	} else {
+		log.Info("out-of-band snapshot round finished")
+	}
+}
+
+// Returns nil if manual and there was no out-of-band snapshot round yet.
+// For manual snapshotting, the report of the most recent out-of-band round is returned.
 func (s *PeriodicOrManual) Report() *Report {
-	if s.s != nil {
-		return s.s.Report()
+	s.mtx.Lock()
+	oob := s.oob
+	s.mtx.Unlock()
+	var oobReport *Report
+	if oob != nil {
+		oobReport = oob.Report()
+	}
+	if s.s == nil {
+		return oobReport
 	}
-	return nil
+	r := s.s.Report()
+	r.OutOfBand = oobReport
+	return r
 }
 
-func FromConfig(g *config.Global, fsf *filters.DatasetMapFilter, in config.SnapshottingEnum) (*PeriodicOrManual, error) {
+// FromConfig builds the snapper of a job, intervals may be nil.
+func FromConfig(g *config.Global, fsf zfs.DatasetFilter, in config.SnapshottingEnum, intervals IntervalOverride) (*PeriodicOrManual, error) {
 	switch v := in.Ret.(type) {
 	case *config.SnapshottingPeriodic:
-		snapper, err := PeriodicFromConfig(g, fsf, v)
+		snapper, err := PeriodicFromConfig(g, fsf, v, intervals)
 		if err != nil {
 			return nil, err
 		}
-		return &PeriodicOrManual{snapper}, nil
+		return &PeriodicOrManual{s: snapper, oobArgs: snapper.args}, nil
 	case *config.SnapshottingManual:
-		return &PeriodicOrManual{}, nil
+		hookList, err := hooks.ListFromConfig(&v.Hooks)
+		if err != nil {
+			return nil, fmt.Errorf("hook config error: %s", err)
+		}
+		a := args{
+			prefix:   v.Prefix,
+			fsf:      fsf,
+			hooks:    hookList,
+			roundMtx: &sync.Mutex{},
+		}
+		return &PeriodicOrManual{oobArgs: a}, nil
 	default:
 		return nil, fmt.Errorf("unknown snapshotting type %T", v)
 	}
@@ -17,6 +17,8 @@ type Report struct {
 	Error string
 	// valid in state Snapshotting
 	Progress []*ReportFilesystem
+	// most recent out-of-band snapshot round, may be nil
+	OutOfBand *Report `json:",omitempty"`
 }
 
 type ReportFilesystem struct {
@@ -0,0 +1,28 @@
+package snapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/zfs"
+)
+
+func TestSelectFSes(t *testing.T) {
+	var fss []*zfs.DatasetPath
+	for _, n := range []string{"pool/a", "pool/b", "pool/b/c"} {
+		p, err := zfs.NewDatasetPath(n)
+		require.NoError(t, err)
+		fss = append(fss, p)
+	}
+
+	sel, err := selectFSes(fss, []string{"pool/b/c", "pool/a", "pool/a"})
+	require.NoError(t, err)
+	require.Len(t, sel, 2)
+	assert.Equal(t, "pool/b/c", sel[0].ToString())
+	assert.Equal(t, "pool/a", sel[1].ToString())
+
+	_, err = selectFSes(fss, []string{"pool/a", "pool/d"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zrepl/zrepl/util/systemd"
+)
+
+// logInheritedSockets must be called before the jobs that may take sockets are started.
+func logInheritedSockets(log Logger) {
+	sockets, err := systemd.InheritedSockets()
+	if err != nil {
+		log.WithError(err).Error("cannot use some sockets passed by systemd")
+	}
+	for _, s := range sockets {
+		log.WithField("socket", s.String()).Info("socket passed by systemd, used by the control socket or a tcp or tls serve listener with the same address")
+	}
+}
+
+// notifyReady sends READY=1 to systemd once the control socket is listening,
+// so that clients started after the daemon (e.g. ExecStartPost=) can connect.
+func notifyReady(ctx context.Context, log Logger, control *controlJob, numJobs int) {
+	if !systemd.NotifyEnabled() {
+		return
+	}
+	select {
+	case <-control.listening:
+	case <-ctx.Done():
+		return
+	}
+	err := systemd.Notify(systemd.StateReady, systemd.Status(fmt.Sprintf("running %d jobs", numJobs)))
+	if err != nil {
+		log.WithError(err).Error("cannot notify systemd")
+	}
+}
+
+// runWatchdog sends WATCHDOG=1 to systemd as long as the daemon is alive, i.e.,
+// as long as the goroutines of all jobs are running and all jobs respond to status requests.
+// If the daemon is not alive, the pings stop and systemd takes the action configured for watchdog failures.
+func runWatchdog(ctx context.Context, log Logger, jobs *jobs) {
+	interval, ok, err := systemd.WatchdogInterval()
+	if err != nil {
+		log.WithError(err).Error("cannot determine systemd watchdog interval")
+		return
+	}
+	if !ok {
+		return
+	}
+	interval /= 2
+	log.WithField("interval", interval).Info("sending systemd watchdog pings")
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := jobs.alive(interval); err != nil && ctx.Err() == nil {
+			log.WithError(err).Error("daemon is not alive, stopping systemd watchdog pings")
+			return
+		}
+		if err := systemd.Notify(systemd.StateWatchdog); err != nil {
+			log.WithError(err).Error("cannot send systemd watchdog ping")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// alive returns an error if a job has exited or if the jobs do not respond to status requests within timeout.
+func (s *jobs) alive(timeout time.Duration) error {
+	s.m.RLock()
+	var exited []string
+	for name := range s.exited {
+		exited = append(exited, name)
+	}
+	s.m.RUnlock()
+	if len(exited) > 0 {
+		sort.Strings(exited)
+		return fmt.Errorf("jobs exited: %v", exited)
+	}
+
+	responded := make(chan struct{})
+	go func() {
+		s.status()
+		close(responded)
+	}()
+	select {
+	case <-responded:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("jobs did not respond to status request within %s", timeout)
+	}
+}
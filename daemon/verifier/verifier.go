@@ -0,0 +1,315 @@
+// Package verifier implements a consistency audit between the sending and the receiving side of a replication setup.
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/replication/logic/diff"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+)
+
+// Try to keep it compatible with github.com/zrepl/zrepl/replication/logic.Sender
+type Sender interface {
+	ListFilesystems(ctx context.Context, req *pdu.ListFilesystemReq) (*pdu.ListFilesystemRes, error)
+	ListFilesystemVersions(ctx context.Context, req *pdu.ListFilesystemVersionsReq) (*pdu.ListFilesystemVersionsRes, error)
+	ReplicationCursor(ctx context.Context, req *pdu.ReplicationCursorReq) (*pdu.ReplicationCursorRes, error)
+}
+
+// Try to keep it compatible with github.com/zrepl/zrepl/replication/logic.Receiver
+type Receiver interface {
+	ListFilesystems(ctx context.Context, req *pdu.ListFilesystemReq) (*pdu.ListFilesystemRes, error)
+	ListFilesystemVersions(ctx context.Context, req *pdu.ListFilesystemVersionsReq) (*pdu.ListFilesystemVersionsRes, error)
+}
+
+type Logger = logger.Logger
+
+type contextKey int
+
+const contextKeyLogger contextKey = 0
+
+func WithLogger(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKeyLogger, log)
+}
+
+func GetLogger(ctx context.Context) Logger {
+	if l, ok := ctx.Value(contextKeyLogger).(Logger); ok {
+		return l
+	}
+	return logger.NewNullLogger()
+}
+
+type DriftKind string
+
+const (
+	// A snapshot that the sender considers replicated does not exist on the receiver.
+	// Considered replicated are the snapshots between the oldest snapshot
+	// common to both sides and the replication cursor.
+	DriftMissingOnReceiver DriftKind = "missing_on_receiver"
+	// The receiver has a snapshot with the same name but a different GUID.
+	DriftGUIDMismatch DriftKind = "guid_mismatch"
+	// The receiver has a snapshot that is more recent than the most recent
+	// snapshot common to both sides.
+	DriftReceiverOnlySnapshot DriftKind = "receiver_only_snapshot"
+	// The receiver has a filesystem that does not exist on the sender.
+	DriftReceiverOnlyFilesystem DriftKind = "receiver_only_filesystem"
+	// The snapshot the replication cursor points to does not exist on the receiver.
+	DriftCursorNotOnReceiver DriftKind = "cursor_not_on_receiver"
+)
+
+var DriftKinds = []DriftKind{
+	DriftMissingOnReceiver,
+	DriftGUIDMismatch,
+	DriftReceiverOnlySnapshot,
+	DriftReceiverOnlyFilesystem,
+	DriftCursorNotOnReceiver,
+}
+
+type Drift struct {
+	Kind DriftKind
+	// relative name (@snap or #bookmark), empty for DriftReceiverOnlyFilesystem
+	Version      string `json:",omitempty"`
+	SenderGUID   uint64 `json:",omitempty"`
+	ReceiverGUID uint64 `json:",omitempty"`
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftReceiverOnlyFilesystem:
+		return string(d.Kind)
+	case DriftGUIDMismatch:
+		return fmt.Sprintf("%s %s (sender guid %d, receiver guid %d)", d.Kind, d.Version, d.SenderGUID, d.ReceiverGUID)
+	default:
+		return fmt.Sprintf("%s %s", d.Kind, d.Version)
+	}
+}
+
+type FilesystemReport struct {
+	Filesystem string
+	// non-empty if the filesystem could not be verified
+	Error string `json:",omitempty"`
+	// number of sender snapshots that were verified to exist on the receiver
+	Verified int
+	Drift    []Drift
+}
+
+type Report struct {
+	StartAt, FinishAt time.Time
+	// non-empty if the verification could not be performed at all
+	Error       string `json:",omitempty"`
+	Filesystems []*FilesystemReport
+}
+
+// DriftCount returns the number of drift entries per kind, including zero counts for all DriftKinds.
+func (r *Report) DriftCount() map[DriftKind]int {
+	c := make(map[DriftKind]int, len(DriftKinds))
+	for _, k := range DriftKinds {
+		c[k] = 0
+	}
+	for _, fs := range r.Filesystems {
+		for _, d := range fs.Drift {
+			c[d.Kind]++
+		}
+	}
+	return c
+}
+
+// ErrorCount returns the number of filesystems that could not be verified.
+func (r *Report) ErrorCount() int {
+	n := 0
+	for _, fs := range r.Filesystems {
+		if fs.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Consistent returns true if the verification completed without errors and found no drift.
+func (r *Report) Consistent() bool {
+	if r.Error != "" || r.ErrorCount() > 0 {
+		return false
+	}
+	for _, n := range r.DriftCount() {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify compares the filesystems and filesystem versions of sender and receiver.
+// The returned report is never nil.
+func Verify(ctx context.Context, sender Sender, receiver Receiver) *Report {
+	r := &Report{StartAt: time.Now()}
+	defer func() { r.FinishAt = time.Now() }()
+	log := GetLogger(ctx)
+
+	sfss, err := sender.ListFilesystems(ctx, &pdu.ListFilesystemReq{})
+	if err != nil {
+		r.Error = fmt.Sprintf("cannot list sender filesystems: %s", err)
+		return r
+	}
+	rfss, err := receiver.ListFilesystems(ctx, &pdu.ListFilesystemReq{})
+	if err != nil {
+		r.Error = fmt.Sprintf("cannot list receiver filesystems: %s", err)
+		return r
+	}
+
+	rfsByPath := make(map[string]*pdu.Filesystem, len(rfss.GetFilesystems()))
+	for _, fs := range rfss.GetFilesystems() {
+		rfsByPath[fs.GetPath()] = fs
+	}
+
+	for _, sfs := range sfss.GetFilesystems() {
+		rfs := rfsByPath[sfs.GetPath()]
+		delete(rfsByPath, sfs.GetPath())
+		fsr := verifyFilesystem(ctx, sender, receiver, sfs.GetPath(), rfs != nil && !rfs.GetIsPlaceholder())
+		if fsr.Error != "" {
+			log.WithField("fs", fsr.Filesystem).WithField("err", fsr.Error).Error("cannot verify filesystem")
+		}
+		r.Filesystems = append(r.Filesystems, fsr)
+	}
+
+	for path, rfs := range rfsByPath {
+		if rfs.GetIsPlaceholder() {
+			continue
+		}
+		r.Filesystems = append(r.Filesystems, &FilesystemReport{
+			Filesystem: path,
+			Drift:      []Drift{{Kind: DriftReceiverOnlyFilesystem}},
+		})
+	}
+
+	sort.Slice(r.Filesystems, func(i, j int) bool {
+		return r.Filesystems[i].Filesystem < r.Filesystems[j].Filesystem
+	})
+	return r
+}
+
+func verifyFilesystem(ctx context.Context, sender Sender, receiver Receiver, path string, onReceiver bool) *FilesystemReport {
+	fsr := &FilesystemReport{Filesystem: path}
+
+	svs, err := sender.ListFilesystemVersions(ctx, &pdu.ListFilesystemVersionsReq{Filesystem: path})
+	if err != nil {
+		fsr.Error = fmt.Sprintf("cannot list sender filesystem versions: %s", err)
+		return fsr
+	}
+	var rvs []*pdu.FilesystemVersion
+	if onReceiver {
+		res, err := receiver.ListFilesystemVersions(ctx, &pdu.ListFilesystemVersionsReq{Filesystem: path})
+		if err != nil {
+			fsr.Error = fmt.Sprintf("cannot list receiver filesystem versions: %s", err)
+			return fsr
+		}
+		rvs = res.GetVersions()
+	}
+
+	cursorRes, err := sender.ReplicationCursor(ctx, &pdu.ReplicationCursorReq{
+		Filesystem: path,
+		Op:         &pdu.ReplicationCursorReq_Get{Get: &pdu.ReplicationCursorReq_GetOp{}},
+	})
+	if err != nil {
+		fsr.Error = fmt.Sprintf("cannot get replication cursor: %s", err)
+		return fsr
+	}
+
+	fsr.Verified, fsr.Drift = compare(svs.GetVersions(), rvs, cursorRes.GetGuid(), !cursorRes.GetNotexist())
+	return fsr
+}
+
+func snapshotsOnly(vs []*pdu.FilesystemVersion) []*pdu.FilesystemVersion {
+	ret := make([]*pdu.FilesystemVersion, 0, len(vs))
+	for _, v := range vs {
+		if v.Type == pdu.FilesystemVersion_Snapshot {
+			ret = append(ret, v)
+		}
+	}
+	return diff.SortVersionListByCreateTXGThenBookmarkLTSnapshot(ret)
+}
+
+// compare sender and receiver versions of a single filesystem.
+func compare(senderVersions, receiverVersions []*pdu.FilesystemVersion, cursorGUID uint64, cursorExists bool) (verified int, drift []Drift) {
+	snd := snapshotsOnly(senderVersions)
+	rcv := snapshotsOnly(receiverVersions)
+
+	rcvByGUID := make(map[uint64]*pdu.FilesystemVersion, len(rcv))
+	rcvByName := make(map[string]*pdu.FilesystemVersion, len(rcv))
+	for _, v := range rcv {
+		rcvByGUID[v.Guid] = v
+		rcvByName[v.Name] = v
+	}
+	sndByGUID := make(map[uint64]*pdu.FilesystemVersion, len(snd))
+	for _, v := range snd {
+		sndByGUID[v.Guid] = v
+	}
+
+	// the cursor is a bookmark, find the sender version it was created from
+	var cursor *pdu.FilesystemVersion
+	if cursorExists {
+		for _, v := range senderVersions {
+			if v.Guid == cursorGUID && (cursor == nil || v.Type == pdu.FilesystemVersion_Snapshot) {
+				cursor = v
+			}
+		}
+		if _, ok := rcvByGUID[cursorGUID]; !ok {
+			d := Drift{Kind: DriftCursorNotOnReceiver, SenderGUID: cursorGUID}
+			if cursor != nil {
+				d.Version = cursor.RelName()
+			}
+			drift = append(drift, d)
+		}
+	}
+
+	// The initial replication starts at the most recent snapshot,
+	// hence older snapshots were never sent.
+	// Only the range from the oldest common snapshot up to the cursor is expected on the receiver.
+	oldestCommon := -1
+	for i, v := range snd {
+		if _, ok := rcvByGUID[v.Guid]; ok {
+			oldestCommon = i
+			break
+		}
+	}
+	mismatched := make(map[uint64]bool)
+	if cursor != nil && oldestCommon != -1 {
+		for _, v := range snd[oldestCommon:] {
+			if v.CreateTXG > cursor.CreateTXG {
+				break
+			}
+			if _, ok := rcvByGUID[v.Guid]; ok {
+				verified++
+				continue
+			}
+			if rv, ok := rcvByName[v.Name]; ok {
+				mismatched[rv.Guid] = true
+				drift = append(drift, Drift{Kind: DriftGUIDMismatch, Version: v.RelName(), SenderGUID: v.Guid, ReceiverGUID: rv.Guid})
+			} else {
+				drift = append(drift, Drift{Kind: DriftMissingOnReceiver, Version: v.RelName(), SenderGUID: v.Guid})
+			}
+		}
+	}
+
+	// Receiver-only snapshots older than the most recent common snapshot are expected
+	// because the sender may prune more aggressively than the receiver.
+	mostRecentCommon := -1
+	for i := len(rcv) - 1; i >= 0; i-- {
+		if _, ok := sndByGUID[rcv[i].Guid]; ok {
+			mostRecentCommon = i
+			break
+		}
+	}
+	if mostRecentCommon != -1 {
+		for _, v := range rcv[mostRecentCommon+1:] {
+			if mismatched[v.Guid] {
+				continue // already reported as DriftGUIDMismatch
+			}
+			drift = append(drift, Drift{Kind: DriftReceiverOnlySnapshot, Version: v.RelName(), ReceiverGUID: v.Guid})
+		}
+	}
+
+	return verified, drift
+}
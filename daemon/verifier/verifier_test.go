@@ -0,0 +1,186 @@
+package verifier
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+)
+
+// fsvlist parses specs of the form "@name,guid" or "#name,guid"
+// where guid is also used as CreateTXG.
+func fsvlist(fsv ...string) (r []*pdu.FilesystemVersion) {
+	r = make([]*pdu.FilesystemVersion, len(fsv))
+	for i, f := range fsv {
+		split := strings.Split(f, ",")
+		if len(split) != 2 {
+			panic("invalid fsv spec")
+		}
+		id, err := strconv.Atoi(split[1])
+		if err != nil {
+			panic(err)
+		}
+		v := &pdu.FilesystemVersion{
+			Name:      split[0][1:],
+			Guid:      uint64(id),
+			CreateTXG: uint64(id),
+			Creation:  pdu.FilesystemVersionCreation(time.Unix(int64(id), 0)),
+		}
+		switch split[0][0] {
+		case '@':
+			v.Type = pdu.FilesystemVersion_Snapshot
+		case '#':
+			v.Type = pdu.FilesystemVersion_Bookmark
+		default:
+			panic("invalid character")
+		}
+		r[i] = v
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+
+	type testCase struct {
+		Name             string
+		Sender, Receiver []*pdu.FilesystemVersion
+		Cursor           uint64 // 0 means no cursor
+		ExpectVerified   int
+		ExpectDrift      []Drift
+	}
+
+	tcs := []testCase{
+		{
+			Name:     "consistent",
+			Sender:   fsvlist("@a,1", "@b,2", "@c,3", "#zrepl_replication_cursor,3"),
+			Receiver: fsvlist("@b,2", "@c,3"),
+			Cursor:   3,
+			// @a was never replicated (initial replication starts at most recent snapshot)
+			ExpectVerified: 2,
+		},
+		{
+			Name:           "not yet replicated",
+			Sender:         fsvlist("@a,1", "@b,2"),
+			Receiver:       nil,
+			ExpectVerified: 0,
+		},
+		{
+			Name:           "snapshots after cursor are not checked",
+			Sender:         fsvlist("@a,1", "@b,2", "#zrepl_replication_cursor,1"),
+			Receiver:       fsvlist("@a,1"),
+			Cursor:         1,
+			ExpectVerified: 1,
+		},
+		{
+			Name:           "missing on receiver",
+			Sender:         fsvlist("@a,1", "@b,2", "@c,3", "#zrepl_replication_cursor,3"),
+			Receiver:       fsvlist("@a,1", "@c,3"),
+			Cursor:         3,
+			ExpectVerified: 2,
+			ExpectDrift:    []Drift{{Kind: DriftMissingOnReceiver, Version: "@b", SenderGUID: 2}},
+		},
+		{
+			Name:           "guid mismatch",
+			Sender:         fsvlist("@a,1", "@b,2", "@c,3", "#zrepl_replication_cursor,3"),
+			Receiver:       fsvlist("@a,1", "@b,20", "@c,3"),
+			Cursor:         3,
+			ExpectVerified: 2,
+			ExpectDrift:    []Drift{{Kind: DriftGUIDMismatch, Version: "@b", SenderGUID: 2, ReceiverGUID: 20}},
+		},
+		{
+			Name:           "cursor not on receiver",
+			Sender:         fsvlist("@a,1", "@b,2", "#zrepl_replication_cursor,2"),
+			Receiver:       fsvlist("@a,1"),
+			Cursor:         2,
+			ExpectVerified: 1,
+			ExpectDrift: []Drift{
+				{Kind: DriftCursorNotOnReceiver, Version: "@b", SenderGUID: 2},
+				{Kind: DriftMissingOnReceiver, Version: "@b", SenderGUID: 2},
+			},
+		},
+		{
+			Name:           "receiver only snapshots",
+			Sender:         fsvlist("@b,2", "@c,3", "#zrepl_replication_cursor,3"),
+			Receiver:       fsvlist("@a,1", "@b,2", "@c,3", "@d,4"),
+			Cursor:         3,
+			ExpectVerified: 2,
+			// @a is older than the most recent common snapshot and was likely pruned on the sender
+			ExpectDrift: []Drift{{Kind: DriftReceiverOnlySnapshot, Version: "@d", ReceiverGUID: 4}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			verified, drift := compare(tc.Sender, tc.Receiver, tc.Cursor, tc.Cursor != 0)
+			assert.Equal(t, tc.ExpectVerified, verified)
+			assert.Equal(t, tc.ExpectDrift, drift)
+		})
+	}
+}
+
+type mockEndpoint struct {
+	fss      []*pdu.Filesystem
+	versions map[string][]*pdu.FilesystemVersion
+	cursors  map[string]uint64
+}
+
+func (e *mockEndpoint) ListFilesystems(ctx context.Context, req *pdu.ListFilesystemReq) (*pdu.ListFilesystemRes, error) {
+	return &pdu.ListFilesystemRes{Filesystems: e.fss}, nil
+}
+
+func (e *mockEndpoint) ListFilesystemVersions(ctx context.Context, req *pdu.ListFilesystemVersionsReq) (*pdu.ListFilesystemVersionsRes, error) {
+	return &pdu.ListFilesystemVersionsRes{Versions: e.versions[req.GetFilesystem()]}, nil
+}
+
+func (e *mockEndpoint) ReplicationCursor(ctx context.Context, req *pdu.ReplicationCursorReq) (*pdu.ReplicationCursorRes, error) {
+	guid, ok := e.cursors[req.GetFilesystem()]
+	if !ok {
+		return &pdu.ReplicationCursorRes{Result: &pdu.ReplicationCursorRes_Notexist{Notexist: true}}, nil
+	}
+	return &pdu.ReplicationCursorRes{Result: &pdu.ReplicationCursorRes_Guid{Guid: guid}}, nil
+}
+
+func TestVerify(t *testing.T) {
+	sender := &mockEndpoint{
+		fss: []*pdu.Filesystem{{Path: "pool/a"}, {Path: "pool/b"}},
+		versions: map[string][]*pdu.FilesystemVersion{
+			"pool/a": fsvlist("@1,1", "@2,2", "#zrepl_replication_cursor,2"),
+			"pool/b": fsvlist("@1,10"),
+		},
+		cursors: map[string]uint64{"pool/a": 2},
+	}
+	receiver := &mockEndpoint{
+		fss: []*pdu.Filesystem{
+			{Path: "pool", IsPlaceholder: true},
+			{Path: "pool/a"},
+			{Path: "pool/stray"},
+		},
+		versions: map[string][]*pdu.FilesystemVersion{
+			"pool/a": fsvlist("@2,2"),
+		},
+	}
+
+	r := Verify(context.Background(), sender, receiver)
+	require.Empty(t, r.Error)
+	require.Len(t, r.Filesystems, 3)
+
+	assert.Equal(t, "pool/a", r.Filesystems[0].Filesystem)
+	assert.Equal(t, 1, r.Filesystems[0].Verified)
+	assert.Empty(t, r.Filesystems[0].Drift)
+
+	assert.Equal(t, "pool/b", r.Filesystems[1].Filesystem)
+	assert.Empty(t, r.Filesystems[1].Drift)
+
+	assert.Equal(t, "pool/stray", r.Filesystems[2].Filesystem)
+	assert.Equal(t, []Drift{{Kind: DriftReceiverOnlyFilesystem}}, r.Filesystems[2].Drift)
+
+	assert.Equal(t, 1, r.DriftCount()[DriftReceiverOnlyFilesystem])
+	assert.Equal(t, 0, r.DriftCount()[DriftMissingOnReceiver])
+	assert.False(t, r.Consistent())
+}
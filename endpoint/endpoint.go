@@ -5,7 +5,10 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
 	"github.com/zrepl/zrepl/replication/logic/pdu"
@@ -17,14 +20,16 @@ import (
 
 // Sender implements replication.ReplicationEndpoint for a sending side
 type Sender struct {
-	FSFilter zfs.DatasetFilter
+	FSFilter           zfs.DatasetFilter
+	allowedSendOptions *pdu.SendOptions
 }
 
-func NewSender(fsf zfs.DatasetFilter) *Sender {
-	return &Sender{FSFilter: fsf}
+// allowedSendOptions are the zfs send flags that clients may request, nil means none.
+func NewSender(fsf zfs.DatasetFilter, allowedSendOptions *pdu.SendOptions) *Sender {
+	return &Sender{FSFilter: fsf, allowedSendOptions: allowedSendOptions}
 }
 
-func (s *Sender) filterCheckFS(fs string) (*zfs.DatasetPath, error) {
+func (s *Sender) filterCheckFS(ctx context.Context, fs string) (*zfs.DatasetPath, error) {
 	dp, err := zfs.NewDatasetPath(fs)
 	if err != nil {
 		return nil, err
@@ -32,7 +37,7 @@ func (s *Sender) filterCheckFS(fs string) (*zfs.DatasetPath, error) {
 	if dp.Length() == 0 {
 		return nil, errors.New("empty filesystem not allowed")
 	}
-	pass, err := s.FSFilter.Filter(dp)
+	pass, err := zfs.FilterDataset(ctx, s.FSFilter, dp)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +65,7 @@ func (s *Sender) ListFilesystems(ctx context.Context, r *pdu.ListFilesystemReq)
 }
 
 func (s *Sender) ListFilesystemVersions(ctx context.Context, r *pdu.ListFilesystemVersionsReq) (*pdu.ListFilesystemVersionsRes, error) {
-	lp, err := s.filterCheckFS(r.GetFilesystem())
+	lp, err := s.filterCheckFS(ctx, r.GetFilesystem())
 	if err != nil {
 		return nil, err
 	}
@@ -80,10 +85,13 @@ func (s *Sender) ListFilesystemVersions(ctx context.Context, r *pdu.ListFilesyst
 var maxConcurrentZFSSendSemaphore = semaphore.New(envconst.Int64("ZREPL_ENDPOINT_MAX_CONCURRENT_SEND", 10))
 
 func (s *Sender) Send(ctx context.Context, r *pdu.SendReq) (*pdu.SendRes, zfs.StreamCopier, error) {
-	_, err := s.filterCheckFS(r.Filesystem)
+	_, err := s.filterCheckFS(ctx, r.Filesystem)
 	if err != nil {
 		return nil, nil, err
 	}
+	if unsupported := r.GetOptions().Unsupported(s.allowedSendOptions); len(unsupported) > 0 {
+		return nil, nil, fmt.Errorf("sender does not allow zfs send flags %s", strings.Join(unsupported, " "))
+	}
 
 	getLogger(ctx).Debug("acquire concurrent send semaphore")
 	// TODO use try-acquire and fail with resource-exhaustion rpc status
@@ -95,7 +103,21 @@ func (s *Sender) Send(ctx context.Context, r *pdu.SendReq) (*pdu.SendRes, zfs.St
 	}
 	defer guard.Release()
 
-	si, err := zfs.ZFSSendDry(r.Filesystem, r.From, r.To, "")
+	var token string
+	if r.ResumeToken != "" {
+		matches, err := resumeTokenMatches(ctx, r)
+		if err != nil {
+			// fall back to a regular send, the receiver will clear its resume state
+			getLogger(ctx).WithError(err).Warn("cannot use resume token, falling back to regular send")
+		} else if !matches {
+			getLogger(ctx).Info("resume token does not match requested send, falling back to regular send")
+		} else {
+			token = r.ResumeToken
+		}
+	}
+
+	flags := r.GetOptions().ZFSSendFlags()
+	si, err := zfs.ZFSSendDry(r.Filesystem, r.From, r.To, token, flags)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -104,21 +126,53 @@ func (s *Sender) Send(ctx context.Context, r *pdu.SendReq) (*pdu.SendRes, zfs.St
 	if si.SizeEstimate != -1 { // but si returns -1 for no size estimate
 		expSize = si.SizeEstimate
 	}
-	res := &pdu.SendRes{ExpectedSize: expSize}
+	res := &pdu.SendRes{ExpectedSize: expSize, UsedResumeToken: token != ""}
 
 	if r.DryRun {
 		return res, nil, nil
 	}
 
-	streamCopier, err := zfs.ZFSSend(ctx, r.Filesystem, r.From, r.To, "")
+	streamCopier, err := zfs.ZFSSend(ctx, r.Filesystem, r.From, r.To, token, flags)
 	if err != nil {
 		return nil, nil, err
 	}
 	return res, streamCopier, nil
 }
 
+// resumeTokenMatches returns true iff r.ResumeToken continues a send of
+// r.Filesystem from r.From (full send if empty) to r.To.
+func resumeTokenMatches(ctx context.Context, r *pdu.SendReq) (bool, error) {
+	t, err := zfs.ParseResumeToken(ctx, r.ResumeToken)
+	if err != nil {
+		return false, err
+	}
+	guidOf := func(v string) (uint64, error) {
+		abs := r.Filesystem + v
+		props, err := zfs.ZFSGetCreateTXGAndGuid(abs)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot get guid of %q", abs)
+		}
+		return props.Guid, nil
+	}
+	toGUID, err := guidOf(r.To)
+	if err != nil {
+		return false, err
+	}
+	if t.ToGUID != toGUID {
+		return false, nil
+	}
+	if r.From == "" {
+		return !t.HasFromGUID, nil
+	}
+	fromGUID, err := guidOf(r.From)
+	if err != nil {
+		return false, err
+	}
+	return t.HasFromGUID && t.FromGUID == fromGUID, nil
+}
+
 func (p *Sender) DestroySnapshots(ctx context.Context, req *pdu.DestroySnapshotsReq) (*pdu.DestroySnapshotsRes, error) {
-	dp, err := p.filterCheckFS(req.Filesystem)
+	dp, err := p.filterCheckFS(ctx, req.Filesystem)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +183,8 @@ func (p *Sender) Ping(ctx context.Context, req *pdu.PingReq) (*pdu.PingRes, erro
 	res := pdu.PingRes{
 		Echo: req.GetMessage(),
 	}
+	// not set if the endpoint is used locally, e.g. by the active side
+	res.ClientIdentity, _ = ctx.Value(ClientIdentityKey).(string)
 	return &res, nil
 }
 
@@ -141,7 +197,7 @@ func (p *Sender) WaitForConnectivity(ctx context.Context) error {
 }
 
 func (p *Sender) ReplicationCursor(ctx context.Context, req *pdu.ReplicationCursorReq) (*pdu.ReplicationCursorRes, error) {
-	dp, err := p.filterCheckFS(req.Filesystem)
+	dp, err := p.filterCheckFS(ctx, req.Filesystem)
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +223,10 @@ func (p *Sender) ReplicationCursor(ctx context.Context, req *pdu.ReplicationCurs
 	}
 }
 
+func (p *Sender) ReceiverCapabilities(ctx context.Context, req *pdu.ReceiverCapabilitiesReq) (*pdu.ReceiverCapabilitiesRes, error) {
+	return nil, fmt.Errorf("sender does not implement ReceiverCapabilities()")
+}
+
 func (p *Sender) Receive(ctx context.Context, r *pdu.ReceiveReq, receive zfs.StreamCopier) (*pdu.ReceiveRes, error) {
 	return nil, fmt.Errorf("sender does not implement Receive()")
 }
@@ -187,17 +247,24 @@ type FSMap interface { // FIXME unused
 type Receiver struct {
 	rootWithoutClientComponent *zfs.DatasetPath
 	appendClientIdentity       bool
+	acceptedSendOptions        *pdu.SendOptions
+	savePartialRecvState       bool
 
 	recvParentCreationMtx *chainlock.L
 }
 
-func NewReceiver(rootDataset *zfs.DatasetPath, appendClientIdentity bool) *Receiver {
+// acceptedSendOptions are the zfs send flags the receiver declares to handle, nil means none.
+// If savePartialRecvState is set, interrupted receives keep their partial state and
+// are resumed using the receive_resume_token (requires ZFS with resumable send & recv).
+func NewReceiver(rootDataset *zfs.DatasetPath, appendClientIdentity bool, acceptedSendOptions *pdu.SendOptions, savePartialRecvState bool) *Receiver {
 	if rootDataset.Length() <= 0 {
 		panic(fmt.Sprintf("root dataset must not be an empty path: %v", rootDataset))
 	}
 	return &Receiver{
 		rootWithoutClientComponent: rootDataset.Copy(),
 		appendClientIdentity:       appendClientIdentity,
+		acceptedSendOptions:        acceptedSendOptions,
+		savePartialRecvState:       savePartialRecvState,
 		recvParentCreationMtx:      chainlock.New(),
 	}
 }
@@ -282,8 +349,16 @@ func (s *Receiver) ListFilesystems(ctx context.Context, req *pdu.ListFilesystemR
 			err := errors.Errorf("inconsistent placeholder state: filesystem %q must exist in this context", a.ToString())
 			return nil, err
 		}
+		var token string
+		if !ph.IsPlaceholder && s.savePartialRecvState {
+			token, err = zfs.ZFSGetReceiveResumeToken(a)
+			if err != nil {
+				l.WithError(err).Error("error getting receive resume token")
+				return nil, errors.Wrapf(err, "cannot get receive resume token for fs %q", a)
+			}
+		}
 		a.TrimPrefix(root)
-		fss = append(fss, &pdu.Filesystem{Path: a.ToString(), IsPlaceholder: ph.IsPlaceholder})
+		fss = append(fss, &pdu.Filesystem{Path: a.ToString(), IsPlaceholder: ph.IsPlaceholder, ResumeToken: token})
 	}
 	if len(fss) == 0 {
 		getLogger(ctx).Debug("no filesystems found")
@@ -316,6 +391,8 @@ func (s *Receiver) Ping(ctx context.Context, req *pdu.PingReq) (*pdu.PingRes, er
 	res := pdu.PingRes{
 		Echo: req.GetMessage(),
 	}
+	// not set if the endpoint is used locally, e.g. by the active side
+	res.ClientIdentity, _ = ctx.Value(ClientIdentityKey).(string)
 	return &res, nil
 }
 
@@ -335,12 +412,25 @@ func (s *Receiver) Send(ctx context.Context, req *pdu.SendReq) (*pdu.SendRes, zf
 	return nil, nil, fmt.Errorf("receiver does not implement Send()")
 }
 
+func (s *Receiver) ReceiverCapabilities(ctx context.Context, req *pdu.ReceiverCapabilitiesReq) (*pdu.ReceiverCapabilitiesRes, error) {
+	accepted := &pdu.SendOptions{}
+	if s.acceptedSendOptions != nil {
+		accepted = proto.Clone(s.acceptedSendOptions).(*pdu.SendOptions)
+	}
+	return &pdu.ReceiverCapabilitiesRes{AcceptedSendOptions: accepted}, nil
+}
+
 var maxConcurrentZFSRecvSemaphore = semaphore.New(envconst.Int64("ZREPL_ENDPOINT_MAX_CONCURRENT_RECV", 10))
 
 func (s *Receiver) Receive(ctx context.Context, req *pdu.ReceiveReq, receive zfs.StreamCopier) (*pdu.ReceiveRes, error) {
 	getLogger(ctx).Debug("incoming Receive")
 	defer receive.Close()
 
+	// clients that predate ReceiveReq.Options are only checked by their planner
+	if unsupported := req.GetOptions().Unsupported(s.acceptedSendOptions); len(unsupported) > 0 {
+		return nil, fmt.Errorf("receiver does not accept streams sent with zfs send flags %s", strings.Join(unsupported, " "))
+	}
+
 	root := s.clientRootFromCtx(ctx)
 	lp, err := subroot{root}.MapToLocal(req.Filesystem)
 	if err != nil {
@@ -408,30 +498,46 @@ func (s *Receiver) Receive(ctx context.Context, req *pdu.ReceiveReq, receive zfs
 		return nil, visitErr
 	}
 
+	getLogger(ctx).Debug("acquire concurrent recv semaphore")
+	// TODO use try-acquire and fail with resource-exhaustion rpc status
+	// => would require handling on the client-side
+	// => this is a dataconn endpoint, doesn't have the status code semantics of gRPC
+	guard, err := maxConcurrentZFSRecvSemaphore.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer guard.Release()
+
+	// The dataset lock is taken only after the semaphore so that snapshotting and pruning
+	// of lp are not blocked while this receive is queued behind other receives.
+	// It covers the placeholder / resume token handling and the zfs recv itself,
+	// since a concurrent snapshot or destroy on lp would make the receive fail.
+	unlock, err := lockDataset(ctx, lp, zfs.DatasetLockOpReceive)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	// determine whether we need to rollback the filesystem / change its placeholder state
 	var clearPlaceholderProperty bool
-	var recvOpts zfs.RecvOptions
+	recvOpts := zfs.RecvOptions{SavePartialRecvState: s.savePartialRecvState}
 	ph, err := zfs.ZFSGetFilesystemPlaceholderState(lp)
 	if err == nil && ph.FSExists && ph.IsPlaceholder {
 		recvOpts.RollbackAndForceRecv = true
 		clearPlaceholderProperty = true
 	}
+	if err == nil && ph.FSExists && !ph.IsPlaceholder && req.ClearResumeToken && s.savePartialRecvState {
+		// the sender did not resume from our partial receive state => discard it
+		if err := zfs.ZFSRecvClearResumeToken(lp.ToString()); err != nil {
+			return nil, err
+		}
+	}
 	if clearPlaceholderProperty {
 		if err := zfs.ZFSSetPlaceholder(lp, false); err != nil {
 			return nil, fmt.Errorf("cannot clear placeholder property for forced receive: %s", err)
 		}
 	}
 
-	getLogger(ctx).Debug("acquire concurrent recv semaphore")
-	// TODO use try-acquire and fail with resource-exhaustion rpc status
-	// => would require handling on the client-side
-	// => this is a dataconn endpoint, doesn't have the status code semantics of gRPC
-	guard, err := maxConcurrentZFSRecvSemaphore.Acquire(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer guard.Release()
-
 	getLogger(ctx).WithField("opts", fmt.Sprintf("%#v", recvOpts)).Debug("start receive command")
 
 	if err := zfs.ZFSRecv(ctx, lp.ToString(), receive, recvOpts); err != nil {
@@ -453,6 +559,17 @@ func (s *Receiver) DestroySnapshots(ctx context.Context, req *pdu.DestroySnapsho
 	return doDestroySnapshots(ctx, lp, req.Snapshots)
 }
 
+// lockDataset serializes op with snapshotting, pruning and receiving of other jobs on lp
+func lockDataset(ctx context.Context, lp *zfs.DatasetPath, op string) (unlock func(), err error) {
+	return zfs.LockDataset(ctx, lp.ToString(), op, func(heldBy string, heldSince time.Time) {
+		getLogger(ctx).
+			WithField("fs", lp.ToString()).
+			WithField("held_by", heldBy).
+			WithField("held_since", heldSince).
+			Info("waiting for dataset lock")
+	})
+}
+
 func doDestroySnapshots(ctx context.Context, lp *zfs.DatasetPath, snaps []*pdu.FilesystemVersion) (*pdu.DestroySnapshotsRes, error) {
 	reqs := make([]*zfs.DestroySnapOp, len(snaps))
 	ress := make([]*pdu.DestroySnapshotRes, len(snaps))
@@ -471,7 +588,12 @@ func doDestroySnapshots(ctx context.Context, lp *zfs.DatasetPath, snaps []*pdu.F
 			ErrOut:     &errs[i],
 		}
 	}
+	unlock, err := lockDataset(ctx, lp, zfs.DatasetLockOpPrune)
+	if err != nil {
+		return nil, err
+	}
 	zfs.ZFSDestroyFilesystemVersions(reqs)
+	unlock()
 	for i := range reqs {
 		if errs[i] != nil {
 			if de, ok := errs[i].(*zfs.DestroySnapshotsError); ok && len(de.Reason) == 1 {
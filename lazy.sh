@@ -27,15 +27,16 @@ fi
 CHECKOUTPATH="${GOPATH}/src/github.com/zrepl/zrepl"
 
 godep() {
-    step "install build dependencies (versions pinned in build/go.mod and build/tools.go)"
+    step "install build dependencies (versions pinned in build/go.mod and build/tools.go, golangci-lint below)"
     pushd "$(dirname "${BASH_SOURCE[0]}")"/build
     set -x
-    export GO111MODULE=on # otherwise, a checkout of this repo in GOPATH will disable modules on Go 1.12 and earlier
+    export GO111MODULE=on # in case the environment disables modules
     go build -v -mod=readonly -o "$GOPATH/bin/stringer"      golang.org/x/tools/cmd/stringer
     go build -v -mod=readonly -o "$GOPATH/bin/protoc-gen-go" github.com/golang/protobuf/protoc-gen-go
     go build -v -mod=readonly -o "$GOPATH/bin/enumer"        github.com/alvaroloes/enumer
     go build -v -mod=readonly -o "$GOPATH/bin/goimports"     golang.org/x/tools/cmd/goimports
-    go build -v -mod=readonly -o "$GOPATH/bin/golangci-lint" github.com/golangci/golangci-lint/cmd/golangci-lint
+    # golangci-lint advises against tracking it in a go.mod, so its version is pinned here
+    GOBIN="$GOPATH/bin" go install github.com/golangci/golangci-lint/cmd/golangci-lint@v1.64.8
     set +x
     popd
     if ! type stringer || ! type protoc-gen-go || ! type enumer || ! type goimports || ! type golangci-lint; then
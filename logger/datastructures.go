@@ -82,7 +82,7 @@ func (l Level) String() string {
 	case Error:
 		return "error"
 	default:
-		return string(l)
+		return fmt.Sprintf("%d", l)
 	}
 }
 
@@ -135,6 +135,12 @@ type Outlet interface {
 	WriteEntry(entry Entry) error
 }
 
+// An Outlet that implements EntryFilter only receives the entries it accepts.
+// Accept is called synchronously by the logging goroutine and must be cheap.
+type EntryFilter interface {
+	Accept(entry Entry) bool
+}
+
 type Outlets struct {
 	mtx  sync.RWMutex
 	outs map[Level][]Outlet
@@ -80,12 +80,17 @@ func (l *loggerImpl) log(level Level, msg string) {
 
 	louts := l.outlets.Get(level)
 	ech := make(chan outletResult, len(louts))
+	started := 0
 	for i := range louts {
+		if f, ok := louts[i].(EntryFilter); ok && !f.Accept(entry) {
+			continue
+		}
+		started++
 		go func(outlet Outlet, entry Entry) {
 			ech <- outletResult{outlet, outlet.WriteEntry(entry)}
 		}(louts[i], entry)
 	}
-	for fin := 0; fin < len(louts); fin++ {
+	for fin := 0; fin < started; fin++ {
 		res := <-ech
 		if res.Error != nil {
 			l.logInternalError(res.Outlet, res.Error.Error())
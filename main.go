@@ -11,10 +11,15 @@ func init() {
 	cli.AddSubcommand(daemon.DaemonCmd)
 	cli.AddSubcommand(client.StatusCmd)
 	cli.AddSubcommand(client.SignalCmd)
+	cli.AddSubcommand(client.VerifyCmd)
+	cli.AddSubcommand(client.HistoryCmd)
+	cli.AddSubcommand(client.LogsCmd)
+	cli.AddSubcommand(client.LogLevelCmd)
 	cli.AddSubcommand(client.StdinserverCmd)
 	cli.AddSubcommand(client.ConfigcheckCmd)
 	cli.AddSubcommand(client.VersionCmd)
 	cli.AddSubcommand(client.PprofCmd)
+	cli.AddSubcommand(client.DebugCmd)
 	cli.AddSubcommand(client.TestCmd)
 	cli.AddSubcommand(client.MigrateCmd)
 }
@@ -89,6 +89,8 @@ type attempt struct {
 	// if both are nil, it must be assumed that Planner.Plan is active
 	planErr *timedError
 	fss     []*fs
+
+	rate rateEstimator
 }
 
 type timedError struct {
@@ -160,12 +162,17 @@ type fs struct {
 		// index into steps, pointing at the step that is currently executing
 		// if step >= len(steps), no more work needs to be done
 		step int
+		// true if the run was paused before steps[step] was started
+		paused bool
 	}
+
+	rate rateEstimator
 }
 
 type step struct {
 	l    *chainlock.L
 	step Step
+	rate rateEstimator
 }
 
 type ReportFunc func() *report.Report
@@ -175,7 +182,6 @@ var maxAttempts = envconst.Int64("ZREPL_REPLICATION_MAX_ATTEMPTS", 3)
 var reconnectHardFailTimeout = envconst.Duration("ZREPL_REPLICATION_RECONNECT_HARD_FAIL_TIMEOUT", 10*time.Minute)
 
 func Do(ctx context.Context, planner Planner) (ReportFunc, WaitFunc) {
-	log := getLog(ctx)
 	l := chainlock.New()
 	run := &run{
 		l:         l,
@@ -186,13 +192,18 @@ func Do(ctx context.Context, planner Planner) (ReportFunc, WaitFunc) {
 	go func() {
 		defer close(done)
 
+		ctx, runSpan := startSpan(ctx, "replication.run")
+		defer runSpan.End()
+		log := getLog(ctx)
+
 		defer run.l.Lock().Unlock()
 		log.Debug("begin run")
 		defer log.Debug("run ended")
 		var prev *attempt
-		mainLog := log
 		for ano := 0; ano < int(maxAttempts) || maxAttempts == 0; ano++ {
-			log := mainLog.WithField("attempt_number", ano)
+			ctx, attemptSpan := startSpan(ctx, "replication.attempt")
+			attemptSpan.SetAttribute("attempt_number", ano)
+			log := getLog(ctx).WithField("attempt_number", ano)
 			log.Debug("start attempt")
 
 			run.waitReconnect.SetZero()
@@ -210,6 +221,8 @@ func Do(ctx context.Context, planner Planner) (ReportFunc, WaitFunc) {
 			})
 			prev = cur
 			if ctx.Err() != nil {
+				attemptSpan.SetError(ctx.Err())
+				attemptSpan.End()
 				log.WithError(ctx.Err()).Info("context error")
 				return
 			}
@@ -218,11 +231,20 @@ func Do(ctx context.Context, planner Planner) (ReportFunc, WaitFunc) {
 			rep := cur.report()
 			log.WithField("attempt_state", rep.State).Debug("attempt state")
 			errRep := cur.errorReport()
+			attemptSpan.SetAttribute("attempt_state", string(rep.State))
+			if err := errRep.AnyError(); err != nil {
+				attemptSpan.SetError(err.Err)
+			}
+			attemptSpan.End()
 
 			if rep.State == report.AttemptDone {
 				log.Debug("attempt completed successfully")
 				break
 			}
+			if pauseRequested(ctx) {
+				log.WithField("attempt_state", rep.State).Info("pause requested, ending run at step boundary")
+				break
+			}
 
 			mostRecentErr, mostRecentErrClass := errRep.MostRecent()
 			log.WithField("most_recent_err", mostRecentErr).WithField("most_recent_err_class", mostRecentErrClass).Debug("most recent error used for re-connect decision")
@@ -364,16 +386,31 @@ func (a *attempt) do(ctx context.Context, prev *attempt) {
 			f.do(ctx, stepQueue, prevs[f])
 		}(f)
 	}
+	stopSampling := make(chan struct{})
+	go a.sampleProgressPeriodically(stopSampling)
 	a.l.DropWhile(func() {
 		fssesDone.Wait()
 	})
+	close(stopSampling)
 	a.finishedAt = time.Now()
 }
 
 func (fs *fs) do(ctx context.Context, pq *stepQueue, prev *fs) {
+	ctx, span := startSpan(ctx, "replication.fs")
+	span.SetAttribute("filesystem", fs.fs.ReportInfo().Name)
+	defer span.End()
+
 	psteps, err := fs.fs.PlanFS(ctx)
 	errTime := time.Now()
 	defer fs.l.Lock().Unlock()
+	defer func() {
+		// fs.l is held
+		if fs.planning.err != nil {
+			span.SetError(fs.planning.err.Err)
+		} else if fs.planned.stepErr != nil {
+			span.SetError(fs.planned.stepErr.Err)
+		}
+	}()
 	debug := debugPrefix("fs=%s", fs.fs.ReportInfo().Name)
 	fs.planning.done = true
 	if err != nil {
@@ -445,14 +482,30 @@ func (fs *fs) do(ctx context.Context, pq *stepQueue, prev *fs) {
 		var (
 			err     error
 			errTime time.Time
+			paused  bool
 		)
 		// lock must not be held while executing step in order for reporting to work
 		fs.l.DropWhile(func() {
+			ctx, span := startSpan(ctx, "replication.step")
+			defer span.End()
+			info := s.step.ReportInfo()
+			span.SetAttribute("from", info.From)
+			span.SetAttribute("to", info.To)
 			targetDate := s.step.TargetDate()
 			defer pq.WaitReady(fs, targetDate)()
+			if pauseRequested(ctx) {
+				paused = true
+				return
+			}
 			err = s.step.Step(ctx) // no shadow
 			errTime = time.Now()   // no shadow
+			span.SetError(err)
 		})
+		if paused {
+			debug("paused before step %d", i)
+			fs.planned.paused = true
+			break
+		}
 		if err != nil {
 			fs.planned.stepErr = newTimedError(err, errTime)
 			break
@@ -491,6 +544,8 @@ func (a *attempt) report() *report.AttemptReport {
 	for i := range r.Filesystems {
 		r.Filesystems[i] = a.fss[i].report()
 	}
+	expected, replicated := r.BytesSum()
+	r.Progress = a.rate.progress(time.Now(), expected, replicated)
 
 	state := report.AttemptPlanning
 	if a.planErr != nil {
@@ -499,13 +554,16 @@ func (a *attempt) report() *report.AttemptReport {
 		if a.finishedAt.IsZero() {
 			state = report.AttemptFanOutFSs
 		} else {
-			fsWithError := false
+			fsWithError, fsPaused := false, false
 			for _, s := range r.Filesystems {
 				fsWithError = fsWithError || s.Error() != nil
+				fsPaused = fsPaused || s.State == report.FilesystemPaused
 			}
 			state = report.AttemptDone
 			if fsWithError {
 				state = report.AttemptFanOutError
+			} else if fsPaused {
+				state = report.AttemptPaused
 			}
 		}
 	}
@@ -521,6 +579,8 @@ func (f *fs) report() *report.FilesystemReport {
 		if f.planning.done {
 			if f.planned.stepErr != nil {
 				state = report.FilesystemSteppingErrored
+			} else if f.planned.paused {
+				state = report.FilesystemPaused
 			} else if f.planned.step < len(f.planned.steps) {
 				state = report.FilesystemStepping
 			} else {
@@ -541,6 +601,8 @@ func (f *fs) report() *report.FilesystemReport {
 	for i := range r.Steps {
 		r.Steps[i] = f.planned.steps[i].report()
 	}
+	expected, replicated := r.BytesSum()
+	r.Progress = f.rate.progress(time.Now(), expected, replicated)
 	return r
 }
 
@@ -549,6 +611,7 @@ func (s *step) report() *report.StepReport {
 	r := &report.StepReport{
 		Info: s.step.ReportInfo(),
 	}
+	r.Progress = s.rate.progress(time.Now(), r.Info.BytesExpected, r.Info.BytesReplicated)
 	return r
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/tracing"
 )
 
 type Logger = logger.Logger
@@ -23,3 +24,9 @@ func getLog(ctx context.Context) Logger {
 func WithLogger(ctx context.Context, log Logger) context.Context {
 	return context.WithValue(ctx, contexKeyLogger, log)
 }
+
+// startSpan starts a span and sets its IDs as fields of the logger in the returned context
+func startSpan(ctx context.Context, name string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, name, tracing.SpanKindInternal)
+	return WithLogger(ctx, tracing.WithLogFields(ctx, getLog(ctx))), span
+}
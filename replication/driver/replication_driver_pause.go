@@ -0,0 +1,28 @@
+package driver
+
+import (
+	"context"
+)
+
+const contexKeyPause contexKey = contexKeyLogger + 1
+
+// WithPause returns a context that makes a replication run started by Do
+// stop at the next step boundary once pause is closed:
+// steps that are already executing run to completion,
+// but no further steps are started and no further attempts are made.
+func WithPause(ctx context.Context, pause <-chan struct{}) context.Context {
+	return context.WithValue(ctx, contexKeyPause, pause)
+}
+
+func pauseRequested(ctx context.Context) bool {
+	pause, ok := ctx.Value(contexKeyPause).(<-chan struct{})
+	if !ok {
+		return false
+	}
+	select {
+	case <-pause:
+		return true
+	default:
+		return false
+	}
+}
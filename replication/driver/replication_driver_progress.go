@@ -0,0 +1,105 @@
+package driver
+
+import (
+	"math"
+	"time"
+
+	"github.com/zrepl/zrepl/replication/report"
+	"github.com/zrepl/zrepl/util/envconst"
+)
+
+var progressSampleInterval = envconst.Duration("ZREPL_REPLICATION_PROGRESS_SAMPLE_INTERVAL", 1*time.Second)
+
+// samples older than this have a weight of less than 1/e in the moving average
+var progressAverageWindow = envconst.Duration("ZREPL_REPLICATION_PROGRESS_AVERAGE_WINDOW", 10*time.Second)
+
+// rateEstimator computes an exponentially weighted moving average
+// of the rate at which a byte counter increases.
+type rateEstimator struct {
+	lastAt    time.Time
+	lastBytes int64
+	bps       float64
+	valid     bool
+}
+
+func (e *rateEstimator) sample(now time.Time, bytes int64) {
+	if e.lastAt.IsZero() {
+		e.lastAt, e.lastBytes = now, bytes
+		return
+	}
+	dt := now.Sub(e.lastAt)
+	if dt <= 0 {
+		return
+	}
+	rate := float64(bytes-e.lastBytes) / dt.Seconds()
+	if rate < 0 {
+		rate = 0 // counters should not decrease, but don't trust them
+	}
+	if e.valid {
+		// weight the sample by the time it covers so that irregular sampling does not skew the average
+		alpha := 1 - math.Exp(-dt.Seconds()/progressAverageWindow.Seconds())
+		e.bps = alpha*rate + (1-alpha)*e.bps
+	} else {
+		e.bps = rate
+		e.valid = true
+	}
+	e.lastAt, e.lastBytes = now, bytes
+}
+
+// progress returns nil if there are not enough samples yet
+func (e *rateEstimator) progress(now time.Time, expected, replicated int64) *report.Progress {
+	if !e.valid {
+		return nil
+	}
+	p := &report.Progress{BytesPerSecond: int64(e.bps)}
+	if remaining := expected - replicated; remaining > 0 && e.bps >= 1 {
+		p.ETA = now.Add(time.Duration(float64(remaining) / e.bps * float64(time.Second)))
+	}
+	return p
+}
+
+// caller must hold lock l
+func (a *attempt) sampleProgress(now time.Time) {
+	var replicated int64
+	for _, fs := range a.fss {
+		if !fs.planning.done || fs.planning.err != nil {
+			continue
+		}
+		var fsReplicated int64
+		for i, s := range fs.planned.steps {
+			info := s.step.ReportInfo()
+			fsReplicated += info.BytesReplicated
+			if i == fs.planned.step {
+				s.rate.sample(now, info.BytesReplicated)
+			}
+		}
+		replicated += fsReplicated
+		// don't let the average of a filesystem decay after it stopped
+		if fs.planned.stepErr == nil && !fs.planned.paused && fs.planned.step < len(fs.planned.steps) {
+			fs.rate.sample(now, fsReplicated)
+		}
+	}
+	a.rate.sample(now, replicated)
+}
+
+// samples progress until stop is closed
+func (a *attempt) sampleProgressPeriodically(stop <-chan struct{}) {
+	t := time.NewTicker(progressSampleInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			func() {
+				defer a.l.Lock().Unlock()
+				select {
+				case <-stop:
+					return // attempt finished while we waited for the lock
+				default:
+				}
+				a.sampleProgress(now)
+			}()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateEstimator(t *testing.T) {
+	var e rateEstimator
+	now := time.Unix(1000, 0)
+
+	e.sample(now, 0)
+	assert.Nil(t, e.progress(now, 100, 0), "a single sample does not make a rate")
+
+	// 10 bytes per second
+	for i := 1; i <= 10; i++ {
+		e.sample(now.Add(time.Duration(i)*time.Second), int64(10*i))
+	}
+	now = now.Add(10 * time.Second)
+	p := e.progress(now, 300, 100)
+	require.NotNil(t, p)
+	assert.Equal(t, int64(10), p.BytesPerSecond)
+	assert.Equal(t, now.Add(20*time.Second), p.ETA)
+
+	// stall: the average decays, but does not drop to zero immediately
+	e.sample(now.Add(time.Second), 100)
+	p = e.progress(now, 300, 100)
+	assert.True(t, p.BytesPerSecond > 0 && p.BytesPerSecond < 10, "%d", p.BytesPerSecond)
+
+	// nothing remaining => no ETA
+	p = e.progress(now, 100, 100)
+	assert.True(t, p.ETA.IsZero())
+
+	// decreasing counter is treated as zero rate
+	var d rateEstimator
+	d.sample(now, 100)
+	d.sample(now.Add(time.Second), 50)
+	p = d.progress(now, 200, 50)
+	assert.Equal(t, int64(0), p.BytesPerSecond)
+	assert.True(t, p.ETA.IsZero(), "unknown ETA at zero rate")
+}
@@ -214,3 +214,31 @@ func TestReplication(t *testing.T) {
 	}
 
 }
+
+func TestReplicationPause(t *testing.T) {
+
+	pause := make(chan struct{})
+	ctx := WithPause(context.Background(), pause)
+
+	mp := &mockPlanner{}
+	getReport, wait := Do(ctx, mp)
+	// planning takes 1s, the first step (which one is up to the step queue) is executing at 1.25s
+	time.Sleep(1250 * time.Millisecond)
+	close(pause)
+	wait(true)
+
+	rep := getReport()
+	require.Len(t, rep.Attempts, 1)
+	latest := rep.Attempts[0]
+	assert.Equal(t, report.AttemptPaused, latest.State)
+
+	// the executing step is completed, no further steps are started
+	completedSteps := 0
+	for _, fs := range latest.Filesystems {
+		assert.Equal(t, report.FilesystemPaused, fs.State)
+		assert.Nil(t, fs.Error())
+		assert.NotNil(t, fs.NextStep())
+		completedSteps += fs.CurrentStep
+	}
+	assert.Equal(t, 1, completedSteps)
+}
@@ -1,27 +1,28 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
 // source: pdu.proto
 
 package pdu
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
 import (
-	context "golang.org/x/net/context"
+	context "context"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
 )
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = fmt.Errorf
-var _ = math.Inf
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the proto package it is being compiled against.
-// A compilation error at this line likely means your copy of the
-// proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type FilesystemVersion_VersionType int32
 
@@ -30,291 +31,371 @@ const (
 	FilesystemVersion_Bookmark FilesystemVersion_VersionType = 1
 )
 
-var FilesystemVersion_VersionType_name = map[int32]string{
-	0: "Snapshot",
-	1: "Bookmark",
-}
-var FilesystemVersion_VersionType_value = map[string]int32{
-	"Snapshot": 0,
-	"Bookmark": 1,
+// Enum value maps for FilesystemVersion_VersionType.
+var (
+	FilesystemVersion_VersionType_name = map[int32]string{
+		0: "Snapshot",
+		1: "Bookmark",
+	}
+	FilesystemVersion_VersionType_value = map[string]int32{
+		"Snapshot": 0,
+		"Bookmark": 1,
+	}
+)
+
+func (x FilesystemVersion_VersionType) Enum() *FilesystemVersion_VersionType {
+	p := new(FilesystemVersion_VersionType)
+	*p = x
+	return p
 }
 
 func (x FilesystemVersion_VersionType) String() string {
-	return proto.EnumName(FilesystemVersion_VersionType_name, int32(x))
-}
-func (FilesystemVersion_VersionType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{5, 0}
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
 }
 
-type ListFilesystemReq struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (FilesystemVersion_VersionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pdu_proto_enumTypes[0].Descriptor()
 }
 
-func (m *ListFilesystemReq) Reset()         { *m = ListFilesystemReq{} }
-func (m *ListFilesystemReq) String() string { return proto.CompactTextString(m) }
-func (*ListFilesystemReq) ProtoMessage()    {}
-func (*ListFilesystemReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{0}
+func (FilesystemVersion_VersionType) Type() protoreflect.EnumType {
+	return &file_pdu_proto_enumTypes[0]
 }
-func (m *ListFilesystemReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListFilesystemReq.Unmarshal(m, b)
+
+func (x FilesystemVersion_VersionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
 }
-func (m *ListFilesystemReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListFilesystemReq.Marshal(b, m, deterministic)
+
+// Deprecated: Use FilesystemVersion_VersionType.Descriptor instead.
+func (FilesystemVersion_VersionType) EnumDescriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{5, 0}
 }
-func (dst *ListFilesystemReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListFilesystemReq.Merge(dst, src)
+
+type ListFilesystemReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 }
-func (m *ListFilesystemReq) XXX_Size() int {
-	return xxx_messageInfo_ListFilesystemReq.Size(m)
+
+func (x *ListFilesystemReq) Reset() {
+	*x = ListFilesystemReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ListFilesystemReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListFilesystemReq.DiscardUnknown(m)
+
+func (x *ListFilesystemReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_ListFilesystemReq proto.InternalMessageInfo
+func (*ListFilesystemReq) ProtoMessage() {}
 
-type ListFilesystemRes struct {
-	Filesystems          []*Filesystem `protobuf:"bytes,1,rep,name=Filesystems,proto3" json:"Filesystems,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+func (x *ListFilesystemReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *ListFilesystemRes) Reset()         { *m = ListFilesystemRes{} }
-func (m *ListFilesystemRes) String() string { return proto.CompactTextString(m) }
-func (*ListFilesystemRes) ProtoMessage()    {}
-func (*ListFilesystemRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{1}
-}
-func (m *ListFilesystemRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListFilesystemRes.Unmarshal(m, b)
+// Deprecated: Use ListFilesystemReq.ProtoReflect.Descriptor instead.
+func (*ListFilesystemReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{0}
 }
-func (m *ListFilesystemRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListFilesystemRes.Marshal(b, m, deterministic)
+
+type ListFilesystemRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filesystems []*Filesystem `protobuf:"bytes,1,rep,name=Filesystems,proto3" json:"Filesystems,omitempty"`
 }
-func (dst *ListFilesystemRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListFilesystemRes.Merge(dst, src)
+
+func (x *ListFilesystemRes) Reset() {
+	*x = ListFilesystemRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ListFilesystemRes) XXX_Size() int {
-	return xxx_messageInfo_ListFilesystemRes.Size(m)
+
+func (x *ListFilesystemRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ListFilesystemRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListFilesystemRes.DiscardUnknown(m)
+
+func (*ListFilesystemRes) ProtoMessage() {}
+
+func (x *ListFilesystemRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_ListFilesystemRes proto.InternalMessageInfo
+// Deprecated: Use ListFilesystemRes.ProtoReflect.Descriptor instead.
+func (*ListFilesystemRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{1}
+}
 
-func (m *ListFilesystemRes) GetFilesystems() []*Filesystem {
-	if m != nil {
-		return m.Filesystems
+func (x *ListFilesystemRes) GetFilesystems() []*Filesystem {
+	if x != nil {
+		return x.Filesystems
 	}
 	return nil
 }
 
 type Filesystem struct {
-	Path                 string   `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
-	ResumeToken          string   `protobuf:"bytes,2,opt,name=ResumeToken,proto3" json:"ResumeToken,omitempty"`
-	IsPlaceholder        bool     `protobuf:"varint,3,opt,name=IsPlaceholder,proto3" json:"IsPlaceholder,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
-}
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *Filesystem) Reset()         { *m = Filesystem{} }
-func (m *Filesystem) String() string { return proto.CompactTextString(m) }
-func (*Filesystem) ProtoMessage()    {}
-func (*Filesystem) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{2}
+	Path          string `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	ResumeToken   string `protobuf:"bytes,2,opt,name=ResumeToken,proto3" json:"ResumeToken,omitempty"`
+	IsPlaceholder bool   `protobuf:"varint,3,opt,name=IsPlaceholder,proto3" json:"IsPlaceholder,omitempty"`
 }
-func (m *Filesystem) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Filesystem.Unmarshal(m, b)
-}
-func (m *Filesystem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Filesystem.Marshal(b, m, deterministic)
-}
-func (dst *Filesystem) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Filesystem.Merge(dst, src)
+
+func (x *Filesystem) Reset() {
+	*x = Filesystem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *Filesystem) XXX_Size() int {
-	return xxx_messageInfo_Filesystem.Size(m)
+
+func (x *Filesystem) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *Filesystem) XXX_DiscardUnknown() {
-	xxx_messageInfo_Filesystem.DiscardUnknown(m)
+
+func (*Filesystem) ProtoMessage() {}
+
+func (x *Filesystem) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_Filesystem proto.InternalMessageInfo
+// Deprecated: Use Filesystem.ProtoReflect.Descriptor instead.
+func (*Filesystem) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{2}
+}
 
-func (m *Filesystem) GetPath() string {
-	if m != nil {
-		return m.Path
+func (x *Filesystem) GetPath() string {
+	if x != nil {
+		return x.Path
 	}
 	return ""
 }
 
-func (m *Filesystem) GetResumeToken() string {
-	if m != nil {
-		return m.ResumeToken
+func (x *Filesystem) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
 	}
 	return ""
 }
 
-func (m *Filesystem) GetIsPlaceholder() bool {
-	if m != nil {
-		return m.IsPlaceholder
+func (x *Filesystem) GetIsPlaceholder() bool {
+	if x != nil {
+		return x.IsPlaceholder
 	}
 	return false
 }
 
 type ListFilesystemVersionsReq struct {
-	Filesystem           string   `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
-}
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *ListFilesystemVersionsReq) Reset()         { *m = ListFilesystemVersionsReq{} }
-func (m *ListFilesystemVersionsReq) String() string { return proto.CompactTextString(m) }
-func (*ListFilesystemVersionsReq) ProtoMessage()    {}
-func (*ListFilesystemVersionsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{3}
-}
-func (m *ListFilesystemVersionsReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListFilesystemVersionsReq.Unmarshal(m, b)
-}
-func (m *ListFilesystemVersionsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListFilesystemVersionsReq.Marshal(b, m, deterministic)
+	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
 }
-func (dst *ListFilesystemVersionsReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListFilesystemVersionsReq.Merge(dst, src)
+
+func (x *ListFilesystemVersionsReq) Reset() {
+	*x = ListFilesystemVersionsReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ListFilesystemVersionsReq) XXX_Size() int {
-	return xxx_messageInfo_ListFilesystemVersionsReq.Size(m)
+
+func (x *ListFilesystemVersionsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ListFilesystemVersionsReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListFilesystemVersionsReq.DiscardUnknown(m)
+
+func (*ListFilesystemVersionsReq) ProtoMessage() {}
+
+func (x *ListFilesystemVersionsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_ListFilesystemVersionsReq proto.InternalMessageInfo
+// Deprecated: Use ListFilesystemVersionsReq.ProtoReflect.Descriptor instead.
+func (*ListFilesystemVersionsReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{3}
+}
 
-func (m *ListFilesystemVersionsReq) GetFilesystem() string {
-	if m != nil {
-		return m.Filesystem
+func (x *ListFilesystemVersionsReq) GetFilesystem() string {
+	if x != nil {
+		return x.Filesystem
 	}
 	return ""
 }
 
 type ListFilesystemVersionsRes struct {
-	Versions             []*FilesystemVersion `protobuf:"bytes,1,rep,name=Versions,proto3" json:"Versions,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
-}
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *ListFilesystemVersionsRes) Reset()         { *m = ListFilesystemVersionsRes{} }
-func (m *ListFilesystemVersionsRes) String() string { return proto.CompactTextString(m) }
-func (*ListFilesystemVersionsRes) ProtoMessage()    {}
-func (*ListFilesystemVersionsRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{4}
+	Versions []*FilesystemVersion `protobuf:"bytes,1,rep,name=Versions,proto3" json:"Versions,omitempty"`
 }
-func (m *ListFilesystemVersionsRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListFilesystemVersionsRes.Unmarshal(m, b)
-}
-func (m *ListFilesystemVersionsRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListFilesystemVersionsRes.Marshal(b, m, deterministic)
-}
-func (dst *ListFilesystemVersionsRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListFilesystemVersionsRes.Merge(dst, src)
+
+func (x *ListFilesystemVersionsRes) Reset() {
+	*x = ListFilesystemVersionsRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ListFilesystemVersionsRes) XXX_Size() int {
-	return xxx_messageInfo_ListFilesystemVersionsRes.Size(m)
+
+func (x *ListFilesystemVersionsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ListFilesystemVersionsRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListFilesystemVersionsRes.DiscardUnknown(m)
+
+func (*ListFilesystemVersionsRes) ProtoMessage() {}
+
+func (x *ListFilesystemVersionsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_ListFilesystemVersionsRes proto.InternalMessageInfo
+// Deprecated: Use ListFilesystemVersionsRes.ProtoReflect.Descriptor instead.
+func (*ListFilesystemVersionsRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{4}
+}
 
-func (m *ListFilesystemVersionsRes) GetVersions() []*FilesystemVersion {
-	if m != nil {
-		return m.Versions
+func (x *ListFilesystemVersionsRes) GetVersions() []*FilesystemVersion {
+	if x != nil {
+		return x.Versions
 	}
 	return nil
 }
 
 type FilesystemVersion struct {
-	Type                 FilesystemVersion_VersionType `protobuf:"varint,1,opt,name=Type,proto3,enum=FilesystemVersion_VersionType" json:"Type,omitempty"`
-	Name                 string                        `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
-	Guid                 uint64                        `protobuf:"varint,3,opt,name=Guid,proto3" json:"Guid,omitempty"`
-	CreateTXG            uint64                        `protobuf:"varint,4,opt,name=CreateTXG,proto3" json:"CreateTXG,omitempty"`
-	Creation             string                        `protobuf:"bytes,5,opt,name=Creation,proto3" json:"Creation,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
-	XXX_unrecognized     []byte                        `json:"-"`
-	XXX_sizecache        int32                         `json:"-"`
-}
-
-func (m *FilesystemVersion) Reset()         { *m = FilesystemVersion{} }
-func (m *FilesystemVersion) String() string { return proto.CompactTextString(m) }
-func (*FilesystemVersion) ProtoMessage()    {}
-func (*FilesystemVersion) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{5}
-}
-func (m *FilesystemVersion) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_FilesystemVersion.Unmarshal(m, b)
-}
-func (m *FilesystemVersion) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_FilesystemVersion.Marshal(b, m, deterministic)
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      FilesystemVersion_VersionType `protobuf:"varint,1,opt,name=Type,proto3,enum=FilesystemVersion_VersionType" json:"Type,omitempty"`
+	Name      string                        `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Guid      uint64                        `protobuf:"varint,3,opt,name=Guid,proto3" json:"Guid,omitempty"`
+	CreateTXG uint64                        `protobuf:"varint,4,opt,name=CreateTXG,proto3" json:"CreateTXG,omitempty"`
+	Creation  string                        `protobuf:"bytes,5,opt,name=Creation,proto3" json:"Creation,omitempty"` // RFC 3339
 }
-func (dst *FilesystemVersion) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_FilesystemVersion.Merge(dst, src)
+
+func (x *FilesystemVersion) Reset() {
+	*x = FilesystemVersion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *FilesystemVersion) XXX_Size() int {
-	return xxx_messageInfo_FilesystemVersion.Size(m)
+
+func (x *FilesystemVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *FilesystemVersion) XXX_DiscardUnknown() {
-	xxx_messageInfo_FilesystemVersion.DiscardUnknown(m)
+
+func (*FilesystemVersion) ProtoMessage() {}
+
+func (x *FilesystemVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_FilesystemVersion proto.InternalMessageInfo
+// Deprecated: Use FilesystemVersion.ProtoReflect.Descriptor instead.
+func (*FilesystemVersion) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{5}
+}
 
-func (m *FilesystemVersion) GetType() FilesystemVersion_VersionType {
-	if m != nil {
-		return m.Type
+func (x *FilesystemVersion) GetType() FilesystemVersion_VersionType {
+	if x != nil {
+		return x.Type
 	}
 	return FilesystemVersion_Snapshot
 }
 
-func (m *FilesystemVersion) GetName() string {
-	if m != nil {
-		return m.Name
+func (x *FilesystemVersion) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (m *FilesystemVersion) GetGuid() uint64 {
-	if m != nil {
-		return m.Guid
+func (x *FilesystemVersion) GetGuid() uint64 {
+	if x != nil {
+		return x.Guid
 	}
 	return 0
 }
 
-func (m *FilesystemVersion) GetCreateTXG() uint64 {
-	if m != nil {
-		return m.CreateTXG
+func (x *FilesystemVersion) GetCreateTXG() uint64 {
+	if x != nil {
+		return x.CreateTXG
 	}
 	return 0
 }
 
-func (m *FilesystemVersion) GetCreation() string {
-	if m != nil {
-		return m.Creation
+func (x *FilesystemVersion) GetCreation() string {
+	if x != nil {
+		return x.Creation
 	}
 	return ""
 }
 
 type SendReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
 	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
 	From       string `protobuf:"bytes,2,opt,name=From,proto3" json:"From,omitempty"`
 	// May be empty / null to request a full transfer of From
@@ -326,439 +407,716 @@ type SendReq struct {
 	// If ResumeToken is not empty, the GUIDs of From and To
 	// MUST correspond to those encoded in the ResumeToken.
 	// Otherwise, the Sender MUST return an error.
-	ResumeToken          string   `protobuf:"bytes,4,opt,name=ResumeToken,proto3" json:"ResumeToken,omitempty"`
-	Compress             bool     `protobuf:"varint,5,opt,name=Compress,proto3" json:"Compress,omitempty"`
-	Dedup                bool     `protobuf:"varint,6,opt,name=Dedup,proto3" json:"Dedup,omitempty"`
-	DryRun               bool     `protobuf:"varint,7,opt,name=DryRun,proto3" json:"DryRun,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
-}
-
-func (m *SendReq) Reset()         { *m = SendReq{} }
-func (m *SendReq) String() string { return proto.CompactTextString(m) }
-func (*SendReq) ProtoMessage()    {}
-func (*SendReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{6}
-}
-func (m *SendReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SendReq.Unmarshal(m, b)
-}
-func (m *SendReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SendReq.Marshal(b, m, deterministic)
+	ResumeToken string `protobuf:"bytes,4,opt,name=ResumeToken,proto3" json:"ResumeToken,omitempty"`
+	Compress    bool   `protobuf:"varint,5,opt,name=Compress,proto3" json:"Compress,omitempty"`
+	Dedup       bool   `protobuf:"varint,6,opt,name=Dedup,proto3" json:"Dedup,omitempty"`
+	DryRun      bool   `protobuf:"varint,7,opt,name=DryRun,proto3" json:"DryRun,omitempty"`
+	// Flags for 'zfs send' that alter the stream format.
+	// The sender MUST NOT use them if ResumeToken is used because the token
+	// encodes the flags of the interrupted send.
+	Options *SendOptions `protobuf:"bytes,8,opt,name=Options,proto3" json:"Options,omitempty"`
 }
-func (dst *SendReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SendReq.Merge(dst, src)
+
+func (x *SendReq) Reset() {
+	*x = SendReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *SendReq) XXX_Size() int {
-	return xxx_messageInfo_SendReq.Size(m)
+
+func (x *SendReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *SendReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_SendReq.DiscardUnknown(m)
+
+func (*SendReq) ProtoMessage() {}
+
+func (x *SendReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_SendReq proto.InternalMessageInfo
+// Deprecated: Use SendReq.ProtoReflect.Descriptor instead.
+func (*SendReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{6}
+}
 
-func (m *SendReq) GetFilesystem() string {
-	if m != nil {
-		return m.Filesystem
+func (x *SendReq) GetFilesystem() string {
+	if x != nil {
+		return x.Filesystem
 	}
 	return ""
 }
 
-func (m *SendReq) GetFrom() string {
-	if m != nil {
-		return m.From
+func (x *SendReq) GetFrom() string {
+	if x != nil {
+		return x.From
 	}
 	return ""
 }
 
-func (m *SendReq) GetTo() string {
-	if m != nil {
-		return m.To
+func (x *SendReq) GetTo() string {
+	if x != nil {
+		return x.To
 	}
 	return ""
 }
 
-func (m *SendReq) GetResumeToken() string {
-	if m != nil {
-		return m.ResumeToken
+func (x *SendReq) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
 	}
 	return ""
 }
 
-func (m *SendReq) GetCompress() bool {
-	if m != nil {
-		return m.Compress
+func (x *SendReq) GetCompress() bool {
+	if x != nil {
+		return x.Compress
 	}
 	return false
 }
 
-func (m *SendReq) GetDedup() bool {
-	if m != nil {
-		return m.Dedup
+func (x *SendReq) GetDedup() bool {
+	if x != nil {
+		return x.Dedup
 	}
 	return false
 }
 
-func (m *SendReq) GetDryRun() bool {
-	if m != nil {
-		return m.DryRun
+func (x *SendReq) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
 	}
 	return false
 }
 
-type Property struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
-	Value                string   `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (x *SendReq) GetOptions() *SendOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
 }
 
-func (m *Property) Reset()         { *m = Property{} }
-func (m *Property) String() string { return proto.CompactTextString(m) }
-func (*Property) ProtoMessage()    {}
-func (*Property) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{7}
+// The zero value corresponds to a 'zfs send' without any of the flags.
+type SendOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LargeBlocks  bool `protobuf:"varint,1,opt,name=LargeBlocks,proto3" json:"LargeBlocks,omitempty"`   // zfs send -L
+	EmbeddedData bool `protobuf:"varint,2,opt,name=EmbeddedData,proto3" json:"EmbeddedData,omitempty"` // zfs send -e
+	Compressed   bool `protobuf:"varint,3,opt,name=Compressed,proto3" json:"Compressed,omitempty"`     // zfs send -c
+	Holds        bool `protobuf:"varint,4,opt,name=Holds,proto3" json:"Holds,omitempty"`               // zfs send -h
+}
+
+func (x *SendOptions) Reset() {
+	*x = SendOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SendOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendOptions) ProtoMessage() {}
+
+func (x *SendOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (m *Property) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Property.Unmarshal(m, b)
+
+// Deprecated: Use SendOptions.ProtoReflect.Descriptor instead.
+func (*SendOptions) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{7}
 }
-func (m *Property) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Property.Marshal(b, m, deterministic)
+
+func (x *SendOptions) GetLargeBlocks() bool {
+	if x != nil {
+		return x.LargeBlocks
+	}
+	return false
 }
-func (dst *Property) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Property.Merge(dst, src)
+
+func (x *SendOptions) GetEmbeddedData() bool {
+	if x != nil {
+		return x.EmbeddedData
+	}
+	return false
 }
-func (m *Property) XXX_Size() int {
-	return xxx_messageInfo_Property.Size(m)
+
+func (x *SendOptions) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
 }
-func (m *Property) XXX_DiscardUnknown() {
-	xxx_messageInfo_Property.DiscardUnknown(m)
+
+func (x *SendOptions) GetHolds() bool {
+	if x != nil {
+		return x.Holds
+	}
+	return false
 }
 
-var xxx_messageInfo_Property proto.InternalMessageInfo
+type Property struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *Property) GetName() string {
-	if m != nil {
-		return m.Name
+	Name  string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+}
+
+func (x *Property) Reset() {
+	*x = Property{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Property) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Property) ProtoMessage() {}
+
+func (x *Property) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Property.ProtoReflect.Descriptor instead.
+func (*Property) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Property) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (m *Property) GetValue() string {
-	if m != nil {
-		return m.Value
+func (x *Property) GetValue() string {
+	if x != nil {
+		return x.Value
 	}
 	return ""
 }
 
 type SendRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
 	// Whether the resume token provided in the request has been used or not.
 	UsedResumeToken bool `protobuf:"varint,2,opt,name=UsedResumeToken,proto3" json:"UsedResumeToken,omitempty"`
 	// Expected stream size determined by dry run, not exact.
 	// 0 indicates that for the given SendReq, no size estimate could be made.
-	ExpectedSize         int64       `protobuf:"varint,3,opt,name=ExpectedSize,proto3" json:"ExpectedSize,omitempty"`
-	Properties           []*Property `protobuf:"bytes,4,rep,name=Properties,proto3" json:"Properties,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+	ExpectedSize int64       `protobuf:"varint,3,opt,name=ExpectedSize,proto3" json:"ExpectedSize,omitempty"`
+	Properties   []*Property `protobuf:"bytes,4,rep,name=Properties,proto3" json:"Properties,omitempty"`
 }
 
-func (m *SendRes) Reset()         { *m = SendRes{} }
-func (m *SendRes) String() string { return proto.CompactTextString(m) }
-func (*SendRes) ProtoMessage()    {}
-func (*SendRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{8}
-}
-func (m *SendRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SendRes.Unmarshal(m, b)
-}
-func (m *SendRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SendRes.Marshal(b, m, deterministic)
-}
-func (dst *SendRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SendRes.Merge(dst, src)
+func (x *SendRes) Reset() {
+	*x = SendRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *SendRes) XXX_Size() int {
-	return xxx_messageInfo_SendRes.Size(m)
+
+func (x *SendRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *SendRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_SendRes.DiscardUnknown(m)
+
+func (*SendRes) ProtoMessage() {}
+
+func (x *SendRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_SendRes proto.InternalMessageInfo
+// Deprecated: Use SendRes.ProtoReflect.Descriptor instead.
+func (*SendRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{9}
+}
 
-func (m *SendRes) GetUsedResumeToken() bool {
-	if m != nil {
-		return m.UsedResumeToken
+func (x *SendRes) GetUsedResumeToken() bool {
+	if x != nil {
+		return x.UsedResumeToken
 	}
 	return false
 }
 
-func (m *SendRes) GetExpectedSize() int64 {
-	if m != nil {
-		return m.ExpectedSize
+func (x *SendRes) GetExpectedSize() int64 {
+	if x != nil {
+		return x.ExpectedSize
 	}
 	return 0
 }
 
-func (m *SendRes) GetProperties() []*Property {
-	if m != nil {
-		return m.Properties
+func (x *SendRes) GetProperties() []*Property {
+	if x != nil {
+		return x.Properties
 	}
 	return nil
 }
 
 type ReceiveReq struct {
-	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"` // FIXME should be snapshot name, we can enforce that on recv
 	// If true, the receiver should clear the resume token before perfoming the zfs recv of the stream in the request
-	ClearResumeToken     bool     `protobuf:"varint,2,opt,name=ClearResumeToken,proto3" json:"ClearResumeToken,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	ClearResumeToken bool `protobuf:"varint,2,opt,name=ClearResumeToken,proto3" json:"ClearResumeToken,omitempty"`
+	// The flags of the 'zfs send' that produced the stream.
+	// The receiver MUST refuse the stream if it does not accept them (see ReceiverCapabilitiesRes).
+	Options *SendOptions `protobuf:"bytes,3,opt,name=Options,proto3" json:"Options,omitempty"`
 }
 
-func (m *ReceiveReq) Reset()         { *m = ReceiveReq{} }
-func (m *ReceiveReq) String() string { return proto.CompactTextString(m) }
-func (*ReceiveReq) ProtoMessage()    {}
-func (*ReceiveReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{9}
-}
-func (m *ReceiveReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReceiveReq.Unmarshal(m, b)
-}
-func (m *ReceiveReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReceiveReq.Marshal(b, m, deterministic)
-}
-func (dst *ReceiveReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReceiveReq.Merge(dst, src)
+func (x *ReceiveReq) Reset() {
+	*x = ReceiveReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ReceiveReq) XXX_Size() int {
-	return xxx_messageInfo_ReceiveReq.Size(m)
+
+func (x *ReceiveReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ReceiveReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReceiveReq.DiscardUnknown(m)
+
+func (*ReceiveReq) ProtoMessage() {}
+
+func (x *ReceiveReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_ReceiveReq proto.InternalMessageInfo
+// Deprecated: Use ReceiveReq.ProtoReflect.Descriptor instead.
+func (*ReceiveReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{10}
+}
 
-func (m *ReceiveReq) GetFilesystem() string {
-	if m != nil {
-		return m.Filesystem
+func (x *ReceiveReq) GetFilesystem() string {
+	if x != nil {
+		return x.Filesystem
 	}
 	return ""
 }
 
-func (m *ReceiveReq) GetClearResumeToken() bool {
-	if m != nil {
-		return m.ClearResumeToken
+func (x *ReceiveReq) GetClearResumeToken() bool {
+	if x != nil {
+		return x.ClearResumeToken
 	}
 	return false
 }
 
-type ReceiveRes struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (x *ReceiveReq) GetOptions() *SendOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
 }
 
-func (m *ReceiveRes) Reset()         { *m = ReceiveRes{} }
-func (m *ReceiveRes) String() string { return proto.CompactTextString(m) }
-func (*ReceiveRes) ProtoMessage()    {}
-func (*ReceiveRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{10}
-}
-func (m *ReceiveRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReceiveRes.Unmarshal(m, b)
+type ReceiveRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 }
-func (m *ReceiveRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReceiveRes.Marshal(b, m, deterministic)
+
+func (x *ReceiveRes) Reset() {
+	*x = ReceiveRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (dst *ReceiveRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReceiveRes.Merge(dst, src)
+
+func (x *ReceiveRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ReceiveRes) XXX_Size() int {
-	return xxx_messageInfo_ReceiveRes.Size(m)
+
+func (*ReceiveRes) ProtoMessage() {}
+
+func (x *ReceiveRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (m *ReceiveRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReceiveRes.DiscardUnknown(m)
+
+// Deprecated: Use ReceiveRes.ProtoReflect.Descriptor instead.
+func (*ReceiveRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{11}
 }
 
-var xxx_messageInfo_ReceiveRes proto.InternalMessageInfo
+type ReceiverCapabilitiesReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
 
-type DestroySnapshotsReq struct {
-	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
-	// Path to filesystem, snapshot or bookmark to be destroyed
-	Snapshots            []*FilesystemVersion `protobuf:"bytes,2,rep,name=Snapshots,proto3" json:"Snapshots,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+func (x *ReceiverCapabilitiesReq) Reset() {
+	*x = ReceiverCapabilitiesReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (m *DestroySnapshotsReq) Reset()         { *m = DestroySnapshotsReq{} }
-func (m *DestroySnapshotsReq) String() string { return proto.CompactTextString(m) }
-func (*DestroySnapshotsReq) ProtoMessage()    {}
-func (*DestroySnapshotsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{11}
+func (x *ReceiverCapabilitiesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *DestroySnapshotsReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DestroySnapshotsReq.Unmarshal(m, b)
+
+func (*ReceiverCapabilitiesReq) ProtoMessage() {}
+
+func (x *ReceiverCapabilitiesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (m *DestroySnapshotsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DestroySnapshotsReq.Marshal(b, m, deterministic)
+
+// Deprecated: Use ReceiverCapabilitiesReq.ProtoReflect.Descriptor instead.
+func (*ReceiverCapabilitiesReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{12}
 }
-func (dst *DestroySnapshotsReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DestroySnapshotsReq.Merge(dst, src)
+
+type ReceiverCapabilitiesRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The flags set in AcceptedSendOptions may be used in SendReqs
+	// whose stream is received by this receiver.
+	AcceptedSendOptions *SendOptions `protobuf:"bytes,1,opt,name=AcceptedSendOptions,proto3" json:"AcceptedSendOptions,omitempty"`
 }
-func (m *DestroySnapshotsReq) XXX_Size() int {
-	return xxx_messageInfo_DestroySnapshotsReq.Size(m)
+
+func (x *ReceiverCapabilitiesRes) Reset() {
+	*x = ReceiverCapabilitiesRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *DestroySnapshotsReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_DestroySnapshotsReq.DiscardUnknown(m)
+
+func (x *ReceiverCapabilitiesRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_DestroySnapshotsReq proto.InternalMessageInfo
+func (*ReceiverCapabilitiesRes) ProtoMessage() {}
 
-func (m *DestroySnapshotsReq) GetFilesystem() string {
-	if m != nil {
-		return m.Filesystem
+func (x *ReceiverCapabilitiesRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (m *DestroySnapshotsReq) GetSnapshots() []*FilesystemVersion {
-	if m != nil {
-		return m.Snapshots
+// Deprecated: Use ReceiverCapabilitiesRes.ProtoReflect.Descriptor instead.
+func (*ReceiverCapabilitiesRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReceiverCapabilitiesRes) GetAcceptedSendOptions() *SendOptions {
+	if x != nil {
+		return x.AcceptedSendOptions
 	}
 	return nil
 }
 
-type DestroySnapshotRes struct {
-	Snapshot             *FilesystemVersion `protobuf:"bytes,1,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
-	Error                string             `protobuf:"bytes,2,opt,name=Error,proto3" json:"Error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+type DestroySnapshotsReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
+	// Path to filesystem, snapshot or bookmark to be destroyed
+	Snapshots []*FilesystemVersion `protobuf:"bytes,2,rep,name=Snapshots,proto3" json:"Snapshots,omitempty"`
 }
 
-func (m *DestroySnapshotRes) Reset()         { *m = DestroySnapshotRes{} }
-func (m *DestroySnapshotRes) String() string { return proto.CompactTextString(m) }
-func (*DestroySnapshotRes) ProtoMessage()    {}
-func (*DestroySnapshotRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{12}
+func (x *DestroySnapshotsReq) Reset() {
+	*x = DestroySnapshotsReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *DestroySnapshotRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DestroySnapshotRes.Unmarshal(m, b)
+
+func (x *DestroySnapshotsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *DestroySnapshotRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DestroySnapshotRes.Marshal(b, m, deterministic)
+
+func (*DestroySnapshotsReq) ProtoMessage() {}
+
+func (x *DestroySnapshotsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (dst *DestroySnapshotRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DestroySnapshotRes.Merge(dst, src)
+
+// Deprecated: Use DestroySnapshotsReq.ProtoReflect.Descriptor instead.
+func (*DestroySnapshotsReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{14}
 }
-func (m *DestroySnapshotRes) XXX_Size() int {
-	return xxx_messageInfo_DestroySnapshotRes.Size(m)
+
+func (x *DestroySnapshotsReq) GetFilesystem() string {
+	if x != nil {
+		return x.Filesystem
+	}
+	return ""
 }
-func (m *DestroySnapshotRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_DestroySnapshotRes.DiscardUnknown(m)
+
+func (x *DestroySnapshotsReq) GetSnapshots() []*FilesystemVersion {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
 }
 
-var xxx_messageInfo_DestroySnapshotRes proto.InternalMessageInfo
+type DestroySnapshotRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *DestroySnapshotRes) GetSnapshot() *FilesystemVersion {
-	if m != nil {
-		return m.Snapshot
+	Snapshot *FilesystemVersion `protobuf:"bytes,1,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	Error    string             `protobuf:"bytes,2,opt,name=Error,proto3" json:"Error,omitempty"`
+}
+
+func (x *DestroySnapshotRes) Reset() {
+	*x = DestroySnapshotRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func (m *DestroySnapshotRes) GetError() string {
-	if m != nil {
-		return m.Error
+func (x *DestroySnapshotRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DestroySnapshotRes) ProtoMessage() {}
+
+func (x *DestroySnapshotRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-type DestroySnapshotsRes struct {
-	Results              []*DestroySnapshotRes `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
-	XXX_unrecognized     []byte                `json:"-"`
-	XXX_sizecache        int32                 `json:"-"`
+// Deprecated: Use DestroySnapshotRes.ProtoReflect.Descriptor instead.
+func (*DestroySnapshotRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{15}
 }
 
-func (m *DestroySnapshotsRes) Reset()         { *m = DestroySnapshotsRes{} }
-func (m *DestroySnapshotsRes) String() string { return proto.CompactTextString(m) }
-func (*DestroySnapshotsRes) ProtoMessage()    {}
-func (*DestroySnapshotsRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{13}
+func (x *DestroySnapshotRes) GetSnapshot() *FilesystemVersion {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
 }
-func (m *DestroySnapshotsRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DestroySnapshotsRes.Unmarshal(m, b)
+
+func (x *DestroySnapshotRes) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
-func (m *DestroySnapshotsRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DestroySnapshotsRes.Marshal(b, m, deterministic)
+
+type DestroySnapshotsRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*DestroySnapshotRes `protobuf:"bytes,1,rep,name=Results,proto3" json:"Results,omitempty"`
 }
-func (dst *DestroySnapshotsRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DestroySnapshotsRes.Merge(dst, src)
+
+func (x *DestroySnapshotsRes) Reset() {
+	*x = DestroySnapshotsRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *DestroySnapshotsRes) XXX_Size() int {
-	return xxx_messageInfo_DestroySnapshotsRes.Size(m)
+
+func (x *DestroySnapshotsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *DestroySnapshotsRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_DestroySnapshotsRes.DiscardUnknown(m)
+
+func (*DestroySnapshotsRes) ProtoMessage() {}
+
+func (x *DestroySnapshotsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_DestroySnapshotsRes proto.InternalMessageInfo
+// Deprecated: Use DestroySnapshotsRes.ProtoReflect.Descriptor instead.
+func (*DestroySnapshotsRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{16}
+}
 
-func (m *DestroySnapshotsRes) GetResults() []*DestroySnapshotRes {
-	if m != nil {
-		return m.Results
+func (x *DestroySnapshotsRes) GetResults() []*DestroySnapshotRes {
+	if x != nil {
+		return x.Results
 	}
 	return nil
 }
 
 type ReplicationCursorReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
 	Filesystem string `protobuf:"bytes,1,opt,name=Filesystem,proto3" json:"Filesystem,omitempty"`
-	// Types that are valid to be assigned to Op:
+	// Types that are assignable to Op:
 	//	*ReplicationCursorReq_Get
 	//	*ReplicationCursorReq_Set
-	Op                   isReplicationCursorReq_Op `protobuf_oneof:"op"`
-	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
-	XXX_unrecognized     []byte                    `json:"-"`
-	XXX_sizecache        int32                     `json:"-"`
+	Op isReplicationCursorReq_Op `protobuf_oneof:"op"`
 }
 
-func (m *ReplicationCursorReq) Reset()         { *m = ReplicationCursorReq{} }
-func (m *ReplicationCursorReq) String() string { return proto.CompactTextString(m) }
-func (*ReplicationCursorReq) ProtoMessage()    {}
-func (*ReplicationCursorReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{14}
+func (x *ReplicationCursorReq) Reset() {
+	*x = ReplicationCursorReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ReplicationCursorReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReplicationCursorReq.Unmarshal(m, b)
+
+func (x *ReplicationCursorReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ReplicationCursorReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReplicationCursorReq.Marshal(b, m, deterministic)
+
+func (*ReplicationCursorReq) ProtoMessage() {}
+
+func (x *ReplicationCursorReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (dst *ReplicationCursorReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReplicationCursorReq.Merge(dst, src)
+
+// Deprecated: Use ReplicationCursorReq.ProtoReflect.Descriptor instead.
+func (*ReplicationCursorReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{17}
 }
-func (m *ReplicationCursorReq) XXX_Size() int {
-	return xxx_messageInfo_ReplicationCursorReq.Size(m)
+
+func (x *ReplicationCursorReq) GetFilesystem() string {
+	if x != nil {
+		return x.Filesystem
+	}
+	return ""
 }
-func (m *ReplicationCursorReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReplicationCursorReq.DiscardUnknown(m)
+
+func (m *ReplicationCursorReq) GetOp() isReplicationCursorReq_Op {
+	if m != nil {
+		return m.Op
+	}
+	return nil
 }
 
-var xxx_messageInfo_ReplicationCursorReq proto.InternalMessageInfo
+func (x *ReplicationCursorReq) GetGet() *ReplicationCursorReq_GetOp {
+	if x, ok := x.GetOp().(*ReplicationCursorReq_Get); ok {
+		return x.Get
+	}
+	return nil
+}
 
-func (m *ReplicationCursorReq) GetFilesystem() string {
-	if m != nil {
-		return m.Filesystem
+func (x *ReplicationCursorReq) GetSet() *ReplicationCursorReq_SetOp {
+	if x, ok := x.GetOp().(*ReplicationCursorReq_Set); ok {
+		return x.Set
 	}
-	return ""
+	return nil
 }
 
 type isReplicationCursorReq_Op interface {
@@ -777,416 +1135,968 @@ func (*ReplicationCursorReq_Get) isReplicationCursorReq_Op() {}
 
 func (*ReplicationCursorReq_Set) isReplicationCursorReq_Op() {}
 
-func (m *ReplicationCursorReq) GetOp() isReplicationCursorReq_Op {
-	if m != nil {
-		return m.Op
-	}
-	return nil
-}
+type ReplicationCursorRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *ReplicationCursorReq) GetGet() *ReplicationCursorReq_GetOp {
-	if x, ok := m.GetOp().(*ReplicationCursorReq_Get); ok {
-		return x.Get
-	}
-	return nil
+	// Types that are assignable to Result:
+	//	*ReplicationCursorRes_Guid
+	//	*ReplicationCursorRes_Notexist
+	Result isReplicationCursorRes_Result `protobuf_oneof:"Result"`
 }
 
-func (m *ReplicationCursorReq) GetSet() *ReplicationCursorReq_SetOp {
-	if x, ok := m.GetOp().(*ReplicationCursorReq_Set); ok {
-		return x.Set
+func (x *ReplicationCursorRes) Reset() {
+	*x = ReplicationCursorRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*ReplicationCursorReq) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _ReplicationCursorReq_OneofMarshaler, _ReplicationCursorReq_OneofUnmarshaler, _ReplicationCursorReq_OneofSizer, []interface{}{
-		(*ReplicationCursorReq_Get)(nil),
-		(*ReplicationCursorReq_Set)(nil),
-	}
+func (x *ReplicationCursorRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func _ReplicationCursorReq_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*ReplicationCursorReq)
-	// op
-	switch x := m.Op.(type) {
-	case *ReplicationCursorReq_Get:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.Get); err != nil {
-			return err
-		}
-	case *ReplicationCursorReq_Set:
-		b.EncodeVarint(3<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.Set); err != nil {
-			return err
+func (*ReplicationCursorRes) ProtoMessage() {}
+
+func (x *ReplicationCursorRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-	case nil:
-	default:
-		return fmt.Errorf("ReplicationCursorReq.Op has unexpected type %T", x)
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func _ReplicationCursorReq_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*ReplicationCursorReq)
-	switch tag {
-	case 2: // op.get
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(ReplicationCursorReq_GetOp)
-		err := b.DecodeMessage(msg)
-		m.Op = &ReplicationCursorReq_Get{msg}
-		return true, err
-	case 3: // op.set
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(ReplicationCursorReq_SetOp)
-		err := b.DecodeMessage(msg)
-		m.Op = &ReplicationCursorReq_Set{msg}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _ReplicationCursorReq_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*ReplicationCursorReq)
-	// op
-	switch x := m.Op.(type) {
-	case *ReplicationCursorReq_Get:
-		s := proto.Size(x.Get)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *ReplicationCursorReq_Set:
-		s := proto.Size(x.Set)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
+// Deprecated: Use ReplicationCursorRes.ProtoReflect.Descriptor instead.
+func (*ReplicationCursorRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{18}
 }
 
-type ReplicationCursorReq_GetOp struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *ReplicationCursorRes) GetResult() isReplicationCursorRes_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
 }
 
-func (m *ReplicationCursorReq_GetOp) Reset()         { *m = ReplicationCursorReq_GetOp{} }
-func (m *ReplicationCursorReq_GetOp) String() string { return proto.CompactTextString(m) }
-func (*ReplicationCursorReq_GetOp) ProtoMessage()    {}
-func (*ReplicationCursorReq_GetOp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{14, 0}
-}
-func (m *ReplicationCursorReq_GetOp) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReplicationCursorReq_GetOp.Unmarshal(m, b)
+func (x *ReplicationCursorRes) GetGuid() uint64 {
+	if x, ok := x.GetResult().(*ReplicationCursorRes_Guid); ok {
+		return x.Guid
+	}
+	return 0
 }
-func (m *ReplicationCursorReq_GetOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReplicationCursorReq_GetOp.Marshal(b, m, deterministic)
+
+func (x *ReplicationCursorRes) GetNotexist() bool {
+	if x, ok := x.GetResult().(*ReplicationCursorRes_Notexist); ok {
+		return x.Notexist
+	}
+	return false
 }
-func (dst *ReplicationCursorReq_GetOp) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReplicationCursorReq_GetOp.Merge(dst, src)
+
+type isReplicationCursorRes_Result interface {
+	isReplicationCursorRes_Result()
 }
-func (m *ReplicationCursorReq_GetOp) XXX_Size() int {
-	return xxx_messageInfo_ReplicationCursorReq_GetOp.Size(m)
+
+type ReplicationCursorRes_Guid struct {
+	Guid uint64 `protobuf:"varint,1,opt,name=Guid,proto3,oneof"`
 }
-func (m *ReplicationCursorReq_GetOp) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReplicationCursorReq_GetOp.DiscardUnknown(m)
+
+type ReplicationCursorRes_Notexist struct {
+	Notexist bool `protobuf:"varint,2,opt,name=Notexist,proto3,oneof"`
 }
 
-var xxx_messageInfo_ReplicationCursorReq_GetOp proto.InternalMessageInfo
+func (*ReplicationCursorRes_Guid) isReplicationCursorRes_Result() {}
+
+func (*ReplicationCursorRes_Notexist) isReplicationCursorRes_Result() {}
 
-type ReplicationCursorReq_SetOp struct {
-	Snapshot             string   `protobuf:"bytes,2,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
-}
+type PingReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (m *ReplicationCursorReq_SetOp) Reset()         { *m = ReplicationCursorReq_SetOp{} }
-func (m *ReplicationCursorReq_SetOp) String() string { return proto.CompactTextString(m) }
-func (*ReplicationCursorReq_SetOp) ProtoMessage()    {}
-func (*ReplicationCursorReq_SetOp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{14, 1}
-}
-func (m *ReplicationCursorReq_SetOp) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReplicationCursorReq_SetOp.Unmarshal(m, b)
-}
-func (m *ReplicationCursorReq_SetOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReplicationCursorReq_SetOp.Marshal(b, m, deterministic)
+	Message string `protobuf:"bytes,1,opt,name=Message,proto3" json:"Message,omitempty"`
 }
-func (dst *ReplicationCursorReq_SetOp) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReplicationCursorReq_SetOp.Merge(dst, src)
+
+func (x *PingReq) Reset() {
+	*x = PingReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ReplicationCursorReq_SetOp) XXX_Size() int {
-	return xxx_messageInfo_ReplicationCursorReq_SetOp.Size(m)
+
+func (x *PingReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ReplicationCursorReq_SetOp) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReplicationCursorReq_SetOp.DiscardUnknown(m)
+
+func (*PingReq) ProtoMessage() {}
+
+func (x *PingReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_ReplicationCursorReq_SetOp proto.InternalMessageInfo
+// Deprecated: Use PingReq.ProtoReflect.Descriptor instead.
+func (*PingReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{19}
+}
 
-func (m *ReplicationCursorReq_SetOp) GetSnapshot() string {
-	if m != nil {
-		return m.Snapshot
+func (x *PingReq) GetMessage() string {
+	if x != nil {
+		return x.Message
 	}
 	return ""
 }
 
-type ReplicationCursorRes struct {
-	// Types that are valid to be assigned to Result:
-	//	*ReplicationCursorRes_Guid
-	//	*ReplicationCursorRes_Notexist
-	Result               isReplicationCursorRes_Result `protobuf_oneof:"Result"`
-	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
-	XXX_unrecognized     []byte                        `json:"-"`
-	XXX_sizecache        int32                         `json:"-"`
+type PingRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Echo must be PingReq.Message
+	Echo string `protobuf:"bytes,1,opt,name=Echo,proto3" json:"Echo,omitempty"`
+	// The client identity that the server authenticated the connection as.
+	// May be empty if the server does not report it.
+	ClientIdentity string `protobuf:"bytes,2,opt,name=ClientIdentity,proto3" json:"ClientIdentity,omitempty"`
 }
 
-func (m *ReplicationCursorRes) Reset()         { *m = ReplicationCursorRes{} }
-func (m *ReplicationCursorRes) String() string { return proto.CompactTextString(m) }
-func (*ReplicationCursorRes) ProtoMessage()    {}
-func (*ReplicationCursorRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{15}
+func (x *PingRes) Reset() {
+	*x = PingRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *ReplicationCursorRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ReplicationCursorRes.Unmarshal(m, b)
+
+func (x *PingRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *ReplicationCursorRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ReplicationCursorRes.Marshal(b, m, deterministic)
+
+func (*PingRes) ProtoMessage() {}
+
+func (x *PingRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-func (dst *ReplicationCursorRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReplicationCursorRes.Merge(dst, src)
+
+// Deprecated: Use PingRes.ProtoReflect.Descriptor instead.
+func (*PingRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{20}
 }
-func (m *ReplicationCursorRes) XXX_Size() int {
-	return xxx_messageInfo_ReplicationCursorRes.Size(m)
+
+func (x *PingRes) GetEcho() string {
+	if x != nil {
+		return x.Echo
+	}
+	return ""
 }
-func (m *ReplicationCursorRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_ReplicationCursorRes.DiscardUnknown(m)
+
+func (x *PingRes) GetClientIdentity() string {
+	if x != nil {
+		return x.ClientIdentity
+	}
+	return ""
 }
 
-var xxx_messageInfo_ReplicationCursorRes proto.InternalMessageInfo
+// For diagnostics, see package rpc/dataconn
+type ThroughputTestReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-type isReplicationCursorRes_Result interface {
-	isReplicationCursorRes_Result()
+	// Number of bytes to transfer, the server may reject large values.
+	Bytes int64 `protobuf:"varint,1,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	// If true, the client sends the stream to the server, otherwise the server sends it to the client.
+	ClientSends bool `protobuf:"varint,2,opt,name=ClientSends,proto3" json:"ClientSends,omitempty"`
 }
 
-type ReplicationCursorRes_Guid struct {
-	Guid uint64 `protobuf:"varint,1,opt,name=Guid,proto3,oneof"`
+func (x *ThroughputTestReq) Reset() {
+	*x = ThroughputTestReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-type ReplicationCursorRes_Notexist struct {
-	Notexist bool `protobuf:"varint,2,opt,name=Notexist,proto3,oneof"`
+func (x *ThroughputTestReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReplicationCursorRes_Guid) isReplicationCursorRes_Result() {}
-
-func (*ReplicationCursorRes_Notexist) isReplicationCursorRes_Result() {}
+func (*ThroughputTestReq) ProtoMessage() {}
 
-func (m *ReplicationCursorRes) GetResult() isReplicationCursorRes_Result {
-	if m != nil {
-		return m.Result
+func (x *ThroughputTestReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (m *ReplicationCursorRes) GetGuid() uint64 {
-	if x, ok := m.GetResult().(*ReplicationCursorRes_Guid); ok {
-		return x.Guid
+// Deprecated: Use ThroughputTestReq.ProtoReflect.Descriptor instead.
+func (*ThroughputTestReq) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ThroughputTestReq) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
 	}
 	return 0
 }
 
-func (m *ReplicationCursorRes) GetNotexist() bool {
-	if x, ok := m.GetResult().(*ReplicationCursorRes_Notexist); ok {
-		return x.Notexist
+func (x *ThroughputTestReq) GetClientSends() bool {
+	if x != nil {
+		return x.ClientSends
 	}
 	return false
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*ReplicationCursorRes) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _ReplicationCursorRes_OneofMarshaler, _ReplicationCursorRes_OneofUnmarshaler, _ReplicationCursorRes_OneofSizer, []interface{}{
-		(*ReplicationCursorRes_Guid)(nil),
-		(*ReplicationCursorRes_Notexist)(nil),
-	}
+type ThroughputTestRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of bytes the server sent or received.
+	Bytes int64 `protobuf:"varint,1,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
 }
 
-func _ReplicationCursorRes_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*ReplicationCursorRes)
-	// Result
-	switch x := m.Result.(type) {
-	case *ReplicationCursorRes_Guid:
-		b.EncodeVarint(1<<3 | proto.WireVarint)
-		b.EncodeVarint(uint64(x.Guid))
-	case *ReplicationCursorRes_Notexist:
-		t := uint64(0)
-		if x.Notexist {
-			t = 1
-		}
-		b.EncodeVarint(2<<3 | proto.WireVarint)
-		b.EncodeVarint(t)
-	case nil:
-	default:
-		return fmt.Errorf("ReplicationCursorRes.Result has unexpected type %T", x)
+func (x *ThroughputTestRes) Reset() {
+	*x = ThroughputTestRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func _ReplicationCursorRes_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*ReplicationCursorRes)
-	switch tag {
-	case 1: // Result.Guid
-		if wire != proto.WireVarint {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeVarint()
-		m.Result = &ReplicationCursorRes_Guid{x}
-		return true, err
-	case 2: // Result.Notexist
-		if wire != proto.WireVarint {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeVarint()
-		m.Result = &ReplicationCursorRes_Notexist{x != 0}
-		return true, err
-	default:
-		return false, nil
-	}
+func (x *ThroughputTestRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func _ReplicationCursorRes_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*ReplicationCursorRes)
-	// Result
-	switch x := m.Result.(type) {
-	case *ReplicationCursorRes_Guid:
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(x.Guid))
-	case *ReplicationCursorRes_Notexist:
-		n += 1 // tag and wire
-		n += 1
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+func (*ThroughputTestRes) ProtoMessage() {}
+
+func (x *ThroughputTestRes) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return n
+	return mi.MessageOf(x)
 }
 
-type PingReq struct {
-	Message              string   `protobuf:"bytes,1,opt,name=Message,proto3" json:"Message,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+// Deprecated: Use ThroughputTestRes.ProtoReflect.Descriptor instead.
+func (*ThroughputTestRes) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{22}
 }
 
-func (m *PingReq) Reset()         { *m = PingReq{} }
-func (m *PingReq) String() string { return proto.CompactTextString(m) }
-func (*PingReq) ProtoMessage()    {}
-func (*PingReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{16}
-}
-func (m *PingReq) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PingReq.Unmarshal(m, b)
-}
-func (m *PingReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PingReq.Marshal(b, m, deterministic)
+func (x *ThroughputTestRes) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
 }
-func (dst *PingReq) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PingReq.Merge(dst, src)
+
+type ReplicationCursorReq_GetOp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 }
-func (m *PingReq) XXX_Size() int {
-	return xxx_messageInfo_PingReq.Size(m)
+
+func (x *ReplicationCursorReq_GetOp) Reset() {
+	*x = ReplicationCursorReq_GetOp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *PingReq) XXX_DiscardUnknown() {
-	xxx_messageInfo_PingReq.DiscardUnknown(m)
+
+func (x *ReplicationCursorReq_GetOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_PingReq proto.InternalMessageInfo
+func (*ReplicationCursorReq_GetOp) ProtoMessage() {}
 
-func (m *PingReq) GetMessage() string {
-	if m != nil {
-		return m.Message
+func (x *ReplicationCursorReq_GetOp) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-type PingRes struct {
-	// Echo must be PingReq.Message
-	Echo                 string   `protobuf:"bytes,1,opt,name=Echo,proto3" json:"Echo,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+// Deprecated: Use ReplicationCursorReq_GetOp.ProtoReflect.Descriptor instead.
+func (*ReplicationCursorReq_GetOp) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{17, 0}
 }
 
-func (m *PingRes) Reset()         { *m = PingRes{} }
-func (m *PingRes) String() string { return proto.CompactTextString(m) }
-func (*PingRes) ProtoMessage()    {}
-func (*PingRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pdu_83b7e2a28d820622, []int{17}
-}
-func (m *PingRes) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PingRes.Unmarshal(m, b)
-}
-func (m *PingRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PingRes.Marshal(b, m, deterministic)
+type ReplicationCursorReq_SetOp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Snapshot string `protobuf:"bytes,2,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
 }
-func (dst *PingRes) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PingRes.Merge(dst, src)
+
+func (x *ReplicationCursorReq_SetOp) Reset() {
+	*x = ReplicationCursorReq_SetOp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pdu_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-func (m *PingRes) XXX_Size() int {
-	return xxx_messageInfo_PingRes.Size(m)
+
+func (x *ReplicationCursorReq_SetOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *PingRes) XXX_DiscardUnknown() {
-	xxx_messageInfo_PingRes.DiscardUnknown(m)
+
+func (*ReplicationCursorReq_SetOp) ProtoMessage() {}
+
+func (x *ReplicationCursorReq_SetOp) ProtoReflect() protoreflect.Message {
+	mi := &file_pdu_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_PingRes proto.InternalMessageInfo
+// Deprecated: Use ReplicationCursorReq_SetOp.ProtoReflect.Descriptor instead.
+func (*ReplicationCursorReq_SetOp) Descriptor() ([]byte, []int) {
+	return file_pdu_proto_rawDescGZIP(), []int{17, 1}
+}
 
-func (m *PingRes) GetEcho() string {
-	if m != nil {
-		return m.Echo
+func (x *ReplicationCursorReq_SetOp) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
 	}
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*ListFilesystemReq)(nil), "ListFilesystemReq")
-	proto.RegisterType((*ListFilesystemRes)(nil), "ListFilesystemRes")
-	proto.RegisterType((*Filesystem)(nil), "Filesystem")
-	proto.RegisterType((*ListFilesystemVersionsReq)(nil), "ListFilesystemVersionsReq")
-	proto.RegisterType((*ListFilesystemVersionsRes)(nil), "ListFilesystemVersionsRes")
-	proto.RegisterType((*FilesystemVersion)(nil), "FilesystemVersion")
-	proto.RegisterType((*SendReq)(nil), "SendReq")
-	proto.RegisterType((*Property)(nil), "Property")
-	proto.RegisterType((*SendRes)(nil), "SendRes")
-	proto.RegisterType((*ReceiveReq)(nil), "ReceiveReq")
-	proto.RegisterType((*ReceiveRes)(nil), "ReceiveRes")
-	proto.RegisterType((*DestroySnapshotsReq)(nil), "DestroySnapshotsReq")
-	proto.RegisterType((*DestroySnapshotRes)(nil), "DestroySnapshotRes")
-	proto.RegisterType((*DestroySnapshotsRes)(nil), "DestroySnapshotsRes")
-	proto.RegisterType((*ReplicationCursorReq)(nil), "ReplicationCursorReq")
-	proto.RegisterType((*ReplicationCursorReq_GetOp)(nil), "ReplicationCursorReq.GetOp")
-	proto.RegisterType((*ReplicationCursorReq_SetOp)(nil), "ReplicationCursorReq.SetOp")
-	proto.RegisterType((*ReplicationCursorRes)(nil), "ReplicationCursorRes")
-	proto.RegisterType((*PingReq)(nil), "PingReq")
-	proto.RegisterType((*PingRes)(nil), "PingRes")
-	proto.RegisterEnum("FilesystemVersion_VersionType", FilesystemVersion_VersionType_name, FilesystemVersion_VersionType_value)
+var File_pdu_proto protoreflect.FileDescriptor
+
+var file_pdu_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x70, 0x64, 0x75, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x13, 0x0a, 0x11, 0x4c,
+	0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71,
+	0x22, 0x42, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74,
+	0x65, 0x6d, 0x52, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x0b, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x0b, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x73, 0x22, 0x68, 0x0a, 0x0a, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74,
+	0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x50, 0x61, 0x74, 0x68, 0x12, 0x20, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x52, 0x65, 0x73,
+	0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x24, 0x0a, 0x0d, 0x49, 0x73, 0x50, 0x6c,
+	0x61, 0x63, 0x65, 0x68, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x49, 0x73, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x68, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x22, 0x3b,
+	0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x12, 0x1e, 0x0a, 0x0a, 0x46,
+	0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x22, 0x4b, 0x0a, 0x19, 0x4c,
+	0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xd4, 0x01, 0x0a, 0x11, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x32,
+	0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x46,
+	0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x47, 0x75, 0x69, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x47, 0x75, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x54, 0x58, 0x47, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x58, 0x47, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x29, 0x0a, 0x0b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x10,
+	0x00, 0x12, 0x0c, 0x0a, 0x08, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x10, 0x01, 0x22,
+	0xe1, 0x01, 0x0a, 0x07, 0x53, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x12, 0x1e, 0x0a, 0x0a, 0x46,
+	0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x46,
+	0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x12,
+	0x0e, 0x0a, 0x02, 0x54, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x54, 0x6f, 0x12,
+	0x20, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x44, 0x65, 0x64, 0x75, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x44, 0x65,
+	0x64, 0x75, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x44, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x44, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x12, 0x26, 0x0a, 0x07, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x53,
+	0x65, 0x6e, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x22, 0x89, 0x01, 0x0a, 0x0b, 0x53, 0x65, 0x6e, 0x64, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x4c, 0x61, 0x72, 0x67, 0x65, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x45, 0x6d, 0x62, 0x65, 0x64, 0x64, 0x65,
+	0x64, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x45, 0x6d, 0x62,
+	0x65, 0x64, 0x64, 0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x43, 0x6f, 0x6d,
+	0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x48, 0x6f, 0x6c,
+	0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x48, 0x6f, 0x6c, 0x64, 0x73, 0x22,
+	0x34, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x4e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x82, 0x01, 0x0a, 0x07, 0x53, 0x65, 0x6e, 0x64, 0x52, 0x65,
+	0x73, 0x12, 0x28, 0x0a, 0x0f, 0x55, 0x73, 0x65, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x55, 0x73, 0x65, 0x64,
+	0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x45,
+	0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x45, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x29, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x79, 0x52, 0x0a,
+	0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x22, 0x80, 0x01, 0x0a, 0x0a, 0x52,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x52, 0x65, 0x71, 0x12, 0x1e, 0x0a, 0x0a, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x46,
+	0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x2a, 0x0a, 0x10, 0x43, 0x6c, 0x65,
+	0x61, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x10, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x26, 0x0a, 0x07, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x0c, 0x0a,
+	0x0a, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x52, 0x65, 0x73, 0x22, 0x19, 0x0a, 0x17, 0x52,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x22, 0x59, 0x0a, 0x17, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x72, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65,
+	0x73, 0x12, 0x3e, 0x0a, 0x13, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x53, 0x65, 0x6e,
+	0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c,
+	0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x13, 0x41, 0x63,
+	0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x53, 0x65, 0x6e, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x22, 0x67, 0x0a, 0x13, 0x44, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x12, 0x1e, 0x0a, 0x0a, 0x46, 0x69, 0x6c, 0x65,
+	0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x46, 0x69,
+	0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x30, 0x0a, 0x09, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x46, 0x69,
+	0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x09, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x22, 0x5a, 0x0a, 0x12, 0x44, 0x65,
+	0x73, 0x74, 0x72, 0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73,
+	0x12, 0x2e, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x44, 0x0a, 0x13, 0x44, 0x65, 0x73, 0x74, 0x72, 0x6f,
+	0x79, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x73, 0x12, 0x2d, 0x0a,
+	0x07, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x44, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x52, 0x65, 0x73, 0x52, 0x07, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xcc, 0x01, 0x0a,
+	0x14, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x75, 0x72, 0x73,
+	0x6f, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1e, 0x0a, 0x0a, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x46, 0x69, 0x6c, 0x65, 0x73,
+	0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x2f, 0x0a, 0x03, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x70, 0x48,
+	0x00, 0x52, 0x03, 0x67, 0x65, 0x74, 0x12, 0x2f, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x2e, 0x53, 0x65, 0x74, 0x4f, 0x70,
+	0x48, 0x00, 0x52, 0x03, 0x73, 0x65, 0x74, 0x1a, 0x07, 0x0a, 0x05, 0x47, 0x65, 0x74, 0x4f, 0x70,
+	0x1a, 0x23, 0x0a, 0x05, 0x53, 0x65, 0x74, 0x4f, 0x70, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x42, 0x04, 0x0a, 0x02, 0x6f, 0x70, 0x22, 0x54, 0x0a, 0x14, 0x52,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72,
+	0x52, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x04, 0x47, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x00, 0x52, 0x04, 0x47, 0x75, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x08, 0x4e, 0x6f, 0x74,
+	0x65, 0x78, 0x69, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x4e,
+	0x6f, 0x74, 0x65, 0x78, 0x69, 0x73, 0x74, 0x42, 0x08, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x22, 0x23, 0x0a, 0x07, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x12, 0x18, 0x0a, 0x07,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x45, 0x0a, 0x07, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x45, 0x63, 0x68, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x45, 0x63, 0x68, 0x6f, 0x12, 0x26, 0x0a, 0x0e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x43,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x22, 0x4b, 0x0a,
+	0x11, 0x54, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70, 0x75, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52,
+	0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x53, 0x65, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x43,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x6e, 0x64, 0x73, 0x22, 0x29, 0x0a, 0x11, 0x54, 0x68,
+	0x72, 0x6f, 0x75, 0x67, 0x68, 0x70, 0x75, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x32, 0x85, 0x03, 0x0a, 0x0b, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x08, 0x2e,
+	0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x1a, 0x08, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65,
+	0x73, 0x12, 0x39, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x73, 0x12, 0x12, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73,
+	0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x46,
+	0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x12, 0x50, 0x0a, 0x16,
+	0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1a, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x65, 0x71, 0x1a, 0x1a, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x12, 0x3e,
+	0x0a, 0x10, 0x44, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x73, 0x12, 0x14, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x14, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x72,
+	0x6f, 0x79, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x73, 0x12, 0x41,
+	0x0a, 0x11, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x75, 0x72,
+	0x73, 0x6f, 0x72, 0x12, 0x15, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x52, 0x65, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x52, 0x65,
+	0x73, 0x12, 0x4a, 0x0a, 0x14, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x43, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x18, 0x2e, 0x52, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x72, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x43, 0x61,
+	0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65, 0x73, 0x42, 0x2e, 0x5a,
+	0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x7a, 0x72, 0x65, 0x70,
+	0x6c, 0x2f, 0x7a, 0x72, 0x65, 0x70, 0x6c, 0x2f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x2f, 0x70, 0x64, 0x75, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pdu_proto_rawDescOnce sync.Once
+	file_pdu_proto_rawDescData = file_pdu_proto_rawDesc
+)
+
+func file_pdu_proto_rawDescGZIP() []byte {
+	file_pdu_proto_rawDescOnce.Do(func() {
+		file_pdu_proto_rawDescData = protoimpl.X.CompressGZIP(file_pdu_proto_rawDescData)
+	})
+	return file_pdu_proto_rawDescData
+}
+
+var file_pdu_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pdu_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_pdu_proto_goTypes = []interface{}{
+	(FilesystemVersion_VersionType)(0), // 0: FilesystemVersion.VersionType
+	(*ListFilesystemReq)(nil),          // 1: ListFilesystemReq
+	(*ListFilesystemRes)(nil),          // 2: ListFilesystemRes
+	(*Filesystem)(nil),                 // 3: Filesystem
+	(*ListFilesystemVersionsReq)(nil),  // 4: ListFilesystemVersionsReq
+	(*ListFilesystemVersionsRes)(nil),  // 5: ListFilesystemVersionsRes
+	(*FilesystemVersion)(nil),          // 6: FilesystemVersion
+	(*SendReq)(nil),                    // 7: SendReq
+	(*SendOptions)(nil),                // 8: SendOptions
+	(*Property)(nil),                   // 9: Property
+	(*SendRes)(nil),                    // 10: SendRes
+	(*ReceiveReq)(nil),                 // 11: ReceiveReq
+	(*ReceiveRes)(nil),                 // 12: ReceiveRes
+	(*ReceiverCapabilitiesReq)(nil),    // 13: ReceiverCapabilitiesReq
+	(*ReceiverCapabilitiesRes)(nil),    // 14: ReceiverCapabilitiesRes
+	(*DestroySnapshotsReq)(nil),        // 15: DestroySnapshotsReq
+	(*DestroySnapshotRes)(nil),         // 16: DestroySnapshotRes
+	(*DestroySnapshotsRes)(nil),        // 17: DestroySnapshotsRes
+	(*ReplicationCursorReq)(nil),       // 18: ReplicationCursorReq
+	(*ReplicationCursorRes)(nil),       // 19: ReplicationCursorRes
+	(*PingReq)(nil),                    // 20: PingReq
+	(*PingRes)(nil),                    // 21: PingRes
+	(*ThroughputTestReq)(nil),          // 22: ThroughputTestReq
+	(*ThroughputTestRes)(nil),          // 23: ThroughputTestRes
+	(*ReplicationCursorReq_GetOp)(nil), // 24: ReplicationCursorReq.GetOp
+	(*ReplicationCursorReq_SetOp)(nil), // 25: ReplicationCursorReq.SetOp
+}
+var file_pdu_proto_depIdxs = []int32{
+	3,  // 0: ListFilesystemRes.Filesystems:type_name -> Filesystem
+	6,  // 1: ListFilesystemVersionsRes.Versions:type_name -> FilesystemVersion
+	0,  // 2: FilesystemVersion.Type:type_name -> FilesystemVersion.VersionType
+	8,  // 3: SendReq.Options:type_name -> SendOptions
+	9,  // 4: SendRes.Properties:type_name -> Property
+	8,  // 5: ReceiveReq.Options:type_name -> SendOptions
+	8,  // 6: ReceiverCapabilitiesRes.AcceptedSendOptions:type_name -> SendOptions
+	6,  // 7: DestroySnapshotsReq.Snapshots:type_name -> FilesystemVersion
+	6,  // 8: DestroySnapshotRes.Snapshot:type_name -> FilesystemVersion
+	16, // 9: DestroySnapshotsRes.Results:type_name -> DestroySnapshotRes
+	24, // 10: ReplicationCursorReq.get:type_name -> ReplicationCursorReq.GetOp
+	25, // 11: ReplicationCursorReq.set:type_name -> ReplicationCursorReq.SetOp
+	20, // 12: Replication.Ping:input_type -> PingReq
+	1,  // 13: Replication.ListFilesystems:input_type -> ListFilesystemReq
+	4,  // 14: Replication.ListFilesystemVersions:input_type -> ListFilesystemVersionsReq
+	15, // 15: Replication.DestroySnapshots:input_type -> DestroySnapshotsReq
+	18, // 16: Replication.ReplicationCursor:input_type -> ReplicationCursorReq
+	13, // 17: Replication.ReceiverCapabilities:input_type -> ReceiverCapabilitiesReq
+	21, // 18: Replication.Ping:output_type -> PingRes
+	2,  // 19: Replication.ListFilesystems:output_type -> ListFilesystemRes
+	5,  // 20: Replication.ListFilesystemVersions:output_type -> ListFilesystemVersionsRes
+	17, // 21: Replication.DestroySnapshots:output_type -> DestroySnapshotsRes
+	19, // 22: Replication.ReplicationCursor:output_type -> ReplicationCursorRes
+	14, // 23: Replication.ReceiverCapabilities:output_type -> ReceiverCapabilitiesRes
+	18, // [18:24] is the sub-list for method output_type
+	12, // [12:18] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_pdu_proto_init() }
+func file_pdu_proto_init() {
+	if File_pdu_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pdu_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListFilesystemReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListFilesystemRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Filesystem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListFilesystemVersionsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListFilesystemVersionsRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FilesystemVersion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Property); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiveReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiveRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiverCapabilitiesReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReceiverCapabilitiesRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestroySnapshotsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestroySnapshotRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestroySnapshotsRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationCursorReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationCursorRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PingReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PingRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ThroughputTestReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ThroughputTestRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationCursorReq_GetOp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pdu_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationCursorReq_SetOp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pdu_proto_msgTypes[17].OneofWrappers = []interface{}{
+		(*ReplicationCursorReq_Get)(nil),
+		(*ReplicationCursorReq_Set)(nil),
+	}
+	file_pdu_proto_msgTypes[18].OneofWrappers = []interface{}{
+		(*ReplicationCursorRes_Guid)(nil),
+		(*ReplicationCursorRes_Notexist)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pdu_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pdu_proto_goTypes,
+		DependencyIndexes: file_pdu_proto_depIdxs,
+		EnumInfos:         file_pdu_proto_enumTypes,
+		MessageInfos:      file_pdu_proto_msgTypes,
+	}.Build()
+	File_pdu_proto = out.File
+	file_pdu_proto_rawDesc = nil
+	file_pdu_proto_goTypes = nil
+	file_pdu_proto_depIdxs = nil
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
-var _ grpc.ClientConn
+var _ grpc.ClientConnInterface
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+const _ = grpc.SupportPackageIsVersion6
 
 // ReplicationClient is the client API for Replication service.
 //
@@ -1197,13 +2107,14 @@ type ReplicationClient interface {
 	ListFilesystemVersions(ctx context.Context, in *ListFilesystemVersionsReq, opts ...grpc.CallOption) (*ListFilesystemVersionsRes, error)
 	DestroySnapshots(ctx context.Context, in *DestroySnapshotsReq, opts ...grpc.CallOption) (*DestroySnapshotsRes, error)
 	ReplicationCursor(ctx context.Context, in *ReplicationCursorReq, opts ...grpc.CallOption) (*ReplicationCursorRes, error)
+	ReceiverCapabilities(ctx context.Context, in *ReceiverCapabilitiesReq, opts ...grpc.CallOption) (*ReceiverCapabilitiesRes, error)
 }
 
 type replicationClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewReplicationClient(cc *grpc.ClientConn) ReplicationClient {
+func NewReplicationClient(cc grpc.ClientConnInterface) ReplicationClient {
 	return &replicationClient{cc}
 }
 
@@ -1252,6 +2163,15 @@ func (c *replicationClient) ReplicationCursor(ctx context.Context, in *Replicati
 	return out, nil
 }
 
+func (c *replicationClient) ReceiverCapabilities(ctx context.Context, in *ReceiverCapabilitiesReq, opts ...grpc.CallOption) (*ReceiverCapabilitiesRes, error) {
+	out := new(ReceiverCapabilitiesRes)
+	err := c.cc.Invoke(ctx, "/Replication/ReceiverCapabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReplicationServer is the server API for Replication service.
 type ReplicationServer interface {
 	Ping(context.Context, *PingReq) (*PingRes, error)
@@ -1259,6 +2179,30 @@ type ReplicationServer interface {
 	ListFilesystemVersions(context.Context, *ListFilesystemVersionsReq) (*ListFilesystemVersionsRes, error)
 	DestroySnapshots(context.Context, *DestroySnapshotsReq) (*DestroySnapshotsRes, error)
 	ReplicationCursor(context.Context, *ReplicationCursorReq) (*ReplicationCursorRes, error)
+	ReceiverCapabilities(context.Context, *ReceiverCapabilitiesReq) (*ReceiverCapabilitiesRes, error)
+}
+
+// UnimplementedReplicationServer can be embedded to have forward compatible implementations.
+type UnimplementedReplicationServer struct {
+}
+
+func (*UnimplementedReplicationServer) Ping(context.Context, *PingReq) (*PingRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (*UnimplementedReplicationServer) ListFilesystems(context.Context, *ListFilesystemReq) (*ListFilesystemRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFilesystems not implemented")
+}
+func (*UnimplementedReplicationServer) ListFilesystemVersions(context.Context, *ListFilesystemVersionsReq) (*ListFilesystemVersionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFilesystemVersions not implemented")
+}
+func (*UnimplementedReplicationServer) DestroySnapshots(context.Context, *DestroySnapshotsReq) (*DestroySnapshotsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DestroySnapshots not implemented")
+}
+func (*UnimplementedReplicationServer) ReplicationCursor(context.Context, *ReplicationCursorReq) (*ReplicationCursorRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicationCursor not implemented")
+}
+func (*UnimplementedReplicationServer) ReceiverCapabilities(context.Context, *ReceiverCapabilitiesReq) (*ReceiverCapabilitiesRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReceiverCapabilities not implemented")
 }
 
 func RegisterReplicationServer(s *grpc.Server, srv ReplicationServer) {
@@ -1355,6 +2299,24 @@ func _Replication_ReplicationCursor_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Replication_ReceiverCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiverCapabilitiesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicationServer).ReceiverCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Replication/ReceiverCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicationServer).ReceiverCapabilities(ctx, req.(*ReceiverCapabilitiesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Replication_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Replication",
 	HandlerType: (*ReplicationServer)(nil),
@@ -1379,63 +2341,11 @@ var _Replication_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ReplicationCursor",
 			Handler:    _Replication_ReplicationCursor_Handler,
 		},
+		{
+			MethodName: "ReceiverCapabilities",
+			Handler:    _Replication_ReceiverCapabilities_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pdu.proto",
 }
-
-func init() { proto.RegisterFile("pdu.proto", fileDescriptor_pdu_83b7e2a28d820622) }
-
-var fileDescriptor_pdu_83b7e2a28d820622 = []byte{
-	// 785 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xd1, 0x8e, 0xe3, 0x34,
-	0x14, 0x9d, 0xb4, 0x69, 0x9b, 0xde, 0x0e, 0xbb, 0x1d, 0x4f, 0x59, 0x85, 0x00, 0xab, 0xca, 0xcb,
-	0x43, 0x17, 0x89, 0x80, 0x0a, 0x2f, 0x08, 0x09, 0x89, 0x4e, 0x67, 0x67, 0x10, 0xb0, 0x54, 0x6e,
-	0x59, 0xad, 0xf6, 0x2d, 0x34, 0x57, 0x6d, 0x34, 0x6d, 0x9d, 0xb5, 0x13, 0xb4, 0xe5, 0x91, 0xbf,
-	0x9a, 0x7f, 0xe0, 0x91, 0x0f, 0x42, 0x76, 0xe3, 0x34, 0x6d, 0x52, 0xa9, 0x4f, 0xf1, 0x39, 0xf7,
-	0xda, 0x3e, 0xf7, 0xd8, 0xd7, 0x81, 0x76, 0x1c, 0xa6, 0x7e, 0x2c, 0x78, 0xc2, 0xe9, 0x35, 0x5c,
-	0xfd, 0x1a, 0xc9, 0xe4, 0x55, 0xb4, 0x42, 0xb9, 0x95, 0x09, 0xae, 0x19, 0xbe, 0xa7, 0xa3, 0x32,
-	0x29, 0xc9, 0x57, 0xd0, 0xd9, 0x13, 0xd2, 0xb5, 0xfa, 0xf5, 0x41, 0x67, 0xd8, 0xf1, 0x0b, 0x49,
-	0xc5, 0x38, 0x5d, 0x02, 0xec, 0x21, 0x21, 0x60, 0x4f, 0x82, 0x64, 0xe9, 0x5a, 0x7d, 0x6b, 0xd0,
-	0x66, 0x7a, 0x4c, 0xfa, 0xd0, 0x61, 0x28, 0xd3, 0x35, 0xce, 0xf8, 0x03, 0x6e, 0xdc, 0x9a, 0x0e,
-	0x15, 0x29, 0xf2, 0x05, 0x7c, 0xf4, 0xb3, 0x9c, 0xac, 0x82, 0x39, 0x2e, 0xf9, 0x2a, 0x44, 0xe1,
-	0xd6, 0xfb, 0xd6, 0xc0, 0x61, 0x87, 0x24, 0xfd, 0x01, 0x3e, 0x39, 0x54, 0xfb, 0x06, 0x85, 0x8c,
-	0xf8, 0x46, 0x32, 0x7c, 0x4f, 0x9e, 0x17, 0x65, 0x64, 0xdb, 0x17, 0x18, 0xfa, 0xcb, 0xe9, 0xc9,
-	0x92, 0xf8, 0xe0, 0x18, 0x98, 0xd5, 0x4b, 0xfc, 0x52, 0x26, 0xcb, 0x73, 0xe8, 0x7f, 0x16, 0x5c,
-	0x95, 0xe2, 0x64, 0x08, 0xf6, 0x6c, 0x1b, 0xa3, 0xde, 0xfc, 0xc9, 0xf0, 0x79, 0x79, 0x05, 0x3f,
-	0xfb, 0xaa, 0x2c, 0xa6, 0x73, 0x95, 0x5f, 0xaf, 0x83, 0x35, 0x66, 0xa6, 0xe8, 0xb1, 0xe2, 0xee,
-	0xd2, 0x28, 0xd4, 0x26, 0xd8, 0x4c, 0x8f, 0xc9, 0x67, 0xd0, 0xbe, 0x11, 0x18, 0x24, 0x38, 0x7b,
-	0x7b, 0xe7, 0xda, 0x3a, 0xb0, 0x27, 0x88, 0x07, 0x8e, 0x06, 0x11, 0xdf, 0xb8, 0x0d, 0xbd, 0x52,
-	0x8e, 0xe9, 0x4b, 0xe8, 0x14, 0xb6, 0x25, 0x97, 0xe0, 0x4c, 0x37, 0x41, 0x2c, 0x97, 0x3c, 0xe9,
-	0x5e, 0x28, 0x34, 0xe2, 0xfc, 0x61, 0x1d, 0x88, 0x87, 0xae, 0x45, 0x1f, 0x2d, 0x68, 0x4d, 0x71,
-	0x13, 0x9e, 0xe1, 0xa7, 0x12, 0xf9, 0x4a, 0xf0, 0xb5, 0x11, 0xae, 0xc6, 0xe4, 0x09, 0xd4, 0x66,
-	0x5c, 0xcb, 0x6e, 0xb3, 0xda, 0x8c, 0x1f, 0x1f, 0xbc, 0x5d, 0x3e, 0x78, 0x25, 0x9c, 0xaf, 0x63,
-	0x81, 0x52, 0x6a, 0xe1, 0x0e, 0xcb, 0x31, 0xe9, 0x41, 0x63, 0x8c, 0x61, 0x1a, 0xbb, 0x4d, 0x1d,
-	0xd8, 0x01, 0xf2, 0x0c, 0x9a, 0x63, 0xb1, 0x65, 0xe9, 0xc6, 0x6d, 0x69, 0x3a, 0x43, 0xf4, 0x3b,
-	0x70, 0x26, 0x82, 0xc7, 0x28, 0x92, 0x6d, 0x6e, 0xaa, 0x55, 0x30, 0xb5, 0x07, 0x8d, 0x37, 0xc1,
-	0x2a, 0x35, 0x4e, 0xef, 0x00, 0xfd, 0x27, 0xaf, 0x58, 0x92, 0x01, 0x3c, 0xfd, 0x43, 0x62, 0x78,
-	0x7c, 0x55, 0x1d, 0x76, 0x4c, 0x13, 0x0a, 0x97, 0xb7, 0x1f, 0x62, 0x9c, 0x27, 0x18, 0x4e, 0xa3,
-	0xbf, 0x51, 0x57, 0x5c, 0x67, 0x07, 0x1c, 0x79, 0x09, 0x90, 0xe9, 0x89, 0x50, 0xba, 0xb6, 0xbe,
-	0x54, 0x6d, 0xdf, 0x48, 0x64, 0x85, 0x20, 0x7d, 0x0b, 0xc0, 0x70, 0x8e, 0xd1, 0x5f, 0x78, 0x8e,
-	0xf1, 0x5f, 0x42, 0xf7, 0x66, 0x85, 0x81, 0x28, 0xeb, 0x2c, 0xf1, 0xf4, 0xb2, 0xb0, 0xb2, 0xa4,
-	0x0b, 0xb8, 0x1e, 0xa3, 0x4c, 0x04, 0xdf, 0x9a, 0x1b, 0x70, 0x4e, 0xe7, 0x90, 0x6f, 0xa0, 0x9d,
-	0xe7, 0xbb, 0xb5, 0x93, 0xdd, 0xb1, 0x4f, 0xa2, 0xef, 0x80, 0x1c, 0x6d, 0x94, 0x35, 0x99, 0x81,
-	0x7a, 0x97, 0x13, 0x4d, 0x66, 0x72, 0xd4, 0x89, 0xdd, 0x0a, 0xc1, 0x85, 0x39, 0x31, 0x0d, 0xe8,
-	0xb8, 0xaa, 0x08, 0xf5, 0x68, 0xb5, 0x54, 0xe1, 0xab, 0xc4, 0x34, 0xf0, 0xb5, 0x5f, 0x96, 0xc0,
-	0x4c, 0x0e, 0xfd, 0xd7, 0x82, 0x1e, 0xc3, 0x78, 0x15, 0xcd, 0x75, 0x93, 0xdc, 0xa4, 0x42, 0x72,
-	0x71, 0x8e, 0x19, 0x5f, 0x43, 0x7d, 0x81, 0x89, 0x96, 0xd4, 0x19, 0x7e, 0xea, 0x57, 0xad, 0xe1,
-	0xdf, 0x61, 0xf2, 0x7b, 0x7c, 0x7f, 0xc1, 0x54, 0xa6, 0x9a, 0x20, 0x31, 0xd1, 0x57, 0xe4, 0xe4,
-	0x84, 0xa9, 0x99, 0x20, 0x31, 0xf1, 0x5a, 0xd0, 0xd0, 0x0b, 0x78, 0x2f, 0xa0, 0xa1, 0x03, 0xaa,
-	0x49, 0x72, 0xe3, 0x76, 0x5e, 0xe4, 0x78, 0x64, 0x43, 0x8d, 0xc7, 0x74, 0x56, 0x59, 0x8d, 0x6a,
-	0xa1, 0xdd, 0x4b, 0xa2, 0xea, 0xb0, 0xef, 0x2f, 0xf2, 0xb7, 0xc4, 0x79, 0xcd, 0x13, 0xfc, 0x10,
-	0xc9, 0xdd, 0x7a, 0xce, 0xfd, 0x05, 0xcb, 0x99, 0x91, 0x03, 0xcd, 0x9d, 0x4b, 0xf4, 0x05, 0xb4,
-	0x26, 0xd1, 0x66, 0xa1, 0x6c, 0x71, 0xa1, 0xf5, 0x1b, 0x4a, 0x19, 0x2c, 0x4c, 0x53, 0x19, 0x48,
-	0x3f, 0x37, 0x49, 0x52, 0xb5, 0xdd, 0xed, 0x7c, 0xc9, 0x4d, 0xdb, 0xa9, 0xf1, 0xf0, 0xb1, 0xa6,
-	0xde, 0x80, 0x5c, 0x1a, 0xf1, 0xc0, 0x56, 0xe9, 0xc4, 0xf1, 0xb3, 0xa5, 0x3d, 0x33, 0x92, 0xe4,
-	0x7b, 0x78, 0x7a, 0xf8, 0x44, 0x4b, 0x42, 0xfc, 0xd2, 0x4f, 0xcb, 0x2b, 0x73, 0x92, 0x4c, 0xe0,
-	0x59, 0xf5, 0xeb, 0x4e, 0x3c, 0xff, 0xe4, 0x3f, 0xc3, 0x3b, 0x1d, 0x93, 0xe4, 0x47, 0xe8, 0x1e,
-	0xdf, 0x33, 0xd2, 0xf3, 0x2b, 0xfa, 0xc7, 0xab, 0x62, 0x25, 0xf9, 0x09, 0xae, 0x4a, 0x47, 0x42,
-	0x3e, 0xae, 0x3c, 0x7f, 0xaf, 0x92, 0x96, 0xa3, 0xc6, 0xbb, 0x7a, 0x1c, 0xa6, 0x7f, 0x36, 0xf5,
-	0x0f, 0xfc, 0xdb, 0xff, 0x03, 0x00, 0x00, 0xff, 0xff, 0x37, 0x0e, 0xf2, 0xe4, 0xcd, 0x07, 0x00,
-	0x00,
-}
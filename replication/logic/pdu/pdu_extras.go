@@ -75,3 +75,28 @@ func (v *FilesystemVersion) ZFSFilesystemVersion() (*zfs.FilesystemVersion, erro
 		Creation:  ct,
 	}, nil
 }
+
+// ZFSSendFlags is safe to call on a nil SendOptions.
+func (o *SendOptions) ZFSSendFlags() zfs.ZFSSendFlags {
+	return zfs.ZFSSendFlags{
+		LargeBlocks:  o.GetLargeBlocks(),
+		EmbeddedData: o.GetEmbeddedData(),
+		Compressed:   o.GetCompressed(),
+		Holds:        o.GetHolds(),
+	}
+}
+
+// Unsupported returns the zfs send flags set in o but not in accepted.
+// Both o and accepted may be nil.
+func (o *SendOptions) Unsupported(accepted *SendOptions) (flags []string) {
+	check := func(used, ok bool, flag string) {
+		if used && !ok {
+			flags = append(flags, flag)
+		}
+	}
+	check(o.GetLargeBlocks(), accepted.GetLargeBlocks(), "-L")
+	check(o.GetEmbeddedData(), accepted.GetEmbeddedData(), "-e")
+	check(o.GetCompressed(), accepted.GetCompressed(), "-c")
+	check(o.GetHolds(), accepted.GetHolds(), "-h")
+	return flags
+}
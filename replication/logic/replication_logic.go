@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -46,18 +47,23 @@ type Receiver interface {
 	// Receive sends r and sendStream (the latter containing a ZFS send stream)
 	// to the parent github.com/zrepl/zrepl/replication.Endpoint.
 	Receive(ctx context.Context, req *pdu.ReceiveReq, receive zfs.StreamCopier) (*pdu.ReceiveRes, error)
+	ReceiverCapabilities(ctx context.Context, req *pdu.ReceiverCapabilitiesReq) (*pdu.ReceiverCapabilitiesRes, error)
 }
 
 type Planner struct {
-	sender   Sender
-	receiver Receiver
+	sender      Sender
+	receiver    Receiver
+	sendOptions *pdu.SendOptions
 
 	promSecsPerState    *prometheus.HistogramVec // labels: state
 	promBytesReplicated *prometheus.CounterVec   // labels: filesystem
 }
 
 func (p *Planner) Plan(ctx context.Context) ([]driver.FS, error) {
+	ctx, span := startSpan(ctx, "logic.plan")
+	defer span.End()
 	fss, err := p.doPlanning(ctx)
+	span.SetError(err)
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +113,9 @@ func (p *Planner) WaitForConnectivity(ctx context.Context) error {
 }
 
 type Filesystem struct {
-	sender   Sender
-	receiver Receiver
+	sender      Sender
+	receiver    Receiver
+	sendOptions *pdu.SendOptions
 
 	Path                string // compat
 	receiverFS          *pdu.Filesystem
@@ -127,7 +134,11 @@ func (f *Filesystem) EqualToPreviousAttempt(other driver.FS) bool {
 }
 
 func (f *Filesystem) PlanFS(ctx context.Context) ([]driver.Step, error) {
+	ctx, span := startSpan(ctx, "logic.plan_fs")
+	span.SetAttribute("filesystem", f.Path)
+	defer span.End()
 	steps, err := f.doPlanning(ctx)
+	span.SetError(err)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +161,9 @@ type Step struct {
 
 	expectedSize int64 // 0 means no size estimate present / possible
 
+	// receiver's receive_resume_token that the sender may use for this step, may be empty
+	resumeToken string
+
 	// byteCounter is nil initially, and set later in Step.doReplication
 	// => concurrent read of that pointer from Step.ReportInfo must be protected
 	byteCounter    bytecounter.StreamCopier
@@ -173,7 +187,18 @@ func (s *Step) TargetDate() time.Time {
 }
 
 func (s *Step) Step(ctx context.Context) error {
-	return s.doReplication(ctx)
+	ctx, span := startSpan(ctx, "logic.step")
+	defer span.End()
+	span.SetAttribute("filesystem", s.parent.Path)
+	if s.from != nil {
+		span.SetAttribute("from", s.from.RelName())
+	}
+	span.SetAttribute("to", s.to.RelName())
+	span.SetAttribute("resumable", s.resumeToken != "")
+	err := s.doReplication(ctx)
+	span.SetAttribute("bytes_replicated", s.ReportInfo().BytesReplicated)
+	span.SetError(err)
+	return err
 }
 
 func (s *Step) ReportInfo() *report.StepInfo {
@@ -199,10 +224,12 @@ func (s *Step) ReportInfo() *report.StepInfo {
 	}
 }
 
-func NewPlanner(secsPerState *prometheus.HistogramVec, bytesReplicated *prometheus.CounterVec, sender Sender, receiver Receiver) *Planner {
+// sendOptions may be nil for a plain zfs send
+func NewPlanner(secsPerState *prometheus.HistogramVec, bytesReplicated *prometheus.CounterVec, sender Sender, receiver Receiver, sendOptions *pdu.SendOptions) *Planner {
 	return &Planner{
 		sender:              sender,
 		receiver:            receiver,
+		sendOptions:         sendOptions,
 		promSecsPerState:    secsPerState,
 		promBytesReplicated: bytesReplicated,
 	}
@@ -248,6 +275,11 @@ func (p *Planner) doPlanning(ctx context.Context) ([]*Filesystem, error) {
 	}
 	rfss := rlfssres.GetFilesystems()
 
+	if err := p.checkReceiverCapabilities(ctx); err != nil {
+		log.WithError(err).Error("receiver cannot handle send options")
+		return nil, err
+	}
+
 	sizeEstimateRequestSem := semaphore.New(envconst.Int64("ZREPL_REPLICATION_MAX_CONCURRENT_SIZE_ESTIMATE", 4))
 
 	q := make([]*Filesystem, 0, len(sfss))
@@ -265,6 +297,7 @@ func (p *Planner) doPlanning(ctx context.Context) ([]*Filesystem, error) {
 		q = append(q, &Filesystem{
 			sender:                 p.sender,
 			receiver:               p.receiver,
+			sendOptions:            p.sendOptions,
 			Path:                   fs.Path,
 			receiverFS:             receiverFS,
 			promBytesReplicated:    ctr,
@@ -275,6 +308,23 @@ func (p *Planner) doPlanning(ctx context.Context) ([]*Filesystem, error) {
 	return q, nil
 }
 
+// checkReceiverCapabilities ensures that the receiver accepts streams produced with p.sendOptions.
+// The receiver is not asked if no flags are requested so that replication to
+// receivers that predate capabilities keeps working.
+func (p *Planner) checkReceiverCapabilities(ctx context.Context) error {
+	if len(p.sendOptions.Unsupported(nil)) == 0 {
+		return nil
+	}
+	res, err := p.receiver.ReceiverCapabilities(ctx, &pdu.ReceiverCapabilitiesReq{})
+	if err != nil {
+		return fmt.Errorf("cannot determine receiver capabilities: %s", err)
+	}
+	if unsupported := p.sendOptions.Unsupported(res.GetAcceptedSendOptions()); len(unsupported) > 0 {
+		return fmt.Errorf("receiver does not accept streams sent with zfs send flags %s", strings.Join(unsupported, " "))
+	}
+	return nil
+}
+
 func (fs *Filesystem) doPlanning(ctx context.Context) ([]*Step, error) {
 
 	log := getLogger(ctx).WithField("filesystem", fs.Path)
@@ -344,6 +394,12 @@ func (fs *Filesystem) doPlanning(ctx context.Context) ([]*Step, error) {
 		}
 	}
 
+	if fs.receiverFS != nil && fs.receiverFS.GetResumeToken() != "" {
+		// the sender decides whether the token actually belongs to the first step
+		log.WithField("token", fs.receiverFS.GetResumeToken()).Debug("receiver has partial receive state")
+		steps[0].resumeToken = fs.receiverFS.GetResumeToken()
+	}
+
 	log.Debug("compute send size estimate")
 	errs := make(chan error, len(steps))
 	var wg sync.WaitGroup
@@ -414,6 +470,8 @@ func (fs *Filesystem) doPlanning(ctx context.Context) ([]*Step, error) {
 
 func (s *Step) updateSizeEstimate(ctx context.Context) error {
 
+	ctx, span := startSpan(ctx, "logic.size_estimate")
+	defer span.End()
 	log := getLogger(ctx)
 
 	sr := s.buildSendRequest(true)
@@ -422,6 +480,7 @@ func (s *Step) updateSizeEstimate(ctx context.Context) error {
 	sres, _, err := s.sender.Send(ctx, sr)
 	if err != nil {
 		log.WithError(err).Error("dry run send request failed")
+		span.SetError(err)
 		return err
 	}
 	s.expectedSize = sres.ExpectedSize
@@ -432,16 +491,20 @@ func (s *Step) buildSendRequest(dryRun bool) (sr *pdu.SendReq) {
 	fs := s.parent.Path
 	if s.from == nil {
 		sr = &pdu.SendReq{
-			Filesystem: fs,
-			To:         s.to.RelName(),
-			DryRun:     dryRun,
+			Filesystem:  fs,
+			To:          s.to.RelName(),
+			ResumeToken: s.resumeToken,
+			DryRun:      dryRun,
+			Options:     s.parent.sendOptions,
 		}
 	} else {
 		sr = &pdu.SendReq{
-			Filesystem: fs,
-			From:       s.from.RelName(),
-			To:         s.to.RelName(),
-			DryRun:     dryRun,
+			Filesystem:  fs,
+			From:        s.from.RelName(),
+			To:          s.to.RelName(),
+			ResumeToken: s.resumeToken,
+			DryRun:      dryRun,
+			Options:     s.parent.sendOptions,
 		}
 	}
 	return sr
@@ -477,8 +540,10 @@ func (s *Step) doReplication(ctx context.Context) error {
 	}()
 
 	rr := &pdu.ReceiveReq{
-		Filesystem:       fs,
-		ClearResumeToken: !sres.UsedResumeToken,
+		Filesystem: fs,
+		// only discard partial receive state that the receiver reported
+		ClearResumeToken: s.resumeToken != "" && !sres.UsedResumeToken,
+		Options:          s.parent.sendOptions,
 	}
 	log.Debug("initiate receive request")
 	_, err = s.receiver.Receive(ctx, rr, byteCountingStream)
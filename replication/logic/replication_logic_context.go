@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/util/tracing"
 )
 
 type contextKey int
@@ -26,3 +27,9 @@ func getLogger(ctx context.Context) Logger {
 	}
 	return l
 }
+
+// startSpan starts a span and sets its IDs as fields of the logger in the returned context
+func startSpan(ctx context.Context, name string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, name, tracing.SpanKindInternal)
+	return WithLogger(ctx, tracing.WithLogFields(ctx, getLogger(ctx))), span
+}
@@ -0,0 +1,221 @@
+package replication_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/endpoint"
+	"github.com/zrepl/zrepl/replication"
+	"github.com/zrepl/zrepl/replication/logic"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/replication/report"
+	"github.com/zrepl/zrepl/zfs"
+	"github.com/zrepl/zrepl/zfs/zfsfake"
+)
+
+// Push -> sink replication against the in-memory ZFS simulation.
+
+type prefixFilter struct{ prefix *zfs.DatasetPath }
+
+func (f prefixFilter) Filter(p *zfs.DatasetPath) (bool, error) {
+	return p.HasPrefix(f.prefix) && !p.Equal(f.prefix), nil
+}
+
+func path(s string) *zfs.DatasetPath {
+	p, err := zfs.NewDatasetPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+type pushSinkTest struct {
+	t        *testing.T
+	b        *zfsfake.Backend
+	sender   *endpoint.Sender
+	receiver *endpoint.Receiver
+}
+
+func newPushSinkTest(t *testing.T, recvResumable bool) (*pushSinkTest, func()) {
+	b := zfsfake.New()
+	prev := zfs.SetBackend(b)
+	require.NoError(t, b.AddPool("src"))
+	require.NoError(t, b.AddPool("dst"))
+	for _, fs := range []string{"src/data", "src/data/a", "src/data/a/b", "dst/sink"} {
+		require.NoError(t, b.Create(fs, nil))
+	}
+	return &pushSinkTest{
+		t:        t,
+		b:        b,
+		sender:   endpoint.NewSender(prefixFilter{path("src/data")}, nil),
+		receiver: endpoint.NewReceiver(path("dst/sink"), false, nil, recvResumable),
+	}, func() { zfs.SetBackend(prev) }
+}
+
+func (p *pushSinkTest) snapshot(fs, name, data string) {
+	require.NoError(p.t, p.b.WriteData(fs, []byte(data)))
+	require.NoError(p.t, zfs.ZFSSnapshot(path(fs), name, false))
+}
+
+func (p *pushSinkTest) replicate() *report.AttemptReport {
+	promSecsPerState := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "state_time"}, []string{"state"})
+	promBytesReplicated := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "bytes_replicated"}, []string{"filesystem"})
+	planner := logic.NewPlanner(promSecsPerState, promBytesReplicated, p.sender, p.receiver, nil)
+	getReport, wait := replication.Do(context.Background(), planner)
+	wait(true)
+	rep := getReport()
+	require.NotEmpty(p.t, rep.Attempts)
+	return rep.Attempts[len(rep.Attempts)-1]
+}
+
+func (p *pushSinkTest) versions(fs string) (names []string, guids []uint64) {
+	vs, err := zfs.ZFSListFilesystemVersions(path(fs), nil)
+	require.NoError(p.t, err)
+	for _, v := range vs {
+		names = append(names, v.String())
+		guids = append(guids, v.Guid)
+	}
+	return names, guids
+}
+
+func (p *pushSinkTest) data(path string) string {
+	d, err := p.b.ReadData(path)
+	require.NoError(p.t, err)
+	return string(d)
+}
+
+func TestPushSink(t *testing.T) {
+	p, cleanup := newPushSinkTest(t, false)
+	defer cleanup()
+
+	// initial replication
+	p.snapshot("src/data/a", "1", "a1")
+	p.snapshot("src/data/a/b", "1", "b1")
+	rep := p.replicate()
+	require.Equal(t, report.AttemptDone, rep.State)
+
+	names, guids := p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1"}, names)
+	_, srcGUIDs := p.versions("src/data/a")
+	assert.Equal(t, srcGUIDs[:1], guids)
+	assert.Equal(t, "b1", p.data("dst/sink/src/data/a/b@1"))
+	for _, fs := range []string{"dst/sink/src", "dst/sink/src/data"} {
+		st, err := zfs.ZFSGetFilesystemPlaceholderState(path(fs))
+		require.NoError(t, err)
+		assert.True(t, st.IsPlaceholder, "%s must be a placeholder", fs)
+	}
+	cursor, err := zfs.ZFSGetReplicationCursor(path("src/data/a"))
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	assert.Equal(t, srcGUIDs[0], cursor.Guid)
+
+	// incremental replication
+	p.snapshot("src/data/a", "2", "a2")
+	p.snapshot("src/data/a", "3", "a3")
+	rep = p.replicate()
+	require.Equal(t, report.AttemptDone, rep.State)
+	names, _ = p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1", "@2", "@3"}, names)
+	assert.Equal(t, "a3", p.data("dst/sink/src/data/a"))
+
+	// the source's snapshots can be pruned down to the replication cursor
+	require.NoError(t, zfs.ZFSDestroy("src/data/a@1,2,3"))
+	p.snapshot("src/data/a", "4", "a4")
+	rep = p.replicate()
+	require.Equal(t, report.AttemptDone, rep.State)
+	names, _ = p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1", "@2", "@3", "@4"}, names)
+}
+
+func TestPushSinkResumesInterruptedReceive(t *testing.T) {
+	p, cleanup := newPushSinkTest(t, true)
+	defer cleanup()
+
+	p.snapshot("src/data/a", "1", "a1")
+	p.snapshot("src/data/a/b", "1", "b1")
+	require.Equal(t, report.AttemptDone, p.replicate().State)
+
+	p.snapshot("src/data/a", "2", "0123456789")
+	p.b.BreakNextSend(4)
+	rep := p.replicate()
+	require.NotEqual(t, report.AttemptDone, rep.State)
+	token, err := zfs.ZFSGetReceiveResumeToken(path("dst/sink/src/data/a"))
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	rep = p.replicate()
+	require.Equal(t, report.AttemptDone, rep.State)
+	var fsRep *report.FilesystemReport
+	for _, fs := range rep.Filesystems {
+		if fs.Info.Name == "src/data/a" {
+			fsRep = fs
+		}
+	}
+	require.NotNil(t, fsRep)
+	require.Len(t, fsRep.Steps, 1)
+	assert.Equal(t, int64(6), fsRep.Steps[0].Info.BytesExpected, "only the remainder of the stream is sent")
+	names, _ := p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1", "@2"}, names)
+	assert.Equal(t, "0123456789", p.data("dst/sink/src/data/a@2"))
+	token, err = zfs.ZFSGetReceiveResumeToken(path("dst/sink/src/data/a"))
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestPushSinkInterruptedReceiveNotResumable(t *testing.T) {
+	p, cleanup := newPushSinkTest(t, false)
+	defer cleanup()
+
+	p.snapshot("src/data/a", "1", "a1")
+	p.snapshot("src/data/a/b", "1", "b1")
+	require.Equal(t, report.AttemptDone, p.replicate().State)
+
+	p.snapshot("src/data/a", "2", "0123456789")
+	p.b.BreakNextSend(4)
+	require.NotEqual(t, report.AttemptDone, p.replicate().State)
+	token, err := zfs.ZFSGetReceiveResumeToken(path("dst/sink/src/data/a"))
+	require.NoError(t, err)
+	require.Empty(t, token, "partial receive state must only be kept if the receiver is resumable")
+
+	require.Equal(t, report.AttemptDone, p.replicate().State)
+	names, _ := p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1", "@2"}, names)
+	assert.Equal(t, "0123456789", p.data("dst/sink/src/data/a@2"))
+}
+
+// Clients that skip the planner's capability check must be refused by the endpoints.
+func TestEndpointsEnforceSendOptions(t *testing.T) {
+	p, cleanup := newPushSinkTest(t, false)
+	defer cleanup()
+	p.snapshot("src/data/a", "1", "a1")
+	ctx := context.Background()
+	largeBlocks := &pdu.SendOptions{LargeBlocks: true}
+	sendReq := &pdu.SendReq{Filesystem: "src/data/a", To: "@1", Options: largeBlocks}
+	recvReq := &pdu.ReceiveReq{Filesystem: "src/data/a", Options: largeBlocks}
+
+	_, _, err := p.sender.Send(ctx, sendReq)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "-L")
+
+	allowing := endpoint.NewSender(prefixFilter{path("src/data")}, largeBlocks)
+	_, stream, err := allowing.Send(ctx, sendReq)
+	require.NoError(t, err)
+	_, err = p.receiver.Receive(ctx, recvReq, stream)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "-L")
+	st, err := zfs.ZFSGetFilesystemPlaceholderState(path("dst/sink/src"))
+	require.NoError(t, err)
+	assert.False(t, st.FSExists, "refused streams must not create placeholders")
+
+	accepting := endpoint.NewReceiver(path("dst/sink"), false, largeBlocks, false)
+	_, stream, err = allowing.Send(ctx, sendReq)
+	require.NoError(t, err)
+	_, err = accepting.Receive(ctx, recvReq, stream)
+	require.NoError(t, err)
+	names, _ := p.versions("dst/sink/src/data/a")
+	assert.Equal(t, []string{"@1"}, names)
+}
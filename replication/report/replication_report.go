@@ -40,6 +40,8 @@ type AttemptReport struct {
 	StartAt, FinishAt time.Time
 	PlanError         *TimedError
 	Filesystems       []*FilesystemReport
+	// Across all filesystems
+	Progress *Progress `json:",omitempty"`
 }
 
 type AttemptState string
@@ -49,6 +51,7 @@ const (
 	AttemptPlanningError AttemptState = "planning-error"
 	AttemptFanOutFSs     AttemptState = "fan-out-filesystems"
 	AttemptFanOutError   AttemptState = "filesystem-error"
+	AttemptPaused        AttemptState = "paused"
 	AttemptDone          AttemptState = "done"
 )
 
@@ -59,6 +62,7 @@ const (
 	FilesystemPlanningErrored FilesystemState = "planning-error"
 	FilesystemStepping        FilesystemState = "stepping"
 	FilesystemSteppingErrored FilesystemState = "step-error"
+	FilesystemPaused          FilesystemState = "paused"
 	FilesystemDone            FilesystemState = "done"
 )
 
@@ -72,9 +76,11 @@ type FilesystemReport struct {
 	// Valid in State = FilesystemSteppingErrored
 	StepError *TimedError
 
-	// Valid in State = FilesystemStepping
+	// Valid in State = FilesystemStepping, FilesystemPaused
 	CurrentStep int
 	Steps       []*StepReport
+
+	Progress *Progress `json:",omitempty"`
 }
 
 type FilesystemInfo struct {
@@ -82,7 +88,17 @@ type FilesystemInfo struct {
 }
 
 type StepReport struct {
-	Info *StepInfo
+	Info     *StepInfo
+	Progress *Progress `json:",omitempty"`
+}
+
+// Progress is the replication rate measured by the daemon
+// and the completion time estimated from it and BytesExpected.
+type Progress struct {
+	// moving average
+	BytesPerSecond int64
+	// zero if unknown, i.e., if the rate is zero or nothing remains to be replicated
+	ETA time.Time
 }
 
 type StepInfo struct {
@@ -139,6 +155,8 @@ func (f *FilesystemReport) NextStep() *StepReport {
 		return nil
 	case FilesystemPlanning:
 		return nil
+	case FilesystemPaused:
+		fallthrough
 	case FilesystemStepping:
 		// invariant is that this is always correct
 		// TODO what about 0-length Steps but short intermediary state?
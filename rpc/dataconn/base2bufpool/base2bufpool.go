@@ -110,8 +110,9 @@ func fittingShift(x uint) uint {
 	return blen
 }
 
-func (p *Pool) handlePotentialNoFit(reqShift uint) (buf Buffer, didHandle bool) {
-	if reqShift == 0 {
+// minSize is passed in addition to reqShift because fittingShift(0) == fittingShift(1)
+func (p *Pool) handlePotentialNoFit(minSize, reqShift uint) (buf Buffer, didHandle bool) {
+	if minSize == 0 {
 		if p.onNoFit&AllocateSmaller != 0 {
 			return Buffer{[]byte{}, 0, nil}, true
 		} else {
@@ -141,7 +142,7 @@ doPanic:
 
 func (p *Pool) Get(minSize uint) Buffer {
 	shift := fittingShift(minSize)
-	buf, didHandle := p.handlePotentialNoFit(shift)
+	buf, didHandle := p.handlePotentialNoFit(minSize, shift)
 	if didHandle {
 		buf.Shrink(minSize)
 		return buf
@@ -49,6 +49,14 @@ func TestPoolAllocBehavior(t *testing.T) {
 			15, 20, Allocate,
 			0, 0,
 		},
+		{
+			15, 20, Allocate,
+			1, 1,
+		},
+		{
+			15, 20, Panic,
+			1, -1,
+		},
 		{
 			15, 20, AllocateSmaller,
 			1 << 14, 1 << 14,
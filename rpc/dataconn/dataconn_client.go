@@ -10,7 +10,9 @@ import (
 
 	"github.com/zrepl/zrepl/replication/logic/pdu"
 	"github.com/zrepl/zrepl/rpc/dataconn/stream"
+	"github.com/zrepl/zrepl/rpc/versionhandshake"
 	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/util/tracing"
 	"github.com/zrepl/zrepl/zfs"
 )
 
@@ -26,14 +28,22 @@ func NewClient(connecter transport.Connecter, log Logger) *Client {
 	}
 }
 
-func (c *Client) send(ctx context.Context, conn *stream.Conn, endpoint string, req proto.Message, streamCopier zfs.StreamCopier) error {
+// clientConn is a connection to the server
+type clientConn struct {
+	*stream.Conn
+	// whether the server announced support for request header fields,
+	// older servers fail requests whose header is more than the endpoint
+	headerFields bool
+}
+
+func (c *Client) send(ctx context.Context, conn *clientConn, endpoint string, req proto.Message, streamCopier zfs.StreamCopier) error {
 
-	var buf bytes.Buffer
-	_, memErr := buf.WriteString(endpoint)
-	if memErr != nil {
-		panic(memErr)
+	var traceparent string
+	if conn.headerFields {
+		traceparent = tracing.Traceparent(ctx)
 	}
-	if err := conn.WriteStreamedMessage(ctx, &buf, ReqHeader); err != nil {
+	buf := bytes.NewBuffer(encodeRequestHeader(endpoint, traceparent))
+	if err := conn.WriteStreamedMessage(ctx, buf, ReqHeader); err != nil {
 		return err
 	}
 
@@ -66,10 +76,10 @@ type ProtocolError struct {
 }
 
 func (e *ProtocolError) Error() string {
-	return fmt.Sprintf("protocol error: %s", e)
+	return fmt.Sprintf("protocol error: %s", e.cause)
 }
 
-func (c *Client) recv(ctx context.Context, conn *stream.Conn, res proto.Message) error {
+func (c *Client) recv(ctx context.Context, conn *clientConn, res proto.Message) error {
 
 	headerBuf, err := conn.ReadStreamedMessage(ctx, ResponseHeaderMaxSize, ResHeader)
 	if err != nil {
@@ -94,22 +104,38 @@ func (c *Client) recv(ctx context.Context, conn *stream.Conn, res proto.Message)
 	return nil
 }
 
-func (c *Client) getWire(ctx context.Context) (*stream.Conn, error) {
+func (c *Client) getWire(ctx context.Context) (*clientConn, error) {
 	nc, err := c.cn.Connect(ctx)
 	if err != nil {
 		return nil, err
 	}
-	conn := stream.Wrap(nc, HeartbeatInterval, HeartbeatPeerTimeout)
+	conn := &clientConn{
+		Conn:         stream.Wrap(nc, HeartbeatInterval, HeartbeatPeerTimeout),
+		headerFields: versionhandshake.PeerSupports(nc, versionhandshake.ExtensionDataconnRequestHeaderFields),
+	}
 	return conn, nil
 }
 
-func (c *Client) putWire(conn *stream.Conn) {
+func (c *Client) putWire(conn *clientConn) {
 	if err := conn.Close(); err != nil {
 		c.log.WithError(err).Error("error closing connection")
 	}
 }
 
-func (c *Client) ReqSend(ctx context.Context, req *pdu.SendReq) (*pdu.SendRes, zfs.StreamCopier, error) {
+// startSpan starts the client span of a request to endpoint
+func startSpan(ctx context.Context, endpoint string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "dataconn.client"+endpoint, tracing.SpanKindClient)
+	span.SetAttribute("rpc.endpoint", endpoint)
+	return ctx, span
+}
+
+func (c *Client) ReqSend(ctx context.Context, req *pdu.SendReq) (_ *pdu.SendRes, _ zfs.StreamCopier, err error) {
+	ctx, span := startSpan(ctx, EndpointSend)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	conn, err := c.getWire(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -133,13 +159,19 @@ func (c *Client) ReqSend(ctx context.Context, req *pdu.SendReq) (*pdu.SendRes, z
 	var copier zfs.StreamCopier = nil
 	if !req.DryRun {
 		putWireOnReturn = false
-		copier = &streamCopier{streamConn: conn, closeStreamOnClose: true}
+		copier = &streamCopier{streamConn: conn.Conn, closeStreamOnClose: true}
 	}
 
 	return &res, copier, nil
 }
 
-func (c *Client) ReqRecv(ctx context.Context, req *pdu.ReceiveReq, streamCopier zfs.StreamCopier) (*pdu.ReceiveRes, error) {
+func (c *Client) ReqRecv(ctx context.Context, req *pdu.ReceiveReq, streamCopier zfs.StreamCopier) (_ *pdu.ReceiveRes, err error) {
+
+	ctx, span := startSpan(ctx, EndpointRecv)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
 
 	defer c.log.Debug("ReqRecv returns")
 	conn, err := c.getWire(ctx)
@@ -215,7 +247,13 @@ func (c *Client) ReqRecv(ctx context.Context, req *pdu.ReceiveReq, streamCopier
 	return res.res, cause
 }
 
-func (c *Client) ReqPing(ctx context.Context, req *pdu.PingReq) (*pdu.PingRes, error) {
+func (c *Client) ReqPing(ctx context.Context, req *pdu.PingReq) (_ *pdu.PingRes, err error) {
+	ctx, span := startSpan(ctx, EndpointPing)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	conn, err := c.getWire(ctx)
 	if err != nil {
 		return nil, err
@@ -11,6 +11,7 @@ import (
 	"github.com/zrepl/zrepl/replication/logic/pdu"
 	"github.com/zrepl/zrepl/rpc/dataconn/stream"
 	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/util/tracing"
 	"github.com/zrepl/zrepl/zfs"
 )
 
@@ -100,15 +101,26 @@ func (s *Server) serveConn(nc *transport.AuthConn) {
 		s.log.WithError(err).Error("error reading structured part")
 		return
 	}
-	endpoint := string(header)
+	endpoint, headerFields := parseRequestHeader(header)
+	if tp, ok := headerFields[requestHeaderFieldTraceparent]; ok {
+		if tctx, err := tracing.ContextWithTraceparent(ctx, tp); err != nil {
+			s.log.WithError(err).Warn("ignoring invalid traceparent in request header")
+		} else {
+			ctx = tctx
+		}
+	}
+	ctx, span := tracing.Start(ctx, "dataconn.server"+endpoint, tracing.SpanKindServer)
+	span.SetAttribute("rpc.endpoint", endpoint)
+	defer span.End()
+	log := tracing.WithLogFields(ctx, s.log)
 
 	reqStructured, err := c.ReadStreamedMessage(ctx, RequestStructuredMaxSize, ReqStructured)
 	if err != nil {
-		s.log.WithError(err).Error("error reading structured part")
+		log.WithError(err).Error("error reading structured part")
 		return
 	}
 
-	s.log.WithField("endpoint", endpoint).Debug("calling handler")
+	log.WithField("endpoint", endpoint).Debug("calling handler")
 
 	var res proto.Message
 	var sendStream zfs.StreamCopier
@@ -117,30 +129,38 @@ func (s *Server) serveConn(nc *transport.AuthConn) {
 	case EndpointSend:
 		var req pdu.SendReq
 		if err := proto.Unmarshal(reqStructured, &req); err != nil {
-			s.log.WithError(err).Error("cannot unmarshal send request")
+			log.WithError(err).Error("cannot unmarshal send request")
 			return
 		}
 		res, sendStream, handlerErr = s.h.Send(ctx, &req) // SHADOWING
 	case EndpointRecv:
 		var req pdu.ReceiveReq
 		if err := proto.Unmarshal(reqStructured, &req); err != nil {
-			s.log.WithError(err).Error("cannot unmarshal receive request")
+			log.WithError(err).Error("cannot unmarshal receive request")
 			return
 		}
 		res, handlerErr = s.h.Receive(ctx, &req, &streamCopier{streamConn: c, closeStreamOnClose: false}) // SHADOWING
 	case EndpointPing:
 		var req pdu.PingReq
 		if err := proto.Unmarshal(reqStructured, &req); err != nil {
-			s.log.WithError(err).Error("cannot unmarshal ping request")
+			log.WithError(err).Error("cannot unmarshal ping request")
 			return
 		}
 		res, handlerErr = s.h.PingDataconn(ctx, &req) // SHADOWING
+	case EndpointThroughputTest:
+		var req pdu.ThroughputTestReq
+		if err := proto.Unmarshal(reqStructured, &req); err != nil {
+			log.WithError(err).Error("cannot unmarshal throughput test request")
+			return
+		}
+		res, sendStream, handlerErr = s.handleThroughputTest(ctx, &req, c) // SHADOWING
 	default:
-		s.log.WithField("endpoint", endpoint).Error("unknown endpoint")
+		log.WithField("endpoint", endpoint).Error("unknown endpoint")
 		handlerErr = fmt.Errorf("requested endpoint does not exist")
 	}
 
-	s.log.WithField("endpoint", endpoint).WithField("errType", fmt.Sprintf("%T", handlerErr)).Debug("handler returned")
+	log.WithField("endpoint", endpoint).WithField("errType", fmt.Sprintf("%T", handlerErr)).Debug("handler returned")
+	span.SetError(handlerErr)
 
 	// prepare protobuf now to return the protobuf error in the header
 	// if marshaling fails. We consider failed marshaling a handler error
@@ -148,11 +168,11 @@ func (s *Server) serveConn(nc *transport.AuthConn) {
 	if handlerErr == nil {
 		if res == nil {
 			handlerErr = fmt.Errorf("implementation error: handler for endpoint %q returns nil error and nil result", endpoint)
-			s.log.WithError(err).Error("handle implementation error")
+			log.WithError(err).Error("handle implementation error")
 		} else {
 			protobufBytes, err := proto.Marshal(res)
 			if err != nil {
-				s.log.WithError(err).Error("cannot marshal handler protobuf")
+				log.WithError(err).Error("cannot marshal handler protobuf")
 				handlerErr = err
 			}
 			protobuf = bytes.NewBuffer(protobufBytes) // SHADOWING
@@ -167,24 +187,25 @@ func (s *Server) serveConn(nc *transport.AuthConn) {
 		resHeaderBuf.WriteString(handlerErr.Error())
 	}
 	if err := c.WriteStreamedMessage(ctx, &resHeaderBuf, ResHeader); err != nil {
-		s.log.WithError(err).Error("cannot write response header")
+		log.WithError(err).Error("cannot write response header")
 		return
 	}
 
 	if handlerErr != nil {
-		s.log.Debug("early exit after handler error")
+		log.Debug("early exit after handler error")
 		return
 	}
 
 	if err := c.WriteStreamedMessage(ctx, protobuf, ResStructured); err != nil {
-		s.log.WithError(err).Error("cannot write structured part of response")
+		log.WithError(err).Error("cannot write structured part of response")
 		return
 	}
 
 	if sendStream != nil {
 		err := c.SendStream(ctx, sendStream, ZFSStream)
 		if err != nil {
-			s.log.WithError(err).Error("cannot write send stream")
+			log.WithError(err).Error("cannot write send stream")
+			span.SetError(err)
 		}
 	}
 }
@@ -2,6 +2,7 @@ package dataconn
 
 import (
 	"io"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ const (
 	EndpointPing string = "/v1/ping"
 	EndpointSend string = "/v1/send"
 	EndpointRecv string = "/v1/recv"
+
+	EndpointThroughputTest string = "/v1/throughput-test"
 )
 
 const (
@@ -40,6 +43,33 @@ const (
 	responseHeaderHandlerErrorPrefix = "HANDLER ERROR:\n"
 )
 
+// The request header is the endpoint, optionally followed by lines of the form "key: value".
+// The only such field is the W3C Trace Context traceparent of the client's span.
+// Older servers take the whole header as the endpoint, hence clients only send fields to servers
+// that announced versionhandshake.ExtensionDataconnRequestHeaderFields.
+const requestHeaderFieldTraceparent = "traceparent"
+
+func encodeRequestHeader(endpoint, traceparent string) []byte {
+	if traceparent == "" {
+		return []byte(endpoint)
+	}
+	return []byte(endpoint + "\n" + requestHeaderFieldTraceparent + ": " + traceparent)
+}
+
+// unknown and malformed header fields are ignored
+func parseRequestHeader(header []byte) (endpoint string, fields map[string]string) {
+	lines := strings.Split(string(header), "\n")
+	fields = make(map[string]string, len(lines)-1)
+	for _, l := range lines[1:] {
+		kv := strings.SplitN(l, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return lines[0], fields
+}
+
 type streamCopier struct {
 	mtx                sync.Mutex
 	used               bool
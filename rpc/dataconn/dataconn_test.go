@@ -1 +1,110 @@
 package dataconn
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc/dataconn/stream"
+	"github.com/zrepl/zrepl/rpc/versionhandshake"
+	"github.com/zrepl/zrepl/util/tracing"
+)
+
+func TestRequestHeader(t *testing.T) {
+	tp := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	endpoint, fields := parseRequestHeader(encodeRequestHeader(EndpointSend, ""))
+	assert.Equal(t, EndpointSend, endpoint)
+	assert.Empty(t, fields)
+
+	endpoint, fields = parseRequestHeader(encodeRequestHeader(EndpointRecv, tp))
+	assert.Equal(t, EndpointRecv, endpoint)
+	assert.Equal(t, map[string]string{requestHeaderFieldTraceparent: tp}, fields)
+
+	// headers of clients that do not support header fields, and malformed fields
+	assert.Equal(t, []byte(EndpointPing), encodeRequestHeader(EndpointPing, ""))
+	endpoint, fields = parseRequestHeader([]byte(EndpointPing + "\nmalformed\nfoo: bar"))
+	assert.Equal(t, EndpointPing, endpoint)
+	assert.Equal(t, map[string]string{"foo": "bar"}, fields)
+}
+
+// The client must only send header fields to servers that announced support for them in the version handshake,
+// older servers take the whole request header as the endpoint name and fail the request.
+func TestClientRequestHeaderDependsOnServerExtensions(t *testing.T) {
+	const tp = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	for _, tc := range []struct {
+		name   string
+		server func(conn net.Conn) error
+		header string
+	}{
+		{
+			name: "old server",
+			server: func(conn net.Conn) error {
+				// the handshake of servers that do not announce any extensions
+				ours := versionhandshake.HandshakeMessage{ProtocolVersion: 1}
+				banner, err := ours.Encode()
+				if err != nil {
+					return err
+				}
+				if _, err := conn.Write(banner); err != nil {
+					return err
+				}
+				var theirs versionhandshake.HandshakeMessage
+				return theirs.DecodeReader(conn, versionhandshake.HandshakeMessageMaxLen)
+			},
+			header: EndpointPing,
+		},
+		{
+			name: "current server",
+			server: func(conn net.Conn) error {
+				if err := versionhandshake.DoHandshakeCurrentVersion(conn, time.Now().Add(2*time.Second)); err != nil {
+					return err
+				}
+				return nil
+			},
+			header: EndpointPing + "\n" + requestHeaderFieldTraceparent + ": " + tp,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			nl, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer nl.Close()
+
+			headerCh := make(chan string, 1)
+			go func() {
+				defer close(headerCh)
+				nc, err := nl.Accept()
+				if err != nil {
+					return
+				}
+				if err := tc.server(nc); err != nil {
+					nc.Close()
+					return
+				}
+				conn := stream.Wrap(nc.(*net.TCPConn), HeartbeatInterval, HeartbeatPeerTimeout)
+				defer conn.Close()
+				header, readErr := conn.ReadStreamedMessage(context.Background(), RequestHeaderMaxSize, ReqHeader)
+				if readErr == nil {
+					headerCh <- string(header)
+				}
+			}()
+
+			ctx, err := tracing.ContextWithTraceparent(context.Background(), tp)
+			require.NoError(t, err)
+			cn := versionhandshake.Connecter(testConnecter{nl.Addr().String()}, 2*time.Second)
+			client := NewClient(cn, logger.NewNullLogger())
+			_, _ = client.ReqPing(ctx, &pdu.PingReq{}) // the server does not respond
+
+			header, ok := <-headerCh
+			require.True(t, ok, "server did not receive a request header")
+			assert.Equal(t, tc.header, header)
+		})
+	}
+}
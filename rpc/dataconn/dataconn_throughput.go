@@ -0,0 +1,106 @@
+package dataconn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc/dataconn/stream"
+	"github.com/zrepl/zrepl/util/devnoop"
+	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// The throughput test transfers a stream of meaningless bytes over the data connection.
+// It is handled by Server itself and does not involve the Handler.
+
+var throughputTestMaxBytes = envconst.Int64("ZREPL_DATACONN_THROUGHPUT_TEST_MAX_BYTES", 1<<30)
+
+// a zfs.StreamCopier that writes n bytes of garbage
+type throughputTestStream struct {
+	n int64
+}
+
+type throughputTestStreamError struct {
+	error
+}
+
+func (throughputTestStreamError) IsReadError() bool  { return false }
+func (throughputTestStreamError) IsWriteError() bool { return true }
+
+func (s throughputTestStream) WriteStreamTo(w io.Writer) zfs.StreamCopierError {
+	var buf [1 << 21]byte
+	_, err := io.CopyBuffer(w, io.LimitReader(devnoop.Get(), s.n), buf[:])
+	if err != nil {
+		return throughputTestStreamError{err}
+	}
+	return nil
+}
+
+func (throughputTestStream) Close() error { return nil }
+
+// an io.Writer that discards and counts what is written to it
+type countingDiscard struct {
+	count int64
+}
+
+func (d *countingDiscard) Write(p []byte) (int, error) {
+	atomic.AddInt64(&d.count, int64(len(p)))
+	return len(p), nil
+}
+
+func (d *countingDiscard) Count() int64 { return atomic.LoadInt64(&d.count) }
+
+func (s *Server) handleThroughputTest(ctx context.Context, req *pdu.ThroughputTestReq, c *stream.Conn) (*pdu.ThroughputTestRes, zfs.StreamCopier, error) {
+	if req.GetBytes() < 0 || req.GetBytes() > throughputTestMaxBytes {
+		return nil, nil, fmt.Errorf("throughput test size must be between 0 and %d bytes", throughputTestMaxBytes)
+	}
+	if !req.GetClientSends() {
+		return &pdu.ThroughputTestRes{Bytes: req.GetBytes()}, throughputTestStream{req.GetBytes()}, nil
+	}
+	var d countingDiscard
+	copier := &streamCopier{streamConn: c, closeStreamOnClose: false}
+	if err := copier.WriteStreamTo(&d); err != nil {
+		return nil, nil, err
+	}
+	return &pdu.ThroughputTestRes{Bytes: d.Count()}, nil, nil
+}
+
+// ReqThroughputTest transfers req.Bytes in the direction specified by req.ClientSends.
+// The caller is expected to measure the time this method takes.
+func (c *Client) ReqThroughputTest(ctx context.Context, req *pdu.ThroughputTestReq) (*pdu.ThroughputTestRes, error) {
+	conn, err := c.getWire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.putWire(conn)
+
+	var sendStream zfs.StreamCopier
+	if req.GetClientSends() {
+		sendStream = throughputTestStream{req.GetBytes()}
+	}
+	if err := c.send(ctx, conn, EndpointThroughputTest, req, sendStream); err != nil {
+		return nil, err
+	}
+
+	var res pdu.ThroughputTestRes
+	if err := c.recv(ctx, conn, &res); err != nil {
+		return nil, err
+	}
+
+	if !req.GetClientSends() {
+		var d countingDiscard
+		if err := conn.ReadStreamInto(&d, ZFSStream); err != nil {
+			return nil, err
+		}
+		if d.Count() != res.GetBytes() {
+			return nil, fmt.Errorf("server announced %d bytes but sent %d", res.GetBytes(), d.Count())
+		}
+	} else if res.GetBytes() != req.GetBytes() {
+		return nil, fmt.Errorf("client sent %d bytes but server received %d", req.GetBytes(), res.GetBytes())
+	}
+
+	return &res, nil
+}
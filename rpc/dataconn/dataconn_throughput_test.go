@@ -0,0 +1,104 @@
+package dataconn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/logger"
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc/dataconn/timeoutconn"
+	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+type testListener struct{ l *net.TCPListener }
+
+func (l testListener) Accept(ctx context.Context) (*transport.AuthConn, error) {
+	conn, err := l.l.AcceptTCP()
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewAuthConn(conn, "client"), nil
+}
+
+func (l testListener) Addr() net.Addr { return l.l.Addr() }
+func (l testListener) Close() error   { return l.l.Close() }
+
+type testConnecter struct{ addr string }
+
+func (c testConnecter) Connect(ctx context.Context) (timeoutconn.Wire, error) {
+	conn, err := net.Dial("tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.TCPConn), nil
+}
+
+// the throughput test is handled by Server itself
+type unusedHandler struct{}
+
+var errUnusedHandler = fmt.Errorf("handler must not be called")
+
+func (unusedHandler) Send(context.Context, *pdu.SendReq) (*pdu.SendRes, zfs.StreamCopier, error) {
+	return nil, nil, errUnusedHandler
+}
+
+func (unusedHandler) Receive(context.Context, *pdu.ReceiveReq, zfs.StreamCopier) (*pdu.ReceiveRes, error) {
+	return nil, errUnusedHandler
+}
+
+func (unusedHandler) PingDataconn(context.Context, *pdu.PingReq) (*pdu.PingRes, error) {
+	return nil, errUnusedHandler
+}
+
+func newThroughputTestClient(t *testing.T) (*Client, func()) {
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := NewServer(nil, logger.NewNullLogger(), unusedHandler{})
+	go srv.Serve(ctx, testListener{nl.(*net.TCPListener)})
+	return NewClient(testConnecter{nl.Addr().String()}, logger.NewNullLogger()), cancel
+}
+
+func TestThroughputTest(t *testing.T) {
+	client, cleanup := newThroughputTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, clientSends := range []bool{false, true} {
+		t.Run(fmt.Sprintf("clientSends=%v", clientSends), func(t *testing.T) {
+			for _, n := range []int64{0, 1, 3<<20 + 17} {
+				res, err := client.ReqThroughputTest(ctx, &pdu.ThroughputTestReq{Bytes: n, ClientSends: clientSends})
+				require.NoError(t, err)
+				assert.Equal(t, n, res.GetBytes())
+			}
+		})
+	}
+}
+
+func TestThroughputTestRejectsSize(t *testing.T) {
+	prevMax := throughputTestMaxBytes
+	throughputTestMaxBytes = 1 << 10
+	defer func() { throughputTestMaxBytes = prevMax }()
+
+	client, cleanup := newThroughputTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, clientSends := range []bool{false, true} {
+		for _, n := range []int64{-1, 1<<10 + 1} {
+			_, err := client.ReqThroughputTest(ctx, &pdu.ThroughputTestReq{Bytes: n, ClientSends: clientSends})
+			require.Error(t, err, "clientSends=%v bytes=%d", clientSends, n)
+			assert.Contains(t, err.Error(), "throughput test size must be between 0 and 1024 bytes")
+		}
+		// the connection remains usable after a rejection
+		res, err := client.ReqThroughputTest(ctx, &pdu.ThroughputTestReq{Bytes: 1 << 10, ClientSends: clientSends})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1<<10), res.GetBytes())
+	}
+}
@@ -27,8 +27,9 @@ const (
 type Logger = logger.Logger
 
 // ClientConn is an easy-to-use wrapper around the Dialer and TransportCredentials interface
-// to produce a grpc.ClientConn
-func ClientConn(cn transport.Connecter, log Logger) *grpc.ClientConn {
+// to produce a grpc.ClientConn.
+// opts are passed to grpc.DialContext in addition to the options set by ClientConn, e.g. interceptors.
+func ClientConn(cn transport.Connecter, log Logger, opts ...grpc.DialOption) *grpc.ClientConn {
 	ka := grpc.WithKeepaliveParams(keepalive.ClientParameters{
 		Time:                StartKeepalivesAfterInactivityDuration,
 		Timeout:             KeepalivePeerTimeout,
@@ -36,7 +37,8 @@ func ClientConn(cn transport.Connecter, log Logger) *grpc.ClientConn {
 	})
 	dialerOption := grpc.WithDialer(grpcclientidentity.NewDialer(log, cn))
 	cred := grpc.WithTransportCredentials(grpcclientidentity.NewTransportCredentials(log))
-	cc, err := grpc.DialContext(context.Background(), "doesn't matter done by dialer", dialerOption, cred, ka)
+	opts = append([]grpc.DialOption{dialerOption, cred, ka}, opts...)
+	cc, err := grpc.DialContext(context.Background(), "doesn't matter done by dialer", opts...)
 	if err != nil {
 		log.WithError(err).Error("cannot create gRPC client conn (non-blocking)")
 		// It's ok to panic here: the we call grpc.DialContext without the
@@ -50,7 +50,7 @@ func NewClient(cn transport.Connecter, loggers Loggers) *Client {
 		loggers: loggers,
 		closed:  make(chan struct{}),
 	}
-	grpcConn := grpchelper.ClientConn(muxedConnecter.control, loggers.Control)
+	grpcConn := grpchelper.ClientConn(muxedConnecter.control, loggers.Control, grpc.WithUnaryInterceptor(tracingUnaryClientInterceptor))
 
 	go func() {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -116,6 +116,24 @@ func (c *Client) ReplicationCursor(ctx context.Context, in *pdu.ReplicationCurso
 	return c.controlClient.ReplicationCursor(ctx, in)
 }
 
+func (c *Client) ReceiverCapabilities(ctx context.Context, in *pdu.ReceiverCapabilitiesReq) (*pdu.ReceiverCapabilitiesRes, error) {
+	return c.controlClient.ReceiverCapabilities(ctx, in)
+}
+
+func (c *Client) Ping(ctx context.Context, in *pdu.PingReq) (*pdu.PingRes, error) {
+	return c.controlClient.Ping(ctx, in, grpc.FailFast(false))
+}
+
+func (c *Client) PingDataconn(ctx context.Context, in *pdu.PingReq) (*pdu.PingRes, error) {
+	return c.dataClient.ReqPing(ctx, in)
+}
+
+// ThroughputTest transfers a stream of meaningless bytes over the data connection,
+// see dataconn.Client.ReqThroughputTest.
+func (c *Client) ThroughputTest(ctx context.Context, in *pdu.ThroughputTestReq) (*pdu.ThroughputTestRes, error) {
+	return c.dataClient.ReqThroughputTest(ctx, in)
+}
+
 func (c *Client) WaitForConnectivity(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
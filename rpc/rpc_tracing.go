@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/zrepl/zrepl/replication/logic/pdu"
+	"github.com/zrepl/zrepl/rpc/dataconn"
+	"github.com/zrepl/zrepl/util/tracing"
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// gRPC metadata key of the W3C Trace Context traceparent of the client's span
+const traceparentMetadataKey = "traceparent"
+
+// tracingUnaryClientInterceptor wraps each control RPC in a client span and propagates it to the server
+func tracingUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := tracing.Start(ctx, "grpc.client"+method, tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("rpc.method", method)
+	if tp := tracing.Traceparent(ctx); tp != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, traceparentMetadataKey, tp)
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	span.SetError(err)
+	return err
+}
+
+// tracingUnaryServerInterceptor runs after identity (the client identity interceptor)
+// and wraps each control RPC handler in a server span that continues the client's trace.
+// ctxInterceptor is applied after the span is started so that the handler's loggers contain the trace ID.
+func tracingUnaryServerInterceptor(identity grpc.UnaryServerInterceptor, ctxInterceptor HandlerContextInterceptor, log Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return identity(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if md, ok := metadata.FromIncomingContext(ctx); ok {
+				if tps := md.Get(traceparentMetadataKey); len(tps) > 0 {
+					if tctx, err := tracing.ContextWithTraceparent(ctx, tps[0]); err != nil {
+						log.WithError(err).Warn("ignoring invalid traceparent in request metadata")
+					} else {
+						ctx = tctx
+					}
+				}
+			}
+			ctx, span := tracing.Start(ctx, "grpc.server"+info.FullMethod, tracing.SpanKindServer)
+			defer span.End()
+			span.SetAttribute("rpc.method", info.FullMethod)
+			if ctxInterceptor != nil {
+				ctx = ctxInterceptor(ctx)
+			}
+			res, err := handler(ctx, req)
+			span.SetError(err)
+			return res, err
+		})
+	}
+}
+
+// dataHandlerInterceptor applies ctxInterceptor to the context of each dataconn request.
+// The dataconn server starts the request's span before calling the handler,
+// so the handler's loggers contain the trace ID.
+type dataHandlerInterceptor struct {
+	handler        dataconn.Handler
+	ctxInterceptor HandlerContextInterceptor
+}
+
+var _ dataconn.Handler = dataHandlerInterceptor{}
+
+func (h dataHandlerInterceptor) intercept(ctx context.Context) context.Context {
+	if h.ctxInterceptor == nil {
+		return ctx
+	}
+	return h.ctxInterceptor(ctx)
+}
+
+func (h dataHandlerInterceptor) Send(ctx context.Context, r *pdu.SendReq) (*pdu.SendRes, zfs.StreamCopier, error) {
+	return h.handler.Send(h.intercept(ctx), r)
+}
+
+func (h dataHandlerInterceptor) Receive(ctx context.Context, r *pdu.ReceiveReq, receive zfs.StreamCopier) (*pdu.ReceiveRes, error) {
+	return h.handler.Receive(h.intercept(ctx), r, receive)
+}
+
+func (h dataHandlerInterceptor) PingDataconn(ctx context.Context, r *pdu.PingReq) (*pdu.PingRes, error) {
+	return h.handler.PingDataconn(h.intercept(ctx), r)
+}
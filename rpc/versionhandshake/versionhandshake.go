@@ -150,26 +150,47 @@ func (m *HandshakeMessage) DecodeReader(r io.Reader, maxLen int) error {
 	return nil
 }
 
+// ExtensionDataconnRequestHeaderFields is announced by peers whose dataconn server
+// accepts header fields (e.g. the traceparent) after the endpoint in the request header.
+// Older servers treat the whole request header as the endpoint name.
+const ExtensionDataconnRequestHeaderFields = "dataconn-request-header-fields"
+
+// the extensions announced by this implementation
+var currentExtensions = []string{
+	ExtensionDataconnRequestHeaderFields,
+}
+
 func DoHandshakeCurrentVersion(conn net.Conn, deadline time.Time) *HandshakeError {
+	_, err := doHandshakeCurrentVersion(conn, deadline)
+	return err
+}
+
+// returns the extensions announced by the peer
+func doHandshakeCurrentVersion(conn net.Conn, deadline time.Time) ([]string, *HandshakeError) {
 	// current protocol version is hardcoded here
-	return DoHandshakeVersion(conn, deadline, 1)
+	return doHandshake(conn, deadline, 1, currentExtensions)
 }
 
 const HandshakeMessageMaxLen = 16 * 4096
 
-func DoHandshakeVersion(conn net.Conn, deadline time.Time, version int) (rErr *HandshakeError) {
+func DoHandshakeVersion(conn net.Conn, deadline time.Time, version int) *HandshakeError {
+	_, err := doHandshake(conn, deadline, version, currentExtensions)
+	return err
+}
+
+func doHandshake(conn net.Conn, deadline time.Time, version int, extensions []string) (_ []string, rErr *HandshakeError) {
 	ours := HandshakeMessage{
 		ProtocolVersion: version,
-		Extensions:      nil,
+		Extensions:      extensions,
 	}
 	hsb, err := ours.Encode()
 	if err != nil {
-		return hsErr("could not encode protocol banner: %s", err)
+		return nil, hsErr("could not encode protocol banner: %s", err)
 	}
 
 	err = conn.SetDeadline(deadline)
 	if err != nil {
-		return hsErr("could not set deadline for protocol banner handshake: %s", err)
+		return nil, hsErr("could not set deadline for protocol banner handshake: %s", err)
 	}
 	defer func() {
 		if rErr != nil {
@@ -182,19 +203,19 @@ func DoHandshakeVersion(conn net.Conn, deadline time.Time, version int) (rErr *H
 	}()
 	_, err = io.Copy(conn, bytes.NewBuffer(hsb))
 	if err != nil {
-		return hsErr("could not send protocol banner: %s", err)
+		return nil, hsErr("could not send protocol banner: %s", err)
 	}
 
 	theirs := HandshakeMessage{}
 	if err := theirs.DecodeReader(conn, HandshakeMessageMaxLen); err != nil {
-		return hsErr("could not decode protocol banner: %s", err)
+		return nil, hsErr("could not decode protocol banner: %s", err)
 	}
 
 	if theirs.ProtocolVersion != ours.ProtocolVersion {
-		return hsErr("protocol versions do not match: ours is %d, theirs is %d",
+		return nil, hsErr("protocol versions do not match: ours is %d, theirs is %d",
 			ours.ProtocolVersion, theirs.ProtocolVersion)
 	}
-	// ignore extensions, we don't use them
+	// unknown extensions are ignored, the caller decides which ones it uses
 
-	return nil
+	return theirs.Extensions, nil
 }
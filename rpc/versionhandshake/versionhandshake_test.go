@@ -2,8 +2,10 @@ package versionhandshake
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -11,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/zrepl/zrepl/rpc/dataconn/timeoutconn"
+	"github.com/zrepl/zrepl/transport"
 	"github.com/zrepl/zrepl/util/socketpair"
 )
 
@@ -117,3 +121,44 @@ func TestDoHandshakeCurrentVersion(t *testing.T) {
 	assert.Nil(t, <-srvErrCh)
 
 }
+
+type socketConnecter struct {
+	conn *net.UnixConn
+}
+
+func (c socketConnecter) Connect(ctx context.Context) (transport.Wire, error) {
+	return c.conn, nil
+}
+
+func TestHandshakeConnecter_PeerSupports(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		peerExtensions []string
+		supported      bool
+	}{
+		{"old peer without extensions", nil, false},
+		{"current peer", currentExtensions, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, client, err := socketpair.SocketPair()
+			require.NoError(t, err)
+			defer srv.Close()
+			defer client.Close()
+
+			srvErrCh := make(chan error)
+			go func() {
+				_, err := doHandshake(srv, time.Now().Add(2*time.Second), 1, tc.peerExtensions)
+				srvErrCh <- err
+			}()
+			wire, err := Connecter(socketConnecter{client}, 2*time.Second).Connect(context.Background())
+			require.NoError(t, err)
+			require.Nil(t, <-srvErrCh)
+
+			assert.Equal(t, tc.supported, PeerSupports(wire, ExtensionDataconnRequestHeaderFields))
+			_, err = wire.(timeoutconn.SyscallConner).SyscallConn()
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.False(t, PeerSupports(nil, ExtensionDataconnRequestHeaderFields))
+}
@@ -3,8 +3,10 @@ package versionhandshake
 import (
 	"context"
 	"net"
+	"syscall"
 	"time"
 
+	"github.com/zrepl/zrepl/rpc/dataconn/timeoutconn"
 	"github.com/zrepl/zrepl/transport"
 )
 
@@ -22,11 +24,45 @@ func (c HandshakeConnecter) Connect(ctx context.Context) (transport.Wire, error)
 	if !ok {
 		dl = time.Now().Add(c.timeout)
 	}
-	if err := DoHandshakeCurrentVersion(conn, dl); err != nil {
+	peerExtensions, handshakeErr := doHandshakeCurrentVersion(conn, dl)
+	if handshakeErr != nil {
 		conn.Close()
-		return nil, err
+		return nil, handshakeErr
 	}
-	return conn, nil
+	return handshakeWire{conn, peerExtensions}, nil
+}
+
+// handshakeWire is a connection returned by HandshakeConnecter,
+// it remembers the extensions announced by the peer.
+type handshakeWire struct {
+	transport.Wire
+	peerExtensions []string
+}
+
+var _ timeoutconn.SyscallConner = handshakeWire{}
+
+func (w handshakeWire) SyscallConn() (rawConn syscall.RawConn, err error) {
+	scc, ok := w.Wire.(timeoutconn.SyscallConner)
+	if !ok {
+		return nil, timeoutconn.SyscallConnNotSupported
+	}
+	return scc.SyscallConn()
+}
+
+// PeerSupports reports whether the peer of a connection returned by HandshakeConnecter
+// announced extension during the handshake.
+// It returns false for connections that were not established through a HandshakeConnecter.
+func PeerSupports(wire transport.Wire, extension string) bool {
+	hw, ok := wire.(handshakeWire)
+	if !ok {
+		return false
+	}
+	for _, ext := range hw.peerExtensions {
+		if ext == extension {
+			return true
+		}
+	}
+	return false
 }
 
 func Connecter(connecter transport.Connecter, timeout time.Duration) HandshakeConnecter {
@@ -0,0 +1,275 @@
+package tlsconf
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Files references the PEM-encoded files from which a TLS configuration is built.
+type Files struct {
+	CA   string
+	Cert string
+	Key  string
+	// optional, PEM or DER encoded certificate revocation list issued by (one of) the CA(s)
+	CRL string
+}
+
+func (f Files) paths() []string {
+	p := []string{f.CA, f.Cert, f.Key}
+	if f.CRL != "" {
+		p = append(p, f.CRL)
+	}
+	return p
+}
+
+type material struct {
+	ca      *x509.CertPool
+	cert    tls.Certificate
+	crl     *x509.RevocationList // nil if no CRL is configured
+	revoked map[string]struct{}  // serial numbers in crl
+	mtimes  []time.Time          // of Files.paths() at the time of loading
+}
+
+func modTimes(paths []string) ([]time.Time, error) {
+	mtimes := make([]time.Time, len(paths))
+	for i, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		mtimes[i] = st.ModTime()
+	}
+	return mtimes, nil
+}
+
+func load(files Files) (*material, error) {
+	var m material
+	var err error
+
+	// stat first so that a change during loading is detected by the next poll
+	if m.mtimes, err = modTimes(files.paths()); err != nil {
+		return nil, err
+	}
+	if m.ca, err = ParseCAFile(files.CA); err != nil {
+		return nil, errors.Wrap(err, "cannot parse ca file")
+	}
+	if m.cert, err = tls.LoadX509KeyPair(files.Cert, files.Key); err != nil {
+		return nil, errors.Wrap(err, "cannot parse cert/key pair")
+	}
+	if files.CRL != "" {
+		if m.crl, err = parseCRLFile(files.CRL, files.CA); err != nil {
+			return nil, errors.Wrap(err, "cannot load crl file")
+		}
+		if err := checkCRLNotExpired(m.crl, time.Now()); err != nil {
+			return nil, err
+		}
+		m.revoked = make(map[string]struct{}, len(m.crl.RevokedCertificateEntries))
+		for _, rc := range m.crl.RevokedCertificateEntries {
+			m.revoked[rc.SerialNumber.String()] = struct{}{}
+		}
+	}
+	return &m, nil
+}
+
+// parseCRLFile parses the CRL and checks that it is signed by one of the certificates in cafile.
+func parseCRLFile(crlfile, cafile string) (*x509.RevocationList, error) {
+	crlBytes, err := ioutil.ReadFile(crlfile)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(crlBytes); block != nil && block.Type == "X509 CRL" {
+		crlBytes = block.Bytes
+	} // else: DER
+	crl, err := x509.ParseRevocationList(crlBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	caBytes, err := ioutil.ReadFile(cafile)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, caBytes = pem.Decode(caBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse ca certificate")
+		}
+		if crl.CheckSignatureFrom(ca) == nil {
+			return crl, nil
+		}
+	}
+	return nil, errors.New("crl is not signed by any of the certificates in the ca file")
+}
+
+// A CRL without a next update time never expires.
+func checkCRLNotExpired(crl *x509.RevocationList, now time.Time) error {
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return fmt.Errorf("crl expired at %s (next update), replace the crl file with a current one", crl.NextUpdate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// verifyNotRevoked is suitable for tls.Config.VerifyPeerCertificate.
+// It is only called after the regular chain verification succeeded.
+// Peers are rejected once the CRL has expired because revocations issued since then are unknown.
+func (m *material) verifyNotRevoked(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if m.crl == nil {
+		return nil
+	}
+	if err := checkCRLNotExpired(m.crl, time.Now()); err != nil {
+		return err
+	}
+	crlIssuer := m.crl.Issuer.String()
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if cert.Issuer.String() != crlIssuer {
+				continue
+			}
+			if _, revoked := m.revoked[cert.SerialNumber.String()]; revoked {
+				return fmt.Errorf("certificate %q (serial %s) has been revoked", cert.Subject, cert.SerialNumber)
+			}
+		}
+	}
+	return nil
+}
+
+// Reloader holds the parsed contents of Files and can reload them at runtime.
+// TLS configurations obtained from a Reloader always use the most recently loaded files,
+// established connections are not affected by a reload.
+type Reloader struct {
+	files  Files
+	keyLog io.Writer
+
+	mtx sync.RWMutex
+	cur *material
+}
+
+// NewReloader loads files, returning an error if they cannot be loaded.
+func NewReloader(files Files) (*Reloader, error) {
+	if files.CA == "" || files.Cert == "" || files.Key == "" {
+		return nil, errors.New("ca, cert and key must be specified")
+	}
+	m, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Reloader{files: files, keyLog: keylogFromEnv(), cur: m}, nil
+}
+
+// Check loads files like NewReloader and returns the certificate in files.Cert.
+func Check(files Files) (*x509.Certificate, error) {
+	r, err := NewReloader(files)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(r.current().cert.Certificate[0])
+}
+
+func (r *Reloader) current() *material {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.cur
+}
+
+// Reload loads the files, keeping the previously loaded state if an error occurs.
+func (r *Reloader) Reload() error {
+	m, err := load(r.files)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	r.cur = m
+	r.mtx.Unlock()
+	return nil
+}
+
+// Changed returns true if any of the files' modification time changed since the last load.
+func (r *Reloader) Changed() (bool, error) {
+	mtimes, err := modTimes(r.files.paths())
+	if err != nil {
+		return false, err
+	}
+	for i, t := range r.current().mtimes {
+		if !t.Equal(mtimes[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Watch reloads the files if they changed, checking every pollInterval, and on SIGHUP.
+// onReload is called after each reload attempt, with a nil error on success.
+// Watch returns when ctx is done.
+func (r *Reloader) Watch(ctx context.Context, pollInterval time.Duration, onReload func(err error)) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			onReload(r.Reload())
+		case <-t.C:
+			changed, err := r.Changed()
+			if err != nil {
+				onReload(err)
+			} else if changed {
+				onReload(r.Reload())
+			}
+		}
+	}
+}
+
+func (r *Reloader) serverConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	m := r.current()
+	return &tls.Config{
+		Certificates:             []tls.Certificate{m.cert},
+		ClientCAs:                m.ca,
+		ClientAuth:               tls.RequireAndVerifyClientCert,
+		PreferServerCipherSuites: true,
+		KeyLogWriter:             r.keyLog,
+		VerifyPeerCertificate:    m.verifyNotRevoked,
+	}, nil
+}
+
+// ClientConfig returns a TLS client configuration based on the currently loaded files.
+// It should be called for each new connection.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	if serverName == "" {
+		panic(serverName)
+	}
+	m := r.current()
+	tlsConfig := &tls.Config{
+		Certificates:          []tls.Certificate{m.cert},
+		RootCAs:               m.ca,
+		ServerName:            serverName,
+		KeyLogWriter:          r.keyLog,
+		VerifyPeerCertificate: m.verifyNotRevoked,
+	}
+	tlsConfig.BuildNameToCertificate()
+	return tlsConfig
+}
@@ -0,0 +1,172 @@
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, cn string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert, key}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, serial int64) (cert *x509.Certificate, certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return cert, certPEM, keyPEM
+}
+
+func (ca *testCA) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+func (ca *testCA) crl(t *testing.T, nextUpdate time.Time, revokedSerials ...int64) []byte {
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-2 * time.Hour),
+		NextUpdate: nextUpdate,
+	}
+	for _, s := range revokedSerials {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries,
+			x509.RevocationListEntry{SerialNumber: big.NewInt(s), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func writeFile(t *testing.T, path string, content []byte, mtime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-tlsconf-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := Files{
+		CA:   filepath.Join(dir, "ca.crt"),
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+	}
+	ca := newTestCA(t, "ca")
+	mtime := time.Now().Add(-time.Minute)
+	writeFile(t, files.CA, ca.pem(), mtime)
+	_, certPEM, keyPEM := ca.issue(t, "server", 2)
+	writeFile(t, files.Cert, certPEM, mtime)
+	writeFile(t, files.Key, keyPEM, mtime)
+
+	r, err := NewReloader(files)
+	require.NoError(t, err)
+	changed, err := r.Changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+	before := r.ClientConfig("server").Certificates[0].Certificate[0]
+
+	// a broken cert must not replace the loaded one
+	writeFile(t, files.Cert, []byte("garbage"), mtime.Add(time.Second))
+	changed, err = r.Changed()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Error(t, r.Reload())
+	assert.Equal(t, before, r.ClientConfig("server").Certificates[0].Certificate[0])
+
+	_, certPEM, keyPEM = ca.issue(t, "server", 3)
+	writeFile(t, files.Cert, certPEM, mtime.Add(2*time.Second))
+	writeFile(t, files.Key, keyPEM, mtime.Add(2*time.Second))
+	require.NoError(t, r.Reload())
+	assert.NotEqual(t, before, r.ClientConfig("server").Certificates[0].Certificate[0])
+	changed, err = r.Changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestCRL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-tlsconf-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, "ca")
+	files := Files{
+		CA:   filepath.Join(dir, "ca.crt"),
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+		CRL:  filepath.Join(dir, "ca.crl"),
+	}
+	_, certPEM, keyPEM := ca.issue(t, "server", 2)
+	writeFile(t, files.CA, ca.pem(), time.Now())
+	writeFile(t, files.Cert, certPEM, time.Now())
+	writeFile(t, files.Key, keyPEM, time.Now())
+
+	// CRL must be signed by the CA
+	writeFile(t, files.CRL, newTestCA(t, "other").crl(t, time.Now().Add(time.Hour), 4), time.Now())
+	_, err = NewReloader(files)
+	assert.Error(t, err)
+
+	writeFile(t, files.CRL, ca.crl(t, time.Now().Add(-time.Hour), 4), time.Now())
+	_, err = NewReloader(files)
+	assert.Error(t, err, "expired CRLs must be rejected")
+
+	writeFile(t, files.CRL, ca.crl(t, time.Now().Add(time.Hour), 4), time.Now())
+	r, err := NewReloader(files)
+	require.NoError(t, err)
+
+	good, _, _ := ca.issue(t, "good", 3)
+	revoked, _, _ := ca.issue(t, "revoked", 4)
+	m := r.current()
+	assert.NoError(t, m.verifyNotRevoked(nil, [][]*x509.Certificate{{good, ca.cert}}))
+	assert.Error(t, m.verifyNotRevoked(nil, [][]*x509.Certificate{{revoked, ca.cert}}))
+
+	// the CRL expires while it is loaded
+	m.crl.NextUpdate = time.Now().Add(-time.Minute)
+	assert.Error(t, m.verifyNotRevoked(nil, [][]*x509.Certificate{{good, ca.cert}}))
+}
@@ -30,23 +30,17 @@ type ClientAuthListener struct {
 	handshakeTimeout time.Duration
 }
 
+// NewClientAuthListener uses the most recently loaded files of r for each handshake.
 func NewClientAuthListener(
-	l *net.TCPListener, ca *x509.CertPool, serverCert tls.Certificate,
+	l *net.TCPListener, r *Reloader,
 	handshakeTimeout time.Duration) *ClientAuthListener {
 
-	if ca == nil {
-		panic(ca)
-	}
-	if serverCert.Certificate == nil || serverCert.PrivateKey == nil {
-		panic(serverCert)
+	if r == nil {
+		panic(r)
 	}
 
 	tlsConf := &tls.Config{
-		Certificates:             []tls.Certificate{serverCert},
-		ClientCAs:                ca,
-		ClientAuth:               tls.RequireAndVerifyClientCert,
-		PreferServerCipherSuites: true,
-		KeyLogWriter:             keylogFromEnv(),
+		GetConfigForClient: r.serverConfig,
 	}
 	return &ClientAuthListener{
 		l,
@@ -56,22 +50,22 @@ func NewClientAuthListener(
 }
 
 // Accept() accepts a connection from the *net.TCPListener passed to the constructor
-// and sets up the TLS connection, including handshake and peer CommmonName validation
+// and sets up the TLS connection, including handshake and peer certificate validation
 // within the specified handshakeTimeout.
+// Mapping the peer certificate to a client identity is up to the caller.
 //
 // It returns both the raw TCP connection (tcpConn) and the TLS connection (tlsConn) on top of it.
 // Access to the raw tcpConn might be necessary if CloseWrite semantics are desired:
 // tlsConn.CloseWrite does NOT call tcpConn.CloseWrite, hence we provide access to tcpConn to
 // allow the caller to do this by themselves.
-func (l *ClientAuthListener) Accept() (tcpConn *net.TCPConn, tlsConn *tls.Conn, clientCN string, err error) {
+func (l *ClientAuthListener) Accept() (tcpConn *net.TCPConn, tlsConn *tls.Conn, peer *x509.Certificate, err error) {
 	tcpConn, err = l.l.AcceptTCP()
 	if err != nil {
-		return nil, nil, "", err
+		return nil, nil, nil, err
 	}
 
 	tlsConn = tls.Server(tcpConn, l.c)
 	var (
-		cn        string
 		peerCerts []*x509.Certificate
 	)
 	if err = tlsConn.SetDeadline(time.Now().Add(l.handshakeTimeout)); err != nil {
@@ -89,12 +83,11 @@ func (l *ClientAuthListener) Accept() (tcpConn *net.TCPConn, tlsConn *tls.Conn,
 		err = errors.New("client must present full RFC5246:7.4.2 TLS client certificate chain")
 		goto CloseAndErr
 	}
-	cn = peerCerts[0].Subject.CommonName
-	return tcpConn, tlsConn, cn, nil
+	return tcpConn, tlsConn, peerCerts[0], nil
 CloseAndErr:
 	// unlike CloseWrite, Close on *tls.Conn actually closes the underlying connection
 	tlsConn.Close() // TODO log error
-	return nil, nil, "", err
+	return nil, nil, nil, err
 }
 
 func (l *ClientAuthListener) Addr() net.Addr {
@@ -3,41 +3,94 @@ package tcp
 import (
 	"context"
 	"net"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/util/systemd"
 )
 
+// identityTemplateIP is replaced by the client's IP address in a client identity
+const identityTemplateIP = "{ip}"
+
 type ipMapEntry struct {
-	ip    net.IP
-	ident string
+	subnet *net.IPNet
+	ident  string // may contain identityTemplateIP
+}
+
+func (e ipMapEntry) identity(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return strings.Replace(e.ident, identityTemplateIP, ip.String(), -1)
 }
 
+// ipMap maps client IPs to client identities using a longest-prefix match.
 type ipMap struct {
-	entries []ipMapEntry
+	entries []ipMapEntry // sorted by prefix length, longest first
+}
+
+// parseSubnet parses a CIDR prefix or a single IP address, which is treated as a host prefix.
+// IPv4-mapped IPv6 prefixes are normalized to IPv4 prefixes.
+func parseSubnet(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.Errorf("cannot parse client IP %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, subnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, errors.Errorf("cannot parse client CIDR %q", s)
+	}
+	ones, bits := subnet.Mask.Size()
+	if ip4 := subnet.IP.To4(); ip4 != nil && bits == 128 && ones >= 96 {
+		subnet = &net.IPNet{IP: ip4, Mask: net.CIDRMask(ones-96, 32)}
+	}
+	return subnet, nil
 }
 
 func ipMapFromConfig(clients map[string]string) (*ipMap, error) {
 	entries := make([]ipMapEntry, 0, len(clients))
+	bySubnet := make(map[string]string, len(clients)) // normalized subnet => config key
 	for clientIPString, clientIdent := range clients {
-		clientIP := net.ParseIP(clientIPString)
-		if clientIP == nil {
-			return nil, errors.Errorf("cannot parse client IP %q", clientIPString)
+		subnet, err := parseSubnet(clientIPString)
+		if err != nil {
+			return nil, err
 		}
-		if err := transport.ValidateClientIdentity(clientIdent); err != nil {
-			return nil, errors.Wrapf(err, "invalid client identity for IP %q", clientIPString)
+		if other, ok := bySubnet[subnet.String()]; ok {
+			return nil, errors.Errorf("ambiguous client map: %q and %q both refer to %s", other, clientIPString, subnet)
 		}
-		entries = append(entries, ipMapEntry{clientIP, clientIdent})
+		bySubnet[subnet.String()] = clientIPString
+		e := ipMapEntry{subnet, clientIdent}
+		// validate with the subnet's address, the template expansion only depends on the IP's address family
+		if err := transport.ValidateClientIdentity(e.identity(subnet.IP)); err != nil {
+			return nil, errors.Wrapf(err, "invalid client identity for %q", clientIPString)
+		}
+		entries = append(entries, e)
 	}
+	sort.Slice(entries, func(i, j int) bool {
+		oi, _ := entries[i].subnet.Mask.Size()
+		oj, _ := entries[j].subnet.Mask.Size()
+		if oi != oj {
+			return oi > oj
+		}
+		return entries[i].subnet.String() < entries[j].subnet.String()
+	})
 	return &ipMap{entries: entries}, nil
 }
 
 func (m *ipMap) Get(ip net.IP) (string, error) {
 	for _, e := range m.entries {
-		if e.ip.Equal(ip) {
-			return e.ident, nil
+		if e.subnet.Contains(ip) {
+			return e.identity(ip), nil
 		}
 	}
 	return "", errors.Errorf("no identity mapping for client IP %s", ip)
@@ -53,7 +106,7 @@ func TCPListenerFactoryFromConfig(c *config.Global, in *config.TCPServe) (transp
 		return nil, errors.Wrap(err, "cannot parse client IP map")
 	}
 	lf := func() (transport.AuthenticatedListener, error) {
-		l, err := net.ListenTCP("tcp", addr)
+		l, err := systemd.ListenTCP(addr)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,69 @@
+package tcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPMap(t *testing.T) {
+	m, err := ipMapFromConfig(map[string]string{
+		"192.168.122.123":       "mysql01",
+		"192.168.122.0/24":      "laptop-{ip}",
+		"10.0.0.0/8":            "office",
+		"fd00::/64":             "v6laptop-{ip}",
+		"fd00::1":               "v6server",
+		"::ffff:172.16.0.0/108": "mapped",
+	})
+	require.NoError(t, err)
+
+	type testCase struct {
+		ip       string
+		identity string // empty means no match expected
+	}
+	tcs := []testCase{
+		{"192.168.122.123", "mysql01"},
+		{"::ffff:192.168.122.123", "mysql01"},
+		{"192.168.122.42", "laptop-192.168.122.42"},
+		{"::ffff:192.168.122.42", "laptop-192.168.122.42"},
+		{"10.1.2.3", "office"},
+		{"172.16.1.1", "mapped"},
+		{"fd00::1", "v6server"},
+		{"fd00::abcd", "v6laptop-fd00::abcd"},
+		{"192.168.123.1", ""},
+		{"fd01::1", ""},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.ip, func(t *testing.T) {
+			ip := net.ParseIP(tc.ip)
+			require.NotNil(t, ip)
+			identity, err := m.Get(ip)
+			if tc.identity == "" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.identity, identity)
+			}
+		})
+	}
+}
+
+func TestIPMapFromConfigErrors(t *testing.T) {
+	invalid := []map[string]string{
+		{"not an ip": "foo"},
+		{"10.0.0.0/33": "foo"},
+		{"10.0.0.1": "foo/bar"},
+		{"10.0.0.0/8": "foo/{ip}"},
+		// ambiguous
+		{"10.0.0.1": "a", "10.0.0.1/32": "b"},
+		{"10.0.0.0/8": "a", "10.1.2.3/8": "b"},
+		{"10.0.0.0/8": "a", "::ffff:10.0.0.0/104": "b"},
+		{"fd00::1": "a", "fd00:0::1/128": "b"},
+	}
+	for _, clients := range invalid {
+		_, err := ipMapFromConfig(clients)
+		assert.Error(t, err, "%v", clients)
+	}
+}
@@ -5,17 +5,16 @@ import (
 	"crypto/tls"
 	"net"
 
-	"github.com/pkg/errors"
-
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/tlsconf"
 	"github.com/zrepl/zrepl/transport"
 )
 
 type TLSConnecter struct {
-	Address   string
-	dialer    net.Dialer
-	tlsConfig *tls.Config
+	Address    string
+	dialer     net.Dialer
+	serverName string
+	reloader   *tlsconf.Reloader
 }
 
 func TLSConnecterFromConfig(in *config.TLSConnect) (*TLSConnecter, error) {
@@ -23,22 +22,25 @@ func TLSConnecterFromConfig(in *config.TLSConnect) (*TLSConnecter, error) {
 		Timeout: in.DialTimeout,
 	}
 
-	ca, err := tlsconf.ParseCAFile(in.Ca)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot parse ca file")
-	}
-
-	cert, err := tls.LoadX509KeyPair(in.Cert, in.Key)
+	reloader, err := tlsconf.NewReloader(tlsconf.Files{CA: in.Ca, Cert: in.Cert, Key: in.Key, CRL: in.CRL})
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot parse cert/key pair")
+		return nil, err
 	}
 
-	tlsConfig, err := tlsconf.ClientAuthClient(in.ServerCN, ca, cert)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot build tls config")
-	}
+	return &TLSConnecter{Address: in.Address, dialer: dialer, serverName: in.ServerCN, reloader: reloader}, nil
+}
 
-	return &TLSConnecter{in.Address, dialer, tlsConfig}, nil
+var _ transport.ReloadWatcher = (*TLSConnecter)(nil)
+
+func (c *TLSConnecter) WatchReload(ctx context.Context) {
+	log := transport.GetLogger(ctx)
+	c.reloader.Watch(ctx, reloadPollInterval, func(err error) {
+		if err != nil {
+			log.WithError(err).Error("cannot reload tls ca, cert, key or crl file, continuing with previously loaded files")
+			return
+		}
+		log.Info("reloaded tls ca, cert, key and crl files")
+	})
 }
 
 func (c *TLSConnecter) Connect(dialCtx context.Context) (transport.Wire, error) {
@@ -47,6 +49,6 @@ func (c *TLSConnecter) Connect(dialCtx context.Context) (transport.Wire, error)
 		return nil, err
 	}
 	tcpConn := conn.(*net.TCPConn)
-	tlsConn := tls.Client(conn, c.tlsConfig)
+	tlsConn := tls.Client(conn, c.reloader.ClientConfig(c.serverName))
 	return newWireAdaptor(tlsConn, tcpConn), nil
 }
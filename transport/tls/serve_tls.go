@@ -2,9 +2,8 @@ package tls
 
 import (
 	"context"
-	"crypto/tls"
-	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,46 +11,50 @@ import (
 	"github.com/zrepl/zrepl/config"
 	"github.com/zrepl/zrepl/tlsconf"
 	"github.com/zrepl/zrepl/transport"
+	"github.com/zrepl/zrepl/util/envconst"
+	"github.com/zrepl/zrepl/util/systemd"
 )
 
+var reloadPollInterval = envconst.Duration("ZREPL_TRANSPORT_TLS_RELOAD_POLL_INTERVAL", 10*time.Second)
+
 type TLSListenerFactory struct{}
 
 func TLSListenerFactoryFromConfig(c *config.Global, in *config.TLSServe) (transport.AuthenticatedListenerFactory, error) {
 
-	address := in.Listen
+	address, err := net.ResolveTCPAddr("tcp", in.Listen)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse listen address")
+	}
 	handshakeTimeout := in.HandshakeTimeout
 
 	if in.Ca == "" || in.Cert == "" || in.Key == "" {
 		return nil, errors.New("fields 'ca', 'cert' and 'key'must be specified")
 	}
 
-	clientCA, err := tlsconf.ParseCAFile(in.Ca)
+	reloader, err := tlsconf.NewReloader(tlsconf.Files{CA: in.Ca, Cert: in.Cert, Key: in.Key, CRL: in.CRL})
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot parse ca file")
+		return nil, err
 	}
 
-	serverCert, err := tls.LoadX509KeyPair(in.Cert, in.Key)
+	identities, err := clientIdentityMapperFromConfig(in)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot parse cer/key pair")
-	}
-
-	clientCNs := make(map[string]struct{}, len(in.ClientCNs))
-	for i, cn := range in.ClientCNs {
-		if err := transport.ValidateClientIdentity(cn); err != nil {
-			return nil, errors.Wrapf(err, "unsuitable client_cn #%d %q", i, cn)
-		}
-		// dupes are ok fr now
-		clientCNs[cn] = struct{}{}
+		return nil, err
 	}
 
 	lf := func() (transport.AuthenticatedListener, error) {
-		l, err := net.Listen("tcp", address)
+		tcpL, err := systemd.ListenTCP(address)
 		if err != nil {
 			return nil, err
 		}
-		tcpL := l.(*net.TCPListener)
-		tl := tlsconf.NewClientAuthListener(tcpL, clientCA, serverCert, handshakeTimeout)
-		return &tlsAuthListener{tl, clientCNs}, nil
+		tl := tlsconf.NewClientAuthListener(tcpL, reloader, handshakeTimeout)
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		return &tlsAuthListener{
+			ClientAuthListener: tl,
+			identities:         identities,
+			reloader:           reloader,
+			watchCtx:           watchCtx,
+			stopWatch:          stopWatch,
+		}, nil
 	}
 
 	return lf, nil
@@ -59,15 +62,35 @@ func TLSListenerFactoryFromConfig(c *config.Global, in *config.TLSServe) (transp
 
 type tlsAuthListener struct {
 	*tlsconf.ClientAuthListener
-	clientCNs map[string]struct{}
+	identities *clientIdentityMapper
+
+	reloader  *tlsconf.Reloader
+	watchOnce sync.Once
+	watchCtx  context.Context
+	stopWatch context.CancelFunc
 }
 
-func (l tlsAuthListener) Accept(ctx context.Context) (*transport.AuthConn, error) {
-	tcpConn, tlsConn, cn, err := l.ClientAuthListener.Accept()
+// watchReload is started on the first call to Accept because only Accept has access to the logger
+func (l *tlsAuthListener) watchReload(ctx context.Context) {
+	log := transport.GetLogger(ctx)
+	go l.reloader.Watch(l.watchCtx, reloadPollInterval, func(err error) {
+		if err != nil {
+			log.WithError(err).Error("cannot reload tls ca, cert, key or crl file, continuing with previously loaded files")
+			return
+		}
+		log.Info("reloaded tls ca, cert, key and crl files")
+	})
+}
+
+func (l *tlsAuthListener) Accept(ctx context.Context) (*transport.AuthConn, error) {
+	l.watchOnce.Do(func() { l.watchReload(ctx) })
+
+	tcpConn, tlsConn, peer, err := l.ClientAuthListener.Accept()
 	if err != nil {
 		return nil, err
 	}
-	if _, ok := l.clientCNs[cn]; !ok {
+	identity, err := l.identities.Identity(peer)
+	if err != nil {
 		log := transport.GetLogger(ctx)
 		if dl, ok := ctx.Deadline(); ok {
 			defer func() {
@@ -82,10 +105,15 @@ func (l tlsAuthListener) Accept(ctx context.Context) (*transport.AuthConn, error
 			}
 		}
 		if err := tlsConn.Close(); err != nil {
-			log.WithError(err).Error("error closing connection with unauthorized common name")
+			log.WithError(err).Error("error closing connection with unauthorized client certificate")
 		}
-		return nil, fmt.Errorf("unauthorized client common name %q from %s", cn, tlsConn.RemoteAddr())
+		return nil, errors.Wrapf(err, "connection from %s", tlsConn.RemoteAddr())
 	}
 	adaptor := newWireAdaptor(tlsConn, tcpConn)
-	return transport.NewAuthConn(adaptor, cn), nil
+	return transport.NewAuthConn(adaptor, identity), nil
+}
+
+func (l *tlsAuthListener) Close() error {
+	l.stopWatch()
+	return l.ClientAuthListener.Close()
 }
@@ -0,0 +1,96 @@
+package tls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/zrepl/zrepl/config"
+	"github.com/zrepl/zrepl/transport"
+)
+
+const (
+	sanPrefixDNS = "dns:"
+	sanPrefixURI = "uri:"
+)
+
+// clientIdentityMapper maps a verified client certificate to a client identity.
+//
+// Subject Alternative Names take precedence over the Common Name:
+// if any of the certificate's DNS or URI SANs is listed in sans, the identity is the mapped value.
+// Otherwise, the certificate's CN is the identity if it is listed in cns.
+type clientIdentityMapper struct {
+	cns  map[string]struct{}
+	sans map[string]string // sanPrefixDNS or sanPrefixURI + SAN => identity
+}
+
+func clientIdentityMapperFromConfig(in *config.TLSServe) (*clientIdentityMapper, error) {
+	if len(in.ClientCNs) == 0 && len(in.ClientSANs) == 0 {
+		return nil, errors.New("at least one of 'client_cns' or 'client_sans' must be specified")
+	}
+	m := &clientIdentityMapper{
+		cns:  make(map[string]struct{}, len(in.ClientCNs)),
+		sans: make(map[string]string, len(in.ClientSANs)),
+	}
+	for i, cn := range in.ClientCNs {
+		if err := transport.ValidateClientIdentity(cn); err != nil {
+			return nil, errors.Wrapf(err, "unsuitable client_cn #%d %q", i, cn)
+		}
+		// dupes are ok fr now
+		m.cns[cn] = struct{}{}
+	}
+	for san, identity := range in.ClientSANs {
+		if !strings.HasPrefix(san, sanPrefixDNS) && !strings.HasPrefix(san, sanPrefixURI) {
+			return nil, errors.Errorf("client_sans key %q must be prefixed with %q or %q", san, sanPrefixDNS, sanPrefixURI)
+		}
+		if err := transport.ValidateClientIdentity(identity); err != nil {
+			return nil, errors.Wrapf(err, "unsuitable client identity %q for client_sans key %q", identity, san)
+		}
+		m.sans[san] = identity
+	}
+	return m, nil
+}
+
+func certSANs(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.URIs))
+	for _, n := range cert.DNSNames {
+		sans = append(sans, sanPrefixDNS+n)
+	}
+	for _, u := range cert.URIs {
+		sans = append(sans, sanPrefixURI+u.String())
+	}
+	return sans
+}
+
+func (m *clientIdentityMapper) Identity(cert *x509.Certificate) (string, error) {
+	sans := certSANs(cert)
+	matched := make(map[string][]string) // identity => matching SANs
+	for _, san := range sans {
+		if identity, ok := m.sans[san]; ok {
+			matched[identity] = append(matched[identity], san)
+		}
+	}
+	switch len(matched) {
+	case 0:
+	case 1:
+		for identity := range matched {
+			return identity, nil
+		}
+	default:
+		identities := make([]string, 0, len(matched))
+		for identity, sans := range matched {
+			identities = append(identities, fmt.Sprintf("%s (%s)", identity, strings.Join(sans, ", ")))
+		}
+		sort.Strings(identities)
+		return "", errors.Errorf("ambiguous client certificate: SANs map to multiple client identities: %s", strings.Join(identities, ", "))
+	}
+
+	cn := cert.Subject.CommonName
+	if _, ok := m.cns[cn]; ok {
+		return cn, nil
+	}
+	return "", errors.Errorf("unauthorized client certificate: common name %q, SANs %q", cn, sans)
+}
@@ -0,0 +1,71 @@
+package tls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/config"
+)
+
+func TestClientIdentityMapper(t *testing.T) {
+	m, err := clientIdentityMapperFromConfig(&config.TLSServe{
+		ClientCNs: []string{"laptop1"},
+		ClientSANs: map[string]string{
+			"dns:backup1.example.com":                "backup1",
+			"uri:spiffe://example.com/zrepl/backup2": "backup2",
+			"dns:backup2.example.com":                "backup2",
+		},
+	})
+	require.NoError(t, err)
+
+	cert := func(cn string, dns []string, uris ...string) *x509.Certificate {
+		c := &x509.Certificate{Subject: pkix.Name{CommonName: cn}, DNSNames: dns}
+		for _, u := range uris {
+			pu, err := url.Parse(u)
+			require.NoError(t, err)
+			c.URIs = append(c.URIs, pu)
+		}
+		return c
+	}
+
+	type testCase struct {
+		name     string
+		cert     *x509.Certificate
+		identity string // empty means error expected
+	}
+	tcs := []testCase{
+		{"cn", cert("laptop1", nil), "laptop1"},
+		{"unknown cn", cert("laptop2", nil), ""},
+		{"dns san", cert("whatever", []string{"backup1.example.com"}), "backup1"},
+		{"san precedence over cn", cert("laptop1", []string{"backup1.example.com"}), "backup1"},
+		{"uri san", cert("", nil, "spiffe://example.com/zrepl/backup2"), "backup2"},
+		{"multiple sans same identity", cert("", []string{"backup2.example.com"}, "spiffe://example.com/zrepl/backup2"), "backup2"},
+		{"ambiguous sans", cert("", []string{"backup1.example.com", "backup2.example.com"}), ""},
+		{"unknown san falls back to cn", cert("laptop1", []string{"other.example.com"}), "laptop1"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := m.Identity(tc.cert)
+			if tc.identity == "" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.identity, identity)
+			}
+		})
+	}
+}
+
+func TestClientIdentityMapperFromConfig(t *testing.T) {
+	_, err := clientIdentityMapperFromConfig(&config.TLSServe{})
+	assert.Error(t, err, "no cns nor sans")
+	_, err = clientIdentityMapperFromConfig(&config.TLSServe{ClientSANs: map[string]string{"backup1.example.com": "backup1"}})
+	assert.Error(t, err, "missing san type prefix")
+	_, err = clientIdentityMapperFromConfig(&config.TLSServe{ClientSANs: map[string]string{"dns:backup1.example.com": "backup/1"}})
+	assert.Error(t, err, "invalid identity")
+}
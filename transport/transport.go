@@ -54,6 +54,13 @@ type Connecter interface {
 	Connect(ctx context.Context) (Wire, error)
 }
 
+// A ReloadWatcher is a Connecter whose configuration files can change at runtime.
+// WatchReload reloads them until ctx is done.
+// Only the daemon runs WatchReload, one-shot commands use the files loaded at construction.
+type ReloadWatcher interface {
+	WatchReload(ctx context.Context)
+}
+
 // A client identity must be a single component in a ZFS filesystem path
 func ValidateClientIdentity(in string) (err error) {
 	path, err := zfs.NewDatasetPath(in)
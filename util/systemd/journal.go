@@ -0,0 +1,123 @@
+package systemd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// JournalSocket is the socket of systemd-journald's native protocol.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// Journal priorities, the syslog severities used by the PRIORITY field.
+const (
+	JournalPriorityErr     = 3
+	JournalPriorityWarning = 4
+	JournalPriorityInfo    = 6
+	JournalPriorityDebug   = 7
+)
+
+// JournalField is a field of a journal entry.
+// Names must be valid journal field names, see JournalFieldName.
+type JournalField struct {
+	Name, Value string
+}
+
+// Journal sends entries to systemd-journald using its native protocol (see systemd.journal-fields(7)).
+type Journal struct {
+	addr *net.UnixAddr
+	conn *net.UnixConn
+}
+
+// NewJournal returns a Journal that sends entries to the journald socket at sockpath, usually JournalSocket.
+// It does not fail if journald is not running, but Send does.
+func NewJournal(sockpath string) (*Journal, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create socket for journald")
+	}
+	return &Journal{addr: &net.UnixAddr{Name: sockpath, Net: "unixgram"}, conn: conn}, nil
+}
+
+// Send sends an entry with the given fields.
+// Entries larger than the maximum datagram size are passed to journald in a temporary file.
+func (j *Journal) Send(fields []JournalField) error {
+	var msg bytes.Buffer
+	for _, f := range fields {
+		if !strings.ContainsRune(f.Value, '\n') {
+			msg.WriteString(f.Name)
+			msg.WriteByte('=')
+			msg.WriteString(f.Value)
+			msg.WriteByte('\n')
+			continue
+		}
+		// values containing newlines are length-prefixed
+		msg.WriteString(f.Name)
+		msg.WriteByte('\n')
+		binary.Write(&msg, binary.LittleEndian, uint64(len(f.Value)))
+		msg.WriteString(f.Value)
+		msg.WriteByte('\n')
+	}
+
+	_, _, err := j.conn.WriteMsgUnix(msg.Bytes(), nil, j.addr)
+	if err == nil {
+		return nil
+	}
+	if !isMessageTooLarge(err) {
+		return errors.Wrap(err, "cannot send entry to journald")
+	}
+
+	f, err := ioutil.TempFile("/dev/shm", "zrepl-journal-")
+	if err != nil {
+		return errors.Wrap(err, "cannot create temporary file for large journal entry")
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return errors.Wrap(err, "cannot remove temporary file for large journal entry")
+	}
+	if _, err := f.Write(msg.Bytes()); err != nil {
+		return errors.Wrap(err, "cannot write large journal entry to temporary file")
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	if _, _, err := j.conn.WriteMsgUnix(nil, rights, j.addr); err != nil {
+		return errors.Wrap(err, "cannot send large entry to journald")
+	}
+	return nil
+}
+
+func isMessageTooLarge(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	return err == syscall.EMSGSIZE || err == syscall.ENOBUFS
+}
+
+// JournalFieldName turns s into a valid journal field name:
+// upper case letters, digits and underscores, not starting with a digit or an underscore,
+// at most 64 characters.
+func JournalFieldName(s string) string {
+	name := []byte(strings.ToUpper(s))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	n := strings.TrimLeft(string(name), "_0123456789")
+	if len(n) > 64 {
+		n = n[:64]
+	}
+	return n
+}
+
+func (j *Journal) Close() error {
+	return j.conn.Close()
+}
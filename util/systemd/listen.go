@@ -0,0 +1,165 @@
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// the first file descriptor passed by the service manager (SD_LISTEN_FDS_START)
+const listenFDsStart = 3
+
+// InheritedSocket is a listening socket passed by the service manager.
+type InheritedSocket struct {
+	// The name of the socket (FileDescriptorName= in the socket unit).
+	Name     string
+	listener net.Listener
+}
+
+func (s *InheritedSocket) Addr() net.Addr { return s.listener.Addr() }
+
+func (s *InheritedSocket) String() string {
+	return fmt.Sprintf("%s %s (name %q)", s.Addr().Network(), s.Addr(), s.Name)
+}
+
+var inherited struct {
+	once    sync.Once
+	err     error
+	m       sync.Mutex
+	sockets []*InheritedSocket // not yet taken
+}
+
+// inheritListeners parses LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES (see sd_listen_fds(3))
+// and unsets them so that child processes don't inherit them.
+func inheritListeners() error {
+	inherited.once.Do(func() {
+		defer func() {
+			os.Unsetenv("LISTEN_PID")
+			os.Unsetenv("LISTEN_FDS")
+			os.Unsetenv("LISTEN_FDNAMES")
+		}()
+		pidStr, fdsStr := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+		if pidStr == "" || fdsStr == "" {
+			return
+		}
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			inherited.err = errors.Wrap(err, "cannot parse LISTEN_PID")
+			return
+		}
+		if pid != os.Getpid() {
+			return
+		}
+		nfds, err := strconv.Atoi(fdsStr)
+		if err != nil || nfds < 0 {
+			inherited.err = errors.Errorf("invalid LISTEN_FDS %q", fdsStr)
+			return
+		}
+		var names []string
+		if namesStr := os.Getenv("LISTEN_FDNAMES"); namesStr != "" {
+			names = strings.Split(namesStr, ":")
+		}
+		files := make([]*os.File, nfds)
+		for i := range files {
+			fd := listenFDsStart + i
+			syscall.CloseOnExec(fd)
+			files[i] = os.NewFile(uintptr(fd), fmt.Sprintf("LISTEN_FD_%d", fd))
+		}
+		inherited.sockets, inherited.err = socketsFromFiles(files, names)
+	})
+	return inherited.err
+}
+
+// socketsFromFiles consumes files, i.e., closes them.
+func socketsFromFiles(files []*os.File, names []string) ([]*InheritedSocket, error) {
+	var sockets []*InheritedSocket
+	var errs []string
+	for i, f := range files {
+		name := "unknown"
+		if i < len(names) {
+			name = names[i]
+		}
+		// FileListener dup(2)s the file descriptor
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("file descriptor %s (name %q) is not a listening stream socket: %s", f.Name(), name, err))
+			continue
+		}
+		sockets = append(sockets, &InheritedSocket{Name: name, listener: l})
+	}
+	if len(errs) > 0 {
+		return sockets, errors.New(strings.Join(errs, "; "))
+	}
+	return sockets, nil
+}
+
+// InheritedSockets returns the sockets passed by the service manager that have not been taken yet.
+func InheritedSockets() ([]*InheritedSocket, error) {
+	err := inheritListeners()
+	inherited.m.Lock()
+	defer inherited.m.Unlock()
+	return append([]*InheritedSocket(nil), inherited.sockets...), err
+}
+
+func addrMatches(a, b net.Addr) bool {
+	switch a := a.(type) {
+	case *net.TCPAddr:
+		b, ok := b.(*net.TCPAddr)
+		if !ok || a.Port != b.Port {
+			return false
+		}
+		unspecified := func(ip net.IP) bool { return ip == nil || ip.IsUnspecified() }
+		if unspecified(a.IP) || unspecified(b.IP) {
+			// systemd binds to [::] with ListenStream=PORT, which also accepts IPv4
+			return unspecified(a.IP) && unspecified(b.IP)
+		}
+		return a.IP.Equal(b.IP)
+	case *net.UnixAddr:
+		b, ok := b.(*net.UnixAddr)
+		return ok && a.Name == b.Name
+	default:
+		return false
+	}
+}
+
+// takeListener ignores errors from inheritListeners because they are reported by InheritedSockets
+// and the sockets that could be parsed are still usable.
+func takeListener(addr net.Addr) net.Listener {
+	_ = inheritListeners()
+	inherited.m.Lock()
+	defer inherited.m.Unlock()
+	for i, s := range inherited.sockets {
+		if addrMatches(addr, s.Addr()) {
+			inherited.sockets = append(inherited.sockets[:i], inherited.sockets[i+1:]...)
+			return s.listener
+		}
+	}
+	return nil
+}
+
+// ListenTCP returns the socket passed by the service manager that is bound to addr,
+// or a new listener if there is none.
+func ListenTCP(addr *net.TCPAddr) (*net.TCPListener, error) {
+	if l := takeListener(addr); l != nil {
+		return l.(*net.TCPListener), nil
+	}
+	return net.ListenTCP("tcp", addr)
+}
+
+// TakeUnixListener returns the socket passed by the service manager that is bound to addr,
+// or nil if there is none.
+// Unlike listeners created by net.ListenUnix, closing the returned listener does not remove the socket file,
+// which is owned by the service manager.
+func TakeUnixListener(addr *net.UnixAddr) *net.UnixListener {
+	if l := takeListener(addr); l != nil {
+		return l.(*net.UnixListener)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package systemd implements the parts of the systemd service manager interface
+// used by the daemon: sd_notify(3) status notifications, the service watchdog,
+// socket activation (sd_listen_fds(3)) and journald's native protocol.
+//
+// All functions but those of Journal are no-ops if the process was not started by systemd.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Status returns a notification state that describes the service status in human-readable form.
+func Status(format string) string {
+	return "STATUS=" + strings.Replace(format, "\n", " ", -1)
+}
+
+// NotifyEnabled returns true if the service manager expects notifications.
+func NotifyEnabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify sends the given states to the service manager (see sd_notify(3)).
+// It returns nil without doing anything if NOTIFY_SOCKET is not set.
+func Notify(states ...string) error {
+	sockpath := os.Getenv("NOTIFY_SOCKET")
+	if sockpath == "" {
+		return nil
+	}
+	if strings.HasPrefix(sockpath, "@") {
+		sockpath = "\x00" + sockpath[1:] // abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: sockpath, Net: "unixgram"})
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to systemd notification socket")
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return errors.Wrap(err, "cannot send systemd notification")
+	}
+	return nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured by the service manager (WatchdogSec=).
+// The service must send StateWatchdog more often than that, sd_watchdog_enabled(3) recommends half the interval.
+// ok is false if the watchdog is disabled or intended for another process.
+func WatchdogInterval() (interval time.Duration, ok bool, err error) {
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	if usecStr == "" {
+		return 0, false, nil
+	}
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, false, errors.Wrap(err, "cannot parse WATCHDOG_PID")
+		}
+		if pid != os.Getpid() {
+			return 0, false, nil
+		}
+	}
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false, errors.Errorf("invalid WATCHDOG_USEC %q", usecStr)
+	}
+	return time.Duration(usec) * time.Microsecond, true, nil
+}
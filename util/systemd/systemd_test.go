@@ -0,0 +1,149 @@
+package systemd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setenv returns a function that restores the previous value
+func setenv(t *testing.T, key, value string) (restore func()) {
+	old, ok := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-systemd-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sockpath := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockpath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	defer setenv(t, "NOTIFY_SOCKET", sockpath)()
+	assert.True(t, NotifyEnabled())
+	require.NoError(t, Notify(StateReady, Status("running\n2 jobs")))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1\nSTATUS=running 2 jobs", string(buf[:n]))
+}
+
+func TestNotifyDisabled(t *testing.T) {
+	defer setenv(t, "NOTIFY_SOCKET", "")()
+	assert.False(t, NotifyEnabled())
+	assert.NoError(t, Notify(StateReady))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer setenv(t, "WATCHDOG_USEC", "")()
+	defer setenv(t, "WATCHDOG_PID", "")()
+	_, ok, err := WatchdogInterval()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	setenv(t, "WATCHDOG_USEC", "30000000")
+	setenv(t, "WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	interval, ok, err := WatchdogInterval()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, interval)
+
+	setenv(t, "WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	_, ok, err = WatchdogInterval()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	setenv(t, "WATCHDOG_PID", "")
+	setenv(t, "WATCHDOG_USEC", "foo")
+	_, _, err = WatchdogInterval()
+	assert.Error(t, err)
+}
+
+func TestSocketsFromFiles(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer l.Close()
+	lf, err := l.File()
+	require.NoError(t, err)
+
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer udp.Close()
+	uf, err := udp.File()
+	require.NoError(t, err)
+
+	sockets, err := socketsFromFiles([]*os.File{lf, uf}, []string{"tls"})
+	assert.Error(t, err, "datagram sockets are not supported")
+	require.Len(t, sockets, 1)
+	defer sockets[0].listener.Close()
+	assert.Equal(t, "tls", sockets[0].Name)
+	assert.True(t, addrMatches(l.Addr(), sockets[0].Addr()))
+}
+
+func TestAddrMatches(t *testing.T) {
+	tcp := func(s string) net.Addr {
+		a, err := net.ResolveTCPAddr("tcp", s)
+		require.NoError(t, err)
+		return a
+	}
+	assert.True(t, addrMatches(tcp(":888"), tcp("[::]:888")))
+	assert.True(t, addrMatches(tcp("0.0.0.0:888"), tcp("[::]:888")))
+	assert.True(t, addrMatches(tcp("192.168.1.1:888"), tcp("192.168.1.1:888")))
+	assert.False(t, addrMatches(tcp(":888"), tcp(":889")))
+	assert.False(t, addrMatches(tcp(":888"), tcp("192.168.1.1:888")))
+	assert.False(t, addrMatches(tcp("192.168.1.2:888"), tcp("192.168.1.1:888")))
+
+	unix := &net.UnixAddr{Name: "/var/run/zrepl/control", Net: "unix"}
+	assert.True(t, addrMatches(unix, &net.UnixAddr{Name: "/var/run/zrepl/control", Net: "unix"}))
+	assert.False(t, addrMatches(unix, &net.UnixAddr{Name: "/var/run/zrepl/other", Net: "unix"}))
+	assert.False(t, addrMatches(unix, tcp(":888")))
+}
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zrepl-systemd-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sockpath := filepath.Join(dir, "journal")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockpath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	j, err := NewJournal(sockpath)
+	require.NoError(t, err)
+	defer j.Close()
+	require.NoError(t, j.Send([]JournalField{
+		{"MESSAGE", "hello"},
+		{"ZREPL_ERR", "a\nb"},
+	}))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "MESSAGE=hello\nZREPL_ERR\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n", string(buf[:n]))
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "JOB", JournalFieldName("job"))
+	assert.Equal(t, "RPC_CTRL", JournalFieldName("rpc.ctrl"))
+	assert.Equal(t, "FS", JournalFieldName("_1fs"))
+	assert.Equal(t, "", JournalFieldName("__"))
+}
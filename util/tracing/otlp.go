@@ -0,0 +1,24 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewOTLPExporter returns an exporter that posts to the OTLP/HTTP receiver of the collector at endpoint,
+// e.g. http://localhost:4318. Spans are posted to /v1/traces below it.
+func NewOTLPExporter(ctx context.Context, endpoint string, headers map[string]string, timeout time.Duration) (sdktrace.SpanExporter, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, errors.Errorf("OTLP endpoint must be an http:// or https:// URL, got %q", endpoint)
+	}
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"),
+		otlptracehttp.WithHeaders(headers),
+		otlptracehttp.WithTimeout(timeout),
+	)
+}
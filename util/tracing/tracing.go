@@ -0,0 +1,204 @@
+// Package tracing wraps the subset of the OpenTelemetry tracing API used by zrepl:
+// spans that are recorded in a context.Context, W3C Trace Context propagation
+// (the traceparent header) and export of finished spans via OTLP (see NewOTLPExporter).
+//
+// Tracing is disabled until Init is called.
+// While disabled, Start returns spans that record nothing,
+// so instrumented code does not need to check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/zrepl/zrepl/logger"
+)
+
+// the name of the tracer (instrumentation scope) of all spans started by Start
+const tracerName = "github.com/zrepl/zrepl"
+
+type SpanKind = trace.SpanKind
+
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindClient   = trace.SpanKindClient
+)
+
+// Span is an operation that is part of a trace.
+// A nil *Span is valid and does nothing.
+type Span struct {
+	span trace.Span
+}
+
+// SpanContext returns the invalid SpanContext for a nil span.
+func (s *Span) SpanContext() trace.SpanContext {
+	if s == nil {
+		return trace.SpanContext{}
+	}
+	return s.span.SpanContext()
+}
+
+// SetAttribute sets an attribute of the span.
+// Values of types other than string, bool, integers and floats are exported as strings.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint64:
+		// OTLP has no unsigned integers
+		return attribute.Int64(key, int64(v))
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	default:
+		return attribute.String(key, fmt.Sprintf("%v", v))
+	}
+}
+
+// SetError marks the span as failed with err, a nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span and hands it to the exporter, subsequent calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+var state struct {
+	mtx      sync.RWMutex
+	provider *sdktrace.TracerProvider
+}
+
+// Enabled returns true if Init was called and the returned shutdown function was not called yet.
+func Enabled() bool {
+	state.mtx.RLock()
+	defer state.mtx.RUnlock()
+	return state.provider != nil
+}
+
+// Init enables tracing, finished spans are exported in batches by e at the given interval.
+// The spans are those of the service serviceName.
+// Spans are sampled if their parent is, the roots of new traces are always sampled.
+// Export errors are passed to onError.
+// The returned function disables tracing and exports the remaining spans.
+func Init(e sdktrace.SpanExporter, serviceName string, interval time.Duration, onError func(error)) (shutdown func(context.Context) error) {
+	p := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(e, sdktrace.WithBatchTimeout(interval)),
+		sdktrace.WithResource(sdkresource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	if onError != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(onError))
+	}
+	state.mtx.Lock()
+	state.provider = p
+	state.mtx.Unlock()
+	return func(ctx context.Context) error {
+		state.mtx.Lock()
+		if state.provider == p {
+			state.provider = nil
+		}
+		state.mtx.Unlock()
+		return p.Shutdown(ctx)
+	}
+}
+
+func tracerProvider() trace.TracerProvider {
+	state.mtx.RLock()
+	defer state.mtx.RUnlock()
+	if state.provider == nil {
+		return noop.NewTracerProvider()
+	}
+	return state.provider
+}
+
+// Start starts a span as a child of the span in ctx, or of the remote span added by ContextWithTraceparent.
+// If neither exists, the span is the root of a new trace.
+// The caller must call End on the returned span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	ctx, span := tracerProvider().Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind))
+	return ctx, &Span{span}
+}
+
+// the W3C Trace Context propagator, it only uses the traceparent and tracestate headers
+var propagator = propagation.TraceContext{}
+
+const traceparentHeader = "traceparent"
+
+// ContextWithTraceparent returns a context whose next span started by Start is a child of
+// the remote span identified by the W3C Trace Context traceparent header tp.
+// The sampling decision of the remote span is respected.
+func ContextWithTraceparent(ctx context.Context, tp string) (context.Context, error) {
+	remote := trace.SpanContextFromContext(propagator.Extract(context.Background(), propagation.MapCarrier{traceparentHeader: tp}))
+	if !remote.IsValid() {
+		return ctx, errors.Errorf("invalid traceparent %q", tp)
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, remote), nil
+}
+
+// Traceparent returns the traceparent header for propagating the trace in ctx to a remote process,
+// or the empty string if ctx has no trace.
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get(traceparentHeader)
+}
+
+const (
+	LogFieldTraceID = "trace_id"
+	LogFieldSpanID  = "span_id"
+)
+
+// WithLogFields sets the trace and span ID of ctx as fields of log, if ctx has a trace.
+// Fields set by a previous call are replaced, so that nested spans can update the logger of their parent.
+func WithLogFields(ctx context.Context, log logger.Logger) logger.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return log
+	}
+	return log.
+		ReplaceField(LogFieldTraceID, sc.TraceID().String()).
+		ReplaceField(LogFieldSpanID, sc.SpanID().String())
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceparent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := ContextWithTraceparent(context.Background(), tp)
+	require.NoError(t, err)
+	assert.Equal(t, tp, Traceparent(ctx))
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		ctx, err := ContextWithTraceparent(context.Background(), invalid)
+		assert.Error(t, err, invalid)
+		assert.Equal(t, "", Traceparent(ctx))
+	}
+	// future versions may append fields
+	_, err = ContextWithTraceparent(context.Background(), "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.NoError(t, err)
+}
+
+// memoryExporter keeps the exported spans after shutdown
+type memoryExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func newMemoryExporter() memoryExporter { return memoryExporter{tracetest.NewInMemoryExporter()} }
+
+func (memoryExporter) Shutdown(context.Context) error { return nil }
+
+func TestSpans(t *testing.T) {
+	ctx, span := Start(context.Background(), "disabled", SpanKindInternal)
+	span.SetAttribute("ignored", 1)
+	span.End()
+	assert.Equal(t, "", Traceparent(ctx))
+
+	e := newMemoryExporter()
+	shutdown := Init(e, "zrepl", time.Hour, nil)
+
+	ctx, err := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	ctx, server := Start(ctx, "server", SpanKindServer)
+	_, child := Start(ctx, "child", SpanKindInternal)
+	child.SetAttribute("bytes", uint64(42))
+	child.SetError(errors.New("failed"))
+	child.End()
+	server.End()
+	server.End()
+
+	require.NoError(t, shutdown(context.Background()))
+	assert.False(t, Enabled())
+	spans := e.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, int64(42), spans[0].Attributes[0].Value.AsInt64())
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].Parent.SpanID().String())
+	assert.True(t, spans[1].Parent.IsRemote())
+	assert.Equal(t, "zrepl", spans[1].Resource.Attributes()[0].Value.AsString())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+server.SpanContext().SpanID().String()+"-01", Traceparent(ctx))
+}
+
+func TestSpansRespectParentSampling(t *testing.T) {
+	e := newMemoryExporter()
+	shutdown := Init(e, "zrepl", time.Hour, nil)
+
+	ctx, err := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	ctx, server := Start(ctx, "server", SpanKindServer)
+	_, child := Start(ctx, "child", SpanKindInternal)
+	child.End()
+	server.End()
+
+	require.NoError(t, shutdown(context.Background()))
+	assert.Empty(t, e.GetSpans())
+	// the unsampled decision is propagated further
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+server.SpanContext().SpanID().String()+"-00", Traceparent(ctx))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var path, contentType, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	e, err := NewOTLPExporter(context.Background(), srv.URL+"/", map[string]string{"Authorization": "Bearer secret"}, time.Second)
+	require.NoError(t, err)
+	shutdown := Init(e, "zrepl", time.Hour, nil)
+	_, span := Start(context.Background(), "replication", SpanKindClient)
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "application/x-protobuf", contentType)
+	assert.Equal(t, "Bearer secret", auth)
+
+	_, err = NewOTLPExporter(context.Background(), "localhost:4318", nil, time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package zfs
+
+import (
+	"context"
+	"sync"
+)
+
+// A Backend performs the operations on datasets, snapshots, bookmarks and properties
+// that the functions of this package are built upon.
+//
+// The default backend runs the zfs binary (see ZFS_BINARY).
+// Tests that cannot rely on a real pool use SetBackend to substitute it,
+// e.g. by the in-memory simulation in package zfs/zfsfake.
+//
+// Errors should be reported like the default backend does,
+// e.g. *DatasetDoesNotExist for Get or *DestroySnapshotsError for Destroy,
+// because the package functions and their callers inspect them.
+type Backend interface {
+	// List behaves like `zfs list -H -p -o properties zfsArgs...`, see ZFSListChan.
+	// Backends other than the default need to support the arguments used by this package
+	// and its callers: -r, -d DEPTH, -t TYPES, -s PROPERTY and an optional dataset name.
+	List(ctx context.Context, out chan ZFSListResult, properties []string, zfsArgs ...string)
+	// Get returns props of path, which may be a filesystem, volume, snapshot or bookmark.
+	// Returns *DatasetDoesNotExist if path does not exist.
+	Get(path string, props []string) (map[string]PropertyValue, error)
+	// GetAll returns props of all filesystems and volumes, see ZFSGetAllDatasets.
+	GetAll(ctx context.Context, props []string) (map[string]map[string]PropertyValue, error)
+	Set(path string, props map[string]string) error
+	// Create creates the filesystem path with the given local property values. The parent must exist.
+	Create(path string, props map[string]string) error
+	Snapshot(snapshot string) error
+	Bookmark(snapshot, bookmark string) error
+	// Destroy destroys a filesystem, snapshot or bookmark.
+	// arg may use the fs@snap1,snap2 syntax if DestroySnapshotsCommaSyntaxSupported returns true.
+	Destroy(arg string) error
+	DestroySnapshotsCommaSyntaxSupported() (bool, error)
+	// Rollback behaves like `zfs rollback rollbackArgs... snapshot`.
+	// Backends other than the default need to support -r.
+	Rollback(snapshot string, rollbackArgs ...string) error
+	// Send and SendDry have the semantics of ZFSSend and ZFSSendDry.
+	// SendDry is not called for incremental sends from bookmarks.
+	Send(ctx context.Context, fs string, from, to string, token string, flags ZFSSendFlags) (StreamCopier, error)
+	SendDry(fs string, from, to string, token string, flags ZFSSendFlags) (*DrySendInfo, error)
+	// Recv receives the stream into fs.
+	// If opts.RollbackAndForceRecv is set, ZFSRecv has already destroyed the snapshots of fs.
+	Recv(ctx context.Context, fs string, streamCopier StreamCopier, opts RecvOptions) error
+	// RecvClearResumeToken aborts an interrupted receive into fs.
+	// It is not an error if fs has no partial receive state.
+	RecvClearResumeToken(fs string) error
+	ParseResumeToken(ctx context.Context, token string) (*ResumeToken, error)
+}
+
+var backendMtx sync.RWMutex
+var backendCur Backend = execBackend{}
+
+func getBackend() Backend {
+	backendMtx.RLock()
+	defer backendMtx.RUnlock()
+	return backendCur
+}
+
+// SetBackend replaces the backend used by the functions of this package and returns the previous one.
+//
+// It is only meant for tests, the daemon always uses the default backend.
+// The backend is process-global state and SetBackend is not safe to use concurrently with ZFS operations:
+// an operation in progress may issue some of its calls to the previous backend and others to b.
+// Hence tests that call SetBackend must restore the previous backend when they are done
+// and must not run in parallel (t.Parallel) with other tests that use this package.
+func SetBackend(b Backend) (previous Backend) {
+	if b == nil {
+		panic("backend must not be nil")
+	}
+	backendMtx.Lock()
+	defer backendMtx.Unlock()
+	previous, backendCur = backendCur, b
+	return previous
+}
+
+// execBackend is the default Backend that runs ZFS_BINARY.
+type execBackend struct{}
+
+var _ Backend = execBackend{}
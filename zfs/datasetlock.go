@@ -0,0 +1,106 @@
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zrepl/zrepl/util/envconst"
+)
+
+// Operations that take a dataset lock, used as op argument for LockDataset.
+const (
+	DatasetLockOpSnapshot = "snapshot"
+	DatasetLockOpPrune    = "prune"
+	DatasetLockOpReceive  = "receive"
+)
+
+// DatasetLockWaitTimeout bounds the time LockDataset waits for another operation to release the lock.
+var DatasetLockWaitTimeout = envconst.Duration("ZREPL_DATASET_LOCK_WAIT_TIMEOUT", 30*time.Minute)
+
+type datasetLock struct {
+	token     chan struct{} // holding the lock = having sent a token
+	holder    string        // protected by datasetLocks.mtx
+	heldSince time.Time     // protected by datasetLocks.mtx
+	refs      int           // protected by datasetLocks.mtx
+}
+
+// datasetLocks serializes snapshotting, pruning and receiving on the same dataset across all jobs of the daemon.
+// Entries are removed when they are neither held nor waited for.
+var datasetLocks struct {
+	mtx sync.Mutex
+	m   map[string]*datasetLock
+}
+
+func init() {
+	datasetLocks.m = make(map[string]*datasetLock)
+}
+
+// LockDataset blocks until the process-wide lock for the dataset fs is acquired or ctx is done.
+// op describes the operation that will hold the lock.
+// If the lock is held by another operation, onWait (if not nil) is called with that operation's op
+// and the time it acquired the lock before blocking.
+// Waiting fails after DatasetLockWaitTimeout, the error names the holding operation.
+// The returned unlock function must be called exactly once.
+//
+// A single operation must not lock more than one dataset at a time.
+func LockDataset(ctx context.Context, fs, op string, onWait func(heldBy string, heldSince time.Time)) (unlock func(), err error) {
+	datasetLocks.mtx.Lock()
+	l, ok := datasetLocks.m[fs]
+	if !ok {
+		l = &datasetLock{token: make(chan struct{}, 1)}
+		datasetLocks.m[fs] = l
+	}
+	l.refs++
+	datasetLocks.mtx.Unlock()
+
+	release := func() {
+		datasetLocks.mtx.Lock()
+		defer datasetLocks.mtx.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(datasetLocks.m, fs)
+		}
+	}
+
+	begin := time.Now()
+	select {
+	case l.token <- struct{}{}:
+	default:
+		datasetLocks.mtx.Lock()
+		heldBy, heldSince := l.holder, l.heldSince
+		datasetLocks.mtx.Unlock()
+		if onWait != nil {
+			onWait(heldBy, heldSince)
+		}
+		timeout := time.NewTimer(DatasetLockWaitTimeout)
+		defer timeout.Stop()
+		select {
+		case l.token <- struct{}{}:
+		case <-timeout.C:
+			release()
+			return nil, fmt.Errorf("timed out after %s waiting for %s lock on dataset %q held by %s since %s",
+				DatasetLockWaitTimeout, op, fs, heldBy, heldSince.Format(time.RFC3339))
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	prom.DatasetLockWaitDuration.WithLabelValues(op).Observe(time.Since(begin).Seconds())
+
+	datasetLocks.mtx.Lock()
+	l.holder, l.heldSince = op, time.Now()
+	datasetLocks.mtx.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			datasetLocks.mtx.Lock()
+			l.holder, l.heldSince = "", time.Time{}
+			datasetLocks.mtx.Unlock()
+			<-l.token
+			release()
+		})
+	}, nil
+}
@@ -0,0 +1,62 @@
+package zfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDataset(t *testing.T) {
+	ctx := context.Background()
+
+	unlock, err := LockDataset(ctx, "pool/a", DatasetLockOpReceive, nil)
+	require.NoError(t, err)
+
+	// other datasets are not affected
+	unlockB, err := LockDataset(ctx, "pool/b", DatasetLockOpPrune, func(string, time.Time) { t.Error("must not wait") })
+	require.NoError(t, err)
+	unlockB()
+
+	// waiting is canceled with the context
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	var heldBy string
+	var heldSince time.Time
+	_, err = LockDataset(waitCtx, "pool/a", DatasetLockOpPrune, func(h string, since time.Time) { heldBy, heldSince = h, since })
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, DatasetLockOpReceive, heldBy)
+	assert.False(t, heldSince.IsZero())
+
+	// waiting gives up after DatasetLockWaitTimeout and reports the holder
+	prevTimeout := DatasetLockWaitTimeout
+	DatasetLockWaitTimeout = 10 * time.Millisecond
+	_, err = LockDataset(ctx, "pool/a", DatasetLockOpSnapshot, nil)
+	DatasetLockWaitTimeout = prevTimeout
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `snapshot lock on dataset "pool/a" held by receive`)
+
+	acquired, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		unlock, err := LockDataset(ctx, "pool/a", DatasetLockOpSnapshot, nil)
+		assert.NoError(t, err)
+		close(acquired)
+		unlock()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlock()
+	unlock() // idempotent
+	<-acquired
+	<-done
+
+	datasetLocks.mtx.Lock()
+	defer datasetLocks.mtx.Unlock()
+	assert.Empty(t, datasetLocks.m)
+}
@@ -9,6 +9,31 @@ type DatasetFilter interface {
 	Filter(p *DatasetPath) (pass bool, err error)
 }
 
+// A DatasetPropertiesFilter is a DatasetFilter whose decision depends on properties of the dataset.
+// ZFSListMapping lists the properties together with the datasets and calls FilterWithProperties
+// instead of Filter, so that the filter does not need to get the properties itself.
+type DatasetPropertiesFilter interface {
+	DatasetFilter
+	// The properties passed to FilterWithProperties, must not contain 'name'
+	FilterProperties() []string
+	FilterWithProperties(ctx context.Context, p *DatasetPath, props map[string]string) (pass bool, err error)
+}
+
+// A DatasetContextFilter is a DatasetFilter whose decision may require running zfs commands.
+// FilterDataset calls FilterContext instead of Filter so that these commands are bound to the caller's context.
+type DatasetContextFilter interface {
+	DatasetFilter
+	FilterContext(ctx context.Context, p *DatasetPath) (pass bool, err error)
+}
+
+// FilterDataset applies filter to p, using FilterContext if filter is a DatasetContextFilter.
+func FilterDataset(ctx context.Context, filter DatasetFilter, p *DatasetPath) (pass bool, err error) {
+	if cf, ok := filter.(DatasetContextFilter); ok {
+		return cf.FilterContext(ctx, p)
+	}
+	return filter.Filter(p)
+}
+
 // Returns a DatasetFilter that does not filter (passes all paths)
 func NoFilter() DatasetFilter {
 	return noFilter{}
@@ -50,9 +75,16 @@ func ZFSListMappingProperties(ctx context.Context, filter DatasetFilter, propert
 			panic("properties must not contain 'name'")
 		}
 	}
-	newProps := make([]string, len(properties)+1)
-	newProps[0] = "name"
-	copy(newProps[1:], properties)
+	var filterProps []string
+	propsFilter, hasPropsFilter := filter.(DatasetPropertiesFilter)
+	if hasPropsFilter {
+		filterProps = propsFilter.FilterProperties()
+	}
+	newProps := make([]string, 0, len(properties)+len(filterProps)+1)
+	newProps = append(newProps, "name")
+	newProps = append(newProps, properties...)
+	newProps = append(newProps, filterProps...)
+	nfields := len(properties) // fields of the caller's properties
 	properties = newProps
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -74,14 +106,24 @@ func ZFSListMappingProperties(ctx context.Context, filter DatasetFilter, propert
 			return
 		}
 
-		pass, filterErr := filter.Filter(path)
+		var pass bool
+		var filterErr error
+		if hasPropsFilter {
+			props := make(map[string]string, len(filterProps))
+			for i, p := range filterProps {
+				props[p] = r.Fields[1+nfields+i]
+			}
+			pass, filterErr = propsFilter.FilterWithProperties(ctx, path, props)
+		} else {
+			pass, filterErr = FilterDataset(ctx, filter, path)
+		}
 		if filterErr != nil {
 			return nil, fmt.Errorf("error calling filter: %s", filterErr)
 		}
 		if pass {
 			datasets = append(datasets, ZFSListMappingPropertiesResult{
 				Path:   path,
-				Fields: r.Fields[1:],
+				Fields: r.Fields[1 : 1+nfields],
 			})
 		}
 
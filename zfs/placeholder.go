@@ -81,10 +81,20 @@ func ZFSCreatePlaceholderFilesystem(p *DatasetPath) (err error) {
 	if p.Length() == 1 {
 		return fmt.Errorf("cannot create %q: pools cannot be created with zfs create", p.ToString())
 	}
-	cmd := exec.Command(ZFS_BINARY, "create",
-		"-o", fmt.Sprintf("%s=%s", PlaceholderPropertyName, placeholderPropertyOn),
-		"-o", "mountpoint=none",
-		p.ToString())
+	props := map[string]string{
+		PlaceholderPropertyName: placeholderPropertyOn,
+		"mountpoint":            "none",
+	}
+	return getBackend().Create(p.ToString(), props)
+}
+
+func (execBackend) Create(path string, props map[string]string) (err error) {
+	args := []string{"create"}
+	if err := appendPropertyArgs(&args, "-o", props); err != nil {
+		return err
+	}
+	args = append(args, path)
+	cmd := exec.Command(ZFS_BINARY, args...)
 
 	stderr := bytes.NewBuffer(make([]byte, 0, 1024))
 	cmd.Stderr = stderr
@@ -7,6 +7,7 @@ var prom struct {
 	ZFSSnapshotDuration              *prometheus.HistogramVec
 	ZFSBookmarkDuration              *prometheus.HistogramVec
 	ZFSDestroyDuration               *prometheus.HistogramVec
+	DatasetLockWaitDuration          *prometheus.HistogramVec
 }
 
 func init() {
@@ -34,6 +35,12 @@ func init() {
 		Name:      "destroy_duration",
 		Help:      "Duration it took to destroy a dataset",
 	}, []string{"dataset_type", "filesystem"})
+	prom.DatasetLockWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "zrepl",
+		Subsystem: "zfs",
+		Name:      "dataset_lock_wait_duration",
+		Help:      "Seconds an operation waited for the lock of a dataset, which serializes snapshotting, pruning and receiving",
+	}, []string{"op"})
 }
 
 func PrometheusRegister(registry prometheus.Registerer) error {
@@ -49,5 +56,8 @@ func PrometheusRegister(registry prometheus.Registerer) error {
 	if err := registry.Register(prom.ZFSDestroyDuration); err != nil {
 		return err
 	}
+	if err := registry.Register(prom.DatasetLockWaitDuration); err != nil {
+		return err
+	}
 	return nil
 }
@@ -27,6 +27,10 @@ var ResumeTokenParsingError = errors.New("zrepl cannot parse resume token values
 //
 // FIXME: implement nvlist unpacking in Go and read through libzfs_sendrecv.c
 func ParseResumeToken(ctx context.Context, token string) (*ResumeToken, error) {
+	return getBackend().ParseResumeToken(ctx, token)
+}
+
+func (execBackend) ParseResumeToken(ctx context.Context, token string) (*ResumeToken, error) {
 
 	// Example resume tokens:
 	//
@@ -0,0 +1,96 @@
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+type PropertySource string
+
+const (
+	PropertySourceLocal     PropertySource = "local"
+	PropertySourceInherited PropertySource = "inherited"
+	PropertySourceReceived  PropertySource = "received"
+	PropertySourceTemporary PropertySource = "temporary"
+	PropertySourceDefault   PropertySource = "default"
+	// the property is not set, e.g. an unset user property
+	PropertySourceNone PropertySource = "-"
+)
+
+// PropertyValue is a property value and its source as reported by zfs get.
+type PropertyValue struct {
+	Value  string
+	Source PropertySource
+	// valid if Source == PropertySourceInherited
+	InheritedFrom string
+}
+
+// IsSet returns false for unset user properties
+func (v PropertyValue) IsSet() bool {
+	return v.Source != PropertySourceNone
+}
+
+func (v PropertyValue) SourceString() string {
+	if v.Source == PropertySourceInherited {
+		return fmt.Sprintf("inherited from %s", v.InheritedFrom)
+	}
+	return string(v.Source)
+}
+
+func parsePropertySource(s string) (src PropertySource, inheritedFrom string, err error) {
+	const inheritedPrefix = "inherited from "
+	switch {
+	case strings.HasPrefix(s, inheritedPrefix):
+		return PropertySourceInherited, strings.TrimPrefix(s, inheritedPrefix), nil
+	case s == string(PropertySourceLocal), s == string(PropertySourceReceived), s == string(PropertySourceTemporary),
+		s == string(PropertySourceDefault), s == string(PropertySourceNone):
+		return PropertySource(s), "", nil
+	default:
+		return "", "", fmt.Errorf("unknown property source %q", s)
+	}
+}
+
+// ZFSGetAllDatasets returns the values of props for all filesystems and volumes, using a single zfs get invocation.
+// The result maps dataset name => property name => value.
+func ZFSGetAllDatasets(ctx context.Context, props []string) (map[string]map[string]PropertyValue, error) {
+	return getBackend().GetAll(ctx, props)
+}
+
+func (execBackend) GetAll(ctx context.Context, props []string) (map[string]map[string]PropertyValue, error) {
+	args := []string{"get", "-H", "-p", "-t", "filesystem,volume", "-o", "name,property,value,source", strings.Join(props, ",")}
+	cmd := exec.CommandContext(ctx, ZFS_BINARY, args...)
+	stdout, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, &ZFSError{Stderr: exitErr.Stderr, WaitErr: exitErr}
+		}
+		return nil, err
+	}
+	return parseZFSGetAllDatasets(string(stdout))
+}
+
+func parseZFSGetAllDatasets(out string) (map[string]map[string]PropertyValue, error) {
+	res := make(map[string]map[string]PropertyValue)
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("zfs get did not return name,property,value,source tuples: %q", line)
+		}
+		src, inheritedFrom, err := parsePropertySource(fields[3])
+		if err != nil {
+			return nil, err
+		}
+		ds, ok := res[fields[0]]
+		if !ok {
+			ds = make(map[string]PropertyValue)
+			res[fields[0]] = ds
+		}
+		ds[fields[1]] = PropertyValue{Value: fields[2], Source: src, InheritedFrom: inheritedFrom}
+	}
+	return res, nil
+}
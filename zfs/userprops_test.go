@@ -0,0 +1,36 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseZFSGetAllDatasets(t *testing.T) {
+	out := "pool\tzrepl:replicate\t-\t-\n" +
+		"pool/data\tzrepl:replicate\ton\tlocal\n" +
+		"pool/data/a b\tzrepl:replicate\ton\tinherited from pool/data\n" +
+		"pool/data/a b\tzrepl:keep\t[{type: last_n, count: 10}]\treceived\n"
+	res, err := parseZFSGetAllDatasets(out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]PropertyValue{
+		"pool": {
+			"zrepl:replicate": {Value: "-", Source: PropertySourceNone},
+		},
+		"pool/data": {
+			"zrepl:replicate": {Value: "on", Source: PropertySourceLocal},
+		},
+		"pool/data/a b": {
+			"zrepl:replicate": {Value: "on", Source: PropertySourceInherited, InheritedFrom: "pool/data"},
+			"zrepl:keep":      {Value: "[{type: last_n, count: 10}]", Source: PropertySourceReceived},
+		},
+	}, res)
+	assert.False(t, res["pool"]["zrepl:replicate"].IsSet())
+	assert.Equal(t, "inherited from pool/data", res["pool/data/a b"]["zrepl:replicate"].SourceString())
+
+	_, err = parseZFSGetAllDatasets("pool\tzrepl:replicate\ton\n")
+	assert.Error(t, err)
+	_, err = parseZFSGetAllDatasets("pool\tzrepl:replicate\ton\tsomewhere\n")
+	assert.Error(t, err)
+}
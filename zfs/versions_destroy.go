@@ -218,6 +218,10 @@ var batchDestroyFeatureCheck struct {
 }
 
 func (d destroyerImpl) DestroySnapshotsCommaSyntaxSupported() (bool, error) {
+	return getBackend().DestroySnapshotsCommaSyntaxSupported()
+}
+
+func (execBackend) DestroySnapshotsCommaSyntaxSupported() (bool, error) {
 	batchDestroyFeatureCheck.once.Do(func() {
 		// "feature discovery"
 		cmd := exec.Command(ZFS_BINARY, "destroy")
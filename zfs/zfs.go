@@ -157,52 +157,19 @@ func (e *ZFSError) Error() string {
 var ZFS_BINARY string = "zfs"
 
 func ZFSList(properties []string, zfsArgs ...string) (res [][]string, err error) {
-
-	args := make([]string, 0, 4+len(zfsArgs))
-	args = append(args,
-		"list", "-H", "-p",
-		"-o", strings.Join(properties, ","))
-	args = append(args, zfsArgs...)
-
-	cmd := exec.Command(ZFS_BINARY, args...)
-
-	var stdout io.Reader
-	stderr := bytes.NewBuffer(make([]byte, 0, 1024))
-	cmd.Stderr = stderr
-
-	if stdout, err = cmd.StdoutPipe(); err != nil {
-		return
-	}
-
-	if err = cmd.Start(); err != nil {
-		return
-	}
-
-	s := bufio.NewScanner(stdout)
-	buf := make([]byte, 1024)
-	s.Buffer(buf, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan ZFSListResult)
+	go ZFSListChan(ctx, out, properties, zfsArgs...)
 
 	res = make([][]string, 0)
-
-	for s.Scan() {
-		fields := strings.SplitN(s.Text(), "\t", len(properties))
-
-		if len(fields) != len(properties) {
-			err = errors.New("unexpected output")
-			return
+	for r := range out {
+		if r.Err != nil {
+			return nil, r.Err
 		}
-
-		res = append(res, fields)
-	}
-
-	if waitErr := cmd.Wait(); waitErr != nil {
-		err := &ZFSError{
-			Stderr:  stderr.Bytes(),
-			WaitErr: waitErr,
-		}
-		return nil, err
+		res = append(res, r.Fields)
 	}
-	return
+	return res, nil
 }
 
 type ZFSListResult struct {
@@ -219,6 +186,10 @@ type ZFSListResult struct {
 // However, if callers do not drain `out` or cancel via `ctx`, the process will leak either running because
 // IO is pending or as a zombie.
 func ZFSListChan(ctx context.Context, out chan ZFSListResult, properties []string, zfsArgs ...string) {
+	getBackend().List(ctx, out, properties, zfsArgs...)
+}
+
+func (execBackend) List(ctx context.Context, out chan ZFSListResult, properties []string, zfsArgs ...string) {
 	defer close(out)
 
 	args := make([]string, 0, 4+len(zfsArgs))
@@ -314,13 +285,42 @@ func absVersion(fs, v string) (full string, err error) {
 	return fmt.Sprintf("%s%s", fs, v), nil
 }
 
-func buildCommonSendArgs(fs string, from, to string, token string) ([]string, error) {
+// ZFSSendFlags are the flags of zfs send that alter the stream format.
+// The zero value produces a stream compatible with any receiver.
+type ZFSSendFlags struct {
+	LargeBlocks  bool // -L
+	EmbeddedData bool // -e
+	Compressed   bool // -c
+	Holds        bool // -h
+}
+
+func (f ZFSSendFlags) args() []string {
+	args := make([]string, 0, 4)
+	if f.LargeBlocks {
+		args = append(args, "-L")
+	}
+	if f.EmbeddedData {
+		args = append(args, "-e")
+	}
+	if f.Compressed {
+		args = append(args, "-c")
+	}
+	if f.Holds {
+		args = append(args, "-h")
+	}
+	return args
+}
+
+// flags are ignored if token is set because the token encodes the flags of the interrupted send
+func buildCommonSendArgs(fs string, from, to string, token string, flags ZFSSendFlags) ([]string, error) {
 	args := make([]string, 0, 3)
 	if token != "" {
 		args = append(args, "-t", token)
 		return args, nil
 	}
 
+	args = append(args, flags.args()...)
+
 	toV, err := absVersion(fs, to)
 	if err != nil {
 		return nil, err
@@ -514,12 +514,16 @@ func (s *sendStream) killAndWait(precedingReadErr error) error {
 // if token != "", then send -t token is used
 // otherwise send [-i from] to is used
 // (if from is "" a full ZFS send is done)
-func ZFSSend(ctx context.Context, fs string, from, to string, token string) (streamCopier StreamCopier, err error) {
+func ZFSSend(ctx context.Context, fs string, from, to string, token string, flags ZFSSendFlags) (streamCopier StreamCopier, err error) {
+	return getBackend().Send(ctx, fs, from, to, token, flags)
+}
+
+func (execBackend) Send(ctx context.Context, fs string, from, to string, token string, flags ZFSSendFlags) (StreamCopier, error) {
 
 	args := make([]string, 0)
 	args = append(args, "send")
 
-	sargs, err := buildCommonSendArgs(fs, from, to, token)
+	sargs, err := buildCommonSendArgs(fs, from, to, token, flags)
 	if err != nil {
 		return nil, err
 	}
@@ -645,7 +649,7 @@ func (s *DrySendInfo) unmarshalInfoLine(l string) (regexMatched bool, err error)
 
 // from may be "", in which case a full ZFS send is done
 // May return BookmarkSizeEstimationNotSupported as err if from is a bookmark.
-func ZFSSendDry(fs string, from, to string, token string) (_ *DrySendInfo, err error) {
+func ZFSSendDry(fs string, from, to string, token string, flags ZFSSendFlags) (_ *DrySendInfo, err error) {
 
 	if strings.Contains(from, "#") {
 		/* TODO:
@@ -669,10 +673,13 @@ func ZFSSendDry(fs string, from, to string, token string) (_ *DrySendInfo, err e
 			To:           toAbs,
 			SizeEstimate: -1}, nil
 	}
+	return getBackend().SendDry(fs, from, to, token, flags)
+}
 
+func (execBackend) SendDry(fs string, from, to string, token string, flags ZFSSendFlags) (*DrySendInfo, error) {
 	args := make([]string, 0)
 	args = append(args, "send", "-n", "-v", "-P")
-	sargs, err := buildCommonSendArgs(fs, from, to, token)
+	sargs, err := buildCommonSendArgs(fs, from, to, token, flags)
 	if err != nil {
 		return nil, err
 	}
@@ -712,6 +719,9 @@ type RecvOptions struct {
 	// Rollback to the oldest snapshot, destroy it, then perform `recv -F`.
 	// Note that this doesn't change property values, i.e. an existing local property value will be kept.
 	RollbackAndForceRecv bool
+	// Use `recv -s` so that an interrupted receive can be resumed using
+	// the filesystem's receive_resume_token.
+	SavePartialRecvState bool
 }
 
 func ZFSRecv(ctx context.Context, fs string, streamCopier StreamCopier, opts RecvOptions) (err error) {
@@ -760,11 +770,18 @@ func ZFSRecv(ctx context.Context, fs string, streamCopier StreamCopier, opts Rec
 		}
 	}
 
+	return getBackend().Recv(ctx, fs, streamCopier, opts)
+}
+
+func (execBackend) Recv(ctx context.Context, fs string, streamCopier StreamCopier, opts RecvOptions) (err error) {
 	args := make([]string, 0)
 	args = append(args, "recv")
 	if opts.RollbackAndForceRecv {
 		args = append(args, "-F")
 	}
+	if opts.SavePartialRecvState {
+		args = append(args, "-s")
+	}
 	args = append(args, fs)
 
 	ctx, cancelCmd := context.WithCancel(ctx)
@@ -851,7 +868,10 @@ func ZFSRecvClearResumeToken(fs string) (err error) {
 	if err := validateZFSFilesystem(fs); err != nil {
 		return err
 	}
+	return getBackend().RecvClearResumeToken(fs)
+}
 
+func (execBackend) RecvClearResumeToken(fs string) error {
 	cmd := exec.Command(ZFS_BINARY, "recv", "-A", fs)
 	o, err := cmd.CombinedOutput()
 	if err != nil {
@@ -879,12 +899,21 @@ func (p *ZFSProperties) Get(key string) string {
 	return p.m[key]
 }
 
-func (p *ZFSProperties) appendArgs(args *[]string) (err error) {
-	for prop, val := range p.m {
+// appendPropertyArgs appends prop=val for all props to args, each preceded by flag if flag != ""
+func appendPropertyArgs(args *[]string, flag string, props map[string]string) (err error) {
+	names := make([]string, 0, len(props))
+	for prop := range props {
 		if strings.Contains(prop, "=") {
 			return errors.New("prop contains rune '=' which is the delimiter between property name and value")
 		}
-		*args = append(*args, fmt.Sprintf("%s=%s", prop, val))
+		names = append(names, prop)
+	}
+	sort.Strings(names)
+	for _, prop := range names {
+		if flag != "" {
+			*args = append(*args, flag)
+		}
+		*args = append(*args, fmt.Sprintf("%s=%s", prop, props[prop]))
 	}
 	return nil
 }
@@ -894,9 +923,13 @@ func ZFSSet(fs *DatasetPath, props *ZFSProperties) (err error) {
 }
 
 func zfsSet(path string, props *ZFSProperties) (err error) {
+	return getBackend().Set(path, props.m)
+}
+
+func (execBackend) Set(path string, props map[string]string) (err error) {
 	args := make([]string, 0)
 	args = append(args, "set")
-	err = props.appendArgs(&args)
+	err = appendPropertyArgs(&args, "", props)
 	if err != nil {
 		return err
 	}
@@ -977,6 +1010,26 @@ func (s zfsPropertySource) zfsGetSourceFieldPrefixes() []string {
 }
 
 func zfsGet(path string, props []string, allowedSources zfsPropertySource) (*ZFSProperties, error) {
+	values, err := getBackend().Get(path, props)
+	if err != nil {
+		return nil, err
+	}
+	res := &ZFSProperties{
+		make(map[string]string, len(values)),
+	}
+	allowedPrefixes := allowedSources.zfsGetSourceFieldPrefixes()
+	for prop, v := range values {
+		for _, p := range allowedPrefixes {
+			if strings.HasPrefix(string(v.Source), p) {
+				res.m[prop] = v.Value
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (execBackend) Get(path string, props []string) (map[string]PropertyValue, error) {
 	args := []string{"get", "-Hp", "-o", "property,value,source", strings.Join(props, ","), path}
 	cmd := exec.Command(ZFS_BINARY, args...)
 	stdout, err := cmd.Output()
@@ -1003,10 +1056,7 @@ func zfsGet(path string, props []string, allowedSources zfsPropertySource) (*ZFS
 		len(lines)-1 != len(props) {
 		return nil, fmt.Errorf("zfs get did not return the number of expected property values")
 	}
-	res := &ZFSProperties{
-		make(map[string]string, len(lines)),
-	}
-	allowedPrefixes := allowedSources.zfsGetSourceFieldPrefixes()
+	res := make(map[string]PropertyValue, len(lines))
 	for _, line := range lines[:len(lines)-1] {
 		fields := strings.FieldsFunc(line, func(r rune) bool {
 			return r == '\t'
@@ -1014,12 +1064,12 @@ func zfsGet(path string, props []string, allowedSources zfsPropertySource) (*ZFS
 		if len(fields) != 3 {
 			return nil, fmt.Errorf("zfs get did not return property,value,source tuples")
 		}
-		for _, p := range allowedPrefixes {
-			if strings.HasPrefix(fields[2], p) {
-				res.m[fields[0]] = fields[1]
-				break
-			}
+		src, inheritedFrom, err := parsePropertySource(fields[2])
+		if err != nil {
+			// be lenient, the caller filters by the source's prefix
+			src = PropertySource(fields[2])
 		}
+		res[fields[0]] = PropertyValue{Value: fields[1], Source: src, InheritedFrom: inheritedFrom}
 	}
 	return res, nil
 }
@@ -1138,6 +1188,10 @@ func ZFSDestroy(arg string) (err error) {
 
 	defer prometheus.NewTimer(prom.ZFSDestroyDuration.WithLabelValues(dstype, filesystem))
 
+	return getBackend().Destroy(arg)
+}
+
+func (execBackend) Destroy(arg string) (err error) {
 	cmd := exec.Command(ZFS_BINARY, "destroy", arg)
 
 	var stderr bytes.Buffer
@@ -1175,7 +1229,10 @@ func ZFSSnapshot(fs *DatasetPath, name string, recursive bool) (err error) {
 	promTimer := prometheus.NewTimer(prom.ZFSSnapshotDuration.WithLabelValues(fs.ToString()))
 	defer promTimer.ObserveDuration()
 
-	snapname := zfsBuildSnapName(fs, name)
+	return getBackend().Snapshot(zfsBuildSnapName(fs, name))
+}
+
+func (execBackend) Snapshot(snapname string) (err error) {
 	cmd := exec.Command(ZFS_BINARY, "snapshot", snapname)
 
 	stderr := bytes.NewBuffer(make([]byte, 0, 1024))
@@ -1206,6 +1263,10 @@ func ZFSBookmark(fs *DatasetPath, snapshot, bookmark string) (err error) {
 
 	debug("bookmark: %q %q", snapname, bookmarkname)
 
+	return getBackend().Bookmark(snapname, bookmarkname)
+}
+
+func (execBackend) Bookmark(snapname, bookmarkname string) (err error) {
 	cmd := exec.Command(ZFS_BINARY, "bookmark", snapname, bookmarkname)
 
 	stderr := bytes.NewBuffer(make([]byte, 0, 1024))
@@ -1233,6 +1294,10 @@ func ZFSRollback(fs *DatasetPath, snapshot FilesystemVersion, rollbackArgs ...st
 		return fmt.Errorf("can only rollback to snapshots, got %s", snapabs)
 	}
 
+	return getBackend().Rollback(snapabs, rollbackArgs...)
+}
+
+func (execBackend) Rollback(snapabs string, rollbackArgs ...string) (err error) {
 	args := []string{"rollback"}
 	args = append(args, rollbackArgs...)
 	args = append(args, snapabs)
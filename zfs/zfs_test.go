@@ -256,3 +256,18 @@ size	10518512
 		})
 	}
 }
+
+func TestBuildCommonSendArgs(t *testing.T) {
+	args, err := buildCommonSendArgs("pool/fs", "@a", "@b", "", ZFSSendFlags{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-i", "pool/fs@a", "pool/fs@b"}, args)
+
+	args, err = buildCommonSendArgs("pool/fs", "", "@b", "", ZFSSendFlags{LargeBlocks: true, EmbeddedData: true, Compressed: true, Holds: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-L", "-e", "-c", "-h", "pool/fs@b"}, args)
+
+	// the resume token determines the flags
+	args, err = buildCommonSendArgs("pool/fs", "@a", "@b", "1-abc", ZFSSendFlags{LargeBlocks: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-t", "1-abc"}, args)
+}
@@ -0,0 +1,911 @@
+// Package zfsfake implements zfs.Backend as an in-memory simulation of ZFS pools.
+//
+// It allows tests to exercise replication, pruning and placeholder handling end-to-end
+// without a real pool:
+//
+//	b := zfsfake.New()
+//	defer zfs.SetBackend(zfs.SetBackend(b))
+//	b.AddPool("pool")
+//
+// The simulation models what zrepl relies on:
+// GUIDs that are preserved by send and receive, transaction groups (createtxg),
+// bookmarks, property sources and inheritance of user properties,
+// validity checks of (incremental) send streams on receive,
+// resumable receives with receive_resume_token, and user holds.
+// Creation times are strictly increasing in steps of at least one second,
+// so that versions created in quick succession can be ordered by creation like real ones.
+//
+// A filesystem's contents are a byte slice (see WriteData) that is captured by snapshots
+// and transferred by send streams. Every stream contains the full contents of its
+// target snapshot, incremental streams are only distinguished by their incremental source.
+// Volumes, clones, encryption and send -R are not simulated.
+package zfsfake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// Backend is an in-memory zfs.Backend. The zero value is not usable, use New.
+type Backend struct {
+	mtx         sync.Mutex
+	txg         uint64
+	guids       *rand.Rand
+	filesystems map[string]*filesystem
+	// most recently assigned creation time
+	lastCreation time.Time
+
+	// payload bytes after which the next send stream fails, -1 if disabled
+	breakSendAfter int64
+}
+
+var _ zfs.Backend = (*Backend)(nil)
+
+type filesystem struct {
+	b               *Backend
+	name            string
+	guid, createtxg uint64
+	creation        time.Time
+	props           map[string]string // local property values
+	data            []byte
+	// data was written since the most recent snapshot
+	modified  bool
+	snapshots []*version // ordered by createtxg
+	bookmarks []*version // ordered by createtxg
+	partial   *partialRecv
+}
+
+type version struct {
+	typ             zfs.VersionType
+	name            string
+	guid, createtxg uint64
+	creation        time.Time
+	data            []byte            // snapshots only
+	props           map[string]string // snapshots only
+	holds           map[string]bool   // snapshots only
+}
+
+// New returns a Backend without any pools.
+func New() *Backend {
+	return &Backend{
+		guids:          rand.New(rand.NewSource(1)),
+		filesystems:    make(map[string]*filesystem),
+		breakSendAfter: -1,
+	}
+}
+
+// AddPool creates a pool, i.e., a root filesystem.
+func (b *Backend) AddPool(name string) error {
+	p, err := zfs.NewDatasetPath(name)
+	if err != nil {
+		return err
+	}
+	if p.Length() != 1 {
+		return fmt.Errorf("invalid pool name %q", name)
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.filesystems[name] != nil {
+		return zfsError("cannot create '%s': pool already exists", name)
+	}
+	b.newFilesystem(name, nil)
+	return nil
+}
+
+// WriteData replaces the contents of filesystem fs.
+func (b *Backend) WriteData(fs string, data []byte) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, err := b.lookupFilesystem(fs)
+	if err != nil {
+		return err
+	}
+	f.data = append([]byte(nil), data...)
+	f.modified = true
+	return nil
+}
+
+// ReadData returns the contents of a filesystem or snapshot.
+func (b *Backend) ReadData(path string) ([]byte, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, v, err := b.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return append([]byte(nil), f.data...), nil
+	}
+	if v.typ != zfs.Snapshot {
+		return nil, fmt.Errorf("%q is not a filesystem or snapshot", path)
+	}
+	return append([]byte(nil), v.data...), nil
+}
+
+// BreakNextSend makes the next send stream fail with a read error
+// after it has produced afterBytes bytes of its payload.
+func (b *Backend) BreakNextSend(afterBytes int64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.breakSendAfter = afterBytes
+}
+
+var errExitStatus = errors.New("exit status 1")
+
+// zfsError returns an error that looks like a failed invocation of the zfs binary
+func zfsError(format string, args ...interface{}) error {
+	return &zfs.ZFSError{
+		Stderr:  []byte(fmt.Sprintf(format, args...) + "\n"),
+		WaitErr: errExitStatus,
+	}
+}
+
+func errDoesNotExist(path string) error {
+	return zfsError("cannot open '%s': dataset does not exist", path)
+}
+
+func (b *Backend) nextTXG() uint64 {
+	b.txg++
+	return b.txg
+}
+
+// creationTime returns the current time in the resolution of the creation property.
+// Like snapshots taken at different times, the results are strictly increasing,
+// even if datasets are created in quick succession.
+func (b *Backend) creationTime() time.Time {
+	now := time.Unix(time.Now().Unix(), 0)
+	if !now.After(b.lastCreation) {
+		now = b.lastCreation.Add(time.Second)
+	}
+	b.lastCreation = now
+	return now
+}
+
+func (b *Backend) newGUID() uint64 {
+	for {
+		if guid := b.guids.Uint64(); guid != 0 {
+			return guid
+		}
+	}
+}
+
+func (b *Backend) newFilesystem(name string, props map[string]string) *filesystem {
+	f := &filesystem{
+		b:         b,
+		name:      name,
+		guid:      b.newGUID(),
+		createtxg: b.nextTXG(),
+		creation:  b.creationTime(),
+		props:     make(map[string]string, len(props)),
+	}
+	for k, v := range props {
+		f.props[k] = v
+	}
+	b.filesystems[name] = f
+	return f
+}
+
+func parentName(fs string) string {
+	i := strings.LastIndex(fs, "/")
+	if i == -1 {
+		return ""
+	}
+	return fs[:i]
+}
+
+func (b *Backend) children(fs string) []*filesystem {
+	var res []*filesystem
+	for name, f := range b.filesystems {
+		if parentName(name) == fs {
+			res = append(res, f)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res
+}
+
+func (f *filesystem) parent() *filesystem {
+	return f.b.filesystems[parentName(f.name)]
+}
+
+func (b *Backend) lookupFilesystem(fs string) (*filesystem, error) {
+	f := b.filesystems[fs]
+	if f == nil {
+		return nil, errDoesNotExist(fs)
+	}
+	return f, nil
+}
+
+// lookup returns the filesystem of path and, if path is a snapshot or bookmark, the version
+func (b *Backend) lookup(path string) (*filesystem, *version, error) {
+	if !strings.ContainsAny(path, "@#") {
+		f, err := b.lookupFilesystem(path)
+		return f, nil, err
+	}
+	fs, typ, name, err := zfs.DecomposeVersionString(path)
+	if err != nil {
+		return nil, nil, zfsError("cannot open '%s': invalid dataset name", path)
+	}
+	f := b.filesystems[fs]
+	if f == nil {
+		return nil, nil, errDoesNotExist(path)
+	}
+	v := f.version(typ, name)
+	if v == nil {
+		return nil, nil, errDoesNotExist(path)
+	}
+	return f, v, nil
+}
+
+func (f *filesystem) versions(typ zfs.VersionType) *[]*version {
+	if typ == zfs.Snapshot {
+		return &f.snapshots
+	}
+	return &f.bookmarks
+}
+
+func (f *filesystem) version(typ zfs.VersionType, name string) *version {
+	for _, v := range *f.versions(typ) {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// versionByGUID returns the snapshot or (if there is none) bookmark with the given guid
+func (f *filesystem) versionByGUID(guid uint64) *version {
+	for _, typ := range []zfs.VersionType{zfs.Snapshot, zfs.Bookmark} {
+		for _, v := range *f.versions(typ) {
+			if v.guid == guid {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (f *filesystem) latestSnapshot() *version {
+	if len(f.snapshots) == 0 {
+		return nil
+	}
+	return f.snapshots[len(f.snapshots)-1]
+}
+
+func (f *filesystem) removeVersion(v *version) {
+	vs := f.versions(v.typ)
+	for i := range *vs {
+		if (*vs)[i] == v {
+			*vs = append((*vs)[:i], (*vs)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *filesystem) versionsAfter(snap *version) (later []*version) {
+	for _, typ := range []zfs.VersionType{zfs.Snapshot, zfs.Bookmark} {
+		for _, v := range *f.versions(typ) {
+			if v.createtxg > snap.createtxg {
+				later = append(later, v)
+			}
+		}
+	}
+	return later
+}
+
+// rollbackTo destroys all snapshots and bookmarks more recent than snap
+func (f *filesystem) rollbackTo(snap *version) error {
+	later := f.versionsAfter(snap)
+	for _, v := range later {
+		if len(v.holds) > 0 {
+			return zfsError("cannot destroy '%s@%s': dataset is busy", f.name, v.name)
+		}
+	}
+	for _, v := range later {
+		f.removeVersion(v)
+	}
+	f.data = append([]byte(nil), snap.data...)
+	f.modified = false
+	return nil
+}
+
+func (b *Backend) List(ctx context.Context, out chan zfs.ZFSListResult, properties []string, zfsArgs ...string) {
+	defer close(out)
+
+	sendResult := func(fields []string, err error) (done bool) {
+		select {
+		case <-ctx.Done():
+			return true
+		case out <- zfs.ZFSListResult{Fields: fields, Err: err}:
+			return false
+		}
+	}
+
+	rows, err := b.list(properties, zfsArgs)
+	if err != nil {
+		sendResult(nil, err)
+		return
+	}
+	for _, row := range rows {
+		if sendResult(row, nil) {
+			return
+		}
+	}
+}
+
+type listArgs struct {
+	recursive bool
+	depth     int // -1 means unlimited
+	types     map[string]bool
+	sortBy    string
+	root      string
+}
+
+func parseListArgs(args []string) (a listArgs, err error) {
+	a.depth = -1
+	a.types = map[string]bool{"filesystem": true}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		needsValue := arg == "-d" || arg == "-t" || arg == "-s"
+		if needsValue && i+1 == len(args) {
+			return a, fmt.Errorf("missing argument for %s", arg)
+		}
+		switch {
+		case arg == "-r":
+			a.recursive = true
+		case arg == "-d":
+			i++
+			a.recursive = true
+			if a.depth, err = strconv.Atoi(args[i]); err != nil || a.depth < 0 {
+				return a, fmt.Errorf("invalid depth %q", args[i])
+			}
+		case arg == "-t":
+			i++
+			a.types = make(map[string]bool)
+			for _, t := range strings.Split(args[i], ",") {
+				switch t {
+				case "all":
+					a.types["filesystem"] = true
+					a.types["snapshot"] = true
+					a.types["bookmark"] = true
+				case "filesystem", "snapshot", "bookmark":
+					a.types[t] = true
+				case "volume":
+					// not simulated
+				default:
+					return a, fmt.Errorf("invalid type %q", t)
+				}
+			}
+		case arg == "-s":
+			i++
+			a.sortBy = args[i]
+		case strings.HasPrefix(arg, "-"):
+			return a, fmt.Errorf("zfs list argument %q is not supported", arg)
+		case a.root != "":
+			return a, fmt.Errorf("listing multiple datasets is not supported")
+		default:
+			a.root = arg
+		}
+	}
+	return a, nil
+}
+
+type listEntry struct {
+	fs *filesystem
+	v  *version // nil for the filesystem itself
+}
+
+func (b *Backend) list(properties []string, zfsArgs []string) ([][]string, error) {
+	a, err := parseListArgs(zfsArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	var entries []listEntry
+	var walk func(f *filesystem, depth int)
+	walk = func(f *filesystem, depth int) {
+		if a.types["filesystem"] {
+			entries = append(entries, listEntry{f, nil})
+		}
+		if !a.recursive || (a.depth != -1 && depth+1 > a.depth) {
+			return
+		}
+		for _, typ := range []zfs.VersionType{zfs.Snapshot, zfs.Bookmark} {
+			if !a.types[string(typ)] {
+				continue
+			}
+			for _, v := range *f.versions(typ) {
+				entries = append(entries, listEntry{f, v})
+			}
+		}
+		for _, c := range b.children(f.name) {
+			walk(c, depth+1)
+		}
+	}
+
+	if a.root == "" {
+		// zfs list without a dataset lists all datasets
+		a.recursive = true
+		for _, pool := range b.children("") {
+			walk(pool, 0)
+		}
+	} else {
+		f, v, err := b.lookup(a.root)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			if a.types[string(v.typ)] {
+				entries = append(entries, listEntry{f, v})
+			}
+		} else {
+			walk(f, 0)
+		}
+	}
+
+	rows := make([][]string, len(entries))
+	for i, e := range entries {
+		rows[i] = make([]string, len(properties))
+		for j, prop := range properties {
+			pv, err := e.get(prop)
+			if err != nil {
+				return nil, err
+			}
+			rows[i][j] = pv.Value
+		}
+	}
+
+	if a.sortBy != "" {
+		col := -1
+		for i, prop := range properties {
+			if prop == a.sortBy {
+				col = i
+			}
+		}
+		if col == -1 {
+			return nil, fmt.Errorf("sorting by a property that is not listed is not supported")
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			x, errX := strconv.ParseUint(rows[i][col], 10, 64)
+			y, errY := strconv.ParseUint(rows[j][col], 10, 64)
+			if errX == nil && errY == nil {
+				return x < y
+			}
+			return rows[i][col] < rows[j][col]
+		})
+	}
+
+	return rows, nil
+}
+
+func (b *Backend) Get(path string, props []string) (map[string]zfs.PropertyValue, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, v, err := b.lookup(path)
+	if err != nil {
+		return nil, &zfs.DatasetDoesNotExist{Path: path}
+	}
+	e := listEntry{f, v}
+	res := make(map[string]zfs.PropertyValue, len(props))
+	for _, prop := range props {
+		if res[prop], err = e.get(prop); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (b *Backend) GetAll(ctx context.Context, props []string) (map[string]map[string]zfs.PropertyValue, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	res := make(map[string]map[string]zfs.PropertyValue, len(b.filesystems))
+	for name, f := range b.filesystems {
+		e := listEntry{f, nil}
+		res[name] = make(map[string]zfs.PropertyValue, len(props))
+		for _, prop := range props {
+			pv, err := e.get(prop)
+			if err != nil {
+				return nil, err
+			}
+			res[name][prop] = pv
+		}
+	}
+	return res, nil
+}
+
+// settable native properties and their default values
+var nativeDefaults = map[string]string{
+	"mountpoint":  "", // derived from the dataset name
+	"canmount":    "on",
+	"readonly":    "off",
+	"compression": "off",
+	"atime":       "on",
+}
+
+func isUserProperty(prop string) bool {
+	return strings.Contains(prop, ":")
+}
+
+func validateSettable(path string, isSnapshot bool, prop string) error {
+	if isUserProperty(prop) {
+		return nil
+	}
+	if _, ok := nativeDefaults[prop]; !ok {
+		return zfsError("cannot set property for '%s': invalid property '%s'", path, prop)
+	}
+	if isSnapshot {
+		return zfsError("cannot set property for '%s': this property can not be modified for snapshots", path)
+	}
+	return nil
+}
+
+func (e listEntry) name() string {
+	if e.v == nil {
+		return e.fs.name
+	}
+	return e.fs.name + e.v.typ.DelimiterChar() + e.v.name
+}
+
+func (e listEntry) get(prop string) (zfs.PropertyValue, error) {
+	none := zfs.PropertyValue{Value: "-", Source: zfs.PropertySourceNone}
+	val := func(v string) zfs.PropertyValue {
+		return zfs.PropertyValue{Value: v, Source: zfs.PropertySourceNone}
+	}
+	u := func(n uint64) zfs.PropertyValue { return val(strconv.FormatUint(n, 10)) }
+
+	guid, createtxg, creation, data := e.fs.guid, e.fs.createtxg, e.fs.creation, e.fs.data
+	if e.v != nil {
+		guid, createtxg, creation, data = e.v.guid, e.v.createtxg, e.v.creation, e.v.data
+	}
+
+	switch prop {
+	case "name":
+		return val(e.name()), nil
+	case "type":
+		if e.v == nil {
+			return val("filesystem"), nil
+		}
+		return val(string(e.v.typ)), nil
+	case "guid":
+		return u(guid), nil
+	case "createtxg":
+		return u(createtxg), nil
+	case "creation":
+		return val(strconv.FormatInt(creation.Unix(), 10)), nil
+	case "used", "referenced":
+		return u(uint64(len(data))), nil
+	case "userrefs":
+		if e.v == nil || e.v.typ != zfs.Snapshot {
+			return none, nil
+		}
+		return u(uint64(len(e.v.holds))), nil
+	case "receive_resume_token":
+		if e.v != nil || e.fs.partial == nil {
+			return none, nil
+		}
+		return val(e.fs.partial.token()), nil
+	}
+
+	if e.v != nil && e.v.typ == zfs.Bookmark {
+		return none, nil
+	}
+
+	if isUserProperty(prop) {
+		if e.v != nil {
+			if pv, ok := e.v.props[prop]; ok {
+				return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceLocal}, nil
+			}
+			if pv, ok := e.fs.props[prop]; ok {
+				return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceInherited, InheritedFrom: e.fs.name}, nil
+			}
+		} else if pv, ok := e.fs.props[prop]; ok {
+			return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceLocal}, nil
+		}
+		for p := e.fs.parent(); p != nil; p = p.parent() {
+			if pv, ok := p.props[prop]; ok {
+				return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceInherited, InheritedFrom: p.name}, nil
+			}
+		}
+		return none, nil
+	}
+
+	def, ok := nativeDefaults[prop]
+	if !ok {
+		return zfs.PropertyValue{}, zfsError("bad property list: invalid property '%s'", prop)
+	}
+	if e.v != nil {
+		return none, nil
+	}
+	if pv, ok := e.fs.props[prop]; ok {
+		return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceLocal}, nil
+	}
+	for p := e.fs.parent(); p != nil; p = p.parent() {
+		if pv, ok := p.props[prop]; ok {
+			if prop == "mountpoint" && pv != "none" && pv != "legacy" {
+				pv = pv + strings.TrimPrefix(e.fs.name, p.name)
+			}
+			return zfs.PropertyValue{Value: pv, Source: zfs.PropertySourceInherited, InheritedFrom: p.name}, nil
+		}
+	}
+	if prop == "mountpoint" {
+		def = "/" + e.fs.name
+	}
+	return zfs.PropertyValue{Value: def, Source: zfs.PropertySourceDefault}, nil
+}
+
+func (b *Backend) Set(path string, props map[string]string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, v, err := b.lookup(path)
+	if err != nil {
+		return err
+	}
+	if v != nil && v.typ == zfs.Bookmark {
+		return zfsError("cannot set property for '%s': bookmarks do not have settable properties", path)
+	}
+	for prop := range props {
+		if err := validateSettable(path, v != nil, prop); err != nil {
+			return err
+		}
+	}
+	dst := f.props
+	if v != nil {
+		dst = v.props
+	}
+	for prop, val := range props {
+		dst[prop] = val
+	}
+	return nil
+}
+
+func (b *Backend) Create(path string, props map[string]string) error {
+	p, err := zfs.NewDatasetPath(path)
+	if err != nil {
+		return zfsError("cannot create '%s': invalid dataset name: %s", path, err)
+	}
+	if p.Length() < 2 {
+		return zfsError("cannot create '%s': missing dataset name", path)
+	}
+	for prop := range props {
+		if err := validateSettable(path, false, prop); err != nil {
+			return err
+		}
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.filesystems[path] != nil {
+		return zfsError("cannot create '%s': dataset already exists", path)
+	}
+	if b.filesystems[parentName(path)] == nil {
+		return zfsError("cannot create '%s': parent does not exist", path)
+	}
+	b.newFilesystem(path, props)
+	return nil
+}
+
+func (b *Backend) filesystemAndVersionName(path string, typ zfs.VersionType) (*filesystem, string, error) {
+	fs, t, name, err := zfs.DecomposeVersionString(path)
+	if err != nil || t != typ {
+		return nil, "", zfsError("cannot open '%s': invalid %s name", path, typ)
+	}
+	f, err := b.lookupFilesystem(fs)
+	return f, name, err
+}
+
+func (b *Backend) Snapshot(snapshot string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, name, err := b.filesystemAndVersionName(snapshot, zfs.Snapshot)
+	if err != nil {
+		return err
+	}
+	if f.version(zfs.Snapshot, name) != nil {
+		return zfsError("cannot create snapshot '%s': dataset already exists", snapshot)
+	}
+	f.snapshots = append(f.snapshots, &version{
+		typ:       zfs.Snapshot,
+		name:      name,
+		guid:      b.newGUID(),
+		createtxg: b.nextTXG(),
+		creation:  b.creationTime(),
+		data:      append([]byte(nil), f.data...),
+		props:     make(map[string]string),
+		holds:     make(map[string]bool),
+	})
+	f.modified = false
+	return nil
+}
+
+func (b *Backend) Bookmark(snapshot, bookmark string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, snap, err := b.lookup(snapshot)
+	if err != nil {
+		return err
+	}
+	if snap == nil || snap.typ != zfs.Snapshot {
+		return zfsError("cannot create bookmark '%s': '%s' is not a snapshot", bookmark, snapshot)
+	}
+	bf, name, err := b.filesystemAndVersionName(bookmark, zfs.Bookmark)
+	if err != nil {
+		return err
+	}
+	if bf != f {
+		return zfsError("cannot create bookmark '%s': must be in the same filesystem as the snapshot", bookmark)
+	}
+	if f.version(zfs.Bookmark, name) != nil {
+		return zfsError("cannot create bookmark '%s': bookmark exists", bookmark)
+	}
+	f.bookmarks = append(f.bookmarks, &version{
+		typ:       zfs.Bookmark,
+		name:      name,
+		guid:      snap.guid,
+		createtxg: snap.createtxg,
+		creation:  snap.creation,
+	})
+	sort.SliceStable(f.bookmarks, func(i, j int) bool { return f.bookmarks[i].createtxg < f.bookmarks[j].createtxg })
+	return nil
+}
+
+func (b *Backend) Destroy(arg string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	switch {
+	case strings.Contains(arg, "#"):
+		f, v, err := b.lookup(arg)
+		if err != nil {
+			return err
+		}
+		f.removeVersion(v)
+		return nil
+
+	case strings.Contains(arg, "@"):
+		fs, names := arg[:strings.Index(arg, "@")], strings.Split(arg[strings.Index(arg, "@")+1:], ",")
+		f, err := b.lookupFilesystem(fs)
+		if err != nil {
+			return err
+		}
+		var snaps []*version
+		dserr := &zfs.DestroySnapshotsError{Filesystem: fs}
+		for _, name := range names {
+			if strings.Contains(name, "%") {
+				return fmt.Errorf("snapshot ranges are not supported: %q", arg)
+			}
+			v := f.version(zfs.Snapshot, name)
+			if v == nil {
+				continue
+			}
+			if len(v.holds) > 0 {
+				const reason = "dataset is busy"
+				dserr.RawLines = append(dserr.RawLines, fmt.Sprintf("cannot destroy snapshot %s@%s: %s", fs, name, reason))
+				dserr.Undestroyable = append(dserr.Undestroyable, name)
+				dserr.Reason = append(dserr.Reason, reason)
+			}
+			snaps = append(snaps, v)
+		}
+		if len(dserr.Undestroyable) > 0 {
+			// like lzc_destroy_snaps, destroy none if one of them cannot be destroyed
+			return dserr
+		}
+		if len(snaps) == 0 {
+			return zfsError("could not find any snapshots to destroy; check snapshot names.")
+		}
+		for _, v := range snaps {
+			f.removeVersion(v)
+		}
+		return nil
+
+	default:
+		f, err := b.lookupFilesystem(arg)
+		if err != nil {
+			return err
+		}
+		if parentName(arg) == "" {
+			return zfsError("cannot destroy '%s': operation does not apply to pools", arg)
+		}
+		if len(b.children(arg)) > 0 || len(f.snapshots) > 0 {
+			return zfsError("cannot destroy '%s': filesystem has children\nuse '-r' to destroy the following datasets:", arg)
+		}
+		delete(b.filesystems, arg)
+		return nil
+	}
+}
+
+func (b *Backend) DestroySnapshotsCommaSyntaxSupported() (bool, error) {
+	return true, nil
+}
+
+func (b *Backend) Rollback(snapshot string, rollbackArgs ...string) error {
+	recursive := false
+	for _, arg := range rollbackArgs {
+		if arg != "-r" {
+			return fmt.Errorf("zfs rollback argument %q is not supported", arg)
+		}
+		recursive = true
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, snap, err := b.lookup(snapshot)
+	if err != nil {
+		return err
+	}
+	if snap == nil || snap.typ != zfs.Snapshot {
+		return zfsError("cannot rollback '%s': not a snapshot", snapshot)
+	}
+	if !recursive && len(f.versionsAfter(snap)) > 0 {
+		return zfsError("cannot rollback to '%s': more recent snapshots or bookmarks exist\nuse '-r' to force deletion of the following snapshots and bookmarks:", snapshot)
+	}
+	return f.rollbackTo(snap)
+}
+
+func (b *Backend) lookupSnapshot(snapshot string) (*version, error) {
+	_, v, err := b.lookup(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || v.typ != zfs.Snapshot {
+		return nil, zfsError("cannot open '%s': not a snapshot", snapshot)
+	}
+	return v, nil
+}
+
+// Hold places a user hold with the given tag on snapshot, which prevents its destruction.
+// zrepl does not place holds itself (send -h only transfers them), so this is not part of zfs.Backend:
+// tests use it to simulate holds placed by other tools or the administrator.
+func (b *Backend) Hold(snapshot, tag string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	v, err := b.lookupSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	if v.holds[tag] {
+		return zfsError("cannot hold snapshot '%s': tag already exists on this dataset", snapshot)
+	}
+	v.holds[tag] = true
+	return nil
+}
+
+// Release releases the user hold with the given tag from snapshot.
+func (b *Backend) Release(snapshot, tag string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	v, err := b.lookupSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	if !v.holds[tag] {
+		return zfsError("cannot release hold from snapshot '%s': no such tag on this dataset", snapshot)
+	}
+	delete(v.holds, tag)
+	return nil
+}
+
+// Holds returns the tags of the user holds on snapshot, sorted by name.
+func (b *Backend) Holds(snapshot string) ([]string, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	v, err := b.lookupSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(v.holds))
+	for tag := range v.holds {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
@@ -0,0 +1,420 @@
+package zfsfake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zrepl/zrepl/zfs"
+)
+
+// A send stream consists of a JSON-encoded streamHeader, a newline
+// and the contents of the target snapshot, starting at Offset.
+type streamHeader struct {
+	ToName   string // fs@snap on the sending side
+	ToGUID   uint64
+	FromGUID uint64 // 0 for full streams
+	Creation int64  // of the target snapshot, unix seconds
+	Size     int64  // of the target snapshot's contents
+	Offset   int64  // > 0 for resumed streams
+	Flags    zfs.ZFSSendFlags
+	Holds    []string `json:",omitempty"` // send -h
+}
+
+func (h *streamHeader) isFull() bool { return h.FromGUID == 0 }
+
+// resumeToken is the content of a receive_resume_token
+type resumeToken struct {
+	ToName   string
+	ToGUID   uint64
+	FromGUID uint64
+	Bytes    int64
+	Flags    zfs.ZFSSendFlags
+}
+
+const resumeTokenPrefix = "1-zfsfake-"
+
+func (t *resumeToken) encode() string {
+	j, err := json.Marshal(t)
+	if err != nil {
+		panic(err)
+	}
+	return resumeTokenPrefix + hex.EncodeToString(j)
+}
+
+func decodeResumeToken(token string) (*resumeToken, error) {
+	if !strings.HasPrefix(token, resumeTokenPrefix) {
+		return nil, zfs.ResumeTokenCorruptError
+	}
+	j, err := hex.DecodeString(strings.TrimPrefix(token, resumeTokenPrefix))
+	if err != nil {
+		return nil, zfs.ResumeTokenCorruptError
+	}
+	var t resumeToken
+	if err := json.Unmarshal(j, &t); err != nil {
+		return nil, zfs.ResumeTokenCorruptError
+	}
+	return &t, nil
+}
+
+// partialRecv is the state saved by an interrupted `zfs recv -s`
+type partialRecv struct {
+	header streamHeader
+	data   []byte // the contents received so far, starting at offset 0
+	// the filesystem did not exist before the receive
+	createdFS bool
+}
+
+func (p *partialRecv) token() string {
+	t := resumeToken{
+		ToName:   p.header.ToName,
+		ToGUID:   p.header.ToGUID,
+		FromGUID: p.header.FromGUID,
+		Bytes:    int64(len(p.data)),
+		Flags:    p.header.Flags,
+	}
+	return t.encode()
+}
+
+func (b *Backend) ParseResumeToken(ctx context.Context, token string) (*zfs.ResumeToken, error) {
+	t, err := decodeResumeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &zfs.ResumeToken{
+		HasFromGUID: t.FromGUID != 0,
+		FromGUID:    t.FromGUID,
+		HasToGUID:   true,
+		ToGUID:      t.ToGUID,
+	}, nil
+}
+
+// prepareSend resolves the arguments of Send and SendDry
+func (b *Backend) prepareSend(fs string, from, to string, token string, flags zfs.ZFSSendFlags) (hdr *streamHeader, payload []byte, err error) {
+	var f *filesystem
+	var fromV, toV *version
+	var offset int64
+	if token != "" {
+		t, err := decodeResumeToken(token)
+		if err != nil {
+			return nil, nil, err
+		}
+		toFS, _, _, err := zfs.DecomposeVersionString(t.ToName)
+		if err != nil {
+			return nil, nil, zfs.ResumeTokenCorruptError
+		}
+		f = b.filesystems[toFS]
+		if f != nil {
+			toV = f.versionByGUID(t.ToGUID)
+		}
+		if toV == nil || toV.typ != zfs.Snapshot {
+			return nil, nil, zfsError("cannot resume send: '%s' used in the initial send no longer exists", t.ToName)
+		}
+		if t.FromGUID != 0 {
+			if fromV = f.versionByGUID(t.FromGUID); fromV == nil {
+				return nil, nil, zfsError("cannot resume send: incremental source 0x%x no longer exists", t.FromGUID)
+			}
+		}
+		if t.Bytes > int64(len(toV.data)) {
+			return nil, nil, zfsError("cannot resume send: offset %d exceeds stream size", t.Bytes)
+		}
+		offset, flags = t.Bytes, t.Flags
+	} else {
+		if f, err = b.lookupFilesystem(fs); err != nil {
+			return nil, nil, err
+		}
+		if !strings.HasPrefix(to, "@") {
+			return nil, nil, fmt.Errorf("send target must be a snapshot, got %q", to)
+		}
+		if toV = f.version(zfs.Snapshot, to[1:]); toV == nil {
+			return nil, nil, errDoesNotExist(fs + to)
+		}
+		if from != "" {
+			_, fromV, err = b.lookup(fs + from)
+			if err != nil {
+				return nil, nil, err
+			}
+			if fromV.createtxg >= toV.createtxg {
+				return nil, nil, zfsError("cannot send '%s': not an earlier snapshot from the same fs", fs+to)
+			}
+		}
+	}
+
+	hdr = &streamHeader{
+		ToName:   f.name + "@" + toV.name,
+		ToGUID:   toV.guid,
+		Creation: toV.creation.Unix(),
+		Size:     int64(len(toV.data)),
+		Offset:   offset,
+		Flags:    flags,
+	}
+	if fromV != nil {
+		hdr.FromGUID = fromV.guid
+	}
+	if flags.Holds {
+		for tag := range toV.holds {
+			hdr.Holds = append(hdr.Holds, tag)
+		}
+	}
+	return hdr, toV.data[offset:], nil
+}
+
+func (b *Backend) SendDry(fs string, from, to string, token string, flags zfs.ZFSSendFlags) (*zfs.DrySendInfo, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	hdr, payload, err := b.prepareSend(fs, from, to, token, flags)
+	if err != nil {
+		return nil, err
+	}
+	toFS, _, _, _ := zfs.DecomposeVersionString(hdr.ToName)
+	si := &zfs.DrySendInfo{
+		Type:         zfs.DrySendTypeFull,
+		Filesystem:   toFS,
+		To:           hdr.ToName,
+		SizeEstimate: int64(len(payload)),
+	}
+	if !hdr.isFull() {
+		si.Type = zfs.DrySendTypeIncremental
+		si.From = toFS + from
+	}
+	return si, nil
+}
+
+var errSendInterrupted = errors.New("zfsfake: send stream interrupted")
+
+func (b *Backend) Send(ctx context.Context, fs string, from, to string, token string, flags zfs.ZFSSendFlags) (zfs.StreamCopier, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	hdr, payload, err := b.prepareSend(fs, from, to, token, flags)
+	if err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+	stream := bytes.NewBuffer(j)
+	stream.WriteByte('\n')
+
+	var r io.Reader = stream
+	if b.breakSendAfter >= 0 && b.breakSendAfter < int64(len(payload)) {
+		stream.Write(payload[:b.breakSendAfter])
+		r = io.MultiReader(stream, errReader{errSendInterrupted})
+	} else {
+		stream.Write(payload)
+	}
+	b.breakSendAfter = -1
+	return &streamCopier{r: r}, nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read(p []byte) (int, error) { return 0, r.err }
+
+type streamCopier struct {
+	r       io.Reader
+	readErr error
+}
+
+type streamCopierError struct {
+	isReadErr bool
+	err       error
+}
+
+func (e streamCopierError) Error() string {
+	if e.isReadErr {
+		return fmt.Sprintf("stream: read error: %s", e.err)
+	}
+	return fmt.Sprintf("stream: writer error: %s", e.err)
+}
+
+func (e streamCopierError) IsReadError() bool  { return e.isReadErr }
+func (e streamCopierError) IsWriteError() bool { return !e.isReadErr }
+
+func (c *streamCopier) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	if err != io.EOF {
+		c.readErr = err
+	}
+	return n, err
+}
+
+func (c *streamCopier) WriteStreamTo(w io.Writer) zfs.StreamCopierError {
+	if _, err := io.Copy(w, c); err != nil {
+		return streamCopierError{isReadErr: c.readErr != nil, err: err}
+	}
+	return nil
+}
+
+func (c *streamCopier) Close() error { return nil }
+
+func parseStream(stream []byte) (hdr *streamHeader, payload []byte, err error) {
+	r := bufio.NewReader(bytes.NewReader(stream))
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+	hdr = &streamHeader{}
+	if err := json.Unmarshal(line, hdr); err != nil {
+		return nil, nil, err
+	}
+	return hdr, stream[len(line):], nil
+}
+
+func (b *Backend) Recv(ctx context.Context, fs string, streamCopier zfs.StreamCopier, opts zfs.RecvOptions) error {
+	var stream bytes.Buffer
+	copierErr := streamCopier.WriteStreamTo(&stream)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	hdr, payload, err := parseStream(stream.Bytes())
+	if err != nil {
+		if copierErr != nil {
+			return copierErr
+		}
+		return zfsError("cannot receive: invalid stream (bad magic number)")
+	}
+	// determine the target's expected state and contents
+	target := b.filesystems[fs]
+	var data []byte
+	if hdr.Offset > 0 {
+		if target == nil || target.partial == nil ||
+			target.partial.header.ToGUID != hdr.ToGUID ||
+			target.partial.header.FromGUID != hdr.FromGUID ||
+			int64(len(target.partial.data)) != hdr.Offset {
+			return zfsError("cannot receive resume stream: destination %s does not have matching partially-complete state", fs)
+		}
+		data = append(append([]byte(nil), target.partial.data...), payload...)
+	} else {
+		if err := b.recvCheckDestination(fs, target, hdr, opts); err != nil {
+			return err
+		}
+		data = append([]byte(nil), payload...)
+	}
+	if int64(len(data)) > hdr.Size {
+		return zfsError("cannot receive: invalid stream (checksum mismatch)")
+	}
+
+	if copierErr != nil || int64(len(data)) < hdr.Size {
+		// interrupted stream
+		var token string
+		if opts.SavePartialRecvState {
+			createdFS := target == nil
+			if hdr.Offset > 0 {
+				createdFS = target.partial.createdFS
+			} else if target == nil {
+				target = b.newFilesystem(fs, nil)
+			}
+			target.partial = &partialRecv{header: *hdr, data: data, createdFS: createdFS}
+			token = target.partial.token()
+		}
+		if copierErr != nil {
+			return copierErr
+		}
+		if token != "" {
+			return zfsError("cannot receive: checksum mismatch or incomplete stream.\nPartially received snapshot is saved.\nA resuming stream can be generated on the sending system by running:\n    zfs send -t %s", token)
+		}
+		return zfsError("cannot receive: checksum mismatch or incomplete stream")
+	}
+
+	if target == nil {
+		target = b.newFilesystem(fs, nil)
+	}
+	_, _, name, _ := zfs.DecomposeVersionString(hdr.ToName)
+	snap := &version{
+		typ:       zfs.Snapshot,
+		name:      name,
+		guid:      hdr.ToGUID,
+		createtxg: b.nextTXG(),
+		creation:  time.Unix(hdr.Creation, 0),
+		data:      data,
+		props:     make(map[string]string),
+		holds:     make(map[string]bool),
+	}
+	for _, tag := range hdr.Holds {
+		snap.holds[tag] = true
+	}
+	target.snapshots = append(target.snapshots, snap)
+	target.data = append([]byte(nil), data...)
+	target.modified = false
+	target.partial = nil
+	return nil
+}
+
+// recvCheckDestination checks that the stream hdr can be received into fs (target, nil if it does not exist).
+// For incremental streams with opts.RollbackAndForceRecv, it rolls back target to the incremental source.
+func (b *Backend) recvCheckDestination(fs string, target *filesystem, hdr *streamHeader, opts zfs.RecvOptions) error {
+	if target != nil && target.partial != nil {
+		return zfsError("cannot receive: destination %s contains partially-complete state from \"zfs receive -s\".", fs)
+	}
+
+	if hdr.isFull() {
+		if target == nil {
+			if b.filesystems[parentName(fs)] == nil {
+				return errDoesNotExist(parentName(fs))
+			}
+			return nil
+		}
+		if !opts.RollbackAndForceRecv {
+			return zfsError("cannot receive new filesystem stream: destination '%s' exists\nmust specify -F to overwrite it", fs)
+		}
+		if len(target.snapshots) > 0 {
+			return zfsError("cannot receive new filesystem stream: destination has snapshots (eg. %s@%s)\nmust destroy them to overwrite it", fs, target.snapshots[0].name)
+		}
+		return nil
+	}
+
+	if target == nil {
+		return zfsError("cannot receive incremental stream: destination '%s' does not exist", fs)
+	}
+	_, _, name, _ := zfs.DecomposeVersionString(hdr.ToName)
+	if target.version(zfs.Snapshot, name) != nil {
+		return zfsError("cannot receive incremental stream: destination snapshot %s@%s already exists", fs, name)
+	}
+	latest := target.latestSnapshot()
+	if latest == nil || latest.guid != hdr.FromGUID {
+		var from *version
+		for _, v := range target.snapshots {
+			if v.guid == hdr.FromGUID {
+				from = v
+			}
+		}
+		if from == nil || !opts.RollbackAndForceRecv {
+			return zfsError("cannot receive incremental stream: most recent snapshot of %s does not\nmatch incremental source", fs)
+		}
+		return target.rollbackTo(from)
+	}
+	if target.modified {
+		if !opts.RollbackAndForceRecv {
+			return zfsError("cannot receive incremental stream: destination %s has been modified\nsince most recent snapshot", fs)
+		}
+		return target.rollbackTo(latest)
+	}
+	return nil
+}
+
+func (b *Backend) RecvClearResumeToken(fs string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	f, err := b.lookupFilesystem(fs)
+	if err != nil {
+		return &zfs.ClearResumeTokenError{ZFSOutput: err.(*zfs.ZFSError).Stderr, CmdError: err}
+	}
+	if f.partial == nil {
+		return nil
+	}
+	if f.partial.createdFS && len(b.children(fs)) == 0 {
+		delete(b.filesystems, fs)
+		return nil
+	}
+	f.partial = nil
+	return nil
+}
@@ -0,0 +1,238 @@
+package zfsfake_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zrepl/zrepl/zfs"
+	"github.com/zrepl/zrepl/zfs/zfsfake"
+)
+
+func setup(t *testing.T) (*zfsfake.Backend, func()) {
+	b := zfsfake.New()
+	prev := zfs.SetBackend(b)
+	require.NoError(t, b.AddPool("src"))
+	require.NoError(t, b.AddPool("dst"))
+	require.NoError(t, b.Create("src/a", nil))
+	return b, func() { zfs.SetBackend(prev) }
+}
+
+func path(s string) *zfs.DatasetPath {
+	p, err := zfs.NewDatasetPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func snapshot(t *testing.T, b *zfsfake.Backend, fs, name, data string) {
+	require.NoError(t, b.WriteData(fs, []byte(data)))
+	require.NoError(t, zfs.ZFSSnapshot(path(fs), name, false))
+}
+
+func versionNames(t *testing.T, fs string) []string {
+	vs, err := zfs.ZFSListFilesystemVersions(path(fs), nil)
+	require.NoError(t, err)
+	names := make([]string, len(vs))
+	for i, v := range vs {
+		names[i] = v.String()
+	}
+	return names
+}
+
+func sendRecv(fs, from, to, token string, recvFS string, opts zfs.RecvOptions) error {
+	copier, err := zfs.ZFSSend(context.Background(), fs, from, to, token, zfs.ZFSSendFlags{})
+	if err != nil {
+		return err
+	}
+	defer copier.Close()
+	return zfs.ZFSRecv(context.Background(), recvFS, copier, opts)
+}
+
+func TestVersionsAndGUIDs(t *testing.T) {
+	b, cleanup := setup(t)
+	defer cleanup()
+
+	snapshot(t, b, "src/a", "1", "one")
+	snapshot(t, b, "src/a", "2", "two")
+	require.NoError(t, zfs.ZFSBookmark(path("src/a"), "1", "b1"))
+
+	vs, err := zfs.ZFSListFilesystemVersions(path("src/a"), nil)
+	require.NoError(t, err)
+	require.Len(t, vs, 3)
+	assert.Equal(t, []string{"@1", "#b1", "@2"}, versionNames(t, "src/a"))
+	assert.Equal(t, vs[0].Guid, vs[1].Guid, "bookmark has the guid of its snapshot")
+	assert.Equal(t, vs[0].CreateTXG, vs[1].CreateTXG)
+	assert.NotEqual(t, vs[0].Guid, vs[2].Guid)
+	assert.True(t, vs[0].CreateTXG < vs[2].CreateTXG)
+
+	props, err := zfs.ZFSGetCreateTXGAndGuid("src/a@2")
+	require.NoError(t, err)
+	assert.Equal(t, vs[2].Guid, props.Guid)
+
+	_, err = zfs.ZFSGetCreateTXGAndGuid("src/a@nonexistent")
+	assert.IsType(t, &zfs.DatasetDoesNotExist{}, err)
+
+	rows, err := zfs.ZFSList([]string{"name"}, "-r", "-t", "filesystem,volume")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"dst"}, {"src"}, {"src/a"}}, rows)
+}
+
+func TestPlaceholderAndUserProperties(t *testing.T) {
+	b, cleanup := setup(t)
+	defer cleanup()
+
+	require.NoError(t, zfs.ZFSCreatePlaceholderFilesystem(path("dst/p")))
+	require.NoError(t, zfs.ZFSCreatePlaceholderFilesystem(path("dst/p/q")))
+	st, err := zfs.ZFSGetFilesystemPlaceholderState(path("dst/p/q"))
+	require.NoError(t, err)
+	assert.True(t, st.FSExists)
+	assert.True(t, st.IsPlaceholder)
+
+	require.NoError(t, zfs.ZFSSetPlaceholder(path("dst/p/q"), false))
+	st, err = zfs.ZFSGetFilesystemPlaceholderState(path("dst/p/q"))
+	require.NoError(t, err)
+	assert.False(t, st.IsPlaceholder)
+
+	// the property is inherited by children, which must not be considered placeholders
+	require.NoError(t, b.Create("dst/p/c", nil))
+	st, err = zfs.ZFSGetFilesystemPlaceholderState(path("dst/p/c"))
+	require.NoError(t, err)
+	assert.True(t, st.FSExists)
+	assert.False(t, st.IsPlaceholder)
+	props, err := zfs.ZFSGetAllDatasets(context.Background(), []string{zfs.PlaceholderPropertyName})
+	require.NoError(t, err)
+	assert.Equal(t, zfs.PropertySourceLocal, props["dst/p"][zfs.PlaceholderPropertyName].Source)
+	assert.Equal(t, zfs.PropertyValue{Value: "on", Source: zfs.PropertySourceInherited, InheritedFrom: "dst/p"},
+		props["dst/p/c"][zfs.PlaceholderPropertyName])
+	assert.False(t, props["dst"][zfs.PlaceholderPropertyName].IsSet())
+
+	require.NoError(t, zfs.ZFSCreatePlaceholderFilesystem(path("dst/p/r")))
+	require.NoError(t, zfs.ZFSDestroy("dst/p/r"))
+	st, err = zfs.ZFSGetFilesystemPlaceholderState(path("dst/p/r"))
+	require.NoError(t, err)
+	assert.False(t, st.FSExists)
+}
+
+func TestIncrementalStreamValidity(t *testing.T) {
+	b, cleanup := setup(t)
+	defer cleanup()
+
+	snapshot(t, b, "src/a", "1", "one")
+	snapshot(t, b, "src/a", "2", "two")
+	snapshot(t, b, "src/a", "3", "three")
+
+	err := sendRecv("src/a", "@1", "@2", "", "dst/a", zfs.RecvOptions{})
+	assert.Error(t, err, "incremental stream into nonexistent filesystem")
+
+	require.NoError(t, sendRecv("src/a", "", "@1", "", "dst/a", zfs.RecvOptions{}))
+	err = sendRecv("src/a", "", "@2", "", "dst/a", zfs.RecvOptions{})
+	assert.Error(t, err, "full stream into existing filesystem")
+
+	err = sendRecv("src/a", "@2", "@3", "", "dst/a", zfs.RecvOptions{})
+	assert.Error(t, err, "incremental source is not the most recent snapshot of the receiver")
+
+	// incremental sends from bookmarks
+	require.NoError(t, zfs.ZFSBookmark(path("src/a"), "1", "b1"))
+	require.NoError(t, zfs.ZFSDestroy("src/a@1"))
+	require.NoError(t, sendRecv("src/a", "#b1", "@2", "", "dst/a", zfs.RecvOptions{}))
+
+	require.NoError(t, b.WriteData("dst/a", []byte("modified")))
+	err = sendRecv("src/a", "@2", "@3", "", "dst/a", zfs.RecvOptions{})
+	assert.Error(t, err, "receiver modified since most recent snapshot")
+
+	latest := zfs.FilesystemVersion{Type: zfs.Snapshot, Name: "2"}
+	require.NoError(t, zfs.ZFSRollback(path("dst/a"), latest))
+	require.NoError(t, sendRecv("src/a", "@2", "@3", "", "dst/a", zfs.RecvOptions{}))
+	data, err := b.ReadData("dst/a")
+	require.NoError(t, err)
+	assert.Equal(t, "three", string(data))
+
+	src, err := zfs.ZFSListFilesystemVersions(path("src/a"), nil)
+	require.NoError(t, err)
+	dst, err := zfs.ZFSListFilesystemVersions(path("dst/a"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, src[len(src)-1].Guid, dst[len(dst)-1].Guid, "receive preserves guids")
+	assert.Equal(t, []string{"@1", "@2", "@3"}, versionNames(t, "dst/a"))
+}
+
+func TestResumableReceive(t *testing.T) {
+	b, cleanup := setup(t)
+	defer cleanup()
+
+	snapshot(t, b, "src/a", "1", "0123456789")
+	snapshot(t, b, "src/a", "2", "abcdefghij")
+	opts := zfs.RecvOptions{SavePartialRecvState: true}
+
+	b.BreakNextSend(4)
+	err := sendRecv("src/a", "", "@1", "", "dst/a", opts)
+	require.Error(t, err)
+	token, err := zfs.ZFSGetReceiveResumeToken(path("dst/a"))
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	rt, err := zfs.ParseResumeToken(context.Background(), token)
+	require.NoError(t, err)
+	src, err := zfs.ZFSGetCreateTXGAndGuid("src/a@1")
+	require.NoError(t, err)
+	assert.Equal(t, &zfs.ResumeToken{HasToGUID: true, ToGUID: src.Guid}, rt)
+	_, err = zfs.ParseResumeToken(context.Background(), "1-abc")
+	assert.Equal(t, zfs.ResumeTokenCorruptError, err)
+
+	err = sendRecv("src/a", "", "@1", "", "dst/a", opts)
+	assert.Error(t, err, "new receive into filesystem with partial receive state")
+
+	require.NoError(t, sendRecv("", "", "", token, "dst/a", opts))
+	data, err := b.ReadData("dst/a@1")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+	token, err = zfs.ZFSGetReceiveResumeToken(path("dst/a"))
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	// aborting an interrupted incremental receive
+	b.BreakNextSend(2)
+	require.Error(t, sendRecv("src/a", "@1", "@2", "", "dst/a", opts))
+	require.NoError(t, zfs.ZFSRecvClearResumeToken("dst/a"))
+	require.NoError(t, sendRecv("src/a", "@1", "@2", "", "dst/a", opts))
+	assert.Equal(t, []string{"@1", "@2"}, versionNames(t, "dst/a"))
+
+	// aborting an interrupted full receive destroys the filesystem
+	b.BreakNextSend(2)
+	require.Error(t, sendRecv("src/a", "", "@2", "", "dst/b", opts))
+	require.NoError(t, zfs.ZFSRecvClearResumeToken("dst/b"))
+	st, err := zfs.ZFSGetFilesystemPlaceholderState(path("dst/b"))
+	require.NoError(t, err)
+	assert.False(t, st.FSExists)
+}
+
+func TestHolds(t *testing.T) {
+	b, cleanup := setup(t)
+	defer cleanup()
+
+	snapshot(t, b, "src/a", "1", "one")
+	snapshot(t, b, "src/a", "2", "two")
+	require.NoError(t, b.Hold("src/a@1", "zrepl_test"))
+	assert.Error(t, b.Hold("src/a@1", "zrepl_test"))
+	tags, err := b.Holds("src/a@1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zrepl_test"}, tags)
+
+	var err1, err2 error
+	zfs.ZFSDestroyFilesystemVersions([]*zfs.DestroySnapOp{
+		{Filesystem: "src/a", Name: "1", ErrOut: &err1},
+		{Filesystem: "src/a", Name: "2", ErrOut: &err2},
+	})
+	require.IsType(t, &zfs.DestroySnapshotsError{}, err1)
+	assert.Equal(t, []string{"dataset is busy"}, err1.(*zfs.DestroySnapshotsError).Reason)
+	assert.NoError(t, err2)
+	assert.Equal(t, []string{"@1"}, versionNames(t, "src/a"))
+
+	require.NoError(t, b.Release("src/a@1", "zrepl_test"))
+	assert.Error(t, b.Release("src/a@1", "zrepl_test"))
+	require.NoError(t, zfs.ZFSDestroy("src/a@1"))
+	assert.Empty(t, versionNames(t, "src/a"))
+}